@@ -10,12 +10,15 @@ import (
 	"time"
 
 	"github.com/trenchjob/backend/internal/config"
+	"github.com/trenchjob/backend/internal/domain"
 	"github.com/trenchjob/backend/internal/handler"
 	"github.com/trenchjob/backend/internal/middleware"
 	"github.com/trenchjob/backend/internal/pkg/database"
+	"github.com/trenchjob/backend/internal/pkg/solana"
 	"github.com/trenchjob/backend/internal/pkg/utils"
 	"github.com/trenchjob/backend/internal/repository/postgres"
 	"github.com/trenchjob/backend/internal/service"
+	ws "github.com/trenchjob/backend/internal/websocket"
 )
 
 func main() {
@@ -34,13 +37,26 @@ func main() {
 	defer db.Close()
 	log.Println("Connected to PostgreSQL database")
 
+	// Initialize Solana RPC client
+	solanaClient := solana.NewClient(solana.Config{
+		Endpoint:   cfg.Solana.RPCEndpoint,
+		Commitment: solana.ParseCommitment(cfg.Solana.Commitment),
+		Timeout:    time.Duration(cfg.Solana.RPCTimeoutSeconds) * time.Second,
+		MaxRetries: cfg.Solana.RPCMaxRetries,
+	})
+	escrowProgramID, err := solana.PublicKeyFromBase58(cfg.Solana.ProgramID)
+	if err != nil {
+		log.Printf("Warning: SOLANA_PROGRAM_ID is not a valid program address (%v); escrow verification is disabled", err)
+	}
+
 	// Initialize JWT manager
-	jwtManager := utils.NewJWTManager(cfg.JWT.Secret, cfg.JWT.ExpireHours)
+	jwtManager := utils.NewJWTManager(cfg.JWT.Secret, cfg.JWT.AccessTokenMinutes)
 
 	// Initialize repositories
 	userRepo := postgres.NewUserRepository(db.Pool)
 	walletRepo := postgres.NewWalletRepository(db.Pool)
 	sessionRepo := postgres.NewSessionRepository(db.Pool)
+	refreshTokenRepo := postgres.NewRefreshTokenRepository(db.Pool)
 	profileRepo := postgres.NewProfileRepository(db.Pool)
 	skillRepo := postgres.NewSkillRepository(db.Pool)
 	portfolioRepo := postgres.NewPortfolioRepository(db.Pool)
@@ -50,24 +66,94 @@ func main() {
 	proposalRepo := postgres.NewProposalRepository(db.Pool)
 	contractRepo := postgres.NewContractRepository(db.Pool)
 	milestoneRepo := postgres.NewMilestoneRepository(db.Pool)
+	milestoneRevisionRepo := postgres.NewMilestoneRevisionRepository(db.Pool)
 	escrowRepo := postgres.NewEscrowRepository(db.Pool)
 	paymentRepo := postgres.NewPaymentRepository(db.Pool)
 	reviewRepo := postgres.NewReviewRepository(db.Pool)
 	notificationRepo := postgres.NewNotificationRepository(db.Pool)
 	conversationRepo := postgres.NewConversationRepository(db.Pool)
 	messageRepo := postgres.NewMessageRepository(db.Pool)
+	presenceRepo := postgres.NewPresenceRepository(db.Pool)
+	serviceRepo := postgres.NewServiceRepository(db.Pool)
+	auditLogRepo := postgres.NewAuditLogRepository(db.Pool)
+	disputeRepo := postgres.NewDisputeRepository(db.Pool)
+	chainCursorRepo := postgres.NewChainCursorRepository(db.Pool)
+	escrowDriftRepo := postgres.NewEscrowDriftRepository(db.Pool)
+	settingsRepo := postgres.NewPlatformSettingsRepository(db.Pool)
+	timeEntryRepo := postgres.NewTimeEntryRepository(db.Pool)
+	timesheetRepo := postgres.NewTimesheetRepository(db.Pool)
+	statusChangeRepo := postgres.NewContractStatusChangeRepository(db.Pool)
+	cancellationRepo := postgres.NewContractCancellationRepository(db.Pool)
+	txManager := database.NewTxManager(db.Pool)
+
+	// WebSocket hub for chat and live notifications. With the Postgres
+	// broker, it reaches connections on every API instance.
+	hub := ws.NewHub()
+	if cfg.Server.WebSocketBroker == "postgres" {
+		hub.SetBroker(ws.NewPostgresBroker(db.Pool))
+	}
 
 	// Initialize services
-	authService := service.NewAuthService(userRepo, walletRepo, sessionRepo, profileRepo, jwtManager)
-	profileService := service.NewProfileService(profileRepo, skillRepo, portfolioRepo, userRepo, socialRepo, tokenWorkRepo)
-	jobService := service.NewJobService(jobRepo, proposalRepo, userRepo)
+	settingsService := service.NewSettingsService(settingsRepo, domain.PlatformSettings{
+		FeePercentage:      cfg.Platform.FeePercentage,
+		MinEscrowAmountSOL: cfg.Platform.MinEscrowAmountSOL,
+		JobPostingFeeSOL:   cfg.Platform.JobPostingFeeSOL,
+	})
+	if err := settingsService.Reload(context.Background()); err != nil {
+		log.Printf("Warning: %v; using configured platform defaults", err)
+	}
+	feeCalculator := service.NewFeeCalculator(settingsService, jobRepo)
+	authService := service.NewAuthService(
+		userRepo, walletRepo, sessionRepo, refreshTokenRepo, profileRepo, txManager, jwtManager,
+		time.Duration(cfg.JWT.RefreshTokenDays)*24*time.Hour, cfg.Server.Domain, cfg.Solana.Network,
+	)
+	profileService := service.NewProfileService(profileRepo, skillRepo, portfolioRepo, userRepo, socialRepo, tokenWorkRepo, txManager)
+	jobService := service.NewJobService(jobRepo, proposalRepo, userRepo, txManager)
+	notificationService := service.NewNotificationService(notificationRepo, hub, txManager)
 	contractService := service.NewContractService(
-		contractRepo, milestoneRepo, escrowRepo, paymentRepo,
-		proposalRepo, jobRepo, userRepo,
+		contractRepo, milestoneRepo, milestoneRevisionRepo, escrowRepo, paymentRepo,
+		proposalRepo, jobRepo, userRepo, txManager, settingsService, notificationService,
+		cancellationRepo,
+	)
+	reviewService := service.NewReviewService(reviewRepo, notificationService, contractRepo, userRepo)
+	// Files are served from baseURL/uploads/; chat attachments may only point there
+	baseURL := "http://localhost:" + cfg.Server.Port
+	messageService := service.NewMessageService(
+		conversationRepo, messageRepo, userRepo, contractRepo, profileRepo, presenceRepo, txManager,
+		time.Duration(cfg.Platform.MessageEditWindowMinutes)*time.Minute,
+		baseURL+"/uploads/",
+	)
+	adminService := service.NewAdminService(userRepo, sessionRepo, jobRepo, serviceRepo, auditLogRepo, txManager)
+	escrowService := service.NewEscrowService(
+		contractRepo, milestoneRepo, escrowRepo, paymentRepo, walletRepo, userRepo, txManager,
+		contractService, notificationService, solanaClient, escrowProgramID,
+		feeCalculator,
+	)
+	escrowIndexer := service.NewEscrowIndexer(escrowService, chainCursorRepo)
+	escrowReconciler := service.NewEscrowReconciler(escrowService, escrowDriftRepo)
+	paymentTracker := service.NewPaymentTracker(
+		paymentRepo, contractRepo, notificationService, solanaClient,
+		solana.ParseCommitment(cfg.Solana.Commitment), time.Duration(cfg.Solana.PaymentTimeoutMinutes)*time.Minute,
+		escrowService,
+	)
+	disputeService := service.NewDisputeService(
+		disputeRepo, contractRepo, milestoneRepo, escrowRepo, paymentRepo,
+		auditLogRepo, txManager, notificationService, escrowService,
+		cancellationRepo,
+	)
+	timeTrackingService := service.NewTimeTrackingService(
+		contractRepo, milestoneRepo, timeEntryRepo, timesheetRepo, txManager, notificationService,
+	)
+	milestoneScheduler := service.NewMilestoneScheduler(
+		milestoneRepo, contractRepo, notificationService,
+		time.Duration(cfg.Platform.MilestoneOverdueDays)*24*time.Hour,
+		time.Duration(cfg.Platform.AutoApproveDays)*24*time.Hour,
+		time.Duration(cfg.Platform.AutoApproveNoticeDays)*24*time.Hour,
+	)
+	contractLifecycleService := service.NewContractLifecycleService(
+		contractRepo, milestoneRepo, escrowRepo, jobRepo, statusChangeRepo, cancellationRepo,
+		txManager, escrowService, notificationService,
 	)
-	reviewService := service.NewReviewService(reviewRepo, notificationRepo, contractRepo, userRepo)
-	notificationService := service.NewNotificationService(notificationRepo)
-	messageService := service.NewMessageService(conversationRepo, messageRepo, userRepo, contractRepo, profileRepo)
 
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(authService)
@@ -76,16 +162,34 @@ func main() {
 	contractHandler := handler.NewContractHandler(contractService)
 	reviewHandler := handler.NewReviewHandler(reviewService)
 	notificationHandler := handler.NewNotificationHandler(notificationService)
-	messageHandler := handler.NewMessageHandler(messageService)
+	messageHandler := handler.NewMessageHandler(messageService, hub)
+	adminHandler := handler.NewAdminHandler(adminService)
+	disputeHandler := handler.NewDisputeHandler(disputeService)
+	escrowHandler := handler.NewEscrowHandler(escrowService, escrowReconciler)
+	timeTrackingHandler := handler.NewTimeTrackingHandler(timeTrackingService)
+	contractLifecycleHandler := handler.NewContractLifecycleHandler(contractLifecycleService)
 
 	// Upload handler - stores files in ./uploads directory
 	uploadDir := "./uploads"
-	baseURL := "http://localhost:" + cfg.Server.Port
 	uploadHandler := handler.NewUploadHandler(uploadDir, baseURL)
 
 	// Initialize middleware
-	authMiddleware := middleware.NewAuthMiddleware(jwtManager)
+	authMiddleware := middleware.NewAuthMiddleware(jwtManager, authService)
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 	corsConfig := middleware.DefaultCORSConfig()
+	corsConfig.AllowedOrigins = cfg.Server.AllowedOrigins
+	if len(corsConfig.AllowedOrigins) == 0 {
+		log.Printf("Warning: neither CORS_ALLOWED_ORIGINS nor FRONTEND_ORIGIN is set; browsers cannot call the API")
+	}
+
+	// WebSocket chat; presence changes are written to user_presence and
+	// sent to the user's contacts
+	wsHandler := handler.NewWebSocketHandler(hub, messageService, authService, corsConfig, cfg.Server.IsProduction())
+	hub.SetPresenceCallback(wsHandler.HandlePresenceChange)
+	go hub.Run()
 
 	// Setup router
 	mux := http.NewServeMux()
@@ -102,11 +206,15 @@ func main() {
 	mux.HandleFunc("POST /api/v1/auth/login", authHandler.Login)
 	mux.HandleFunc("GET /api/v1/wallet/nonce", authHandler.GetNonce)
 	mux.HandleFunc("POST /api/v1/auth/login/wallet", authHandler.WalletLogin)
+	mux.HandleFunc("POST /api/v1/auth/refresh", authHandler.RefreshToken)
 
 	// Auth routes (protected)
 	mux.Handle("POST /api/v1/auth/logout", authMiddleware.Authenticate(http.HandlerFunc(authHandler.Logout)))
-	mux.Handle("POST /api/v1/auth/refresh", authMiddleware.Authenticate(http.HandlerFunc(authHandler.RefreshToken)))
+	mux.Handle("POST /api/v1/auth/logout-all", authMiddleware.Authenticate(http.HandlerFunc(authHandler.LogoutAll)))
+	mux.Handle("GET /api/v1/auth/sessions", authMiddleware.Authenticate(http.HandlerFunc(authHandler.ListSessions)))
+	mux.Handle("DELETE /api/v1/auth/sessions/{id}", authMiddleware.Authenticate(http.HandlerFunc(authHandler.RevokeSession)))
 	mux.Handle("POST /api/v1/wallet/connect", authMiddleware.Authenticate(http.HandlerFunc(authHandler.ConnectWallet)))
+	mux.Handle("POST /api/v1/wallet/primary", authMiddleware.Authenticate(http.HandlerFunc(authHandler.SetPrimaryWallet)))
 	mux.Handle("GET /api/v1/wallet/me", authMiddleware.Authenticate(http.HandlerFunc(authHandler.GetWallets)))
 	mux.Handle("DELETE /api/v1/wallet", authMiddleware.Authenticate(http.HandlerFunc(authHandler.DisconnectWallet)))
 
@@ -162,11 +270,48 @@ func main() {
 	mux.Handle("GET /api/v1/contracts/{id}", authMiddleware.Authenticate(http.HandlerFunc(contractHandler.GetContract)))
 	mux.Handle("POST /api/v1/contracts/{id}/milestones", authMiddleware.Authenticate(http.HandlerFunc(contractHandler.AddMilestone)))
 	mux.Handle("POST /api/v1/contracts/{id}/complete", authMiddleware.Authenticate(http.HandlerFunc(contractHandler.CompleteContract)))
+	mux.Handle("POST /api/v1/contracts/{id}/escrow/fund", authMiddleware.Authenticate(http.HandlerFunc(escrowHandler.ConfirmFunding)))
+	mux.Handle("POST /api/v1/contracts/{id}/escrow/build/initialize", authMiddleware.Authenticate(http.HandlerFunc(escrowHandler.BuildInitializeEscrow)))
+	mux.Handle("POST /api/v1/contracts/{id}/escrow/build/fund", authMiddleware.Authenticate(http.HandlerFunc(escrowHandler.BuildFundEscrow)))
+	mux.Handle("POST /api/v1/contracts/{id}/escrow/build/refund", authMiddleware.Authenticate(http.HandlerFunc(escrowHandler.BuildRefund)))
+	mux.Handle("POST /api/v1/contracts/{id}/escrow/build/dispute", authMiddleware.Authenticate(http.HandlerFunc(escrowHandler.BuildOpenDispute)))
+	mux.Handle("POST /api/v1/contracts/{id}/escrow/build/close", authMiddleware.Authenticate(http.HandlerFunc(escrowHandler.BuildCloseEscrow)))
+
+	// Dispute routes (protected)
+	mux.Handle("POST /api/v1/contracts/{id}/disputes", authMiddleware.Authenticate(http.HandlerFunc(disputeHandler.OpenDispute)))
+	mux.Handle("GET /api/v1/contracts/{id}/disputes", authMiddleware.Authenticate(http.HandlerFunc(disputeHandler.GetContractDisputes)))
+	mux.Handle("GET /api/v1/disputes/{id}", authMiddleware.Authenticate(http.HandlerFunc(disputeHandler.GetDispute)))
+	mux.Handle("POST /api/v1/disputes/{id}/evidence", authMiddleware.Authenticate(http.HandlerFunc(disputeHandler.AddEvidence)))
+	mux.Handle("POST /api/v1/disputes/{id}/build/settle", authMiddleware.Authenticate(http.HandlerFunc(disputeHandler.BuildSettlement)))
+
+	// Time tracking routes (protected)
+	mux.Handle("POST /api/v1/contracts/{id}/time-entries", authMiddleware.Authenticate(http.HandlerFunc(timeTrackingHandler.LogTime)))
+	mux.Handle("GET /api/v1/contracts/{id}/time-entries", authMiddleware.Authenticate(http.HandlerFunc(timeTrackingHandler.GetWeek)))
+	mux.Handle("POST /api/v1/contracts/{id}/timer/start", authMiddleware.Authenticate(http.HandlerFunc(timeTrackingHandler.StartTimer)))
+	mux.Handle("POST /api/v1/contracts/{id}/timer/stop", authMiddleware.Authenticate(http.HandlerFunc(timeTrackingHandler.StopTimer)))
+	mux.Handle("DELETE /api/v1/time-entries/{id}", authMiddleware.Authenticate(http.HandlerFunc(timeTrackingHandler.DeleteTimeEntry)))
+	mux.Handle("POST /api/v1/contracts/{id}/timesheets", authMiddleware.Authenticate(http.HandlerFunc(timeTrackingHandler.SubmitTimesheet)))
+	mux.Handle("GET /api/v1/contracts/{id}/timesheets", authMiddleware.Authenticate(http.HandlerFunc(timeTrackingHandler.ListTimesheets)))
+	mux.Handle("GET /api/v1/timesheets/{id}", authMiddleware.Authenticate(http.HandlerFunc(timeTrackingHandler.GetTimesheet)))
+	mux.Handle("POST /api/v1/timesheets/{id}/approve", authMiddleware.Authenticate(http.HandlerFunc(timeTrackingHandler.ApproveTimesheet)))
+	mux.Handle("POST /api/v1/timesheets/{id}/dispute", authMiddleware.Authenticate(http.HandlerFunc(timeTrackingHandler.DisputeTimesheet)))
+
+	// Contract lifecycle routes (protected)
+	mux.Handle("POST /api/v1/contracts/{id}/pause", authMiddleware.Authenticate(http.HandlerFunc(contractLifecycleHandler.PauseContract)))
+	mux.Handle("POST /api/v1/contracts/{id}/resume", authMiddleware.Authenticate(http.HandlerFunc(contractLifecycleHandler.ResumeContract)))
+	mux.Handle("POST /api/v1/contracts/{id}/cancel", authMiddleware.Authenticate(http.HandlerFunc(contractLifecycleHandler.RequestCancellation)))
+	mux.Handle("GET /api/v1/contracts/{id}/cancellation", authMiddleware.Authenticate(http.HandlerFunc(contractLifecycleHandler.GetCancellation)))
+	mux.Handle("POST /api/v1/contracts/{id}/cancellation/accept", authMiddleware.Authenticate(http.HandlerFunc(contractLifecycleHandler.AcceptCancellation)))
+	mux.Handle("POST /api/v1/contracts/{id}/cancellation/decline", authMiddleware.Authenticate(http.HandlerFunc(contractLifecycleHandler.DeclineCancellation)))
+	mux.Handle("POST /api/v1/contracts/{id}/cancellation/withdraw", authMiddleware.Authenticate(http.HandlerFunc(contractLifecycleHandler.WithdrawCancellation)))
+	mux.Handle("GET /api/v1/contracts/{id}/status-history", authMiddleware.Authenticate(http.HandlerFunc(contractLifecycleHandler.GetStatusHistory)))
 
 	// Milestone routes (protected)
 	mux.Handle("POST /api/v1/milestones/{id}/submit", authMiddleware.Authenticate(http.HandlerFunc(contractHandler.SubmitMilestone)))
 	mux.Handle("POST /api/v1/milestones/{id}/approve", authMiddleware.Authenticate(http.HandlerFunc(contractHandler.ApproveMilestone)))
 	mux.Handle("POST /api/v1/milestones/{id}/revision", authMiddleware.Authenticate(http.HandlerFunc(contractHandler.RequestRevision)))
+	mux.Handle("POST /api/v1/milestones/{id}/release", authMiddleware.Authenticate(http.HandlerFunc(escrowHandler.ReleaseMilestone)))
+	mux.Handle("POST /api/v1/milestones/{id}/escrow/build/release", authMiddleware.Authenticate(http.HandlerFunc(escrowHandler.BuildReleaseMilestone)))
 
 	// Review routes
 	mux.Handle("POST /api/v1/reviews", authMiddleware.Authenticate(http.HandlerFunc(reviewHandler.CreateReview)))
@@ -188,8 +333,33 @@ func main() {
 	mux.Handle("POST /api/v1/conversations/{id}/messages", authMiddleware.Authenticate(http.HandlerFunc(messageHandler.SendMessage)))
 	mux.Handle("POST /api/v1/conversations/{id}/read", authMiddleware.Authenticate(http.HandlerFunc(messageHandler.MarkConversationRead)))
 	mux.Handle("GET /api/v1/messages/unread-count", authMiddleware.Authenticate(http.HandlerFunc(messageHandler.GetUnreadCount)))
+	mux.Handle("PUT /api/v1/messages/{id}", authMiddleware.Authenticate(http.HandlerFunc(messageHandler.EditMessage)))
+	mux.Handle("DELETE /api/v1/messages/{id}", authMiddleware.Authenticate(http.HandlerFunc(messageHandler.DeleteMessage)))
+	mux.Handle("GET /api/v1/messages/{id}/edits", authMiddleware.Authenticate(http.HandlerFunc(messageHandler.GetMessageEdits)))
 	mux.Handle("GET /api/v1/contracts/{id}/conversation", authMiddleware.Authenticate(http.HandlerFunc(messageHandler.GetContractConversation)))
 
+	// Admin routes (protected - admin only)
+	admin := func(h http.HandlerFunc) http.Handler {
+		return authMiddleware.Authenticate(authMiddleware.RequireAdmin(h))
+	}
+	mux.Handle("GET /api/v1/admin/users", admin(adminHandler.SearchUsers))
+	mux.Handle("POST /api/v1/admin/users/{id}/suspend", admin(adminHandler.SuspendUser))
+	mux.Handle("POST /api/v1/admin/users/{id}/ban", admin(adminHandler.BanUser))
+	mux.Handle("POST /api/v1/admin/users/{id}/reinstate", admin(adminHandler.ReinstateUser))
+	mux.Handle("POST /api/v1/admin/jobs/{id}/close", admin(adminHandler.CloseJob))
+	mux.Handle("POST /api/v1/admin/services/{id}/close", admin(adminHandler.CloseService))
+	mux.Handle("GET /api/v1/admin/audit-log", admin(adminHandler.GetAuditLog))
+	mux.Handle("GET /api/v1/admin/disputes", admin(disputeHandler.ListDisputes))
+	mux.Handle("GET /api/v1/admin/disputes/{id}", admin(disputeHandler.AdminGetDispute))
+	mux.Handle("POST /api/v1/admin/disputes/{id}/review", admin(disputeHandler.StartReview))
+	mux.Handle("POST /api/v1/admin/disputes/{id}/escalate", admin(disputeHandler.Escalate))
+	mux.Handle("POST /api/v1/admin/disputes/{id}/resolve", admin(disputeHandler.Resolve))
+	mux.Handle("POST /api/v1/admin/disputes/{id}/close", admin(disputeHandler.Close))
+	mux.Handle("GET /api/v1/admin/escrows/reconciliation", admin(escrowHandler.GetReconciliationReport))
+
+	// WebSocket (JSON-RPC chat); browsers pass the access token as ?token=
+	mux.Handle("GET /ws", authMiddleware.Authenticate(http.HandlerFunc(wsHandler.HandleWebSocket)))
+
 	// Upload routes
 	mux.Handle("POST /api/v1/upload", authMiddleware.Authenticate(http.HandlerFunc(uploadHandler.UploadFile)))
 	mux.HandleFunc("GET /uploads/", uploadHandler.ServeFile)
@@ -197,6 +367,7 @@ func main() {
 	// Apply global middleware
 	var handler http.Handler = mux
 	handler = middleware.CORS(corsConfig)(handler)
+	handler = middleware.RealIP(trustedProxies)(handler)
 	handler = middleware.Logger(handler)
 	handler = middleware.Recovery(handler)
 
@@ -209,6 +380,20 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Start background workers
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	go authService.RunSessionSweeper(workerCtx, time.Hour)
+	go settingsService.Run(workerCtx, time.Duration(cfg.Platform.SettingsReloadSeconds)*time.Second)
+	go paymentTracker.Run(workerCtx, time.Duration(cfg.Solana.PaymentPollSeconds)*time.Second)
+	go contractLifecycleService.Run(workerCtx, time.Duration(cfg.Platform.CancellationCheckMinutes)*time.Minute)
+	go milestoneScheduler.Run(workerCtx, time.Duration(cfg.Platform.MilestoneCheckMinutes)*time.Minute)
+	go hub.RunBroker(workerCtx, time.Duration(cfg.Server.WebSocketHeartbeatSeconds)*time.Second)
+	if !escrowProgramID.IsZero() {
+		go escrowIndexer.Run(workerCtx, time.Duration(cfg.Solana.IndexerIntervalSeconds)*time.Second)
+		go escrowReconciler.Run(workerCtx, time.Duration(cfg.Solana.ReconcileIntervalMinutes)*time.Minute)
+	}
+
 	// Start server in goroutine
 	go func() {
 		log.Printf("Server listening on http://localhost:%s", cfg.Server.Port)
@@ -223,6 +408,7 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server...")
+	stopWorkers()
 
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
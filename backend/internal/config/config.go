@@ -3,6 +3,9 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
 )
 
 type Config struct {
@@ -10,11 +13,19 @@ type Config struct {
 	Database DatabaseConfig
 	JWT      JWTConfig
 	Solana   SolanaConfig
+	Platform PlatformConfig
 }
 
 type ServerConfig struct {
-	Port string
-	Env  string
+	Port   string
+	Env    string
+	Domain string // Domain wallets sign in to (sign-in-with-Solana messages)
+
+	AllowedOrigins []string // Browser origins allowed by CORS and WebSocket upgrades; none when unset
+	TrustedProxies []string // Proxies (IPs or CIDRs) whose X-Forwarded-For and X-Real-IP headers are believed
+
+	WebSocketBroker           string // "postgres" fans chat out across instances; "local" keeps it in-process
+	WebSocketHeartbeatSeconds int    // How often each instance re-announces its online users
 }
 
 type DatabaseConfig struct {
@@ -27,22 +38,54 @@ type DatabaseConfig struct {
 }
 
 type JWTConfig struct {
-	Secret     string
-	ExpireHours int
+	Secret             string
+	AccessTokenMinutes int
+	RefreshTokenDays   int
 }
 
 type SolanaConfig struct {
-	RPCEndpoint    string
-	ProgramID      string
-	Network        string
-	PlatformWallet string
+	RPCEndpoint              string
+	ProgramID                string
+	Network                  string
+	PlatformWallet           string
+	Commitment               string // processed, confirmed or finalized
+	RPCTimeoutSeconds        int
+	RPCMaxRetries            int
+	IndexerIntervalSeconds   int // How often the escrow indexer polls the program
+	ReconcileIntervalMinutes int // How often escrows are reconciled with chain state
+	PaymentPollSeconds       int // How often pending payments are checked
+	PaymentTimeoutMinutes    int // How long a submitted payment may stay unconfirmed
+}
+
+// PlatformConfig holds the fallbacks for platform_settings rows that are
+// missing or invalid; the table itself is authoritative
+type PlatformConfig struct {
+	FeePercentage            decimal.Decimal // Taken from each milestone release
+	MinEscrowAmountSOL       decimal.Decimal
+	JobPostingFeeSOL         decimal.Decimal
+	SettingsReloadSeconds    int // How often platform_settings and fee_overrides are re-read
+	CancellationCheckMinutes int // How often expired cancellation notices are executed
+	MilestoneCheckMinutes    int // How often milestone due dates and reviews are checked
+	MilestoneOverdueDays     int // Grace after the due date before a milestone is flagged overdue
+	AutoApproveDays          int // Days a submitted milestone waits for review; 0 disables auto-approval
+	AutoApproveNoticeDays    int // How long before auto-approval both parties are warned
+	MessageEditWindowMinutes int // How long after sending a chat message its sender may edit it
 }
 
 func Load() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Env:  getEnv("SERVER_ENV", "development"),
+			Port:   getEnv("SERVER_PORT", "8080"),
+			Env:    getEnv("SERVER_ENV", "development"),
+			Domain: getEnv("SERVER_DOMAIN", "localhost:5173"),
+
+			// Defaults to the web app's own origin; with neither set no
+			// browser origin is allowed
+			AllowedOrigins: getEnvAsSlice("CORS_ALLOWED_ORIGINS", getEnvAsSlice("FRONTEND_ORIGIN", nil)),
+			TrustedProxies: getEnvAsSlice("TRUSTED_PROXIES", nil),
+
+			WebSocketBroker:           getEnv("WS_BROKER", "postgres"),
+			WebSocketHeartbeatSeconds: getEnvAsInt("WS_HEARTBEAT_SECONDS", 15),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -53,18 +96,43 @@ func Load() *Config {
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
 		},
 		JWT: JWTConfig{
-			Secret:     getEnv("JWT_SECRET", "your-super-secret-key-change-in-production"),
-			ExpireHours: getEnvAsInt("JWT_EXPIRE_HOURS", 24),
+			Secret:             getEnv("JWT_SECRET", "your-super-secret-key-change-in-production"),
+			AccessTokenMinutes: getEnvAsInt("JWT_ACCESS_TOKEN_MINUTES", 15),
+			RefreshTokenDays:   getEnvAsInt("JWT_REFRESH_TOKEN_DAYS", 30),
 		},
 		Solana: SolanaConfig{
-			RPCEndpoint:    getEnv("SOLANA_RPC_ENDPOINT", "https://api.devnet.solana.com"),
-			ProgramID:      getEnv("SOLANA_PROGRAM_ID", ""),
-			Network:        getEnv("SOLANA_NETWORK", "devnet"),
-			PlatformWallet: getEnv("PLATFORM_WALLET", ""),
+			RPCEndpoint:              getEnv("SOLANA_RPC_ENDPOINT", "https://api.devnet.solana.com"),
+			ProgramID:                getEnv("SOLANA_PROGRAM_ID", ""),
+			Network:                  getEnv("SOLANA_NETWORK", "devnet"),
+			PlatformWallet:           getEnv("PLATFORM_WALLET", ""),
+			Commitment:               getEnv("SOLANA_COMMITMENT", "confirmed"),
+			RPCTimeoutSeconds:        getEnvAsInt("SOLANA_RPC_TIMEOUT_SECONDS", 10),
+			RPCMaxRetries:            getEnvAsInt("SOLANA_RPC_MAX_RETRIES", 3),
+			IndexerIntervalSeconds:   getEnvAsInt("SOLANA_INDEXER_INTERVAL_SECONDS", 15),
+			ReconcileIntervalMinutes: getEnvAsInt("SOLANA_RECONCILE_INTERVAL_MINUTES", 10),
+			PaymentPollSeconds:       getEnvAsInt("SOLANA_PAYMENT_POLL_SECONDS", 10),
+			PaymentTimeoutMinutes:    getEnvAsInt("SOLANA_PAYMENT_TIMEOUT_MINUTES", 30),
+		},
+		Platform: PlatformConfig{
+			FeePercentage:            getEnvAsDecimal("PLATFORM_FEE_PERCENTAGE", decimal.NewFromInt(5)),
+			MinEscrowAmountSOL:       getEnvAsDecimal("MIN_ESCROW_AMOUNT_SOL", decimal.RequireFromString("0.1")),
+			JobPostingFeeSOL:         getEnvAsDecimal("JOB_POSTING_FEE_SOL", decimal.RequireFromString("0.01")),
+			SettingsReloadSeconds:    getEnvAsInt("PLATFORM_SETTINGS_RELOAD_SECONDS", 30),
+			CancellationCheckMinutes: getEnvAsInt("CONTRACT_CANCELLATION_CHECK_MINUTES", 5),
+			MilestoneCheckMinutes:    getEnvAsInt("MILESTONE_CHECK_MINUTES", 15),
+			MilestoneOverdueDays:     getEnvAsInt("MILESTONE_OVERDUE_DAYS", 3),
+			AutoApproveDays:          getEnvAsInt("MILESTONE_AUTO_APPROVE_DAYS", 14),
+			AutoApproveNoticeDays:    getEnvAsInt("MILESTONE_AUTO_APPROVE_NOTICE_DAYS", 3),
+			MessageEditWindowMinutes: getEnvAsInt("MESSAGE_EDIT_WINDOW_MINUTES", 15),
 		},
 	}
 }
 
+// IsProduction reports whether the server runs with SERVER_ENV=production
+func (c ServerConfig) IsProduction() bool {
+	return c.Env == "production"
+}
+
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -80,3 +148,25 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	if value, exists := os.LookupEnv(key); exists {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	return defaultValue
+}
+
+func getEnvAsDecimal(key string, defaultValue decimal.Decimal) decimal.Decimal {
+	if value, exists := os.LookupEnv(key); exists {
+		if d, err := decimal.NewFromString(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
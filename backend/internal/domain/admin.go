@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditLogEntry records a single admin action. Entries are append-only.
+type AuditLogEntry struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	AdminID       uuid.UUID `json:"admin_id" db:"admin_id"`
+	Action        string    `json:"action" db:"action"`
+	TargetType    string    `json:"target_type" db:"target_type"`
+	TargetID      uuid.UUID `json:"target_id" db:"target_id"`
+	Reason        string    `json:"reason" db:"reason"`
+	PreviousValue *string   `json:"previous_value" db:"previous_value"`
+	NewValue      *string   `json:"new_value" db:"new_value"`
+	IPAddress     *string   `json:"ip_address" db:"ip_address"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+
+	// Joined fields
+	AdminUsername string `json:"admin_username,omitempty" db:"-"`
+}
+
+// Audit action constants
+const (
+	AuditActionSuspendUser     = "suspend_user"
+	AuditActionBanUser         = "ban_user"
+	AuditActionReinstateUser   = "reinstate_user"
+	AuditActionCloseJob        = "close_job"
+	AuditActionCloseService    = "close_service"
+	AuditActionReviewDispute   = "review_dispute"
+	AuditActionEscalateDispute = "escalate_dispute"
+	AuditActionResolveDispute  = "resolve_dispute"
+	AuditActionCloseDispute    = "close_dispute"
+)
+
+// Audit target type constants
+const (
+	AuditTargetUser    = "user"
+	AuditTargetJob     = "job"
+	AuditTargetService = "service"
+	AuditTargetDispute = "dispute"
+)
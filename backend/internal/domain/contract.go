@@ -25,6 +25,7 @@ type Contract struct {
 	Status               string          `json:"status" db:"status"`
 	StartedAt            *time.Time      `json:"started_at" db:"started_at"`
 	EndedAt              *time.Time      `json:"ended_at" db:"ended_at"`
+	Version              int             `json:"version" db:"version"`
 	CreatedAt            time.Time       `json:"created_at" db:"created_at"`
 	UpdatedAt            time.Time       `json:"updated_at" db:"updated_at"`
 
@@ -51,16 +52,37 @@ type Milestone struct {
 	ApprovedAt     *time.Time      `json:"approved_at" db:"approved_at"`
 	PaymentID      *uuid.UUID      `json:"payment_id" db:"payment_id"`
 	PaidAt         *time.Time      `json:"paid_at" db:"paid_at"`
+	MaxRevisions   *int            `json:"max_revisions" db:"max_revisions"`
+	Version        int             `json:"version" db:"version"`
 	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+
+	// Timers run by the milestone scheduler. OverdueAt flags work that is
+	// still not delivered after the grace period past DueDate.
+	DueReminderSentAt       *time.Time `json:"due_reminder_sent_at" db:"due_reminder_sent_at"`
+	OverdueAt               *time.Time `json:"overdue_at" db:"overdue_at"`
+	AutoApproveNoticeSentAt *time.Time `json:"auto_approve_notice_sent_at" db:"auto_approve_notice_sent_at"`
+
+	// Joined fields
+	Revisions []MilestoneRevision `json:"revisions,omitempty" db:"-"`
 }
 
+// MilestoneRevision is one step of a milestone's revision history. A
+// "requested" entry is the client asking for changes in round
+// RevisionNumber; the "resubmitted" entry of the same round is the
+// freelancer's answer. RequestedBy is whoever made the entry, and the
+// previous submission is the work it set aside.
 type MilestoneRevision struct {
-	ID            uuid.UUID `json:"id" db:"id"`
-	MilestoneID   uuid.UUID `json:"milestone_id" db:"milestone_id"`
-	RequestedBy   uuid.UUID `json:"requested_by" db:"requested_by"`
-	RevisionNotes string    `json:"revision_notes" db:"revision_notes"`
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	ID                     uuid.UUID  `json:"id" db:"id"`
+	MilestoneID            uuid.UUID  `json:"milestone_id" db:"milestone_id"`
+	RevisionNumber         int        `json:"revision_number" db:"revision_number"`
+	Action                 string     `json:"action" db:"action"`
+	RequestedBy            uuid.UUID  `json:"requested_by" db:"requested_by"`
+	RevisionNotes          *string    `json:"revision_notes" db:"revision_notes"`
+	PreviousSubmissionText *string    `json:"previous_submission_text" db:"previous_submission_text"`
+	PreviousSubmissionURLs []string   `json:"previous_submission_urls" db:"previous_submission_urls"`
+	PreviousSubmittedAt    *time.Time `json:"previous_submitted_at" db:"previous_submitted_at"`
+	CreatedAt              time.Time  `json:"created_at" db:"created_at"`
 }
 
 // Contract status constants
@@ -83,3 +105,54 @@ const (
 	MilestoneStatusPaid              = "paid"
 	MilestoneStatusCancelled         = "cancelled"
 )
+
+// Milestone revision actions
+const (
+	MilestoneRevisionRequested   = "requested"
+	MilestoneRevisionResubmitted = "resubmitted"
+)
+
+// ContractStatusChange records a pause, resume or cancellation. ChangedBy
+// is nil when the platform made the change, such as a notice running out.
+type ContractStatusChange struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	ContractID uuid.UUID  `json:"contract_id" db:"contract_id"`
+	FromStatus string     `json:"from_status" db:"from_status"`
+	ToStatus   string     `json:"to_status" db:"to_status"`
+	ChangedBy  *uuid.UUID `json:"changed_by" db:"changed_by"`
+	Reason     *string    `json:"reason" db:"reason"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// ContractCancellation is a request to end a contract early. A mutual
+// request needs the other party to accept it; one with notice takes effect
+// on its own at EffectiveAt.
+type ContractCancellation struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	ContractID  uuid.UUID  `json:"contract_id" db:"contract_id"`
+	RequestedBy uuid.UUID  `json:"requested_by" db:"requested_by"`
+	Mode        string     `json:"mode" db:"mode"`
+	Reason      string     `json:"reason" db:"reason"`
+	Status      string     `json:"status" db:"status"`
+	EffectiveAt *time.Time `json:"effective_at" db:"effective_at"`
+	RespondedBy *uuid.UUID `json:"responded_by" db:"responded_by"`
+	RespondedAt *time.Time `json:"responded_at" db:"responded_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Cancellation mode constants
+const (
+	CancellationModeMutual = "mutual"
+	CancellationModeNotice = "notice"
+)
+
+// Cancellation status constants
+const (
+	CancellationStatusPending   = "pending"
+	CancellationStatusExecuted  = "executed"
+	CancellationStatusDeclined  = "declined"
+	CancellationStatusWithdrawn = "withdrawn"
+	// The contract ended some other way before the request was acted on
+	CancellationStatusClosed = "closed"
+)
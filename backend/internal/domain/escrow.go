@@ -20,6 +20,7 @@ type Escrow struct {
 	RefundedAmountSOL decimal.Decimal `json:"refunded_amount_sol" db:"refunded_amount_sol"`
 	Status           string          `json:"status" db:"status"`
 	InitTxSignature  *string         `json:"init_tx_signature" db:"init_tx_signature"`
+	Version          int             `json:"version" db:"version"`
 	CreatedAt        time.Time       `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time       `json:"updated_at" db:"updated_at"`
 }
@@ -57,11 +58,13 @@ type EscrowLog struct {
 // Escrow status constants
 const (
 	EscrowStatusCreated          = "created"
+	EscrowStatusPartiallyFunded  = "partially_funded"
 	EscrowStatusFunded           = "funded"
 	EscrowStatusPartiallyReleased = "partially_released"
 	EscrowStatusFullyReleased    = "fully_released"
 	EscrowStatusRefunded         = "refunded"
 	EscrowStatusDisputed         = "disputed"
+	EscrowStatusClosed           = "closed"
 )
 
 // Payment type constants
@@ -88,4 +91,51 @@ const (
 	EscrowLogActionRefunded         = "refunded"
 	EscrowLogActionDisputed         = "disputed"
 	EscrowLogActionResolved         = "resolved"
+	EscrowLogActionClosed           = "closed"
+	EscrowLogActionIndexSkipped     = "index_skipped"
+)
+
+// ChainCursor records how far an on-chain indexer has read
+type ChainCursor struct {
+	Name          string    `json:"name" db:"name"`
+	LastSignature string    `json:"last_signature" db:"last_signature"`
+	LastSlot      int64     `json:"last_slot" db:"last_slot"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// EscrowDrift is a field on which an escrow row disagrees with the chain
+// and that the reconciler could not safely correct
+type EscrowDrift struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	EscrowID   uuid.UUID  `json:"escrow_id" db:"escrow_id"`
+	ContractID uuid.UUID  `json:"contract_id" db:"contract_id"`
+	Field      string     `json:"field" db:"field"`
+	DBValue    string     `json:"db_value" db:"db_value"`
+	ChainValue string     `json:"chain_value" db:"chain_value"`
+	DetectedAt time.Time  `json:"detected_at" db:"detected_at"`
+	LastSeenAt time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// EscrowReconciliationRun summarizes one reconciliation pass
+type EscrowReconciliationRun struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	Checked    int       `json:"checked" db:"checked"`
+	OK         int       `json:"ok" db:"ok"`
+	Healed     int       `json:"healed" db:"healed"`
+	Mismatched int       `json:"mismatched" db:"mismatched"`
+	Failed     int       `json:"failed" db:"failed"`
+	StartedAt  time.Time `json:"started_at" db:"started_at"`
+	FinishedAt time.Time `json:"finished_at" db:"finished_at"`
+}
+
+// Escrow drift fields
+const (
+	EscrowDriftFieldTotal        = "total_amount"
+	EscrowDriftFieldFunded       = "funded_amount"
+	EscrowDriftFieldReleased     = "released_amount"
+	EscrowDriftFieldRefunded     = "refunded_amount"
+	EscrowDriftFieldWallets      = "wallets"
+	EscrowDriftFieldVaultBalance = "vault_balance"
+	EscrowDriftFieldAccount      = "account"
 )
@@ -40,6 +40,10 @@ type Message struct {
 	EditedAt       *time.Time `json:"edited_at" db:"edited_at"`
 	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
 
+	// Threading and soft delete
+	ReplyToMessageID *uuid.UUID `json:"reply_to_message_id" db:"reply_to_message_id"`
+	DeletedAt        *time.Time `json:"deleted_at" db:"deleted_at"`
+
 	// Joined fields
 	Sender      *User               `json:"sender,omitempty" db:"-"`
 	Attachments []MessageAttachment `json:"attachments,omitempty" db:"-"`
@@ -55,6 +59,14 @@ type MessageAttachment struct {
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 }
 
+// MessageEdit keeps the text a message had before one of its edits
+type MessageEdit struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	MessageID    uuid.UUID `json:"message_id" db:"message_id"`
+	PreviousText string    `json:"previous_text" db:"previous_text"`
+	EditedAt     time.Time `json:"edited_at" db:"edited_at"`
+}
+
 // Message type constants
 const (
 	MessageTypeText            = "text"
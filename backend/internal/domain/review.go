@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type Review struct {
@@ -40,8 +41,9 @@ type Dispute struct {
 	ResolvedBy           *uuid.UUID       `json:"resolved_by" db:"resolved_by"`
 	ResolutionType       *string          `json:"resolution_type" db:"resolution_type"`
 	ResolutionNotes      *string          `json:"resolution_notes" db:"resolution_notes"`
-	ClientRefundSOL      *string          `json:"client_refund_sol" db:"client_refund_sol"`
-	FreelancerPaymentSOL *string          `json:"freelancer_payment_sol" db:"freelancer_payment_sol"`
+	ClientRefundSOL      *decimal.Decimal `json:"client_refund_sol" db:"client_refund_sol"`
+	FreelancerPaymentSOL *decimal.Decimal `json:"freelancer_payment_sol" db:"freelancer_payment_sol"`
+	Version              int              `json:"version" db:"version"`
 	CreatedAt            time.Time        `json:"created_at" db:"created_at"`
 	ResolvedAt           *time.Time       `json:"resolved_at" db:"resolved_at"`
 
@@ -98,10 +100,23 @@ const (
 	NotificationTypeProposalAccepted  = "proposal_accepted"
 	NotificationTypeMilestoneSubmitted = "milestone_submitted"
 	NotificationTypePaymentReceived   = "payment_received"
+	NotificationTypePaymentConfirmed  = "payment_confirmed"
+	NotificationTypePaymentFailed     = "payment_failed"
 	NotificationTypeNewMessage        = "new_message"
 	NotificationTypeContractStarted   = "contract_started"
 	NotificationTypeContractCompleted = "contract_completed"
 	NotificationTypeDisputeOpened     = "dispute_opened"
 	NotificationTypeDisputeResolved   = "dispute_resolved"
 	NotificationTypeNewReview         = "new_review"
+	NotificationTypeTimesheetSubmitted = "timesheet_submitted"
+	NotificationTypeTimesheetApproved = "timesheet_approved"
+	NotificationTypeTimesheetDisputed = "timesheet_disputed"
+	NotificationTypeContractPaused    = "contract_paused"
+	NotificationTypeContractResumed   = "contract_resumed"
+	NotificationTypeCancellationRequested = "cancellation_requested"
+	NotificationTypeContractCancelled = "contract_cancelled"
+	NotificationTypeMilestoneDueReminder = "milestone_due_reminder"
+	NotificationTypeMilestoneOverdue = "milestone_overdue"
+	NotificationTypeAutoApprovalScheduled = "auto_approval_scheduled"
+	NotificationTypeMilestoneAutoApproved = "milestone_auto_approved"
 )
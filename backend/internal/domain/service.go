@@ -103,6 +103,9 @@ type ServiceOrder struct {
 	EscrowAccountAddress *string `json:"escrow_account_address" db:"escrow_account_address"`
 	EscrowFunded         bool    `json:"escrow_funded" db:"escrow_funded"`
 
+	// Version increments on every update and guards against lost updates
+	Version int `json:"version" db:"version"`
+
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// PlatformSetting is one row of the platform_settings key/value table
+type PlatformSetting struct {
+	Key         string    `json:"key" db:"key"`
+	Value       string    `json:"value" db:"value"`
+	Description *string   `json:"description" db:"description"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Platform setting keys
+const (
+	SettingPlatformFeePercentage = "platform_fee_percentage"
+	SettingMinEscrowAmountSOL    = "min_escrow_amount_sol"
+	SettingJobPostingFeeSOL      = "job_posting_fee_sol"
+)
+
+// PlatformSettings is the typed view of platform_settings
+type PlatformSettings struct {
+	FeePercentage      decimal.Decimal `json:"platform_fee_percentage"`
+	MinEscrowAmountSOL decimal.Decimal `json:"min_escrow_amount_sol"`
+	JobPostingFeeSOL   decimal.Decimal `json:"job_posting_fee_sol"`
+}
+
+// FeeOverride replaces the platform fee for a category, a user or, with
+// neither set, everyone. StartsAt/EndsAt bound promotional periods.
+type FeeOverride struct {
+	ID            uuid.UUID       `json:"id" db:"id"`
+	CategoryID    *int            `json:"category_id" db:"category_id"`
+	UserID        *uuid.UUID      `json:"user_id" db:"user_id"`
+	FeePercentage decimal.Decimal `json:"fee_percentage" db:"fee_percentage"`
+	StartsAt      *time.Time      `json:"starts_at" db:"starts_at"`
+	EndsAt        *time.Time      `json:"ends_at" db:"ends_at"`
+	Description   *string         `json:"description" db:"description"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+}
+
+// ActiveAt reports whether the override applies at t
+func (o *FeeOverride) ActiveAt(t time.Time) bool {
+	if o.StartsAt != nil && t.Before(*o.StartsAt) {
+		return false
+	}
+	if o.EndsAt != nil && !t.Before(*o.EndsAt) {
+		return false
+	}
+	return true
+}
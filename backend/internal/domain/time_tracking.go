@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// TimeEntry is work logged on an hourly contract, either entered by hand or
+// recorded with a timer. A running timer has no EndedAt and counts no
+// minutes until it is stopped.
+type TimeEntry struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	ContractID   uuid.UUID  `json:"contract_id" db:"contract_id"`
+	FreelancerID uuid.UUID  `json:"freelancer_id" db:"freelancer_id"`
+	WeekStart    time.Time  `json:"week_start" db:"week_start"`
+	StartedAt    time.Time  `json:"started_at" db:"started_at"`
+	EndedAt      *time.Time `json:"ended_at" db:"ended_at"`
+	Minutes      int        `json:"minutes" db:"minutes"`
+	Description  *string    `json:"description" db:"description"`
+	Source       string     `json:"source" db:"source"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Running reports whether the entry is a timer that has not been stopped
+func (e *TimeEntry) Running() bool {
+	return e.EndedAt == nil
+}
+
+// Timesheet is a week of time entries submitted to the client for approval.
+// Approving it bills the hours as an approved milestone.
+type Timesheet struct {
+	ID            uuid.UUID       `json:"id" db:"id"`
+	ContractID    uuid.UUID       `json:"contract_id" db:"contract_id"`
+	WeekStart     time.Time       `json:"week_start" db:"week_start"`
+	TotalMinutes  int             `json:"total_minutes" db:"total_minutes"`
+	HourlyRateSOL decimal.Decimal `json:"hourly_rate_sol" db:"hourly_rate_sol"`
+	AmountSOL     decimal.Decimal `json:"amount_sol" db:"amount_sol"`
+	Status        string          `json:"status" db:"status"`
+	SubmittedAt   time.Time       `json:"submitted_at" db:"submitted_at"`
+	ReviewedAt    *time.Time      `json:"reviewed_at" db:"reviewed_at"`
+	DisputeReason *string         `json:"dispute_reason" db:"dispute_reason"`
+	MilestoneID   *uuid.UUID      `json:"milestone_id" db:"milestone_id"`
+	Version       int             `json:"version" db:"version"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at" db:"updated_at"`
+
+	// Joined fields
+	Entries []TimeEntry `json:"entries,omitempty" db:"-"`
+}
+
+// Time entry source constants
+const (
+	TimeEntrySourceManual = "manual"
+	TimeEntrySourceTimer  = "timer"
+)
+
+// Timesheet status constants
+const (
+	TimesheetStatusSubmitted = "submitted"
+	TimesheetStatusApproved  = "approved"
+	TimesheetStatusDisputed  = "disputed"
+)
+
+// WeekStart returns midnight UTC on the Monday of t's week
+func WeekStart(t time.Time) time.Time {
+	t = t.UTC()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+// BillableAmount prices minutes at an hourly rate, rounded down to the
+// lamport so a contract's bills never add up to more than its escrow
+func BillableAmount(minutes int, hourlyRate decimal.Decimal) decimal.Decimal {
+	return hourlyRate.Mul(decimal.NewFromInt(int64(minutes))).Div(decimal.NewFromInt(60)).Truncate(9)
+}
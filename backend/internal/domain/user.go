@@ -17,6 +17,7 @@ type User struct {
 	EmailVerified        bool       `json:"email_verified" db:"email_verified"`
 	WalletVerified       bool       `json:"wallet_verified" db:"wallet_verified"`
 	AccountStatus        string     `json:"account_status" db:"account_status"`
+	IsAdmin              bool       `json:"is_admin" db:"is_admin"`
 	CreatedAt            time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt            time.Time  `json:"updated_at" db:"updated_at"`
 	LastLoginAt          *time.Time `json:"last_login_at" db:"last_login_at"`
@@ -39,12 +40,28 @@ type UserWallet struct {
 type AuthSession struct {
 	ID            uuid.UUID  `json:"id" db:"id"`
 	UserID        uuid.UUID  `json:"user_id" db:"user_id"`
-	SessionToken  string     `json:"session_token" db:"session_token"`
+	SessionToken  string     `json:"-" db:"session_token"`
 	WalletAddress *string    `json:"wallet_address" db:"wallet_address"`
 	IPAddress     *string    `json:"ip_address" db:"ip_address"`
 	UserAgent     *string    `json:"user_agent" db:"user_agent"`
 	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
 	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+
+	// Computed fields
+	IsCurrent bool `json:"is_current" db:"-"`
+}
+
+// RefreshToken is a single-use token that renews an auth session's access
+// token. Only the SHA-256 hash of the token is stored.
+type RefreshToken struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	SessionID  uuid.UUID  `json:"session_id" db:"session_id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	TokenHash  string     `json:"-" db:"token_hash"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt     *time.Time `json:"used_at" db:"used_at"`
+	ReplacedBy *uuid.UUID `json:"replaced_by" db:"replaced_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
 }
 
 // Account status constants
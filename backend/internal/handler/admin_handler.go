@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/trenchjob/backend/internal/domain"
+	"github.com/trenchjob/backend/internal/middleware"
+	"github.com/trenchjob/backend/internal/service"
+)
+
+type AdminHandler struct {
+	adminService *service.AdminService
+}
+
+func NewAdminHandler(adminService *service.AdminService) *AdminHandler {
+	return &AdminHandler{adminService: adminService}
+}
+
+// SearchUsers handles GET /api/v1/admin/users
+func (h *AdminHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, offset := parsePagination(r)
+
+	resp, err := h.adminService.SearchUsers(r.Context(), &service.SearchUsersRequest{
+		Query:  query.Get("q"),
+		Status: query.Get("status"),
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// SuspendUser handles POST /api/v1/admin/users/{id}/suspend
+func (h *AdminHandler) SuspendUser(w http.ResponseWriter, r *http.Request) {
+	h.moderateUser(w, r, h.adminService.SuspendUser)
+}
+
+// BanUser handles POST /api/v1/admin/users/{id}/ban
+func (h *AdminHandler) BanUser(w http.ResponseWriter, r *http.Request) {
+	h.moderateUser(w, r, h.adminService.BanUser)
+}
+
+// ReinstateUser handles POST /api/v1/admin/users/{id}/reinstate
+func (h *AdminHandler) ReinstateUser(w http.ResponseWriter, r *http.Request) {
+	h.moderateUser(w, r, h.adminService.ReinstateUser)
+}
+
+type moderateUserFunc func(ctx context.Context, actor service.AdminActor, userID uuid.UUID, req *service.ModerationRequest) (*domain.User, error)
+
+func (h *AdminHandler) moderateUser(w http.ResponseWriter, r *http.Request, action moderateUserFunc) {
+	actor, ok := adminActor(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	var req service.ModerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, err := action(r.Context(), actor, userID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
+
+// CloseJob handles POST /api/v1/admin/jobs/{id}/close
+func (h *AdminHandler) CloseJob(w http.ResponseWriter, r *http.Request) {
+	actor, ok := adminActor(w, r)
+	if !ok {
+		return
+	}
+
+	jobID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid job ID")
+		return
+	}
+
+	var req service.ModerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	job, err := h.adminService.ForceCloseJob(r.Context(), actor, jobID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, job)
+}
+
+// CloseService handles POST /api/v1/admin/services/{id}/close
+func (h *AdminHandler) CloseService(w http.ResponseWriter, r *http.Request) {
+	actor, ok := adminActor(w, r)
+	if !ok {
+		return
+	}
+
+	serviceID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid service ID")
+		return
+	}
+
+	var req service.ModerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	svc, err := h.adminService.ForceCloseService(r.Context(), actor, serviceID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, svc)
+}
+
+// GetAuditLog handles GET /api/v1/admin/audit-log
+func (h *AdminHandler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, offset := parsePagination(r)
+
+	req := &service.AuditLogRequest{
+		TargetType: query.Get("target_type"),
+		Limit:      limit,
+		Offset:     offset,
+	}
+	if v := query.Get("admin_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid admin_id")
+			return
+		}
+		req.AdminID = &id
+	}
+	if v := query.Get("target_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid target_id")
+			return
+		}
+		req.TargetID = &id
+	}
+
+	resp, err := h.adminService.GetAuditLog(r.Context(), req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func adminActor(w http.ResponseWriter, r *http.Request) (service.AdminActor, bool) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return service.AdminActor{}, false
+	}
+	return service.AdminActor{
+		AdminID:   claims.UserID,
+		IPAddress: middleware.ClientIP(r),
+	}, true
+}
+
+func parsePagination(r *http.Request) (limit, offset int) {
+	query := r.URL.Query()
+	limit = 20
+	if l := query.Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+	if o := query.Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil {
+			offset = parsed
+		}
+	}
+	return limit, offset
+}
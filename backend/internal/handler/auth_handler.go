@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/google/uuid"
 	"github.com/trenchjob/backend/internal/middleware"
 	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
 	"github.com/trenchjob/backend/internal/service"
@@ -25,7 +26,7 @@ func (h *AuthHandler) Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.authService.Signup(r.Context(), &req)
+	resp, err := h.authService.Signup(r.Context(), &req, sessionMeta(r))
 	if err != nil {
 		handleAppError(w, err)
 		return
@@ -42,7 +43,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.authService.Login(r.Context(), &req)
+	resp, err := h.authService.Login(r.Context(), &req, sessionMeta(r))
 	if err != nil {
 		handleAppError(w, err)
 		return
@@ -51,7 +52,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, resp)
 }
 
-// GetNonce returns a nonce for wallet authentication
+// GetNonce returns a sign-in message for wallet authentication
 func (h *AuthHandler) GetNonce(w http.ResponseWriter, r *http.Request) {
 	walletAddress := r.URL.Query().Get("wallet_address")
 	if walletAddress == "" {
@@ -59,16 +60,13 @@ func (h *AuthHandler) GetNonce(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	nonce, err := h.authService.GetNonce(r.Context(), walletAddress)
+	challenge, err := h.authService.GetNonce(r.Context(), walletAddress)
 	if err != nil {
 		handleAppError(w, err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]string{
-		"nonce":   nonce,
-		"message": "Sign this message to verify your wallet ownership: " + nonce,
-	})
+	respondJSON(w, http.StatusOK, challenge)
 }
 
 // WalletLogin handles wallet-based authentication
@@ -79,7 +77,7 @@ func (h *AuthHandler) WalletLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.authService.WalletLogin(r.Context(), &req)
+	resp, err := h.authService.WalletLogin(r.Context(), &req, sessionMeta(r))
 	if err != nil {
 		handleAppError(w, err)
 		return
@@ -88,7 +86,8 @@ func (h *AuthHandler) WalletLogin(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, resp)
 }
 
-// ConnectWallet links a wallet to the authenticated user's account
+// ConnectWallet links a wallet to the authenticated user's account after
+// verifying the signed sign-in message
 func (h *AuthHandler) ConnectWallet(w http.ResponseWriter, r *http.Request) {
 	claims := middleware.GetUserFromContext(r.Context())
 	if claims == nil {
@@ -96,10 +95,7 @@ func (h *AuthHandler) ConnectWallet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req struct {
-		WalletAddress string `json:"wallet_address"`
-		WalletType    string `json:"wallet_type"`
-	}
+	var req service.ConnectWalletRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, "invalid request body")
 		return
@@ -109,7 +105,7 @@ func (h *AuthHandler) ConnectWallet(w http.ResponseWriter, r *http.Request) {
 		req.WalletType = "phantom"
 	}
 
-	err := h.authService.ConnectWallet(r.Context(), claims.UserID, req.WalletAddress, req.WalletType)
+	err := h.authService.ConnectWallet(r.Context(), claims.UserID, &req)
 	if err != nil {
 		handleAppError(w, err)
 		return
@@ -118,6 +114,30 @@ func (h *AuthHandler) ConnectWallet(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{"message": "wallet connected successfully"})
 }
 
+// SetPrimaryWallet makes a verified wallet the authenticated user's primary wallet
+func (h *AuthHandler) SetPrimaryWallet(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req struct {
+		WalletAddress string `json:"wallet_address"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.authService.SetPrimaryWallet(r.Context(), claims.UserID, req.WalletAddress); err != nil {
+		handleAppError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "primary wallet updated"})
+}
+
 // GetWallets returns all wallets for the authenticated user
 func (h *AuthHandler) GetWallets(w http.ResponseWriter, r *http.Request) {
 	claims := middleware.GetUserFromContext(r.Context())
@@ -176,7 +196,7 @@ func (h *AuthHandler) EnableRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.authService.EnableRole(r.Context(), claims.UserID, req.Role)
+	resp, err := h.authService.EnableRole(r.Context(), claims, req.Role)
 	if err != nil {
 		handleAppError(w, err)
 		return
@@ -185,15 +205,16 @@ func (h *AuthHandler) EnableRole(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, resp)
 }
 
-// RefreshToken generates a new token
+// RefreshToken exchanges a refresh token for a new access token and a
+// rotated refresh token
 func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
-	claims := middleware.GetUserFromContext(r.Context())
-	if claims == nil {
-		respondError(w, http.StatusUnauthorized, "unauthorized")
+	var req service.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	resp, err := h.authService.RefreshToken(r.Context(), claims)
+	resp, err := h.authService.RefreshToken(r.Context(), &req)
 	if err != nil {
 		handleAppError(w, err)
 		return
@@ -202,15 +223,86 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, resp)
 }
 
-// Logout invalidates the user's session (client should discard token)
+// Logout revokes the session behind the current token
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	// Since we use JWTs, logout is primarily handled client-side
-	// The token should be removed from local storage
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.authService.Logout(r.Context(), claims); err != nil {
+		handleAppError(w, err)
+		return
+	}
+
 	respondJSON(w, http.StatusOK, map[string]string{"message": "logged out successfully"})
 }
 
+// ListSessions returns the authenticated user's active sessions
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(r.Context(), claims)
+	if err != nil {
+		handleAppError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"sessions": sessions})
+}
+
+// RevokeSession revokes one of the authenticated user's sessions
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	sessionID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid session ID")
+		return
+	}
+
+	if err := h.authService.RevokeSession(r.Context(), claims.UserID, sessionID); err != nil {
+		handleAppError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "session revoked"})
+}
+
+// LogoutAll revokes every session of the authenticated user
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.authService.RevokeAllSessions(r.Context(), claims.UserID); err != nil {
+		handleAppError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "logged out of all sessions"})
+}
+
 // Helper functions
 
+func sessionMeta(r *http.Request) service.SessionMeta {
+	return service.SessionMeta{
+		IPAddress: middleware.ClientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+}
+
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/trenchjob/backend/internal/middleware"
+	"github.com/trenchjob/backend/internal/service"
+)
+
+type ContractLifecycleHandler struct {
+	contractLifecycleService *service.ContractLifecycleService
+}
+
+func NewContractLifecycleHandler(contractLifecycleService *service.ContractLifecycleService) *ContractLifecycleHandler {
+	return &ContractLifecycleHandler{contractLifecycleService: contractLifecycleService}
+}
+
+// PauseContract handles POST /api/v1/contracts/{id}/pause
+func (h *ContractLifecycleHandler) PauseContract(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	contractID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contract ID")
+		return
+	}
+
+	var req service.ContractStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	contract, err := h.contractLifecycleService.PauseContract(r.Context(), contractID, claims.UserID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, contract)
+}
+
+// ResumeContract handles POST /api/v1/contracts/{id}/resume
+func (h *ContractLifecycleHandler) ResumeContract(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	contractID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contract ID")
+		return
+	}
+
+	var req service.ContractStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err.Error() != "EOF" {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	contract, err := h.contractLifecycleService.ResumeContract(r.Context(), contractID, claims.UserID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, contract)
+}
+
+// RequestCancellation handles POST /api/v1/contracts/{id}/cancel
+func (h *ContractLifecycleHandler) RequestCancellation(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	contractID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contract ID")
+		return
+	}
+
+	var req service.CancelContractRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	resp, err := h.contractLifecycleService.RequestCancellation(r.Context(), contractID, claims.UserID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// GetCancellation handles GET /api/v1/contracts/{id}/cancellation
+func (h *ContractLifecycleHandler) GetCancellation(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	contractID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contract ID")
+		return
+	}
+
+	cancellation, err := h.contractLifecycleService.GetCancellation(r.Context(), contractID, claims.UserID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cancellation)
+}
+
+// AcceptCancellation handles POST /api/v1/contracts/{id}/cancellation/accept
+func (h *ContractLifecycleHandler) AcceptCancellation(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	contractID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contract ID")
+		return
+	}
+
+	resp, err := h.contractLifecycleService.AcceptCancellation(r.Context(), contractID, claims.UserID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// DeclineCancellation handles POST /api/v1/contracts/{id}/cancellation/decline
+func (h *ContractLifecycleHandler) DeclineCancellation(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	contractID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contract ID")
+		return
+	}
+
+	cancellation, err := h.contractLifecycleService.DeclineCancellation(r.Context(), contractID, claims.UserID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cancellation)
+}
+
+// WithdrawCancellation handles POST /api/v1/contracts/{id}/cancellation/withdraw
+func (h *ContractLifecycleHandler) WithdrawCancellation(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	contractID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contract ID")
+		return
+	}
+
+	cancellation, err := h.contractLifecycleService.WithdrawCancellation(r.Context(), contractID, claims.UserID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cancellation)
+}
+
+// GetStatusHistory handles GET /api/v1/contracts/{id}/status-history
+func (h *ContractLifecycleHandler) GetStatusHistory(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	contractID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contract ID")
+		return
+	}
+
+	changes, err := h.contractLifecycleService.GetStatusHistory(r.Context(), contractID, claims.UserID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"changes": changes})
+}
@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/trenchjob/backend/internal/domain"
+	"github.com/trenchjob/backend/internal/middleware"
+	"github.com/trenchjob/backend/internal/service"
+)
+
+type DisputeHandler struct {
+	disputeService *service.DisputeService
+}
+
+func NewDisputeHandler(disputeService *service.DisputeService) *DisputeHandler {
+	return &DisputeHandler{disputeService: disputeService}
+}
+
+// OpenDispute handles POST /api/v1/contracts/{id}/disputes
+func (h *DisputeHandler) OpenDispute(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	contractID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contract ID")
+		return
+	}
+
+	var req service.OpenDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	dispute, err := h.disputeService.OpenDispute(r.Context(), contractID, claims.UserID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, dispute)
+}
+
+// GetContractDisputes handles GET /api/v1/contracts/{id}/disputes
+func (h *DisputeHandler) GetContractDisputes(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	contractID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contract ID")
+		return
+	}
+
+	disputes, err := h.disputeService.GetContractDisputes(r.Context(), contractID, claims.UserID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"disputes": disputes})
+}
+
+// GetDispute handles GET /api/v1/disputes/{id}
+func (h *DisputeHandler) GetDispute(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	disputeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid dispute ID")
+		return
+	}
+
+	dispute, err := h.disputeService.GetDispute(r.Context(), disputeID, claims.UserID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dispute)
+}
+
+// AddEvidence handles POST /api/v1/disputes/{id}/evidence
+func (h *DisputeHandler) AddEvidence(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	disputeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid dispute ID")
+		return
+	}
+
+	var req service.AddEvidenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	dispute, err := h.disputeService.AddEvidence(r.Context(), disputeID, claims.UserID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dispute)
+}
+
+// ListDisputes handles GET /api/v1/admin/disputes
+func (h *DisputeHandler) ListDisputes(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePagination(r)
+
+	resp, err := h.disputeService.ListDisputes(r.Context(), r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// AdminGetDispute handles GET /api/v1/admin/disputes/{id}
+func (h *DisputeHandler) AdminGetDispute(w http.ResponseWriter, r *http.Request) {
+	disputeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid dispute ID")
+		return
+	}
+
+	dispute, err := h.disputeService.AdminGetDispute(r.Context(), disputeID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dispute)
+}
+
+// StartReview handles POST /api/v1/admin/disputes/{id}/review
+func (h *DisputeHandler) StartReview(w http.ResponseWriter, r *http.Request) {
+	h.moderateDispute(w, r, h.disputeService.StartReview)
+}
+
+// Escalate handles POST /api/v1/admin/disputes/{id}/escalate
+func (h *DisputeHandler) Escalate(w http.ResponseWriter, r *http.Request) {
+	h.moderateDispute(w, r, h.disputeService.Escalate)
+}
+
+// Close handles POST /api/v1/admin/disputes/{id}/close
+func (h *DisputeHandler) Close(w http.ResponseWriter, r *http.Request) {
+	h.moderateDispute(w, r, h.disputeService.Close)
+}
+
+func (h *DisputeHandler) moderateDispute(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, actor service.AdminActor, disputeID uuid.UUID, req *service.ModerationRequest) (*domain.Dispute, error)) {
+	actor, ok := adminActor(w, r)
+	if !ok {
+		return
+	}
+
+	disputeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid dispute ID")
+		return
+	}
+
+	var req service.ModerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	dispute, err := action(r.Context(), actor, disputeID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dispute)
+}
+
+// Resolve handles POST /api/v1/admin/disputes/{id}/resolve
+func (h *DisputeHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	actor, ok := adminActor(w, r)
+	if !ok {
+		return
+	}
+
+	disputeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid dispute ID")
+		return
+	}
+
+	var req service.ResolveDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	resp, err := h.disputeService.Resolve(r.Context(), actor, disputeID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// BuildSettlement handles POST /api/v1/disputes/{id}/build/settle
+func (h *DisputeHandler) BuildSettlement(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	disputeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid dispute ID")
+		return
+	}
+
+	resp, err := h.disputeService.BuildSettlement(r.Context(), disputeID, claims.UserID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
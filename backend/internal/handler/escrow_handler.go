@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/trenchjob/backend/internal/middleware"
+	"github.com/trenchjob/backend/internal/service"
+)
+
+type EscrowHandler struct {
+	escrowService    *service.EscrowService
+	escrowReconciler *service.EscrowReconciler
+}
+
+func NewEscrowHandler(escrowService *service.EscrowService, escrowReconciler *service.EscrowReconciler) *EscrowHandler {
+	return &EscrowHandler{
+		escrowService:    escrowService,
+		escrowReconciler: escrowReconciler,
+	}
+}
+
+// ConfirmFunding handles POST /api/v1/contracts/{id}/escrow/fund
+func (h *EscrowHandler) ConfirmFunding(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	contractID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contract ID")
+		return
+	}
+
+	var req service.ConfirmFundingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	resp, err := h.escrowService.ConfirmFunding(r.Context(), contractID, claims.UserID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ReleaseMilestone handles POST /api/v1/milestones/{id}/release
+func (h *EscrowHandler) ReleaseMilestone(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	milestoneID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid milestone ID")
+		return
+	}
+
+	var req service.ReleaseMilestoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	resp, err := h.escrowService.ReleaseMilestone(r.Context(), milestoneID, claims.UserID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// BuildInitializeEscrow handles POST /api/v1/contracts/{id}/escrow/build/initialize
+func (h *EscrowHandler) BuildInitializeEscrow(w http.ResponseWriter, r *http.Request) {
+	h.buildWithWallet(w, r, h.escrowService.BuildInitializeEscrow)
+}
+
+// BuildFundEscrow handles POST /api/v1/contracts/{id}/escrow/build/fund
+func (h *EscrowHandler) BuildFundEscrow(w http.ResponseWriter, r *http.Request) {
+	h.buildWithWallet(w, r, h.escrowService.BuildFundEscrow)
+}
+
+// BuildRefund handles POST /api/v1/contracts/{id}/escrow/build/refund
+func (h *EscrowHandler) BuildRefund(w http.ResponseWriter, r *http.Request) {
+	h.build(w, r, "invalid contract ID", h.escrowService.BuildRefund)
+}
+
+// BuildOpenDispute handles POST /api/v1/contracts/{id}/escrow/build/dispute
+func (h *EscrowHandler) BuildOpenDispute(w http.ResponseWriter, r *http.Request) {
+	h.build(w, r, "invalid contract ID", h.escrowService.BuildOpenDispute)
+}
+
+// BuildCloseEscrow handles POST /api/v1/contracts/{id}/escrow/build/close
+func (h *EscrowHandler) BuildCloseEscrow(w http.ResponseWriter, r *http.Request) {
+	h.build(w, r, "invalid contract ID", h.escrowService.BuildCloseEscrow)
+}
+
+// BuildReleaseMilestone handles POST /api/v1/milestones/{id}/escrow/build/release
+func (h *EscrowHandler) BuildReleaseMilestone(w http.ResponseWriter, r *http.Request) {
+	h.build(w, r, "invalid milestone ID", h.escrowService.BuildReleaseMilestone)
+}
+
+type buildFunc func(ctx context.Context, id, userID uuid.UUID) (*service.UnsignedTransactionResponse, error)
+
+func (h *EscrowHandler) build(w http.ResponseWriter, r *http.Request, invalidID string, fn buildFunc) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, invalidID)
+		return
+	}
+
+	resp, err := fn(r.Context(), id, claims.UserID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// buildWithWallet is build for transactions where the caller may choose
+// the signing wallet in an optional JSON body
+func (h *EscrowHandler) buildWithWallet(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, contractID, userID uuid.UUID, req *service.BuildTransactionRequest) (*service.UnsignedTransactionResponse, error)) {
+	var req service.BuildTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	h.build(w, r, "invalid contract ID", func(ctx context.Context, id, userID uuid.UUID) (*service.UnsignedTransactionResponse, error) {
+		return fn(ctx, id, userID, &req)
+	})
+}
+
+// GetReconciliationReport handles GET /api/v1/admin/escrows/reconciliation
+func (h *EscrowHandler) GetReconciliationReport(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePagination(r)
+
+	report, err := h.escrowReconciler.GetReport(r.Context(), limit, offset)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
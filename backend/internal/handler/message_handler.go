@@ -8,15 +8,20 @@ import (
 	"github.com/google/uuid"
 	"github.com/trenchjob/backend/internal/middleware"
 	"github.com/trenchjob/backend/internal/service"
+	ws "github.com/trenchjob/backend/internal/websocket"
 )
 
 type MessageHandler struct {
 	messageService *service.MessageService
+	hub            *ws.Hub
 }
 
-func NewMessageHandler(messageService *service.MessageService) *MessageHandler {
+// NewMessageHandler creates the REST chat handler. Changes made here are
+// broadcast through the hub like those made over the WebSocket.
+func NewMessageHandler(messageService *service.MessageService, hub *ws.Hub) *MessageHandler {
 	return &MessageHandler{
 		messageService: messageService,
+		hub:            hub,
 	}
 }
 
@@ -139,8 +144,9 @@ func (h *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		MessageText string `json:"message_text"`
-		MessageType string `json:"message_type,omitempty"`
+		MessageText      string     `json:"message_text"`
+		MessageType      string     `json:"message_type,omitempty"`
+		ReplyToMessageID *uuid.UUID `json:"reply_to_message_id,omitempty"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -149,9 +155,10 @@ func (h *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	sendReq := &service.SendMessageRequest{
-		ConversationID: conversationID,
-		MessageText:    req.MessageText,
-		MessageType:    req.MessageType,
+		ConversationID:   conversationID,
+		MessageText:      req.MessageText,
+		MessageType:      req.MessageType,
+		ReplyToMessageID: req.ReplyToMessageID,
 	}
 
 	message, err := h.messageService.SendMessage(r.Context(), claims.UserID, sendReq)
@@ -160,6 +167,10 @@ func (h *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.hub.NotifyConversation(conversationID, claims.UserID, ws.NotifyNewMessage, ws.NewMessageNotification{
+		Message: message,
+	}, true)
+
 	writeJSON(w, http.StatusCreated, message)
 }
 
@@ -255,3 +266,88 @@ func (h *MessageHandler) MarkConversationRead(w http.ResponseWriter, r *http.Req
 		"success": true,
 	})
 }
+
+// EditMessage handles PUT /api/v1/messages/{id}
+func (h *MessageHandler) EditMessage(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	messageID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid message id")
+		return
+	}
+
+	var req service.EditMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	message, err := h.messageService.EditMessage(r.Context(), claims.UserID, messageID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	h.hub.NotifyConversation(message.ConversationID, claims.UserID, ws.NotifyMessageEdited, ws.MessageChangedNotification{
+		Message: message,
+	}, false)
+
+	writeJSON(w, http.StatusOK, message)
+}
+
+// DeleteMessage handles DELETE /api/v1/messages/{id}
+func (h *MessageHandler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	messageID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid message id")
+		return
+	}
+
+	message, err := h.messageService.DeleteMessage(r.Context(), claims.UserID, messageID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	h.hub.NotifyConversation(message.ConversationID, claims.UserID, ws.NotifyMessageDeleted, ws.MessageChangedNotification{
+		Message: message,
+	}, false)
+
+	writeJSON(w, http.StatusOK, message)
+}
+
+// GetMessageEdits handles GET /api/v1/messages/{id}/edits
+func (h *MessageHandler) GetMessageEdits(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	messageID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid message id")
+		return
+	}
+
+	edits, err := h.messageService.GetMessageEdits(r.Context(), claims.UserID, messageID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"edits": edits,
+	})
+}
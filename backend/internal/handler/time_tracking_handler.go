@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/trenchjob/backend/internal/middleware"
+	"github.com/trenchjob/backend/internal/service"
+)
+
+type TimeTrackingHandler struct {
+	timeTrackingService *service.TimeTrackingService
+}
+
+func NewTimeTrackingHandler(timeTrackingService *service.TimeTrackingService) *TimeTrackingHandler {
+	return &TimeTrackingHandler{timeTrackingService: timeTrackingService}
+}
+
+// LogTime handles POST /api/v1/contracts/{id}/time-entries
+func (h *TimeTrackingHandler) LogTime(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	contractID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contract ID")
+		return
+	}
+
+	var req service.LogTimeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	entry, err := h.timeTrackingService.LogTime(r.Context(), contractID, claims.UserID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+// GetWeek handles GET /api/v1/contracts/{id}/time-entries?week=YYYY-MM-DD
+func (h *TimeTrackingHandler) GetWeek(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	contractID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contract ID")
+		return
+	}
+
+	summary, err := h.timeTrackingService.GetWeek(r.Context(), contractID, claims.UserID, r.URL.Query().Get("week"))
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// StartTimer handles POST /api/v1/contracts/{id}/timer/start
+func (h *TimeTrackingHandler) StartTimer(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	contractID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contract ID")
+		return
+	}
+
+	var req service.StartTimerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err.Error() != "EOF" {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	entry, err := h.timeTrackingService.StartTimer(r.Context(), contractID, claims.UserID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+// StopTimer handles POST /api/v1/contracts/{id}/timer/stop
+func (h *TimeTrackingHandler) StopTimer(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	contractID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contract ID")
+		return
+	}
+
+	entry, err := h.timeTrackingService.StopTimer(r.Context(), contractID, claims.UserID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// DeleteTimeEntry handles DELETE /api/v1/time-entries/{id}
+func (h *TimeTrackingHandler) DeleteTimeEntry(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	entryID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid time entry ID")
+		return
+	}
+
+	if err := h.timeTrackingService.DeleteTimeEntry(r.Context(), entryID, claims.UserID); err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "time entry deleted"})
+}
+
+// SubmitTimesheet handles POST /api/v1/contracts/{id}/timesheets
+func (h *TimeTrackingHandler) SubmitTimesheet(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	contractID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contract ID")
+		return
+	}
+
+	var req service.SubmitTimesheetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	timesheet, err := h.timeTrackingService.SubmitTimesheet(r.Context(), contractID, claims.UserID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, timesheet)
+}
+
+// ListTimesheets handles GET /api/v1/contracts/{id}/timesheets
+func (h *TimeTrackingHandler) ListTimesheets(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	contractID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contract ID")
+		return
+	}
+
+	timesheets, err := h.timeTrackingService.ListTimesheets(r.Context(), contractID, claims.UserID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"timesheets": timesheets})
+}
+
+// GetTimesheet handles GET /api/v1/timesheets/{id}
+func (h *TimeTrackingHandler) GetTimesheet(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	timesheetID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid timesheet ID")
+		return
+	}
+
+	timesheet, err := h.timeTrackingService.GetTimesheet(r.Context(), timesheetID, claims.UserID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, timesheet)
+}
+
+// ApproveTimesheet handles POST /api/v1/timesheets/{id}/approve
+func (h *TimeTrackingHandler) ApproveTimesheet(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	timesheetID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid timesheet ID")
+		return
+	}
+
+	timesheet, err := h.timeTrackingService.ApproveTimesheet(r.Context(), timesheetID, claims.UserID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, timesheet)
+}
+
+// DisputeTimesheet handles POST /api/v1/timesheets/{id}/dispute
+func (h *TimeTrackingHandler) DisputeTimesheet(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	timesheetID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid timesheet ID")
+		return
+	}
+
+	var req service.DisputeTimesheetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	timesheet, err := h.timeTrackingService.DisputeTimesheet(r.Context(), timesheetID, claims.UserID, &req)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, timesheet)
+}
@@ -15,28 +15,34 @@ import (
 	ws "github.com/trenchjob/backend/internal/websocket"
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		// Allow all origins in development, restrict in production
-		return true
-	},
-}
-
 // WebSocketHandler handles WebSocket connections
 type WebSocketHandler struct {
 	hub            *ws.Hub
 	messageService *service.MessageService
 	userService    *service.AuthService
+	upgrader       websocket.Upgrader
 }
 
-// NewWebSocketHandler creates a new WebSocket handler
-func NewWebSocketHandler(hub *ws.Hub, messageService *service.MessageService, userService *service.AuthService) *WebSocketHandler {
+// NewWebSocketHandler creates a new WebSocket handler. Browsers may only
+// upgrade from origins the CORS config allows. Clients that send no Origin
+// header, which browsers always do, are let through outside production so
+// local tools can connect.
+func NewWebSocketHandler(hub *ws.Hub, messageService *service.MessageService, userService *service.AuthService, corsConfig middleware.CORSConfig, production bool) *WebSocketHandler {
 	return &WebSocketHandler{
 		hub:            hub,
 		messageService: messageService,
 		userService:    userService,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				if origin == "" {
+					return !production
+				}
+				return corsConfig.AllowsOrigin(origin)
+			},
+		},
 	}
 }
 
@@ -50,7 +56,7 @@ func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Upgrade HTTP connection to WebSocket
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("Failed to upgrade connection: %v", err)
 		return
@@ -63,6 +69,31 @@ func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reques
 	log.Printf("WebSocket client connected: user=%s", claims.UserID)
 }
 
+// HandlePresenceChange is the hub's presence callback. It records the change
+// and tells the user's contacts connected to this instance; with a broker,
+// every instance sees the same change and tells its own connections.
+func (h *WebSocketHandler) HandlePresenceChange(userID uuid.UUID, isOnline bool) {
+	h.messageService.UpdatePresence(userID, isOnline)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	contactIDs, err := h.messageService.GetContactIDs(ctx, userID)
+	if err != nil {
+		log.Printf("presence: failed to load contacts of user %s: %v", userID, err)
+		return
+	}
+
+	notification := ws.NewNotification(ws.NotifyPresence, ws.PresenceNotification{
+		UserID:   userID.String(),
+		IsOnline: isOnline,
+	})
+	notifData, _ := ws.MarshalNotification(notification)
+	for _, contactID := range contactIDs {
+		h.hub.SendToLocalUser(contactID, notifData)
+	}
+}
+
 // handleMessage processes incoming JSON-RPC messages
 func (h *WebSocketHandler) handleMessage(client *ws.Client, message []byte) {
 	req, err := ws.ParseRequest(message)
@@ -102,6 +133,12 @@ func (h *WebSocketHandler) handleMessage(client *ws.Client, message []byte) {
 		resp = h.handleJoinConversation(ctx, client, req)
 	case ws.MethodLeaveConversation:
 		resp = h.handleLeaveConversation(ctx, client, req)
+	case ws.MethodEditMessage:
+		resp = h.handleEditMessage(ctx, client, req)
+	case ws.MethodDeleteMessage:
+		resp = h.handleDeleteMessage(ctx, client, req)
+	case ws.MethodGetMessageEdits:
+		resp = h.handleGetMessageEdits(ctx, client, req)
 	default:
 		resp = ws.MethodNotFoundResponse(req.ID, req.Method)
 	}
@@ -145,6 +182,13 @@ func (h *WebSocketHandler) handleSendMessage(ctx context.Context, client *ws.Cli
 		MessageText:    params.Text,
 		MessageType:    domain.MessageTypeText,
 	}
+	if params.ReplyToMessageID != "" {
+		replyTo, err := uuid.Parse(params.ReplyToMessageID)
+		if err != nil {
+			return ws.InvalidParamsResponse(req.ID, "invalid reply_to_message_id")
+		}
+		sendReq.ReplyToMessageID = &replyTo
+	}
 
 	message, err := h.messageService.SendMessageWithAttachments(ctx, client.UserID(), sendReq, attachments)
 	if err != nil {
@@ -381,3 +425,80 @@ func (h *WebSocketHandler) handleLeaveConversation(ctx context.Context, client *
 
 	return ws.NewResponse(req.ID, map[string]bool{"success": true})
 }
+
+// handleEditMessage handles chat.editMessage
+func (h *WebSocketHandler) handleEditMessage(ctx context.Context, client *ws.Client, req *ws.RPCRequest) *ws.RPCResponse {
+	var params ws.EditMessageParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return ws.InvalidParamsResponse(req.ID, err.Error())
+	}
+
+	if params.MessageID == "" || params.Text == "" {
+		return ws.InvalidParamsResponse(req.ID, "message_id and text are required")
+	}
+
+	messageID, err := uuid.Parse(params.MessageID)
+	if err != nil {
+		return ws.InvalidParamsResponse(req.ID, "invalid message_id")
+	}
+
+	message, err := h.messageService.EditMessage(ctx, client.UserID(), messageID, &service.EditMessageRequest{
+		MessageText: params.Text,
+	})
+	if err != nil {
+		return ws.InternalErrorResponse(req.ID, err.Error())
+	}
+
+	// Other tabs of the sender update too, so the sender is not excluded
+	h.hub.NotifyConversation(message.ConversationID, client.UserID(), ws.NotifyMessageEdited, ws.MessageChangedNotification{
+		Message: message,
+	}, false)
+
+	return ws.NewResponse(req.ID, message)
+}
+
+// handleDeleteMessage handles chat.deleteMessage
+func (h *WebSocketHandler) handleDeleteMessage(ctx context.Context, client *ws.Client, req *ws.RPCRequest) *ws.RPCResponse {
+	var params ws.MessageIDParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return ws.InvalidParamsResponse(req.ID, err.Error())
+	}
+
+	messageID, err := uuid.Parse(params.MessageID)
+	if err != nil {
+		return ws.InvalidParamsResponse(req.ID, "invalid message_id")
+	}
+
+	message, err := h.messageService.DeleteMessage(ctx, client.UserID(), messageID)
+	if err != nil {
+		return ws.InternalErrorResponse(req.ID, err.Error())
+	}
+
+	h.hub.NotifyConversation(message.ConversationID, client.UserID(), ws.NotifyMessageDeleted, ws.MessageChangedNotification{
+		Message: message,
+	}, false)
+
+	return ws.NewResponse(req.ID, message)
+}
+
+// handleGetMessageEdits handles chat.getMessageEdits
+func (h *WebSocketHandler) handleGetMessageEdits(ctx context.Context, client *ws.Client, req *ws.RPCRequest) *ws.RPCResponse {
+	var params ws.MessageIDParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return ws.InvalidParamsResponse(req.ID, err.Error())
+	}
+
+	messageID, err := uuid.Parse(params.MessageID)
+	if err != nil {
+		return ws.InvalidParamsResponse(req.ID, "invalid message_id")
+	}
+
+	edits, err := h.messageService.GetMessageEdits(ctx, client.UserID(), messageID)
+	if err != nil {
+		return ws.InternalErrorResponse(req.ID, err.Error())
+	}
+
+	return ws.NewResponse(req.ID, map[string]interface{}{
+		"edits": edits,
+	})
+}
@@ -14,12 +14,19 @@ const (
 	UserContextKey contextKey = "user"
 )
 
+// SessionValidator reports whether the server-side session behind a token
+// is still active (not logged out, revoked or expired)
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, claims *utils.JWTClaims) error
+}
+
 type AuthMiddleware struct {
 	jwtManager *utils.JWTManager
+	sessions   SessionValidator
 }
 
-func NewAuthMiddleware(jwtManager *utils.JWTManager) *AuthMiddleware {
-	return &AuthMiddleware{jwtManager: jwtManager}
+func NewAuthMiddleware(jwtManager *utils.JWTManager, sessions SessionValidator) *AuthMiddleware {
+	return &AuthMiddleware{jwtManager: jwtManager, sessions: sessions}
 }
 
 // Authenticate validates the JWT token and adds user claims to context
@@ -54,6 +61,12 @@ func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
+		// Reject tokens whose session was revoked
+		if err := m.sessions.ValidateSession(r.Context(), claims); err != nil {
+			http.Error(w, `{"error": "session revoked or expired"}`, http.StatusUnauthorized)
+			return
+		}
+
 		// Add claims to context
 		ctx := context.WithValue(r.Context(), UserContextKey, claims)
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -82,6 +95,11 @@ func (m *AuthMiddleware) OptionalAuth(next http.Handler) http.Handler {
 			return
 		}
 
+		if err := m.sessions.ValidateSession(r.Context(), claims); err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		ctx := context.WithValue(r.Context(), UserContextKey, claims)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -123,6 +141,24 @@ func (m *AuthMiddleware) RequireFreelancer(next http.Handler) http.Handler {
 	})
 }
 
+// RequireAdmin ensures the user is an admin
+func (m *AuthMiddleware) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims := GetUserFromContext(r.Context())
+		if claims == nil {
+			http.Error(w, `{"error": "unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+
+		if !claims.IsAdmin {
+			http.Error(w, `{"error": "admin role required"}`, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 // GetUserFromContext retrieves user claims from the request context
 func GetUserFromContext(ctx context.Context) *utils.JWTClaims {
 	claims, ok := ctx.Value(UserContextKey).(*utils.JWTClaims)
@@ -24,21 +24,23 @@ func DefaultCORSConfig() CORSConfig {
 	}
 }
 
+// AllowsOrigin reports whether a browser at origin may call the API
+func (c CORSConfig) AllowsOrigin(origin string) bool {
+	for _, o := range c.AllowedOrigins {
+		if o == "*" || o == origin {
+			return true
+		}
+	}
+	return false
+}
+
 func CORS(config CORSConfig) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			origin := r.Header.Get("Origin")
 
 			// Check if origin is allowed
-			allowed := false
-			for _, o := range config.AllowedOrigins {
-				if o == "*" || o == origin {
-					allowed = true
-					break
-				}
-			}
-
-			if allowed {
+			if config.AllowsOrigin(origin) {
 				w.Header().Set("Access-Control-Allow-Origin", origin)
 			}
 
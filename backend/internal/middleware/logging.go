@@ -2,9 +2,12 @@ package middleware
 
 import (
 	"bufio"
+	"context"
+	"fmt"
 	"log"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -71,3 +74,110 @@ func Recovery(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// ClientIPContextKey carries the client address resolved by RealIP
+const ClientIPContextKey contextKey = "client_ip"
+
+// ParseTrustedProxies parses proxy addresses given as CIDRs or single IPs
+func ParseTrustedProxies(entries []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// RealIP resolves the client address of each request. X-Forwarded-For and
+// X-Real-IP are only honoured when the connection comes from a trusted
+// proxy, since any client can set them.
+func RealIP(trustedProxies []*net.IPNet) func(http.Handler) http.Handler {
+	trusted := func(ip net.IP) bool {
+		for _, ipNet := range trustedProxies {
+			if ipNet.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := remoteIP(r)
+			if ip != nil && trusted(ip) {
+				ip = forwardedIP(r, ip, trusted)
+			}
+
+			clientIP := ""
+			if ip != nil {
+				clientIP = ip.String()
+			}
+			ctx := context.WithValue(r.Context(), ClientIPContextKey, clientIP)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// forwardedIP walks X-Forwarded-For from the nearest hop back, skipping our
+// own proxies; the first address they did not add is the client. Entries
+// further left were written by the client and cannot be believed.
+func forwardedIP(r *http.Request, peer net.IP, trusted func(net.IP) bool) net.IP {
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	if len(hops) == 0 {
+		if realIP := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); realIP != nil {
+			return realIP
+		}
+		return peer
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		client = ip
+		if !trusted(ip) {
+			break
+		}
+	}
+	return client
+}
+
+// ClientIP returns the client address resolved by RealIP, or the connection's
+// peer address when RealIP is not installed
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(ClientIPContextKey).(string); ok {
+		return ip
+	}
+	if ip := remoteIP(r); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+func remoteIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
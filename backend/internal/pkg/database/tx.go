@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type txKey struct{}
+
+type afterCommitKey struct{}
+
+// afterCommitHooks collects the callbacks registered in one transaction or
+// savepoint
+type afterCommitHooks struct {
+	fns []func()
+}
+
+// Conn runs queries on the transaction carried by the context, or on the
+// pool when there is none. Repositories hold a Conn instead of the pool so
+// that they join whatever unit of work their caller started.
+type Conn struct {
+	pool *pgxpool.Pool
+}
+
+func NewConn(pool *pgxpool.Pool) *Conn {
+	return &Conn{pool: pool}
+}
+
+func (c *Conn) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if tx := txFromContext(ctx); tx != nil {
+		return tx.Exec(ctx, sql, args...)
+	}
+	return c.pool.Exec(ctx, sql, args...)
+}
+
+func (c *Conn) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if tx := txFromContext(ctx); tx != nil {
+		return tx.Query(ctx, sql, args...)
+	}
+	return c.pool.Query(ctx, sql, args...)
+}
+
+func (c *Conn) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if tx := txFromContext(ctx); tx != nil {
+		return tx.QueryRow(ctx, sql, args...)
+	}
+	return c.pool.QueryRow(ctx, sql, args...)
+}
+
+// Begin starts a transaction, or a savepoint inside the context's
+// transaction if there is one
+func (c *Conn) Begin(ctx context.Context) (pgx.Tx, error) {
+	if tx := txFromContext(ctx); tx != nil {
+		return tx.Begin(ctx)
+	}
+	return c.pool.Begin(ctx)
+}
+
+// TxManager runs units of work in a single database transaction
+type TxManager struct {
+	pool *pgxpool.Pool
+}
+
+func NewTxManager(pool *pgxpool.Pool) *TxManager {
+	return &TxManager{pool: pool}
+}
+
+// WithinTx runs fn with a context carrying a transaction, committing if fn
+// returns nil and rolling back otherwise. When ctx already carries a
+// transaction fn runs in a savepoint of it, so units of work compose and a
+// failed inner unit can be tolerated without aborting the outer one.
+// AfterCommit callbacks of a released savepoint wait for the outer commit.
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	var tx pgx.Tx
+	if outer := txFromContext(ctx); outer != nil {
+		tx, err = outer.Begin(ctx)
+	} else {
+		tx, err = m.pool.Begin(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback(ctx)
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	hooks := &afterCommitHooks{}
+	txCtx := context.WithValue(context.WithValue(ctx, txKey{}, tx), afterCommitKey{}, hooks)
+	if err = fn(txCtx); err != nil {
+		return err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if outer := hooksFromContext(ctx); outer != nil {
+		outer.fns = append(outer.fns, hooks.fns...)
+		return nil
+	}
+	for _, hook := range hooks.fns {
+		hook()
+	}
+	return nil
+}
+
+// AfterCommit runs fn once the outermost transaction carried by ctx
+// commits, dropping it if the transaction or the savepoint it was
+// registered in rolls back. Outside a transaction fn runs immediately.
+func (m *TxManager) AfterCommit(ctx context.Context, fn func()) {
+	if hooks := hooksFromContext(ctx); hooks != nil {
+		hooks.fns = append(hooks.fns, fn)
+		return
+	}
+	fn()
+}
+
+func hooksFromContext(ctx context.Context) *afterCommitHooks {
+	hooks, _ := ctx.Value(afterCommitKey{}).(*afterCommitHooks)
+	return hooks
+}
+
+func txFromContext(ctx context.Context) pgx.Tx {
+	tx, _ := ctx.Value(txKey{}).(pgx.Tx)
+	return tx
+}
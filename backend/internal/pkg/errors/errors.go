@@ -22,6 +22,7 @@ var (
 	ErrInsufficientFunds = errors.New("insufficient funds")
 	ErrEscrowNotFunded   = errors.New("escrow not funded")
 	ErrInvalidSignature  = errors.New("invalid signature")
+	ErrVersionConflict   = errors.New("resource was modified concurrently")
 )
 
 // AppError represents an application error with HTTP status
@@ -90,6 +91,17 @@ func NewConflict(message string) *AppError {
 	}
 }
 
+func NewInvalidSignature(message string) *AppError {
+	if message == "" {
+		message = "invalid signature"
+	}
+	return &AppError{
+		Err:        ErrInvalidSignature,
+		Message:    message,
+		StatusCode: http.StatusUnauthorized,
+	}
+}
+
 func NewInternal(err error) *AppError {
 	return &AppError{
 		Err:        err,
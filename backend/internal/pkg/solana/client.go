@@ -0,0 +1,379 @@
+package solana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Commitment is the level of finality a query is evaluated at
+type Commitment string
+
+const (
+	CommitmentProcessed Commitment = "processed"
+	CommitmentConfirmed Commitment = "confirmed"
+	CommitmentFinalized Commitment = "finalized"
+)
+
+// ParseCommitment maps a config string to a Commitment, defaulting to confirmed
+func ParseCommitment(s string) Commitment {
+	switch Commitment(s) {
+	case CommitmentProcessed, CommitmentFinalized:
+		return Commitment(s)
+	default:
+		return CommitmentConfirmed
+	}
+}
+
+// ErrAccountNotFound is returned by GetAccountInfo when the account does not exist
+var ErrAccountNotFound = errors.New("solana: account not found")
+
+// ErrTransactionNotFound is returned by GetTransaction when the node has no
+// record of the signature at the requested commitment
+var ErrTransactionNotFound = errors.New("solana: transaction not found")
+
+// Client is the subset of the Solana JSON-RPC API the backend relies on.
+// Services depend on this interface so they can run against a fake server.
+type Client interface {
+	GetAccountInfo(ctx context.Context, address string) (*AccountInfo, error)
+	GetSignatureStatuses(ctx context.Context, signatures ...string) ([]*SignatureStatus, error)
+	GetTransaction(ctx context.Context, signature string) (*Transaction, error)
+	GetBalance(ctx context.Context, address string) (uint64, error)
+	GetSlot(ctx context.Context) (uint64, error)
+	GetLatestBlockhash(ctx context.Context) (*Blockhash, error)
+	GetSignaturesForAddress(ctx context.Context, address string, opts SignaturesOptions) ([]SignatureInfo, error)
+}
+
+// Config controls how the RPC client talks to a node
+type Config struct {
+	Endpoint   string
+	Commitment Commitment
+	Timeout    time.Duration // per attempt
+	MaxRetries int
+	HTTPClient *http.Client
+}
+
+// RPCClient is a JSON-RPC over HTTP implementation of Client
+type RPCClient struct {
+	endpoint   string
+	commitment Commitment
+	timeout    time.Duration
+	maxRetries int
+	httpClient *http.Client
+	nextID     atomic.Uint64
+
+	// Wait before the first retry, doubled for each one after it
+	backoff time.Duration
+}
+
+// NewClient creates a new Solana RPC client
+func NewClient(cfg Config) *RPCClient {
+	if cfg.Commitment == "" {
+		cfg.Commitment = CommitmentConfirmed
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{}
+	}
+
+	return &RPCClient{
+		endpoint:   cfg.Endpoint,
+		commitment: cfg.Commitment,
+		timeout:    cfg.Timeout,
+		maxRetries: cfg.MaxRetries,
+		httpClient: cfg.HTTPClient,
+
+		backoff: 250 * time.Millisecond,
+	}
+}
+
+// Commitment returns the commitment level the client queries at
+func (c *RPCClient) Commitment() Commitment {
+	return c.commitment
+}
+
+// GetAccountInfo fetches an account's lamports, owner and raw data
+func (c *RPCClient) GetAccountInfo(ctx context.Context, address string) (*AccountInfo, error) {
+	var result struct {
+		Value *accountInfoJSON `json:"value"`
+	}
+	params := []interface{}{address, map[string]interface{}{
+		"encoding":   "base64",
+		"commitment": c.commitment,
+	}}
+	if err := c.call(ctx, "getAccountInfo", params, &result); err != nil {
+		return nil, err
+	}
+	if result.Value == nil {
+		return nil, ErrAccountNotFound
+	}
+	return result.Value.toAccountInfo()
+}
+
+// GetSignatureStatuses looks up the status of each signature. The returned
+// slice lines up with the input and holds nil for unknown signatures.
+func (c *RPCClient) GetSignatureStatuses(ctx context.Context, signatures ...string) ([]*SignatureStatus, error) {
+	if len(signatures) == 0 {
+		return nil, nil
+	}
+
+	var result struct {
+		Value []*SignatureStatus `json:"value"`
+	}
+	params := []interface{}{signatures, map[string]interface{}{
+		"searchTransactionHistory": true,
+	}}
+	if err := c.call(ctx, "getSignatureStatuses", params, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Value) != len(signatures) {
+		return nil, fmt.Errorf("solana: expected %d signature statuses, got %d", len(signatures), len(result.Value))
+	}
+	return result.Value, nil
+}
+
+// GetTransaction fetches a confirmed transaction with its metadata
+func (c *RPCClient) GetTransaction(ctx context.Context, signature string) (*Transaction, error) {
+	// getTransaction does not support the processed commitment level
+	commitment := c.commitment
+	if commitment == CommitmentProcessed {
+		commitment = CommitmentConfirmed
+	}
+
+	var result *Transaction
+	params := []interface{}{signature, map[string]interface{}{
+		"encoding":                       "json",
+		"commitment":                     commitment,
+		"maxSupportedTransactionVersion": 0,
+	}}
+	if err := c.call(ctx, "getTransaction", params, &result); err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, ErrTransactionNotFound
+	}
+	result.Signature = signature
+	return result, nil
+}
+
+// GetBalance returns an account's balance in lamports
+func (c *RPCClient) GetBalance(ctx context.Context, address string) (uint64, error) {
+	var result struct {
+		Value uint64 `json:"value"`
+	}
+	params := []interface{}{address, map[string]interface{}{"commitment": c.commitment}}
+	if err := c.call(ctx, "getBalance", params, &result); err != nil {
+		return 0, err
+	}
+	return result.Value, nil
+}
+
+// GetSlot returns the slot the node has reached at the client's commitment
+func (c *RPCClient) GetSlot(ctx context.Context) (uint64, error) {
+	var slot uint64
+	params := []interface{}{map[string]interface{}{"commitment": c.commitment}}
+	if err := c.call(ctx, "getSlot", params, &slot); err != nil {
+		return 0, err
+	}
+	return slot, nil
+}
+
+// GetLatestBlockhash returns a recent blockhash for building transactions
+func (c *RPCClient) GetLatestBlockhash(ctx context.Context) (*Blockhash, error) {
+	var result struct {
+		Value Blockhash `json:"value"`
+	}
+	params := []interface{}{map[string]interface{}{"commitment": c.commitment}}
+	if err := c.call(ctx, "getLatestBlockhash", params, &result); err != nil {
+		return nil, err
+	}
+	return &result.Value, nil
+}
+
+// SignaturesOptions pages through an address's history, newest first.
+// Before and Until are exclusive signature bounds; Limit is at most 1000.
+type SignaturesOptions struct {
+	Before string
+	Until  string
+	Limit  int
+}
+
+// GetSignaturesForAddress lists transactions that touched address, newest first
+func (c *RPCClient) GetSignaturesForAddress(ctx context.Context, address string, opts SignaturesOptions) ([]SignatureInfo, error) {
+	// getSignaturesForAddress does not support the processed commitment level
+	commitment := c.commitment
+	if commitment == CommitmentProcessed {
+		commitment = CommitmentConfirmed
+	}
+
+	cfg := map[string]interface{}{"commitment": commitment}
+	if opts.Before != "" {
+		cfg["before"] = opts.Before
+	}
+	if opts.Until != "" {
+		cfg["until"] = opts.Until
+	}
+	if opts.Limit > 0 {
+		cfg["limit"] = opts.Limit
+	}
+
+	var result []SignatureInfo
+	if err := c.call(ctx, "getSignaturesForAddress", []interface{}{address, cfg}, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+type rpcRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      uint64      `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// RPCError is an error object returned by the node
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("solana rpc error %d: %s", e.Code, e.Message)
+}
+
+// Node-side errors that are worth retrying against the same endpoint
+const (
+	rpcErrBlockNotAvailable = -32004
+	rpcErrNodeUnhealthy     = -32005
+)
+
+// httpStatusError is a non-200 response from the RPC endpoint
+type httpStatusError struct {
+	status int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("solana rpc returned status %d", e.status)
+}
+
+// decodeError is a response body that could not be parsed. Repeating the
+// request will not help, so it is never retried.
+type decodeError struct {
+	what string
+	err  error
+}
+
+func (e *decodeError) Error() string {
+	return fmt.Sprintf("failed to decode %s: %v", e.what, e.err)
+}
+
+func (e *decodeError) Unwrap() error {
+	return e.err
+}
+
+// call performs a JSON-RPC request, retrying transient failures with
+// exponential backoff. Each attempt gets its own timeout.
+func (c *RPCClient) call(ctx context.Context, method string, params interface{}, out interface{}) error {
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      c.nextID.Add(1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		err = c.do(ctx, body, out)
+		if err == nil || attempt >= c.maxRetries || !isRetryable(err) || ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	return nil
+}
+
+func (c *RPCClient) do(ctx context.Context, body []byte, out interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return &httpStatusError{status: resp.StatusCode}
+	}
+
+	var rpcResp rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return &decodeError{what: "response", err: err}
+	}
+	if rpcResp.Error != nil {
+		return rpcResp.Error
+	}
+	if err := json.Unmarshal(rpcResp.Result, out); err != nil {
+		return &decodeError{what: "result", err: err}
+	}
+	return nil
+}
+
+// isRetryable reports whether a failed attempt may succeed if repeated:
+// timeouts, connection failures, rate limiting, 5xx and unhealthy nodes
+func isRetryable(err error) bool {
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code == rpcErrNodeUnhealthy || rpcErr.Code == rpcErrBlockNotAvailable
+	}
+
+	var decodeErr *decodeError
+	if errors.As(err, &decodeErr) {
+		return false
+	}
+
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.status == http.StatusTooManyRequests || statusErr.status >= 500
+	}
+
+	// Per-attempt deadline or transport failure
+	return !errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,283 @@
+package solana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rpcCall is a request as the fake node received it
+type rpcCall struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	at     time.Time
+}
+
+// fakeNode serves JSON-RPC requests with respond, recording each one
+type fakeNode struct {
+	mu      sync.Mutex
+	calls   []rpcCall
+	respond func(w http.ResponseWriter, r *http.Request, attempt int)
+}
+
+func newFakeNode(t *testing.T, respond func(w http.ResponseWriter, r *http.Request, attempt int)) (*fakeNode, *httptest.Server) {
+	t.Helper()
+	node := &fakeNode{respond: respond}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call rpcCall
+		if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		call.at = time.Now()
+
+		node.mu.Lock()
+		node.calls = append(node.calls, call)
+		attempt := len(node.calls)
+		node.mu.Unlock()
+
+		node.respond(w, r, attempt)
+	}))
+	t.Cleanup(srv.Close)
+	return node, srv
+}
+
+func (n *fakeNode) recorded() []rpcCall {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]rpcCall(nil), n.calls...)
+}
+
+func writeResult(w http.ResponseWriter, result string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + result + `}`))
+}
+
+func writeRPCError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	body, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"error":   map[string]interface{}{"code": code, "message": message},
+	})
+	w.Write(body)
+}
+
+func testClient(endpoint string, cfg Config) *RPCClient {
+	cfg.Endpoint = endpoint
+	c := NewClient(cfg)
+	c.backoff = 20 * time.Millisecond
+	return c
+}
+
+func TestClientRetriesWithBackoff(t *testing.T) {
+	node, srv := newFakeNode(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		switch attempt {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			writeResult(w, "1234")
+		}
+	})
+	c := testClient(srv.URL, Config{MaxRetries: 3})
+
+	slot, err := c.GetSlot(context.Background())
+	if err != nil {
+		t.Fatalf("GetSlot: %v", err)
+	}
+	if slot != 1234 {
+		t.Errorf("slot = %d, want 1234", slot)
+	}
+
+	calls := node.recorded()
+	if len(calls) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(calls))
+	}
+	// The wait doubles after every failed attempt
+	if gap := calls[1].at.Sub(calls[0].at); gap < 20*time.Millisecond {
+		t.Errorf("first retry after %v, want at least 20ms", gap)
+	}
+	if gap := calls[2].at.Sub(calls[1].at); gap < 40*time.Millisecond {
+		t.Errorf("second retry after %v, want at least 40ms", gap)
+	}
+}
+
+func TestClientGivesUpAfterMaxRetries(t *testing.T) {
+	node, srv := newFakeNode(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	c := testClient(srv.URL, Config{MaxRetries: 2})
+
+	_, err := c.GetSlot(context.Background())
+	var statusErr *httpStatusError
+	if !errors.As(err, &statusErr) || statusErr.status != http.StatusBadGateway {
+		t.Fatalf("err = %v, want status 502", err)
+	}
+	if n := len(node.recorded()); n != 3 {
+		t.Errorf("attempts = %d, want 3", n)
+	}
+}
+
+func TestClientDoesNotRetryPermanentFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func(w http.ResponseWriter)
+	}{
+		{"bad request", func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadRequest) }},
+		{"invalid params", func(w http.ResponseWriter) { writeRPCError(w, -32602, "Invalid params") }},
+		{"malformed body", func(w http.ResponseWriter) { w.Write([]byte("not json")) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, srv := newFakeNode(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+				tt.respond(w)
+			})
+			c := testClient(srv.URL, Config{MaxRetries: 3})
+
+			if _, err := c.GetSlot(context.Background()); err == nil {
+				t.Fatal("GetSlot succeeded, want an error")
+			}
+			if n := len(node.recorded()); n != 1 {
+				t.Errorf("attempts = %d, want 1", n)
+			}
+		})
+	}
+}
+
+func TestClientMapsRPCErrors(t *testing.T) {
+	node, srv := newFakeNode(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		if attempt == 1 {
+			writeRPCError(w, rpcErrNodeUnhealthy, "Node is unhealthy")
+			return
+		}
+		writeRPCError(w, -32602, "Invalid param: WrongSize")
+	})
+	c := testClient(srv.URL, Config{MaxRetries: 3})
+
+	_, err := c.GetBalance(context.Background(), "11111111111111111111111111111111")
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("err = %v, want an *RPCError", err)
+	}
+	if rpcErr.Code != -32602 || rpcErr.Message != "Invalid param: WrongSize" {
+		t.Errorf("rpc error = %d %q", rpcErr.Code, rpcErr.Message)
+	}
+	if want := "getBalance: solana rpc error -32602: Invalid param: WrongSize"; err.Error() != want {
+		t.Errorf("err = %q, want %q", err, want)
+	}
+	// The unhealthy node was retried, the bad params were not
+	if n := len(node.recorded()); n != 2 {
+		t.Errorf("attempts = %d, want 2", n)
+	}
+}
+
+func TestClientMapsMissingResults(t *testing.T) {
+	_, srv := newFakeNode(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		writeResult(w, `null`)
+	})
+	c := testClient(srv.URL, Config{})
+
+	if _, err := c.GetTransaction(context.Background(), "sig"); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("GetTransaction err = %v, want ErrTransactionNotFound", err)
+	}
+
+	_, srv = newFakeNode(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		writeResult(w, `{"context":{"slot":1},"value":null}`)
+	})
+	c = testClient(srv.URL, Config{})
+
+	if _, err := c.GetAccountInfo(context.Background(), "addr"); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("GetAccountInfo err = %v, want ErrAccountNotFound", err)
+	}
+}
+
+func TestClientSendsCommitment(t *testing.T) {
+	tests := []struct {
+		commitment Commitment
+		call       func(c *RPCClient) error
+		want       string
+	}{
+		{CommitmentFinalized, func(c *RPCClient) error {
+			_, err := c.GetBalance(context.Background(), "addr")
+			return err
+		}, "finalized"},
+		{CommitmentProcessed, func(c *RPCClient) error {
+			_, err := c.GetBalance(context.Background(), "addr")
+			return err
+		}, "processed"},
+		// getTransaction and getSignaturesForAddress reject processed
+		{CommitmentProcessed, func(c *RPCClient) error {
+			_, err := c.GetTransaction(context.Background(), "sig")
+			return err
+		}, "confirmed"},
+		{CommitmentProcessed, func(c *RPCClient) error {
+			_, err := c.GetSignaturesForAddress(context.Background(), "addr", SignaturesOptions{})
+			return err
+		}, "confirmed"},
+	}
+	for _, tt := range tests {
+		node, srv := newFakeNode(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+			writeResult(w, `{"context":{"slot":1},"value":5}`)
+		})
+		c := testClient(srv.URL, Config{Commitment: tt.commitment})
+		tt.call(c)
+
+		calls := node.recorded()
+		if len(calls) != 1 || len(calls[0].Params) != 2 {
+			t.Fatalf("%s: calls = %+v", tt.commitment, calls)
+		}
+		var cfg struct {
+			Commitment string `json:"commitment"`
+		}
+		if err := json.Unmarshal(calls[0].Params[1], &cfg); err != nil {
+			t.Fatalf("%s: decode config: %v", calls[0].Method, err)
+		}
+		if cfg.Commitment != tt.want {
+			t.Errorf("%s at %s sent commitment %q, want %q", calls[0].Method, tt.commitment, cfg.Commitment, tt.want)
+		}
+	}
+}
+
+func TestClientTimesOutEachAttempt(t *testing.T) {
+	node, srv := newFakeNode(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+	c := testClient(srv.URL, Config{Timeout: 30 * time.Millisecond, MaxRetries: 1})
+
+	start := time.Now()
+	_, err := c.GetSlot(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want a deadline error", err)
+	}
+	if n := len(node.recorded()); n != 2 {
+		t.Errorf("attempts = %d, want 2", n)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("took %v, want each attempt cut off at the timeout", elapsed)
+	}
+}
+
+func TestClientStopsWhenContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	node, srv := newFakeNode(t, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	c := testClient(srv.URL, Config{MaxRetries: 5})
+
+	if _, err := c.GetSlot(ctx); err == nil {
+		t.Fatal("GetSlot succeeded, want an error")
+	}
+	if n := len(node.recorded()); n != 1 {
+		t.Errorf("attempts = %d, want 1", n)
+	}
+}
@@ -0,0 +1,253 @@
+package solana
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trenchjob/backend/internal/domain"
+)
+
+// PDA seeds used by the trenchjob-escrow program
+var (
+	EscrowSeed = []byte("escrow")
+	VaultSeed  = []byte("vault")
+)
+
+// EscrowAccountSize is the on-chain size of an Escrow account, including
+// the 8-byte Anchor discriminator (Escrow::LEN in the program)
+const EscrowAccountSize = 8 + 32 + 32 + 16 + 8 + 8 + 8 + 8 + 1 + 1 + 1 + 8 + 8
+
+// EscrowAccountDiscriminator is the Anchor account discriminator for
+// Escrow: the first 8 bytes of sha256("account:Escrow")
+var EscrowAccountDiscriminator = anchorDiscriminator("account", "Escrow")
+
+// ErrNotEscrowAccount is returned when account data does not hold an Escrow
+var ErrNotEscrowAccount = errors.New("solana: account is not an escrow account")
+
+// EscrowStatus mirrors the program's EscrowStatus enum. Borsh encodes a
+// fieldless enum as its one-byte variant index.
+type EscrowStatus uint8
+
+const (
+	EscrowStatusCreated EscrowStatus = iota
+	EscrowStatusPartiallyFunded
+	EscrowStatusFullyFunded
+	EscrowStatusPartiallyReleased
+	EscrowStatusFullyReleased
+	EscrowStatusRefunded
+	EscrowStatusDisputed
+)
+
+func (s EscrowStatus) String() string {
+	switch s {
+	case EscrowStatusCreated:
+		return "Created"
+	case EscrowStatusPartiallyFunded:
+		return "PartiallyFunded"
+	case EscrowStatusFullyFunded:
+		return "FullyFunded"
+	case EscrowStatusPartiallyReleased:
+		return "PartiallyReleased"
+	case EscrowStatusFullyReleased:
+		return "FullyReleased"
+	case EscrowStatusRefunded:
+		return "Refunded"
+	case EscrowStatusDisputed:
+		return "Disputed"
+	default:
+		return fmt.Sprintf("EscrowStatus(%d)", uint8(s))
+	}
+}
+
+// DomainStatus maps the on-chain status to the escrows.status value
+func (s EscrowStatus) DomainStatus() string {
+	switch s {
+	case EscrowStatusPartiallyFunded:
+		return domain.EscrowStatusPartiallyFunded
+	case EscrowStatusFullyFunded:
+		return domain.EscrowStatusFunded
+	case EscrowStatusPartiallyReleased:
+		return domain.EscrowStatusPartiallyReleased
+	case EscrowStatusFullyReleased:
+		return domain.EscrowStatusFullyReleased
+	case EscrowStatusRefunded:
+		return domain.EscrowStatusRefunded
+	case EscrowStatusDisputed:
+		return domain.EscrowStatusDisputed
+	default:
+		return domain.EscrowStatusCreated
+	}
+}
+
+// EscrowAccount is the decoded state of an Escrow account. Amounts are in lamports.
+type EscrowAccount struct {
+	Client         PublicKey
+	Freelancer     PublicKey
+	ContractID     uuid.UUID
+	TotalAmount    uint64
+	FundedAmount   uint64
+	ReleasedAmount uint64
+	RefundedAmount uint64
+	Status         EscrowStatus
+	Bump           uint8
+	VaultBump      uint8
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Available returns the lamports still held for the contract
+func (a *EscrowAccount) Available() uint64 {
+	spent := a.ReleasedAmount + a.RefundedAmount
+	if spent >= a.FundedAmount {
+		return 0
+	}
+	return a.FundedAmount - spent
+}
+
+// EscrowAddresses are the PDAs the program uses for one contract
+type EscrowAddresses struct {
+	Escrow     PublicKey
+	EscrowBump uint8
+	Vault      PublicKey
+	VaultBump  uint8
+}
+
+// DeriveEscrowAddresses derives the escrow and vault PDAs for an off-chain
+// contract ID
+func DeriveEscrowAddresses(programID PublicKey, contractID uuid.UUID) (*EscrowAddresses, error) {
+	escrow, escrowBump, err := FindProgramAddress([][]byte{EscrowSeed, contractID[:]}, programID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive escrow address: %w", err)
+	}
+	vault, vaultBump, err := FindProgramAddress([][]byte{VaultSeed, contractID[:]}, programID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive vault address: %w", err)
+	}
+	return &EscrowAddresses{
+		Escrow:     escrow,
+		EscrowBump: escrowBump,
+		Vault:      vault,
+		VaultBump:  vaultBump,
+	}, nil
+}
+
+// DecodeEscrowAccount decodes Borsh-serialized Escrow account data,
+// checking the Anchor discriminator first
+func DecodeEscrowAccount(data []byte) (*EscrowAccount, error) {
+	if len(data) < EscrowAccountSize {
+		return nil, fmt.Errorf("%w: expected %d bytes, got %d", ErrNotEscrowAccount, EscrowAccountSize, len(data))
+	}
+	if [8]byte(data[:8]) != EscrowAccountDiscriminator {
+		return nil, fmt.Errorf("%w: discriminator mismatch", ErrNotEscrowAccount)
+	}
+
+	r := borshReader{buf: data[8:]}
+	acc := &EscrowAccount{}
+	acc.Client = r.pubkey()
+	acc.Freelancer = r.pubkey()
+	copy(acc.ContractID[:], r.bytes(16))
+	acc.TotalAmount = r.u64()
+	acc.FundedAmount = r.u64()
+	acc.ReleasedAmount = r.u64()
+	acc.RefundedAmount = r.u64()
+	acc.Status = EscrowStatus(r.u8())
+	acc.Bump = r.u8()
+	acc.VaultBump = r.u8()
+	acc.CreatedAt = time.Unix(r.i64(), 0).UTC()
+	acc.UpdatedAt = time.Unix(r.i64(), 0).UTC()
+
+	if acc.Status > EscrowStatusDisputed {
+		return nil, fmt.Errorf("solana: invalid escrow status variant %d", uint8(acc.Status))
+	}
+	return acc, nil
+}
+
+// FetchEscrowAccount loads and decodes the escrow account for a contract.
+// It returns ErrAccountNotFound if the escrow has not been initialized.
+func FetchEscrowAccount(ctx context.Context, client Client, programID PublicKey, contractID uuid.UUID) (*EscrowAccount, *EscrowAddresses, error) {
+	addrs, err := DeriveEscrowAddresses(programID, contractID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := client.GetAccountInfo(ctx, addrs.Escrow.String())
+	if err != nil {
+		return nil, addrs, err
+	}
+	if info.Owner != programID.String() {
+		return nil, addrs, fmt.Errorf("%w: owned by %s", ErrNotEscrowAccount, info.Owner)
+	}
+
+	acc, err := DecodeEscrowAccount(info.Data)
+	if err != nil {
+		return nil, addrs, err
+	}
+	if acc.ContractID != contractID {
+		return nil, addrs, fmt.Errorf("%w: contract ID mismatch", ErrNotEscrowAccount)
+	}
+	return acc, addrs, nil
+}
+
+// ApplyTo copies chain state onto an escrow record. Identity fields (ID,
+// ContractID, InitTxSignature, timestamps) are left to the caller.
+func (a *EscrowAccount) ApplyTo(e *domain.Escrow, addrs *EscrowAddresses) {
+	e.EscrowPDA = addrs.Escrow.String()
+	e.VaultAddress = addrs.Vault.String()
+	e.ClientWallet = a.Client.String()
+	e.FreelancerWallet = a.Freelancer.String()
+	e.TotalAmountSOL = LamportsToSOL(a.TotalAmount)
+	e.FundedAmountSOL = LamportsToSOL(a.FundedAmount)
+	e.ReleasedAmountSOL = LamportsToSOL(a.ReleasedAmount)
+	e.RefundedAmountSOL = LamportsToSOL(a.RefundedAmount)
+	e.Status = a.Status.DomainStatus()
+}
+
+// ToDomain builds a new escrow record for a contract from chain state
+func (a *EscrowAccount) ToDomain(addrs *EscrowAddresses) *domain.Escrow {
+	e := &domain.Escrow{ContractID: a.ContractID}
+	a.ApplyTo(e, addrs)
+	return e
+}
+
+func anchorDiscriminator(namespace, name string) [8]byte {
+	sum := sha256.Sum256([]byte(namespace + ":" + name))
+	var d [8]byte
+	copy(d[:], sum[:8])
+	return d
+}
+
+// borshReader reads little-endian Borsh primitives. Callers check the
+// buffer length up front, so reads never run past the end.
+type borshReader struct {
+	buf []byte
+	off int
+}
+
+func (r *borshReader) bytes(n int) []byte {
+	b := r.buf[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *borshReader) pubkey() PublicKey {
+	var pk PublicKey
+	copy(pk[:], r.bytes(PublicKeySize))
+	return pk
+}
+
+func (r *borshReader) u8() uint8 {
+	return r.bytes(1)[0]
+}
+
+func (r *borshReader) u64() uint64 {
+	return binary.LittleEndian.Uint64(r.bytes(8))
+}
+
+func (r *borshReader) i64() int64 {
+	return int64(r.u64())
+}
@@ -0,0 +1,143 @@
+package solana
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Event names emitted by the trenchjob-escrow program
+const (
+	EventEscrowCreated     = "EscrowCreated"
+	EventEscrowFunded      = "EscrowFunded"
+	EventMilestoneReleased = "MilestoneReleased"
+	EventEscrowRefunded    = "EscrowRefunded"
+	EventDisputeOpened     = "DisputeOpened"
+	EventEscrowClosed      = "EscrowClosed"
+)
+
+// escrowEventSizes is the Borsh-encoded size of each event's fields
+var escrowEventSizes = map[string]int{
+	EventEscrowCreated:     32 + 32 + 32 + 16 + 8,
+	EventEscrowFunded:      32 + 32 + 8 + 8 + 1,
+	EventMilestoneReleased: 32 + 16 + 32 + 8 + 8 + 1,
+	EventEscrowRefunded:    32 + 32 + 8 + 8 + 1,
+	EventDisputeOpened:     32 + 32,
+	EventEscrowClosed:      32 + 32,
+}
+
+var escrowEventsByDiscriminator = func() map[[8]byte]string {
+	m := make(map[[8]byte]string, len(escrowEventSizes))
+	for name := range escrowEventSizes {
+		m[anchorDiscriminator("event", name)] = name
+	}
+	return m
+}()
+
+// EscrowEvent is a decoded Anchor event emitted by the escrow program.
+// Fields not carried by a given event are left zero.
+type EscrowEvent struct {
+	Name string
+
+	Escrow     PublicKey
+	Client     PublicKey // EscrowCreated, EscrowFunded, EscrowRefunded, EscrowClosed
+	Freelancer PublicKey // EscrowCreated, MilestoneReleased
+	Initiator  PublicKey // DisputeOpened
+
+	ContractID  uuid.UUID // EscrowCreated
+	MilestoneID uuid.UUID // MilestoneReleased
+
+	// Amount is the transfer (or the escrow total for EscrowCreated) and
+	// Total the running funded/released/refunded sum after it, in lamports
+	Amount uint64
+	Total  uint64
+	Status EscrowStatus
+}
+
+const (
+	logProgramData = "Program data: "
+	logInvoke      = " invoke ["
+)
+
+// ParseEscrowEvents decodes the events the escrow program emitted in tx.
+// Anchor writes events as base64 "Program data:" log lines; only lines
+// logged while the escrow program itself is executing are trusted.
+func ParseEscrowEvents(tx *Transaction, programID PublicKey) ([]EscrowEvent, error) {
+	if tx.Meta == nil {
+		return nil, nil
+	}
+
+	program := programID.String()
+	var stack []string
+	var events []EscrowEvent
+	for _, line := range tx.Meta.LogMessages {
+		switch {
+		case strings.HasPrefix(line, "Program ") && strings.Contains(line, logInvoke):
+			id := strings.TrimPrefix(line, "Program ")
+			stack = append(stack, id[:strings.Index(id, " ")])
+		case strings.HasPrefix(line, "Program ") && (strings.HasSuffix(line, " success") || strings.Contains(line, " failed")):
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case strings.HasPrefix(line, logProgramData):
+			if len(stack) == 0 || stack[len(stack)-1] != program {
+				continue
+			}
+			data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, logProgramData))
+			if err != nil {
+				return nil, fmt.Errorf("invalid event data: %w", err)
+			}
+			ev, ok, err := decodeEscrowEvent(data)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				events = append(events, *ev)
+			}
+		}
+	}
+	return events, nil
+}
+
+// decodeEscrowEvent decodes one event, reporting false for data that does
+// not carry a known escrow event discriminator
+func decodeEscrowEvent(data []byte) (*EscrowEvent, bool, error) {
+	if len(data) < 8 {
+		return nil, false, nil
+	}
+	name, ok := escrowEventsByDiscriminator[[8]byte(data[:8])]
+	if !ok {
+		return nil, false, nil
+	}
+	if len(data)-8 < escrowEventSizes[name] {
+		return nil, false, fmt.Errorf("%s: expected %d bytes, got %d", name, escrowEventSizes[name], len(data)-8)
+	}
+
+	r := borshReader{buf: data[8:]}
+	ev := &EscrowEvent{Name: name, Escrow: r.pubkey()}
+	switch name {
+	case EventEscrowCreated:
+		ev.Client = r.pubkey()
+		ev.Freelancer = r.pubkey()
+		copy(ev.ContractID[:], r.bytes(16))
+		ev.Amount = r.u64()
+	case EventEscrowFunded, EventEscrowRefunded:
+		ev.Client = r.pubkey()
+		ev.Amount = r.u64()
+		ev.Total = r.u64()
+		ev.Status = EscrowStatus(r.u8())
+	case EventMilestoneReleased:
+		copy(ev.MilestoneID[:], r.bytes(16))
+		ev.Freelancer = r.pubkey()
+		ev.Amount = r.u64()
+		ev.Total = r.u64()
+		ev.Status = EscrowStatus(r.u8())
+	case EventDisputeOpened:
+		ev.Initiator = r.pubkey()
+	case EventEscrowClosed:
+		ev.Client = r.pubkey()
+	}
+	return ev, true, nil
+}
@@ -0,0 +1,231 @@
+package solana
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/trenchjob/backend/internal/pkg/utils"
+)
+
+// Instruction names of the trenchjob-escrow program
+const (
+	InstructionInitializeEscrow = "initialize_escrow"
+	InstructionFundEscrow       = "fund_escrow"
+	InstructionReleaseMilestone = "release_milestone"
+	InstructionRefund           = "refund"
+	InstructionOpenDispute      = "open_dispute"
+	InstructionCloseEscrow      = "close_escrow"
+)
+
+// escrowInstructionLayouts lists, per instruction, the number of accounts
+// in its Accounts struct and the size of its Borsh arguments
+var escrowInstructionLayouts = map[string]struct {
+	accounts int
+	args     int
+}{
+	InstructionInitializeEscrow: {accounts: 4, args: 16 + 8 + 32},
+	InstructionFundEscrow:       {accounts: 4, args: 8},
+	InstructionReleaseMilestone: {accounts: 5, args: 16 + 8},
+	InstructionRefund:           {accounts: 4, args: 8},
+	InstructionOpenDispute:      {accounts: 2, args: 0},
+	InstructionCloseEscrow:      {accounts: 4, args: 0},
+}
+
+var escrowInstructionsByDiscriminator = func() map[[8]byte]string {
+	m := make(map[[8]byte]string, len(escrowInstructionLayouts))
+	for name := range escrowInstructionLayouts {
+		m[InstructionDiscriminator(name)] = name
+	}
+	return m
+}()
+
+// InstructionDiscriminator returns the Anchor discriminator for an
+// instruction: the first 8 bytes of sha256("global:<name>")
+func InstructionDiscriminator(name string) [8]byte {
+	return anchorDiscriminator("global", name)
+}
+
+// EscrowInstruction is a decoded top-level call into the escrow program.
+// Accounts are resolved addresses in the order of the program's Accounts
+// struct: for every instruction index 0 is the client (or the dispute
+// initiator) and index 1 the escrow PDA; index 2 is the vault where present.
+type EscrowInstruction struct {
+	Name     string
+	Accounts []string
+
+	ContractID  uuid.UUID // initialize_escrow
+	Freelancer  PublicKey // initialize_escrow
+	MilestoneID uuid.UUID // release_milestone
+	Amount      uint64    // total for initialize_escrow, transfer otherwise
+}
+
+// Signer returns the account that authorized the instruction
+func (ix *EscrowInstruction) Signer() string {
+	return ix.Accounts[0]
+}
+
+// Escrow returns the escrow PDA the instruction operates on
+func (ix *EscrowInstruction) Escrow() string {
+	return ix.Accounts[1]
+}
+
+// Vault returns the vault PDA, or "" for instructions that do not touch it
+func (ix *EscrowInstruction) Vault() string {
+	if len(ix.Accounts) < 3 {
+		return ""
+	}
+	return ix.Accounts[2]
+}
+
+// ParseEscrowInstructions decodes every top-level instruction in tx that
+// targets the escrow program. Instructions for other programs are skipped.
+func ParseEscrowInstructions(tx *Transaction, programID PublicKey) ([]EscrowInstruction, error) {
+	keys := tx.AccountKeys()
+	program := programID.String()
+
+	var out []EscrowInstruction
+	for i, compiled := range tx.Transaction.Message.Instructions {
+		if compiled.ProgramIDIndex < 0 || compiled.ProgramIDIndex >= len(keys) || keys[compiled.ProgramIDIndex] != program {
+			continue
+		}
+
+		ix, err := decodeEscrowInstruction(compiled, keys)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", i, err)
+		}
+		out = append(out, *ix)
+	}
+	return out, nil
+}
+
+func decodeEscrowInstruction(compiled CompiledInstruction, keys []string) (*EscrowInstruction, error) {
+	data, err := utils.DecodeBase58(compiled.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid instruction data: %w", err)
+	}
+	if len(data) < 8 {
+		return nil, fmt.Errorf("instruction data too short")
+	}
+
+	name, ok := escrowInstructionsByDiscriminator[[8]byte(data[:8])]
+	if !ok {
+		return nil, fmt.Errorf("unknown escrow instruction")
+	}
+	layout := escrowInstructionLayouts[name]
+	args := data[8:]
+	if len(args) < layout.args {
+		return nil, fmt.Errorf("%s: expected %d argument bytes, got %d", name, layout.args, len(args))
+	}
+	if len(compiled.Accounts) < layout.accounts {
+		return nil, fmt.Errorf("%s: expected %d accounts, got %d", name, layout.accounts, len(compiled.Accounts))
+	}
+
+	ix := &EscrowInstruction{Name: name, Accounts: make([]string, len(compiled.Accounts))}
+	for i, idx := range compiled.Accounts {
+		if idx < 0 || idx >= len(keys) {
+			return nil, fmt.Errorf("%s: account index %d out of range", name, idx)
+		}
+		ix.Accounts[i] = keys[idx]
+	}
+
+	switch name {
+	case InstructionInitializeEscrow:
+		copy(ix.ContractID[:], args[:16])
+		ix.Amount = binary.LittleEndian.Uint64(args[16:24])
+		copy(ix.Freelancer[:], args[24:56])
+	case InstructionFundEscrow, InstructionRefund:
+		ix.Amount = binary.LittleEndian.Uint64(args[:8])
+	case InstructionReleaseMilestone:
+		copy(ix.MilestoneID[:], args[:16])
+		ix.Amount = binary.LittleEndian.Uint64(args[16:24])
+	}
+	return ix, nil
+}
+
+// NewInitializeEscrowInstruction creates the escrow and vault PDAs for a
+// contract with its total in lamports
+func NewInitializeEscrowInstruction(programID PublicKey, addrs *EscrowAddresses, client, freelancer PublicKey, contractID uuid.UUID, totalAmount uint64) Instruction {
+	data := escrowInstructionData(InstructionInitializeEscrow)
+	data = append(data, contractID[:]...)
+	data = appendU64(data, totalAmount)
+	data = append(data, freelancer[:]...)
+	return Instruction{
+		ProgramID: programID,
+		Accounts:  escrowVaultAccounts(client, addrs),
+		Data:      data,
+	}
+}
+
+// NewFundEscrowInstruction moves lamports from the client into the vault
+func NewFundEscrowInstruction(programID PublicKey, addrs *EscrowAddresses, client PublicKey, amount uint64) Instruction {
+	return Instruction{
+		ProgramID: programID,
+		Accounts:  escrowVaultAccounts(client, addrs),
+		Data:      appendU64(escrowInstructionData(InstructionFundEscrow), amount),
+	}
+}
+
+// NewReleaseMilestoneInstruction pays a milestone from the vault to the freelancer
+func NewReleaseMilestoneInstruction(programID PublicKey, addrs *EscrowAddresses, client, freelancer PublicKey, milestoneID uuid.UUID, amount uint64) Instruction {
+	data := escrowInstructionData(InstructionReleaseMilestone)
+	data = append(data, milestoneID[:]...)
+	data = appendU64(data, amount)
+	return Instruction{
+		ProgramID: programID,
+		Accounts: []AccountMeta{
+			{PublicKey: client, IsSigner: true, IsWritable: true},
+			{PublicKey: addrs.Escrow, IsWritable: true},
+			{PublicKey: addrs.Vault, IsWritable: true},
+			{PublicKey: freelancer, IsWritable: true},
+			{PublicKey: SystemProgramID},
+		},
+		Data: data,
+	}
+}
+
+// NewRefundInstruction returns lamports from the vault to the client
+func NewRefundInstruction(programID PublicKey, addrs *EscrowAddresses, client PublicKey, amount uint64) Instruction {
+	return Instruction{
+		ProgramID: programID,
+		Accounts:  escrowVaultAccounts(client, addrs),
+		Data:      appendU64(escrowInstructionData(InstructionRefund), amount),
+	}
+}
+
+// NewOpenDisputeInstruction freezes the escrow; either party may sign it
+func NewOpenDisputeInstruction(programID PublicKey, addrs *EscrowAddresses, initiator PublicKey) Instruction {
+	return Instruction{
+		ProgramID: programID,
+		Accounts: []AccountMeta{
+			{PublicKey: initiator, IsSigner: true, IsWritable: true},
+			{PublicKey: addrs.Escrow, IsWritable: true},
+		},
+		Data: escrowInstructionData(InstructionOpenDispute),
+	}
+}
+
+// NewCloseEscrowInstruction closes a settled escrow and returns its rent to the client
+func NewCloseEscrowInstruction(programID PublicKey, addrs *EscrowAddresses, client PublicKey) Instruction {
+	return Instruction{
+		ProgramID: programID,
+		Accounts:  escrowVaultAccounts(client, addrs),
+		Data:      escrowInstructionData(InstructionCloseEscrow),
+	}
+}
+
+// escrowVaultAccounts is the client/escrow/vault/system_program account
+// list shared by most escrow instructions
+func escrowVaultAccounts(client PublicKey, addrs *EscrowAddresses) []AccountMeta {
+	return []AccountMeta{
+		{PublicKey: client, IsSigner: true, IsWritable: true},
+		{PublicKey: addrs.Escrow, IsWritable: true},
+		{PublicKey: addrs.Vault, IsWritable: true},
+		{PublicKey: SystemProgramID},
+	}
+}
+
+func escrowInstructionData(name string) []byte {
+	d := InstructionDiscriminator(name)
+	return append([]byte{}, d[:]...)
+}
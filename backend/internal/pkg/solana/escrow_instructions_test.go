@@ -0,0 +1,125 @@
+package solana
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/trenchjob/backend/internal/pkg/utils"
+)
+
+func TestEscrowInstructionData(t *testing.T) {
+	programID := testKey(9)
+	addrs := &EscrowAddresses{Escrow: testKey(4), Vault: testKey(5)}
+	client, freelancer := testKey(1), testKey(2)
+	contractID := uuid.MustParse("6f1c2a3e-4b5d-4e6f-8a9b-0c1d2e3f4a5b")
+	milestoneID := uuid.MustParse("11223344-5566-7788-99aa-bbccddeeff00")
+
+	// Amounts as little-endian u64
+	const (
+		fiveSOL        = "00f2052a01000000" // 5_000_000_000
+		oneAndHalfSOL  = "002f685900000000" // 1_500_000_000
+		quarterSOL     = "80b2e60e00000000" // 250_000_000
+		contractIDHex  = "6f1c2a3e4b5d4e6f8a9b0c1d2e3f4a5b"
+		milestoneIDHex = "112233445566778899aabbccddeeff00"
+	)
+	freelancerHex := hex.EncodeToString(freelancer[:])
+
+	signerW := func(pk PublicKey) AccountMeta { return AccountMeta{PublicKey: pk, IsSigner: true, IsWritable: true} }
+	writable := func(pk PublicKey) AccountMeta { return AccountMeta{PublicKey: pk, IsWritable: true} }
+	vaultAccounts := []AccountMeta{signerW(client), writable(addrs.Escrow), writable(addrs.Vault), {PublicKey: SystemProgramID}}
+
+	tests := []struct {
+		name     string
+		ix       Instruction
+		data     string // discriminator, then Borsh arguments
+		accounts []AccountMeta
+	}{
+		{
+			InstructionInitializeEscrow,
+			NewInitializeEscrowInstruction(programID, addrs, client, freelancer, contractID, 5_000_000_000),
+			"f3a04d990b5c30d1" + contractIDHex + fiveSOL + freelancerHex,
+			vaultAccounts,
+		},
+		{
+			InstructionFundEscrow,
+			NewFundEscrowInstruction(programID, addrs, client, 1_500_000_000),
+			"9b12da8db6d545c9" + oneAndHalfSOL,
+			vaultAccounts,
+		},
+		{
+			InstructionReleaseMilestone,
+			NewReleaseMilestoneInstruction(programID, addrs, client, freelancer, milestoneID, 1_500_000_000),
+			"3802c7a4b86ca7de" + milestoneIDHex + oneAndHalfSOL,
+			[]AccountMeta{signerW(client), writable(addrs.Escrow), writable(addrs.Vault), writable(freelancer), {PublicKey: SystemProgramID}},
+		},
+		{
+			InstructionRefund,
+			NewRefundInstruction(programID, addrs, client, 250_000_000),
+			"0260b7fb3fd02e2e" + quarterSOL,
+			vaultAccounts,
+		},
+		{
+			InstructionOpenDispute,
+			NewOpenDisputeInstruction(programID, addrs, freelancer),
+			"8919637717dfa12a",
+			[]AccountMeta{signerW(freelancer), writable(addrs.Escrow)},
+		},
+		{
+			InstructionCloseEscrow,
+			NewCloseEscrowInstruction(programID, addrs, client),
+			"8bab5e92bf5b9032",
+			vaultAccounts,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.ix.ProgramID != programID {
+				t.Errorf("program = %s", tt.ix.ProgramID)
+			}
+			if got := hex.EncodeToString(tt.ix.Data); got != tt.data {
+				t.Errorf("data\n got %s\nwant %s", got, tt.data)
+			}
+			if len(tt.ix.Accounts) != len(tt.accounts) {
+				t.Fatalf("accounts = %+v, want %+v", tt.ix.Accounts, tt.accounts)
+			}
+			for i, want := range tt.accounts {
+				if tt.ix.Accounts[i] != want {
+					t.Errorf("account %d = %+v, want %+v", i, tt.ix.Accounts[i], want)
+				}
+			}
+			if layout := escrowInstructionLayouts[tt.name]; len(tt.ix.Accounts) != layout.accounts || len(tt.ix.Data)-8 != layout.args {
+				t.Errorf("layout = %d accounts, %d argument bytes; decoder expects %+v", len(tt.ix.Accounts), len(tt.ix.Data)-8, layout)
+			}
+		})
+	}
+}
+
+func TestDecodeEscrowInstruction(t *testing.T) {
+	addrs := &EscrowAddresses{Escrow: testKey(4), Vault: testKey(5)}
+	milestoneID := uuid.MustParse("11223344-5566-7788-99aa-bbccddeeff00")
+	ix := NewReleaseMilestoneInstruction(testKey(9), addrs, testKey(1), testKey(2), milestoneID, 1_500_000_000)
+
+	keys := []string{testKey(1).String(), testKey(4).String(), testKey(5).String(), testKey(2).String(), SystemProgramID.String()}
+	decoded, err := decodeEscrowInstruction(CompiledInstruction{
+		Accounts: []int{0, 1, 2, 3, 4},
+		Data:     utils.EncodeBase58(ix.Data),
+	}, keys)
+	if err != nil {
+		t.Fatalf("decodeEscrowInstruction: %v", err)
+	}
+	if decoded.Name != InstructionReleaseMilestone || decoded.MilestoneID != milestoneID || decoded.Amount != 1_500_000_000 {
+		t.Errorf("decoded %+v", decoded)
+	}
+	if decoded.Signer() != keys[0] || decoded.Escrow() != keys[1] || decoded.Vault() != keys[2] {
+		t.Errorf("accounts = %v", decoded.Accounts)
+	}
+
+	// Arguments cut short
+	if _, err := decodeEscrowInstruction(CompiledInstruction{
+		Accounts: []int{0, 1, 2, 3, 4},
+		Data:     utils.EncodeBase58(ix.Data[:len(ix.Data)-1]),
+	}, keys); err == nil {
+		t.Error("decoded truncated arguments")
+	}
+}
@@ -0,0 +1,100 @@
+package solana
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// escrowFixture is an Escrow account as the program serializes it: client
+// 0x01..0x20, freelancer 0x21..0x40, 5 SOL total, 3 funded, 1 released,
+// 0.25 refunded, PartiallyReleased, bumps 254 and 255, created at
+// 1700000000 and updated a day later
+const escrowFixture = "1fd57bbbba16da9b" +
+	"0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20" +
+	"2122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f40" +
+	"6f1c2a3e4b5d4e6f8a9b0c1d2e3f4a5b" +
+	"00f2052a01000000" + "005ed0b200000000" + "00ca9a3b00000000" + "80b2e60e00000000" +
+	"03" + "fe" + "ff" +
+	"00f1536500000000" + "8042556500000000"
+
+func escrowFixtureBytes(t *testing.T) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(escrowFixture)
+	if err != nil {
+		t.Fatalf("decode fixture: %v", err)
+	}
+	return data
+}
+
+func TestEscrowAccountDiscriminator(t *testing.T) {
+	// sha256("account:Escrow")[:8]
+	if got := hex.EncodeToString(EscrowAccountDiscriminator[:]); got != "1fd57bbbba16da9b" {
+		t.Errorf("discriminator = %s", got)
+	}
+}
+
+func TestDecodeEscrowAccount(t *testing.T) {
+	data := escrowFixtureBytes(t)
+	if len(data) != EscrowAccountSize {
+		t.Fatalf("fixture is %d bytes, want %d", len(data), EscrowAccountSize)
+	}
+
+	acc, err := DecodeEscrowAccount(data)
+	if err != nil {
+		t.Fatalf("DecodeEscrowAccount: %v", err)
+	}
+
+	var client, freelancer PublicKey
+	for i := range client {
+		client[i] = byte(i + 1)
+		freelancer[i] = byte(i + 33)
+	}
+	want := EscrowAccount{
+		Client:         client,
+		Freelancer:     freelancer,
+		ContractID:     uuid.MustParse("6f1c2a3e-4b5d-4e6f-8a9b-0c1d2e3f4a5b"),
+		TotalAmount:    5_000_000_000,
+		FundedAmount:   3_000_000_000,
+		ReleasedAmount: 1_000_000_000,
+		RefundedAmount: 250_000_000,
+		Status:         EscrowStatusPartiallyReleased,
+		Bump:           254,
+		VaultBump:      255,
+		CreatedAt:      time.Unix(1700000000, 0).UTC(),
+		UpdatedAt:      time.Unix(1700086400, 0).UTC(),
+	}
+	if *acc != want {
+		t.Errorf("decoded %+v\nwant    %+v", *acc, want)
+	}
+	if acc.Available() != 1_750_000_000 {
+		t.Errorf("Available = %d, want 1750000000", acc.Available())
+	}
+}
+
+func TestDecodeEscrowAccountRejectsWrongDiscriminator(t *testing.T) {
+	data := escrowFixtureBytes(t)
+	// An account of another type in the same program
+	disc := anchorDiscriminator("account", "Milestone")
+	copy(data, disc[:])
+
+	if _, err := DecodeEscrowAccount(data); !errors.Is(err, ErrNotEscrowAccount) {
+		t.Errorf("err = %v, want ErrNotEscrowAccount", err)
+	}
+}
+
+func TestDecodeEscrowAccountRejectsBadData(t *testing.T) {
+	data := escrowFixtureBytes(t)
+	if _, err := DecodeEscrowAccount(data[:EscrowAccountSize-1]); !errors.Is(err, ErrNotEscrowAccount) {
+		t.Errorf("short data: err = %v, want ErrNotEscrowAccount", err)
+	}
+
+	// Status sits after the discriminator, two keys, the ID and four amounts
+	data[8+32+32+16+8*4] = byte(EscrowStatusDisputed) + 1
+	if _, err := DecodeEscrowAccount(data); err == nil {
+		t.Error("accepted an unknown status variant")
+	}
+}
@@ -0,0 +1,146 @@
+package solana
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/trenchjob/backend/internal/pkg/utils"
+)
+
+// PublicKeySize is the length of a Solana public key in bytes
+const PublicKeySize = 32
+
+// Limits enforced by the runtime when deriving program addresses
+const (
+	maxSeeds      = 16
+	maxSeedLength = 32
+)
+
+const pdaMarker = "ProgramDerivedAddress"
+
+// ErrNoViableBump is returned when no bump seed yields an off-curve address
+var ErrNoViableBump = errors.New("solana: unable to find a viable program address bump seed")
+
+// PublicKey is a 32-byte ed25519 public key or program derived address
+type PublicKey [PublicKeySize]byte
+
+// PublicKeyFromBase58 parses a base58-encoded public key
+func PublicKeyFromBase58(s string) (PublicKey, error) {
+	var pk PublicKey
+	b, err := utils.DecodeBase58(s)
+	if err != nil {
+		return pk, fmt.Errorf("invalid public key: %w", err)
+	}
+	if len(b) != PublicKeySize {
+		return pk, fmt.Errorf("invalid public key length %d", len(b))
+	}
+	copy(pk[:], b)
+	return pk, nil
+}
+
+// String returns the base58 encoding of the key
+func (pk PublicKey) String() string {
+	return utils.EncodeBase58(pk[:])
+}
+
+// IsZero reports whether the key is all zeroes (the system program / unset)
+func (pk PublicKey) IsZero() bool {
+	return pk == PublicKey{}
+}
+
+// CreateProgramAddress derives a program address from seeds that already
+// include the bump. It fails if the result lands on the ed25519 curve.
+func CreateProgramAddress(seeds [][]byte, programID PublicKey) (PublicKey, error) {
+	if len(seeds) > maxSeeds {
+		return PublicKey{}, fmt.Errorf("solana: too many seeds (%d)", len(seeds))
+	}
+
+	h := sha256.New()
+	for _, seed := range seeds {
+		if len(seed) > maxSeedLength {
+			return PublicKey{}, fmt.Errorf("solana: seed longer than %d bytes", maxSeedLength)
+		}
+		h.Write(seed)
+	}
+	h.Write(programID[:])
+	h.Write([]byte(pdaMarker))
+
+	var pk PublicKey
+	copy(pk[:], h.Sum(nil))
+	if isOnCurve(pk[:]) {
+		return PublicKey{}, errors.New("solana: derived address is on the ed25519 curve")
+	}
+	return pk, nil
+}
+
+// FindProgramAddress searches bump seeds from 255 down for the first
+// off-curve address, matching Anchor's canonical bump
+func FindProgramAddress(seeds [][]byte, programID PublicKey) (PublicKey, uint8, error) {
+	withBump := make([][]byte, len(seeds)+1)
+	copy(withBump, seeds)
+
+	for bump := 255; bump >= 0; bump-- {
+		withBump[len(seeds)] = []byte{byte(bump)}
+		pk, err := CreateProgramAddress(withBump, programID)
+		if err == nil {
+			return pk, uint8(bump), nil
+		}
+	}
+	return PublicKey{}, 0, ErrNoViableBump
+}
+
+// Curve25519 field parameters used by isOnCurve
+var (
+	fieldP = func() *big.Int {
+		p := new(big.Int).Lsh(big.NewInt(1), 255)
+		return p.Sub(p, big.NewInt(19))
+	}()
+	// edwardsD is -121665/121666 mod p
+	edwardsD = func() *big.Int {
+		num := new(big.Int).Neg(big.NewInt(121665))
+		den := new(big.Int).ModInverse(big.NewInt(121666), fieldP)
+		d := num.Mul(num, den)
+		return d.Mod(d, fieldP)
+	}()
+	// legendreExp is (p-1)/2
+	legendreExp = new(big.Int).Rsh(new(big.Int).Sub(fieldP, big.NewInt(1)), 1)
+)
+
+// isOnCurve reports whether b decompresses to a point on edwards25519. Like
+// the runtime it only checks that y yields a valid x: x² = (y²-1)/(d·y²+1)
+// must be a square mod p.
+func isOnCurve(b []byte) bool {
+	// y is encoded little-endian; flip to big-endian and clear the sign bit
+	be := make([]byte, PublicKeySize)
+	for i := range be {
+		be[i] = b[PublicKeySize-1-i]
+	}
+	be[0] &= 0x7f
+	y := new(big.Int).SetBytes(be)
+	y.Mod(y, fieldP)
+
+	y2 := new(big.Int).Mul(y, y)
+	y2.Mod(y2, fieldP)
+
+	u := new(big.Int).Sub(y2, big.NewInt(1))
+	u.Mod(u, fieldP)
+
+	v := new(big.Int).Mul(edwardsD, y2)
+	v.Add(v, big.NewInt(1))
+	v.Mod(v, fieldP)
+	if v.Sign() == 0 {
+		return false
+	}
+
+	x2 := new(big.Int).ModInverse(v, fieldP)
+	x2.Mul(x2, u)
+	x2.Mod(x2, fieldP)
+	if x2.Sign() == 0 {
+		return true
+	}
+
+	// Euler's criterion: x² is a square iff x²^((p-1)/2) == 1
+	return new(big.Int).Exp(x2, legendreExp, fieldP).Cmp(big.NewInt(1)) == 0
+}
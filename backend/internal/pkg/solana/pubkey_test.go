@@ -0,0 +1,86 @@
+package solana
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func mustPublicKey(t *testing.T, s string) PublicKey {
+	t.Helper()
+	pk, err := PublicKeyFromBase58(s)
+	if err != nil {
+		t.Fatalf("PublicKeyFromBase58(%q): %v", s, err)
+	}
+	return pk
+}
+
+// Vectors from the Solana SDK's create_program_address tests
+func TestCreateProgramAddressKnownVectors(t *testing.T) {
+	programID := mustPublicKey(t, "BPFLoader1111111111111111111111111111111111")
+	seedKey := mustPublicKey(t, "SeedPubey1111111111111111111111111111111111")
+
+	tests := []struct {
+		seeds [][]byte
+		want  string
+	}{
+		{[][]byte{[]byte(""), {1}}, "3gF2KMe9KiC6FNVBmfg9i267aMPvK37FewCip4eGBFcT"},
+		{[][]byte{[]byte("☉")}, "7ytmC1nT1xY4RfxCV2ZgyA7UakC93do5ZdyhdF3EtPj7"},
+		{[][]byte{[]byte("Talking"), []byte("Squirrels")}, "HwRVBufQ4haG5XSgpspwKtNd3PC9GM9m1196uJW36vds"},
+		{[][]byte{seedKey[:]}, "GUs5qLUfsEHkcMB9T38vjr18ypEhRuNWiePW2LoK4E3K"},
+	}
+	for _, tt := range tests {
+		got, err := CreateProgramAddress(tt.seeds, programID)
+		if err != nil {
+			t.Errorf("CreateProgramAddress(%q): %v", tt.seeds, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("CreateProgramAddress(%q) = %s, want %s", tt.seeds, got, tt.want)
+		}
+	}
+}
+
+func TestCreateProgramAddressRejectsLongSeeds(t *testing.T) {
+	programID := mustPublicKey(t, "BPFLoader1111111111111111111111111111111111")
+	if _, err := CreateProgramAddress([][]byte{make([]byte, maxSeedLength+1)}, programID); err == nil {
+		t.Error("accepted a seed longer than 32 bytes")
+	}
+}
+
+func TestDeriveEscrowAddresses(t *testing.T) {
+	programID := mustPublicKey(t, "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS")
+	contractID := uuid.MustParse("6f1c2a3e-4b5d-4e6f-8a9b-0c1d2e3f4a5b")
+
+	addrs, err := DeriveEscrowAddresses(programID, contractID)
+	if err != nil {
+		t.Fatalf("DeriveEscrowAddresses: %v", err)
+	}
+	if addrs.Escrow.String() != "H6r13Q4gVzBj4FcVY3fQGtfKHQWM9xe4TwgAC7EHt6bM" || addrs.EscrowBump != 255 {
+		t.Errorf("escrow = %s bump %d", addrs.Escrow, addrs.EscrowBump)
+	}
+	// Bump 255 lands on the curve for the vault seeds, so the search has to
+	// step down to 254
+	if addrs.Vault.String() != "6ytw2pxG9YtiofJi5j7GvaG6xV4MxMEYQ87EeHkFEeKn" || addrs.VaultBump != 254 {
+		t.Errorf("vault = %s bump %d", addrs.Vault, addrs.VaultBump)
+	}
+	if _, err := CreateProgramAddress([][]byte{VaultSeed, contractID[:], {255}}, programID); err == nil {
+		t.Error("bump 255 derived an off-curve vault address, want it on the curve")
+	}
+}
+
+func TestIsOnCurve(t *testing.T) {
+	// The system program ID and the ed25519 base point are curve points; a
+	// PDA is not
+	for _, s := range []string{
+		"11111111111111111111111111111111",
+		"6x5SYnLroiN7WYq8NQYU9KHcH4YjpBbwpUfVu3EB7ieH",
+	} {
+		if pk := mustPublicKey(t, s); !isOnCurve(pk[:]) {
+			t.Errorf("isOnCurve(%s) = false", s)
+		}
+	}
+	if pk := mustPublicKey(t, "3gF2KMe9KiC6FNVBmfg9i267aMPvK37FewCip4eGBFcT"); isOnCurve(pk[:]) {
+		t.Error("isOnCurve(PDA) = true")
+	}
+}
@@ -0,0 +1,130 @@
+package solana
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// SystemProgramID is the native system program (all zero bytes)
+var SystemProgramID = PublicKey{}
+
+// AccountMeta is an account an instruction reads or writes
+type AccountMeta struct {
+	PublicKey  PublicKey
+	IsSigner   bool
+	IsWritable bool
+}
+
+// Instruction is an uncompiled program instruction
+type Instruction struct {
+	ProgramID PublicKey
+	Accounts  []AccountMeta
+	Data      []byte
+}
+
+// BuildUnsignedTransaction compiles instructions into a legacy transaction
+// paid for by feePayer and serializes it in wire format with zeroed
+// signature slots, ready for a wallet to sign
+func BuildUnsignedTransaction(feePayer PublicKey, recentBlockhash string, instructions ...Instruction) ([]byte, error) {
+	blockhash, err := PublicKeyFromBase58(recentBlockhash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid blockhash: %w", err)
+	}
+	if len(instructions) == 0 {
+		return nil, fmt.Errorf("transaction has no instructions")
+	}
+
+	keys, header := compileAccountKeys(feePayer, instructions)
+	index := make(map[PublicKey]int, len(keys))
+	for i, key := range keys {
+		index[key] = i
+	}
+
+	var msg []byte
+	msg = append(msg, header[:]...)
+	msg = appendCompactU16(msg, len(keys))
+	for _, key := range keys {
+		msg = append(msg, key[:]...)
+	}
+	msg = append(msg, blockhash[:]...)
+	msg = appendCompactU16(msg, len(instructions))
+	for _, ix := range instructions {
+		msg = append(msg, byte(index[ix.ProgramID]))
+		msg = appendCompactU16(msg, len(ix.Accounts))
+		for _, acc := range ix.Accounts {
+			msg = append(msg, byte(index[acc.PublicKey]))
+		}
+		msg = appendCompactU16(msg, len(ix.Data))
+		msg = append(msg, ix.Data...)
+	}
+
+	numSigners := int(header[0])
+	tx := appendCompactU16(nil, numSigners)
+	tx = append(tx, make([]byte, 64*numSigners)...)
+	return append(tx, msg...), nil
+}
+
+// compileAccountKeys orders every referenced account the way the runtime
+// expects — fee payer first, then writable signers, readonly signers,
+// writable non-signers and readonly non-signers — and returns the message
+// header describing that layout
+func compileAccountKeys(feePayer PublicKey, instructions []Instruction) ([]PublicKey, [3]byte) {
+	type flags struct{ signer, writable bool }
+	seen := map[PublicKey]*flags{feePayer: {signer: true, writable: true}}
+	order := []PublicKey{feePayer}
+
+	add := func(key PublicKey, signer, writable bool) {
+		f, ok := seen[key]
+		if !ok {
+			f = &flags{}
+			seen[key] = f
+			order = append(order, key)
+		}
+		f.signer = f.signer || signer
+		f.writable = f.writable || writable
+	}
+	for _, ix := range instructions {
+		for _, acc := range ix.Accounts {
+			add(acc.PublicKey, acc.IsSigner, acc.IsWritable)
+		}
+		add(ix.ProgramID, false, false)
+	}
+
+	var keys []PublicKey
+	var header [3]byte
+	for _, group := range []flags{{true, true}, {true, false}, {false, true}, {false, false}} {
+		for _, key := range order {
+			if *seen[key] != group {
+				continue
+			}
+			keys = append(keys, key)
+			switch {
+			case group.signer:
+				header[0]++
+				if !group.writable {
+					header[1]++
+				}
+			case !group.writable:
+				header[2]++
+			}
+		}
+	}
+	return keys, header
+}
+
+// appendCompactU16 appends n in Solana's shortvec encoding
+func appendCompactU16(b []byte, n int) []byte {
+	v := uint16(n)
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+func appendU64(b []byte, v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(b, v)
+}
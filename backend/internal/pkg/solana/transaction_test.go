@@ -0,0 +1,102 @@
+package solana
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// testKey is a public key of 32 copies of b, easy to spot in a byte dump
+func testKey(b byte) PublicKey {
+	var pk PublicKey
+	for i := range pk {
+		pk[i] = b
+	}
+	return pk
+}
+
+func TestAppendCompactU16(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "00"},
+		{1, "01"},
+		{127, "7f"},
+		{128, "8001"},
+		{255, "ff01"},
+		{16383, "ff7f"},
+		{16384, "808001"},
+		{65535, "ffff03"},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(appendCompactU16(nil, tt.n)); got != tt.want {
+			t.Errorf("appendCompactU16(%d) = %s, want %s", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestBuildUnsignedTransaction(t *testing.T) {
+	feePayer, writableSigner, readonlySigner := testKey(1), testKey(2), testKey(3)
+	writable, promoted, program := testKey(4), testKey(5), testKey(9)
+	blockhash := testKey(7)
+
+	instructions := []Instruction{
+		{
+			ProgramID: program,
+			Accounts: []AccountMeta{
+				{PublicKey: promoted},
+				{PublicKey: writable, IsWritable: true},
+				{PublicKey: readonlySigner, IsSigner: true},
+				{PublicKey: writableSigner, IsSigner: true, IsWritable: true},
+			},
+			Data: []byte{0xaa, 0xbb},
+		},
+		{
+			// Writable here, so the account is writable for the whole message
+			ProgramID: program,
+			Accounts: []AccountMeta{
+				{PublicKey: promoted, IsWritable: true},
+				{PublicKey: feePayer},
+			},
+		},
+	}
+
+	raw, err := BuildUnsignedTransaction(feePayer, blockhash.String(), instructions...)
+	if err != nil {
+		t.Fatalf("BuildUnsignedTransaction: %v", err)
+	}
+
+	var want []byte
+	// Three empty signature slots
+	want = append(want, 3)
+	want = append(want, make([]byte, 3*64)...)
+	// Header: 3 signers, 1 of them readonly, 1 readonly non-signer
+	want = append(want, 3, 1, 1)
+	// Fee payer, writable signers, readonly signers, writable non-signers in
+	// the order first seen, then readonly non-signers
+	want = append(want, 6)
+	for _, key := range []PublicKey{feePayer, writableSigner, readonlySigner, promoted, writable, program} {
+		want = append(want, key[:]...)
+	}
+	want = append(want, blockhash[:]...)
+	want = append(want, 2)
+	// program 5, accounts [promoted, writable, readonlySigner, writableSigner], data
+	want = append(want, 5, 4, 3, 4, 2, 1, 2, 0xaa, 0xbb)
+	// program 5, accounts [promoted, feePayer], no data
+	want = append(want, 5, 2, 3, 0, 0)
+
+	if !bytes.Equal(raw, want) {
+		t.Errorf("transaction bytes\n got %x\nwant %x", raw, want)
+	}
+}
+
+func TestBuildUnsignedTransactionRejectsBadInput(t *testing.T) {
+	ix := Instruction{ProgramID: testKey(9)}
+	if _, err := BuildUnsignedTransaction(testKey(1), "not-a-blockhash", ix); err == nil {
+		t.Error("accepted an invalid blockhash")
+	}
+	if _, err := BuildUnsignedTransaction(testKey(1), testKey(7).String()); err == nil {
+		t.Error("accepted a transaction with no instructions")
+	}
+}
@@ -0,0 +1,216 @@
+package solana
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// LamportsPerSOL is the number of lamports in one SOL
+const LamportsPerSOL = 1_000_000_000
+
+// LamportsToSOL converts a lamport amount to SOL
+func LamportsToSOL(lamports uint64) decimal.Decimal {
+	return decimal.NewFromUint64(lamports).Shift(-9)
+}
+
+// SOLToLamports converts a SOL amount to lamports, truncating anything
+// below one lamport
+func SOLToLamports(sol decimal.Decimal) uint64 {
+	return uint64(sol.Shift(9).Truncate(0).IntPart())
+}
+
+// AccountInfo is an on-chain account
+type AccountInfo struct {
+	Lamports   uint64
+	Owner      string
+	Data       []byte
+	Executable bool
+	RentEpoch  uint64
+}
+
+type accountInfoJSON struct {
+	Lamports   uint64   `json:"lamports"`
+	Owner      string   `json:"owner"`
+	Data       []string `json:"data"` // [payload, encoding]
+	Executable bool     `json:"executable"`
+	RentEpoch  uint64   `json:"rentEpoch"`
+}
+
+func (a *accountInfoJSON) toAccountInfo() (*AccountInfo, error) {
+	info := &AccountInfo{
+		Lamports:   a.Lamports,
+		Owner:      a.Owner,
+		Executable: a.Executable,
+		RentEpoch:  a.RentEpoch,
+	}
+	if len(a.Data) > 0 && a.Data[0] != "" {
+		if len(a.Data) > 1 && a.Data[1] != "base64" {
+			return nil, fmt.Errorf("solana: unexpected account data encoding %q", a.Data[1])
+		}
+		data, err := base64.StdEncoding.DecodeString(a.Data[0])
+		if err != nil {
+			return nil, fmt.Errorf("solana: invalid account data: %w", err)
+		}
+		info.Data = data
+	}
+	return info, nil
+}
+
+// Blockhash is a recent blockhash and the last block height at which a
+// transaction referencing it is still accepted
+type Blockhash struct {
+	Blockhash            string `json:"blockhash"`
+	LastValidBlockHeight uint64 `json:"lastValidBlockHeight"`
+}
+
+// SignatureInfo is one entry of an address's transaction history
+type SignatureInfo struct {
+	Signature          string          `json:"signature"`
+	Slot               uint64          `json:"slot"`
+	Err                json.RawMessage `json:"err"`
+	BlockTime          *int64          `json:"blockTime"`
+	ConfirmationStatus Commitment      `json:"confirmationStatus"`
+}
+
+// Failed reports whether the transaction executed with an error
+func (s *SignatureInfo) Failed() bool {
+	return hasTxError(s.Err)
+}
+
+// SignatureStatus is the processing state of a submitted transaction
+type SignatureStatus struct {
+	Slot               uint64          `json:"slot"`
+	Confirmations      *uint64         `json:"confirmations"` // nil once rooted
+	Err                json.RawMessage `json:"err"`
+	ConfirmationStatus Commitment      `json:"confirmationStatus"`
+}
+
+// Failed reports whether the transaction landed but returned an error
+func (s *SignatureStatus) Failed() bool {
+	return hasTxError(s.Err)
+}
+
+// Reached reports whether the transaction has reached at least the given commitment
+func (s *SignatureStatus) Reached(c Commitment) bool {
+	return commitmentRank(s.ConfirmationStatus) >= commitmentRank(c)
+}
+
+func commitmentRank(c Commitment) int {
+	switch c {
+	case CommitmentProcessed:
+		return 1
+	case CommitmentConfirmed:
+		return 2
+	case CommitmentFinalized:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Transaction is a confirmed transaction as returned by getTransaction
+type Transaction struct {
+	Signature   string              `json:"-"`
+	Slot        uint64              `json:"slot"`
+	BlockTime   *int64              `json:"blockTime"`
+	Meta        *TransactionMeta    `json:"meta"`
+	Transaction TransactionEnvelope `json:"transaction"`
+}
+
+// Failed reports whether the transaction executed with an error
+func (t *Transaction) Failed() bool {
+	return t.Meta != nil && hasTxError(t.Meta.Err)
+}
+
+// AccountKeys returns the static account keys followed by any keys loaded
+// from address lookup tables, matching the indexes used by instructions
+func (t *Transaction) AccountKeys() []string {
+	keys := append([]string{}, t.Transaction.Message.AccountKeys...)
+	if t.Meta != nil && t.Meta.LoadedAddresses != nil {
+		keys = append(keys, t.Meta.LoadedAddresses.Writable...)
+		keys = append(keys, t.Meta.LoadedAddresses.Readonly...)
+	}
+	return keys
+}
+
+// IsSigner reports whether address signed the transaction
+func (t *Transaction) IsSigner(address string) bool {
+	keys := t.Transaction.Message.AccountKeys
+	for i := 0; i < t.Transaction.Message.Header.NumRequiredSignatures && i < len(keys); i++ {
+		if keys[i] == address {
+			return true
+		}
+	}
+	return false
+}
+
+// BalanceChange returns the net lamport change of an account over the
+// transaction, or false if the account is not part of it
+func (t *Transaction) BalanceChange(address string) (int64, bool) {
+	if t.Meta == nil {
+		return 0, false
+	}
+	for i, key := range t.AccountKeys() {
+		if key != address {
+			continue
+		}
+		if i >= len(t.Meta.PreBalances) || i >= len(t.Meta.PostBalances) {
+			return 0, false
+		}
+		return int64(t.Meta.PostBalances[i]) - int64(t.Meta.PreBalances[i]), true
+	}
+	return 0, false
+}
+
+// TransactionMeta is the execution metadata of a transaction
+type TransactionMeta struct {
+	Err             json.RawMessage  `json:"err"`
+	Fee             uint64           `json:"fee"`
+	PreBalances     []uint64         `json:"preBalances"`
+	PostBalances    []uint64         `json:"postBalances"`
+	LogMessages     []string         `json:"logMessages"`
+	LoadedAddresses *LoadedAddresses `json:"loadedAddresses,omitempty"`
+}
+
+// LoadedAddresses are accounts a versioned transaction pulled from lookup tables
+type LoadedAddresses struct {
+	Writable []string `json:"writable"`
+	Readonly []string `json:"readonly"`
+}
+
+// TransactionEnvelope is the signed transaction body
+type TransactionEnvelope struct {
+	Signatures []string           `json:"signatures"`
+	Message    TransactionMessage `json:"message"`
+}
+
+// TransactionMessage is the message signed by a transaction's signers
+type TransactionMessage struct {
+	Header          MessageHeader         `json:"header"`
+	AccountKeys     []string              `json:"accountKeys"`
+	RecentBlockhash string                `json:"recentBlockhash"`
+	Instructions    []CompiledInstruction `json:"instructions"`
+}
+
+// MessageHeader describes how the static account keys are partitioned;
+// the first NumRequiredSignatures keys are the signers
+type MessageHeader struct {
+	NumRequiredSignatures       int `json:"numRequiredSignatures"`
+	NumReadonlySignedAccounts   int `json:"numReadonlySignedAccounts"`
+	NumReadonlyUnsignedAccounts int `json:"numReadonlyUnsignedAccounts"`
+}
+
+// CompiledInstruction references its program and accounts by index into
+// the transaction's account keys; Data is base58-encoded
+type CompiledInstruction struct {
+	ProgramIDIndex int    `json:"programIdIndex"`
+	Accounts       []int  `json:"accounts"`
+	Data           string `json:"data"`
+}
+
+func hasTxError(raw json.RawMessage) bool {
+	return len(raw) > 0 && string(raw) != "null"
+}
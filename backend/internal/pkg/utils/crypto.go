@@ -2,6 +2,7 @@ package utils
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
@@ -65,3 +66,15 @@ func GenerateNonce() (string, error) {
 func GenerateSessionToken() (string, error) {
 	return GenerateRandomString(64)
 }
+
+// GenerateRefreshToken generates an opaque refresh token
+func GenerateRefreshToken() (string, error) {
+	return GenerateRandomString(64)
+}
+
+// HashToken returns the hex SHA-256 digest of a token for storage. Refresh
+// tokens are high-entropy, so a fast unsalted hash is sufficient.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -14,24 +14,32 @@ type JWTClaims struct {
 	Username     string    `json:"username"`
 	IsClient     bool      `json:"is_client"`
 	IsFreelancer bool      `json:"is_freelancer"`
+	IsAdmin      bool      `json:"is_admin"`
 	jwt.RegisteredClaims
 }
 
 type JWTManager struct {
-	secretKey   []byte
-	expireHours int
+	secretKey      []byte
+	accessTokenTTL time.Duration
 }
 
-func NewJWTManager(secret string, expireHours int) *JWTManager {
+func NewJWTManager(secret string, accessTokenMinutes int) *JWTManager {
 	return &JWTManager{
-		secretKey:   []byte(secret),
-		expireHours: expireHours,
+		secretKey:      []byte(secret),
+		accessTokenTTL: time.Duration(accessTokenMinutes) * time.Minute,
 	}
 }
 
-// GenerateToken creates a new JWT token for a user
-func (j *JWTManager) GenerateToken(userID uuid.UUID, email, username string, isClient, isFreelancer bool) (string, time.Time, error) {
-	expiresAt := time.Now().Add(time.Duration(j.expireHours) * time.Hour)
+// AccessTokenTTL returns how long issued access tokens stay valid
+func (j *JWTManager) AccessTokenTTL() time.Duration {
+	return j.accessTokenTTL
+}
+
+// GenerateToken creates a short-lived access token for a user. The session
+// token is carried as the JWT ID so the token can be revoked server-side;
+// clients renew it with a refresh token rather than re-signing claims.
+func (j *JWTManager) GenerateToken(sessionToken string, userID uuid.UUID, email, username string, isClient, isFreelancer, isAdmin bool) (string, time.Time, error) {
+	expiresAt := time.Now().Add(j.accessTokenTTL)
 
 	claims := &JWTClaims{
 		UserID:       userID,
@@ -39,12 +47,14 @@ func (j *JWTManager) GenerateToken(userID uuid.UUID, email, username string, isC
 		Username:     username,
 		IsClient:     isClient,
 		IsFreelancer: isFreelancer,
+		IsAdmin:      isAdmin,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "trenchjob",
 			Subject:   userID.String(),
+			ID:        sessionToken,
 		},
 	}
 
@@ -76,14 +86,3 @@ func (j *JWTManager) ValidateToken(tokenString string) (*JWTClaims, error) {
 
 	return nil, fmt.Errorf("invalid token")
 }
-
-// RefreshToken creates a new token with extended expiry
-func (j *JWTManager) RefreshToken(claims *JWTClaims) (string, time.Time, error) {
-	return j.GenerateToken(
-		claims.UserID,
-		claims.Email,
-		claims.Username,
-		claims.IsClient,
-		claims.IsFreelancer,
-	)
-}
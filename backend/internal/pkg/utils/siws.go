@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	siwsHeaderSuffix     = " wants you to sign in with your Solana account:"
+	siwsDefaultStatement = "Sign in to TrenchJobs"
+)
+
+// SIWSMessage is a sign-in-with-Solana login message. The wallet signs the
+// exact text produced by String, and the server parses it back to check the
+// domain, nonce and validity window before trusting the signature.
+type SIWSMessage struct {
+	Domain    string
+	Address   string
+	Statement string
+	ChainID   string
+	Nonce     string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// NewSIWSMessage builds a login message for a wallet
+func NewSIWSMessage(domain, address, chainID, nonce string, ttl time.Duration) *SIWSMessage {
+	now := time.Now().UTC().Truncate(time.Second)
+	return &SIWSMessage{
+		Domain:    domain,
+		Address:   address,
+		Statement: siwsDefaultStatement,
+		ChainID:   chainID,
+		Nonce:     nonce,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(ttl),
+	}
+}
+
+// String renders the message in the format the wallet is asked to sign
+func (m *SIWSMessage) String() string {
+	var b strings.Builder
+	b.WriteString(m.Domain + siwsHeaderSuffix + "\n")
+	b.WriteString(m.Address + "\n")
+	if m.Statement != "" {
+		b.WriteString("\n" + m.Statement + "\n")
+	}
+	b.WriteString("\n")
+	if m.ChainID != "" {
+		b.WriteString("Chain ID: " + m.ChainID + "\n")
+	}
+	b.WriteString("Nonce: " + m.Nonce + "\n")
+	b.WriteString("Issued At: " + m.IssuedAt.Format(time.RFC3339) + "\n")
+	b.WriteString("Expiration Time: " + m.ExpiresAt.Format(time.RFC3339))
+	return b.String()
+}
+
+// ParseSIWSMessage parses a message previously produced by String
+func ParseSIWSMessage(raw string) (*SIWSMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	if len(lines) < 2 || !strings.HasSuffix(lines[0], siwsHeaderSuffix) {
+		return nil, fmt.Errorf("missing sign-in header")
+	}
+
+	m := &SIWSMessage{
+		Domain:  strings.TrimSuffix(lines[0], siwsHeaderSuffix),
+		Address: strings.TrimSpace(lines[1]),
+	}
+
+	for _, line := range lines[2:] {
+		key, value, found := strings.Cut(line, ": ")
+		if !found {
+			if line != "" && m.Statement == "" && m.Nonce == "" {
+				m.Statement = line
+			}
+			continue
+		}
+
+		var err error
+		switch key {
+		case "Chain ID":
+			m.ChainID = value
+		case "Nonce":
+			m.Nonce = value
+		case "Issued At":
+			m.IssuedAt, err = time.Parse(time.RFC3339, value)
+		case "Expiration Time":
+			m.ExpiresAt, err = time.Parse(time.RFC3339, value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", strings.ToLower(key), err)
+		}
+	}
+
+	if m.Domain == "" || m.Address == "" || m.Nonce == "" {
+		return nil, fmt.Errorf("message is missing domain, address or nonce")
+	}
+	if m.IssuedAt.IsZero() || m.ExpiresAt.IsZero() {
+		return nil, fmt.Errorf("message is missing issued-at or expiration time")
+	}
+	return m, nil
+}
+
+// VerifyWalletSignature checks a base58 ed25519 signature over message
+// against a base58-encoded Solana public key
+func VerifyWalletSignature(walletAddress string, message []byte, signature string) error {
+	pubKey, err := DecodeBase58(walletAddress)
+	if err != nil {
+		return fmt.Errorf("invalid wallet address: %w", err)
+	}
+	if len(pubKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key length %d", len(pubKey))
+	}
+
+	sig, err := DecodeBase58(signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid signature length %d", len(sig))
+	}
+
+	if !ed25519.Verify(ed25519.PublicKey(pubKey), message, sig) {
+		return fmt.Errorf("signature does not match wallet")
+	}
+	return nil
+}
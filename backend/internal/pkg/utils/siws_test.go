@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSIWSMessageRoundTrip(t *testing.T) {
+	issued := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	full := &SIWSMessage{
+		Domain:    "trenchjob.io",
+		Address:   "9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM",
+		Statement: siwsDefaultStatement,
+		ChainID:   "devnet",
+		Nonce:     "3f9a1c",
+		IssuedAt:  issued,
+		ExpiresAt: issued.Add(5 * time.Minute),
+	}
+	noStatement := *full
+	noStatement.Statement = ""
+	noChain := *full
+	noChain.ChainID = ""
+
+	tests := []struct {
+		name string
+		msg  *SIWSMessage
+	}{
+		{"full", full},
+		{"no statement", &noStatement},
+		{"no chain id", &noChain},
+	}
+	for _, tt := range tests {
+		raw := tt.msg.String()
+		for _, variant := range []string{raw, strings.ReplaceAll(raw, "\n", "\r\n")} {
+			got, err := ParseSIWSMessage(variant)
+			if err != nil {
+				t.Errorf("%s: ParseSIWSMessage: %v", tt.name, err)
+				continue
+			}
+			if *got != *tt.msg {
+				t.Errorf("%s: ParseSIWSMessage = %+v, want %+v", tt.name, got, tt.msg)
+			}
+			if got.String() != raw {
+				t.Errorf("%s: String after parse = %q, want %q", tt.name, got.String(), raw)
+			}
+		}
+	}
+}
+
+func TestNewSIWSMessage(t *testing.T) {
+	msg := NewSIWSMessage("trenchjob.io", "wallet", "devnet", "nonce", 5*time.Minute)
+	if msg.Statement != siwsDefaultStatement {
+		t.Errorf("statement = %q, want %q", msg.Statement, siwsDefaultStatement)
+	}
+	if msg.IssuedAt.Location() != time.UTC || msg.IssuedAt.Nanosecond() != 0 {
+		t.Errorf("issued at = %v, want whole seconds in UTC", msg.IssuedAt)
+	}
+	if got := msg.ExpiresAt.Sub(msg.IssuedAt); got != 5*time.Minute {
+		t.Errorf("validity = %v, want 5m", got)
+	}
+}
+
+func TestParseSIWSMessageRejects(t *testing.T) {
+	valid := NewSIWSMessage("trenchjob.io", "wallet", "devnet", "nonce", 5*time.Minute).String()
+
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"empty", ""},
+		{"missing header", strings.SplitN(valid, "\n", 2)[1]},
+		{"missing domain", strings.TrimPrefix(valid, "trenchjob.io")},
+		{"missing nonce", removeLine(valid, "Nonce: ")},
+		{"missing issued at", removeLine(valid, "Issued At: ")},
+		{"missing expiration", removeLine(valid, "Expiration Time: ")},
+		{"bad issued at", replaceLine(valid, "Issued At: ", "Issued At: yesterday")},
+		{"bad expiration", replaceLine(valid, "Expiration Time: ", "Expiration Time: 1714564800")},
+	}
+	for _, tt := range tests {
+		if msg, err := ParseSIWSMessage(tt.raw); err == nil {
+			t.Errorf("%s: ParseSIWSMessage = %+v, want error", tt.name, msg)
+		}
+	}
+}
+
+func TestVerifyWalletSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	wallet := EncodeBase58(pub)
+	message := []byte(NewSIWSMessage("trenchjob.io", wallet, "devnet", "nonce", 5*time.Minute).String())
+	sig := ed25519.Sign(priv, message)
+	tampered := []byte(strings.Replace(string(message), "Nonce: nonce", "Nonce: other", 1))
+
+	tests := []struct {
+		name      string
+		wallet    string
+		message   []byte
+		signature string
+		wantErr   bool
+	}{
+		{"valid", wallet, message, EncodeBase58(sig), false},
+		{"tampered message", wallet, tampered, EncodeBase58(sig), true},
+		{"other wallet", EncodeBase58(otherPub), message, EncodeBase58(sig), true},
+		{"truncated signature", wallet, message, EncodeBase58(sig[:ed25519.SignatureSize-1]), true},
+		{"signature not base58", wallet, message, "0OIl", true},
+		{"short public key", EncodeBase58(pub[:16]), message, EncodeBase58(sig), true},
+		{"wallet not base58", "0OIl", message, EncodeBase58(sig), true},
+	}
+	for _, tt := range tests {
+		err := VerifyWalletSignature(tt.wallet, tt.message, tt.signature)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: VerifyWalletSignature = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// removeLine drops the line of raw starting with prefix
+func removeLine(raw, prefix string) string {
+	var kept []string
+	for _, line := range strings.Split(raw, "\n") {
+		if !strings.HasPrefix(line, prefix) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// replaceLine swaps the line of raw starting with prefix for line
+func replaceLine(raw, prefix, line string) string {
+	lines := strings.Split(raw, "\n")
+	for i := range lines {
+		if strings.HasPrefix(lines[i], prefix) {
+			lines[i] = line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
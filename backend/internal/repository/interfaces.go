@@ -2,11 +2,22 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/trenchjob/backend/internal/domain"
 )
 
+// Transactor runs a unit of work atomically. Repository calls made with the
+// context passed to fn join the transaction; nested calls run in a savepoint of
+// the outer one.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+	// AfterCommit runs fn once the outermost transaction carried by ctx
+	// commits, dropping it on rollback; outside a transaction it runs now
+	AfterCommit(ctx context.Context, fn func())
+}
+
 // UserRepository defines user data access methods
 type UserRepository interface {
 	Create(ctx context.Context, user *domain.User) error
@@ -18,27 +29,43 @@ type UserRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	EmailExists(ctx context.Context, email string) (bool, error)
 	UsernameExists(ctx context.Context, username string) (bool, error)
+	Search(ctx context.Context, query, status string, limit, offset int) ([]domain.User, int, error)
 }
 
 // WalletRepository defines wallet data access methods
 type WalletRepository interface {
 	Create(ctx context.Context, wallet *domain.UserWallet) error
 	GetByAddress(ctx context.Context, address string) (*domain.UserWallet, error)
+	GetByUserIDAndAddress(ctx context.Context, userID uuid.UUID, address string) (*domain.UserWallet, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]domain.UserWallet, error)
+	MarkVerified(ctx context.Context, id uuid.UUID, verifiedAt time.Time) error
 	SetPrimary(ctx context.Context, userID uuid.UUID, walletID uuid.UUID) error
 	Delete(ctx context.Context, id uuid.UUID) error
-	SaveNonce(ctx context.Context, walletAddress, nonce string) error
+	SaveNonce(ctx context.Context, walletAddress, nonce string, expiresAt time.Time) error
 	GetNonce(ctx context.Context, walletAddress string) (string, error)
 	DeleteNonce(ctx context.Context, walletAddress string) error
+	ConsumeNonce(ctx context.Context, walletAddress, nonce string) error
 }
 
 // SessionRepository defines session data access methods
 type SessionRepository interface {
 	Create(ctx context.Context, session *domain.AuthSession) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.AuthSession, error)
 	GetByToken(ctx context.Context, token string) (*domain.AuthSession, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]domain.AuthSession, error)
 	Delete(ctx context.Context, id uuid.UUID) error
+	DeleteByToken(ctx context.Context, token string) error
 	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
-	DeleteExpired(ctx context.Context) error
+	DeleteExpired(ctx context.Context) (int64, error)
+	Extend(ctx context.Context, id uuid.UUID, expiresAt time.Time) error
+}
+
+// RefreshTokenRepository defines refresh token data access methods
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *domain.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error)
+	MarkUsed(ctx context.Context, id, replacedBy uuid.UUID) error
+	DeleteExpired(ctx context.Context) (int64, error)
 }
 
 // ProfileRepository defines profile data access methods
@@ -103,6 +130,39 @@ type JobRepository interface {
 	GetSkills(ctx context.Context, jobID uuid.UUID) ([]domain.Skill, error)
 }
 
+// ServiceRepository defines service (gig) data access methods
+type ServiceRepository interface {
+	Create(ctx context.Context, service *domain.Service) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Service, error)
+	GetByFreelancerID(ctx context.Context, freelancerID uuid.UUID, status string, limit, offset int) ([]domain.Service, int, error)
+	Update(ctx context.Context, service *domain.Service) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	Search(ctx context.Context, query string, categoryID *int, skills []int, limit, offset int) ([]domain.Service, int, error)
+	IncrementViews(ctx context.Context, id uuid.UUID) error
+	AddSkills(ctx context.Context, serviceID uuid.UUID, skillIDs []int) error
+	RemoveSkills(ctx context.Context, serviceID uuid.UUID) error
+	GetSkills(ctx context.Context, serviceID uuid.UUID) ([]domain.Skill, error)
+	AddFAQ(ctx context.Context, faq *domain.ServiceFAQ) error
+	GetFAQs(ctx context.Context, serviceID uuid.UUID) ([]domain.ServiceFAQ, error)
+	UpdateFAQ(ctx context.Context, faq *domain.ServiceFAQ) error
+	DeleteFAQ(ctx context.Context, faqID uuid.UUID) error
+}
+
+// ServiceOrderRepository defines service order data access methods
+type ServiceOrderRepository interface {
+	Create(ctx context.Context, order *domain.ServiceOrder) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.ServiceOrder, error)
+	GetByClientID(ctx context.Context, clientID uuid.UUID, status string, limit, offset int) ([]domain.ServiceOrder, int, error)
+	GetByFreelancerID(ctx context.Context, freelancerID uuid.UUID, status string, limit, offset int) ([]domain.ServiceOrder, int, error)
+	GetByServiceID(ctx context.Context, serviceID uuid.UUID, limit, offset int) ([]domain.ServiceOrder, int, error)
+	Update(ctx context.Context, order *domain.ServiceOrder) error
+	CreateMessage(ctx context.Context, message *domain.ServiceOrderMessage) error
+	GetMessages(ctx context.Context, orderID uuid.UUID, limit, offset int) ([]domain.ServiceOrderMessage, int, error)
+	CreateReview(ctx context.Context, review *domain.ServiceReview) error
+	GetReviewByOrderID(ctx context.Context, orderID uuid.UUID) (*domain.ServiceReview, error)
+	GetReviewsByServiceID(ctx context.Context, serviceID uuid.UUID, limit, offset int) ([]domain.ServiceReview, int, error)
+}
+
 // ProposalRepository defines proposal data access methods
 type ProposalRepository interface {
 	Create(ctx context.Context, proposal *domain.Proposal) error
@@ -128,10 +188,55 @@ type MilestoneRepository interface {
 	Create(ctx context.Context, milestone *domain.Milestone) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Milestone, error)
 	GetByContractID(ctx context.Context, contractID uuid.UUID) ([]domain.Milestone, error)
+	ListPastDue(ctx context.Context, now, overdueBefore time.Time, limit int) ([]domain.Milestone, error)
+	ListSubmittedBefore(ctx context.Context, noticeBefore, approveBefore, noticedBefore time.Time, limit int) ([]domain.Milestone, error)
 	Update(ctx context.Context, milestone *domain.Milestone) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// MilestoneRevisionRepository defines milestone revision history data access methods
+type MilestoneRevisionRepository interface {
+	Create(ctx context.Context, revision *domain.MilestoneRevision) error
+	CountRequested(ctx context.Context, milestoneID uuid.UUID) (int, error)
+	ListByContract(ctx context.Context, contractID uuid.UUID) ([]domain.MilestoneRevision, error)
+}
+
+// ContractStatusChangeRepository defines contract status history data access methods
+type ContractStatusChangeRepository interface {
+	Create(ctx context.Context, change *domain.ContractStatusChange) error
+	ListByContract(ctx context.Context, contractID uuid.UUID) ([]domain.ContractStatusChange, error)
+}
+
+// ContractCancellationRepository defines cancellation request data access methods
+type ContractCancellationRepository interface {
+	Create(ctx context.Context, cancellation *domain.ContractCancellation) error
+	GetPending(ctx context.Context, contractID uuid.UUID) (*domain.ContractCancellation, error)
+	ListDue(ctx context.Context, now time.Time, limit int) ([]domain.ContractCancellation, error)
+	Update(ctx context.Context, cancellation *domain.ContractCancellation) error
+}
+
+// TimeEntryRepository defines time entry data access methods
+type TimeEntryRepository interface {
+	Create(ctx context.Context, entry *domain.TimeEntry) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.TimeEntry, error)
+	GetRunning(ctx context.Context, contractID uuid.UUID) (*domain.TimeEntry, error)
+	ListByWeek(ctx context.Context, contractID uuid.UUID, weekStart time.Time) ([]domain.TimeEntry, error)
+	SumMinutes(ctx context.Context, contractID uuid.UUID, weekStart *time.Time) (int, error)
+	HasOverlap(ctx context.Context, contractID, freelancerID uuid.UUID, start, end time.Time) (bool, error)
+	Update(ctx context.Context, entry *domain.TimeEntry) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	LockContract(ctx context.Context, contractID uuid.UUID) error
+}
+
+// TimesheetRepository defines timesheet data access methods
+type TimesheetRepository interface {
+	Create(ctx context.Context, timesheet *domain.Timesheet) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Timesheet, error)
+	GetByWeek(ctx context.Context, contractID uuid.UUID, weekStart time.Time) (*domain.Timesheet, error)
+	ListByContract(ctx context.Context, contractID uuid.UUID) ([]domain.Timesheet, error)
+	Update(ctx context.Context, timesheet *domain.Timesheet) error
+}
+
 // EscrowRepository defines escrow data access methods
 type EscrowRepository interface {
 	Create(ctx context.Context, escrow *domain.Escrow) error
@@ -140,6 +245,28 @@ type EscrowRepository interface {
 	GetByPDA(ctx context.Context, pda string) (*domain.Escrow, error)
 	Update(ctx context.Context, escrow *domain.Escrow) error
 	CreateLog(ctx context.Context, log *domain.EscrowLog) error
+	ListUnclosed(ctx context.Context) ([]domain.Escrow, error)
+}
+
+// ChainCursorRepository persists on-chain indexer positions
+type ChainCursorRepository interface {
+	Get(ctx context.Context, name string) (*domain.ChainCursor, error)
+	Save(ctx context.Context, cursor *domain.ChainCursor) error
+}
+
+// EscrowDriftRepository persists escrow reconciliation results
+type EscrowDriftRepository interface {
+	Upsert(ctx context.Context, drift *domain.EscrowDrift) error
+	ResolveExcept(ctx context.Context, escrowID uuid.UUID, openFields []string) error
+	ListOpen(ctx context.Context, limit, offset int) ([]domain.EscrowDrift, int, error)
+	CreateRun(ctx context.Context, run *domain.EscrowReconciliationRun) error
+	GetLatestRun(ctx context.Context) (*domain.EscrowReconciliationRun, error)
+}
+
+// PlatformSettingsRepository defines platform settings data access methods
+type PlatformSettingsRepository interface {
+	List(ctx context.Context) ([]domain.PlatformSetting, error)
+	ListFeeOverrides(ctx context.Context, since time.Time) ([]domain.FeeOverride, error)
 }
 
 // PaymentRepository defines payment data access methods
@@ -148,6 +275,8 @@ type PaymentRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error)
 	GetByContractID(ctx context.Context, contractID uuid.UUID) ([]domain.Payment, error)
 	GetByTxSignature(ctx context.Context, txSignature string) (*domain.Payment, error)
+	ListByTxSignature(ctx context.Context, txSignature string) ([]domain.Payment, error)
+	ListPendingSubmitted(ctx context.Context, limit int) ([]domain.Payment, error)
 	Update(ctx context.Context, payment *domain.Payment) error
 }
 
@@ -161,6 +290,7 @@ type ConversationRepository interface {
 	GetParticipants(ctx context.Context, conversationID uuid.UUID) ([]domain.ConversationParticipant, error)
 	UpdateLastRead(ctx context.Context, conversationID, userID uuid.UUID) error
 	IsParticipant(ctx context.Context, conversationID, userID uuid.UUID) (bool, error)
+	GetContactIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
 }
 
 // MessageRepository defines message data access methods
@@ -172,6 +302,17 @@ type MessageRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	GetLastMessage(ctx context.Context, conversationID uuid.UUID) (*domain.Message, error)
 	GetUnreadCount(ctx context.Context, userID uuid.UUID) (int, error)
+	CreateAttachment(ctx context.Context, attachment *domain.MessageAttachment) error
+	GetAttachmentsByMessageID(ctx context.Context, messageID uuid.UUID) ([]domain.MessageAttachment, error)
+	GetByConversationIDWithAttachments(ctx context.Context, conversationID uuid.UUID, limit, offset int) ([]domain.Message, int, error)
+	SoftDelete(ctx context.Context, message *domain.Message) error
+	CreateEdit(ctx context.Context, edit *domain.MessageEdit) error
+	GetEdits(ctx context.Context, messageID uuid.UUID) ([]domain.MessageEdit, error)
+}
+
+// PresenceRepository defines user presence data access methods
+type PresenceRepository interface {
+	SetOnline(ctx context.Context, userID uuid.UUID, isOnline bool) error
 }
 
 // ReviewRepository defines review data access methods
@@ -183,6 +324,15 @@ type ReviewRepository interface {
 	Exists(ctx context.Context, contractID, reviewerID uuid.UUID) (bool, error)
 }
 
+// DisputeRepository defines dispute data access methods
+type DisputeRepository interface {
+	Create(ctx context.Context, dispute *domain.Dispute) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Dispute, error)
+	GetByContractID(ctx context.Context, contractID uuid.UUID) ([]domain.Dispute, error)
+	List(ctx context.Context, status string, limit, offset int) ([]domain.Dispute, int, error)
+	Update(ctx context.Context, dispute *domain.Dispute) error
+}
+
 // NotificationRepository defines notification data access methods
 type NotificationRepository interface {
 	Create(ctx context.Context, notification *domain.Notification) error
@@ -191,3 +341,10 @@ type NotificationRepository interface {
 	MarkAllAsRead(ctx context.Context, userID uuid.UUID) error
 	GetUnreadCount(ctx context.Context, userID uuid.UUID) (int, error)
 }
+
+// AuditLogRepository defines admin audit log data access methods. The log is
+// append-only, so there are no update or delete methods.
+type AuditLogRepository interface {
+	Create(ctx context.Context, entry *domain.AuditLogEntry) error
+	List(ctx context.Context, adminID, targetID *uuid.UUID, targetType string, limit, offset int) ([]domain.AuditLogEntry, int, error)
+}
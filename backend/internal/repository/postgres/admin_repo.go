@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/trenchjob/backend/internal/domain"
+	"github.com/trenchjob/backend/internal/pkg/database"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+type AuditLogRepository struct {
+	db *database.Conn
+}
+
+func NewAuditLogRepository(db *pgxpool.Pool) repository.AuditLogRepository {
+	return &AuditLogRepository{db: database.NewConn(db)}
+}
+
+func (r *AuditLogRepository) Create(ctx context.Context, entry *domain.AuditLogEntry) error {
+	query := `
+		INSERT INTO admin_audit_log (
+			id, admin_id, action, target_type, target_id, reason,
+			previous_value, new_value, ip_address, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9::text::inet, $10
+		)`
+
+	entry.ID = uuid.New()
+	entry.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(ctx, query,
+		entry.ID, entry.AdminID, entry.Action, entry.TargetType, entry.TargetID, entry.Reason,
+		entry.PreviousValue, entry.NewValue, entry.IPAddress, entry.CreatedAt,
+	)
+	return err
+}
+
+func (r *AuditLogRepository) List(ctx context.Context, adminID, targetID *uuid.UUID, targetType string, limit, offset int) ([]domain.AuditLogEntry, int, error) {
+	var conditions []string
+	var args []interface{}
+	argNum := 1
+
+	if adminID != nil {
+		conditions = append(conditions, fmt.Sprintf("a.admin_id = $%d", argNum))
+		args = append(args, *adminID)
+		argNum++
+	}
+	if targetType != "" {
+		conditions = append(conditions, fmt.Sprintf("a.target_type = $%d", argNum))
+		args = append(args, targetType)
+		argNum++
+	}
+	if targetID != nil {
+		conditions = append(conditions, fmt.Sprintf("a.target_id = $%d", argNum))
+		args = append(args, *targetID)
+		argNum++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM admin_audit_log a`+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT a.id, a.admin_id, a.action, a.target_type, a.target_id, a.reason,
+			   a.previous_value, a.new_value, host(a.ip_address), a.created_at, u.username
+		FROM admin_audit_log a
+		JOIN users u ON a.admin_id = u.id` + whereClause +
+		fmt.Sprintf(` ORDER BY a.created_at DESC LIMIT $%d OFFSET $%d`, argNum, argNum+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []domain.AuditLogEntry
+	for rows.Next() {
+		var e domain.AuditLogEntry
+		if err := rows.Scan(
+			&e.ID, &e.AdminID, &e.Action, &e.TargetType, &e.TargetID, &e.Reason,
+			&e.PreviousValue, &e.NewValue, &e.IPAddress, &e.CreatedAt, &e.AdminUsername,
+		); err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/trenchjob/backend/internal/domain"
+	"github.com/trenchjob/backend/internal/pkg/database"
+	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+type ChainCursorRepository struct {
+	db *database.Conn
+}
+
+func NewChainCursorRepository(db *pgxpool.Pool) repository.ChainCursorRepository {
+	return &ChainCursorRepository{db: database.NewConn(db)}
+}
+
+func (r *ChainCursorRepository) Get(ctx context.Context, name string) (*domain.ChainCursor, error) {
+	query := `
+		SELECT name, last_signature, last_slot, updated_at
+		FROM chain_cursors
+		WHERE name = $1`
+
+	cursor := &domain.ChainCursor{}
+	err := r.db.QueryRow(ctx, query, name).Scan(
+		&cursor.Name, &cursor.LastSignature, &cursor.LastSlot, &cursor.UpdatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperrors.ErrNotFound
+	}
+	return cursor, err
+}
+
+func (r *ChainCursorRepository) Save(ctx context.Context, cursor *domain.ChainCursor) error {
+	query := `
+		INSERT INTO chain_cursors (name, last_signature, last_slot, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name) DO UPDATE SET
+			last_signature = EXCLUDED.last_signature,
+			last_slot = EXCLUDED.last_slot,
+			updated_at = EXCLUDED.updated_at`
+
+	cursor.UpdatedAt = time.Now()
+	_, err := r.db.Exec(ctx, query, cursor.Name, cursor.LastSignature, cursor.LastSlot, cursor.UpdatedAt)
+	return err
+}
+
+type EscrowDriftRepository struct {
+	db *database.Conn
+}
+
+func NewEscrowDriftRepository(db *pgxpool.Pool) repository.EscrowDriftRepository {
+	return &EscrowDriftRepository{db: database.NewConn(db)}
+}
+
+// Upsert records a drift, refreshing the open row for the same escrow and
+// field if there is one
+func (r *EscrowDriftRepository) Upsert(ctx context.Context, drift *domain.EscrowDrift) error {
+	query := `
+		INSERT INTO escrow_drifts (id, escrow_id, field, db_value, chain_value, detected_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (escrow_id, field) WHERE resolved_at IS NULL DO UPDATE SET
+			db_value = EXCLUDED.db_value,
+			chain_value = EXCLUDED.chain_value,
+			last_seen_at = EXCLUDED.last_seen_at
+		RETURNING id, detected_at, last_seen_at`
+
+	return r.db.QueryRow(ctx, query,
+		uuid.New(), drift.EscrowID, drift.Field, drift.DBValue, drift.ChainValue, time.Now(),
+	).Scan(&drift.ID, &drift.DetectedAt, &drift.LastSeenAt)
+}
+
+// ResolveExcept closes the escrow's open drifts on every field not in openFields
+func (r *EscrowDriftRepository) ResolveExcept(ctx context.Context, escrowID uuid.UUID, openFields []string) error {
+	query := `
+		UPDATE escrow_drifts SET resolved_at = NOW()
+		WHERE escrow_id = $1 AND resolved_at IS NULL AND NOT (field = ANY($2))`
+
+	if openFields == nil {
+		openFields = []string{}
+	}
+	_, err := r.db.Exec(ctx, query, escrowID, openFields)
+	return err
+}
+
+func (r *EscrowDriftRepository) ListOpen(ctx context.Context, limit, offset int) ([]domain.EscrowDrift, int, error) {
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM escrow_drifts WHERE resolved_at IS NULL`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT d.id, d.escrow_id, e.contract_id, d.field, d.db_value, d.chain_value,
+			   d.detected_at, d.last_seen_at, d.resolved_at
+		FROM escrow_drifts d
+		JOIN escrows e ON e.id = d.escrow_id
+		WHERE d.resolved_at IS NULL
+		ORDER BY d.detected_at DESC
+		LIMIT $1 OFFSET $2`
+
+	rows, err := r.db.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var drifts []domain.EscrowDrift
+	for rows.Next() {
+		var d domain.EscrowDrift
+		if err := rows.Scan(
+			&d.ID, &d.EscrowID, &d.ContractID, &d.Field, &d.DBValue, &d.ChainValue,
+			&d.DetectedAt, &d.LastSeenAt, &d.ResolvedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		drifts = append(drifts, d)
+	}
+
+	return drifts, total, rows.Err()
+}
+
+func (r *EscrowDriftRepository) CreateRun(ctx context.Context, run *domain.EscrowReconciliationRun) error {
+	query := `
+		INSERT INTO escrow_reconciliation_runs (
+			id, checked, ok, healed, mismatched, failed, started_at, finished_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		)`
+
+	run.ID = uuid.New()
+	_, err := r.db.Exec(ctx, query,
+		run.ID, run.Checked, run.OK, run.Healed, run.Mismatched, run.Failed,
+		run.StartedAt, run.FinishedAt,
+	)
+	return err
+}
+
+func (r *EscrowDriftRepository) GetLatestRun(ctx context.Context) (*domain.EscrowReconciliationRun, error) {
+	query := `
+		SELECT id, checked, ok, healed, mismatched, failed, started_at, finished_at
+		FROM escrow_reconciliation_runs
+		ORDER BY started_at DESC
+		LIMIT 1`
+
+	run := &domain.EscrowReconciliationRun{}
+	err := r.db.QueryRow(ctx, query).Scan(
+		&run.ID, &run.Checked, &run.OK, &run.Healed, &run.Mismatched, &run.Failed,
+		&run.StartedAt, &run.FinishedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperrors.ErrNotFound
+	}
+	return run, err
+}
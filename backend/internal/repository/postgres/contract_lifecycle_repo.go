@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/trenchjob/backend/internal/domain"
+	"github.com/trenchjob/backend/internal/pkg/database"
+	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+type ContractStatusChangeRepository struct {
+	db *database.Conn
+}
+
+func NewContractStatusChangeRepository(db *pgxpool.Pool) repository.ContractStatusChangeRepository {
+	return &ContractStatusChangeRepository{db: database.NewConn(db)}
+}
+
+func (r *ContractStatusChangeRepository) Create(ctx context.Context, change *domain.ContractStatusChange) error {
+	query := `
+		INSERT INTO contract_status_changes (
+			id, contract_id, from_status, to_status, changed_by, reason, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		)`
+
+	change.ID = uuid.New()
+	change.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(ctx, query,
+		change.ID, change.ContractID, change.FromStatus, change.ToStatus,
+		change.ChangedBy, change.Reason, change.CreatedAt,
+	)
+
+	return err
+}
+
+func (r *ContractStatusChangeRepository) ListByContract(ctx context.Context, contractID uuid.UUID) ([]domain.ContractStatusChange, error) {
+	query := `
+		SELECT id, contract_id, from_status, to_status, changed_by, reason, created_at
+		FROM contract_status_changes
+		WHERE contract_id = $1
+		ORDER BY created_at ASC`
+
+	rows, err := r.db.Query(ctx, query, contractID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []domain.ContractStatusChange
+	for rows.Next() {
+		var change domain.ContractStatusChange
+		if err := rows.Scan(
+			&change.ID, &change.ContractID, &change.FromStatus, &change.ToStatus,
+			&change.ChangedBy, &change.Reason, &change.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+type ContractCancellationRepository struct {
+	db *database.Conn
+}
+
+func NewContractCancellationRepository(db *pgxpool.Pool) repository.ContractCancellationRepository {
+	return &ContractCancellationRepository{db: database.NewConn(db)}
+}
+
+func (r *ContractCancellationRepository) Create(ctx context.Context, cancellation *domain.ContractCancellation) error {
+	query := `
+		INSERT INTO contract_cancellations (
+			id, contract_id, requested_by, mode, reason, status, effective_at,
+			responded_by, responded_at, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+		)`
+
+	cancellation.ID = uuid.New()
+	cancellation.CreatedAt = time.Now()
+	cancellation.UpdatedAt = time.Now()
+	if cancellation.Status == "" {
+		cancellation.Status = domain.CancellationStatusPending
+	}
+
+	_, err := r.db.Exec(ctx, query,
+		cancellation.ID, cancellation.ContractID, cancellation.RequestedBy, cancellation.Mode,
+		cancellation.Reason, cancellation.Status, cancellation.EffectiveAt,
+		cancellation.RespondedBy, cancellation.RespondedAt, cancellation.CreatedAt, cancellation.UpdatedAt,
+	)
+
+	return err
+}
+
+// GetPending returns the contract's open cancellation request
+func (r *ContractCancellationRepository) GetPending(ctx context.Context, contractID uuid.UUID) (*domain.ContractCancellation, error) {
+	query := `
+		SELECT id, contract_id, requested_by, mode, reason, status, effective_at,
+			   responded_by, responded_at, created_at, updated_at
+		FROM contract_cancellations
+		WHERE contract_id = $1 AND status = 'pending'`
+
+	cancellation := &domain.ContractCancellation{}
+	err := r.db.QueryRow(ctx, query, contractID).Scan(
+		&cancellation.ID, &cancellation.ContractID, &cancellation.RequestedBy, &cancellation.Mode,
+		&cancellation.Reason, &cancellation.Status, &cancellation.EffectiveAt,
+		&cancellation.RespondedBy, &cancellation.RespondedAt, &cancellation.CreatedAt, &cancellation.UpdatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperrors.ErrNotFound
+	}
+	return cancellation, err
+}
+
+// ListDue returns pending notice cancellations whose notice ran out by now
+func (r *ContractCancellationRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]domain.ContractCancellation, error) {
+	query := `
+		SELECT id, contract_id, requested_by, mode, reason, status, effective_at,
+			   responded_by, responded_at, created_at, updated_at
+		FROM contract_cancellations
+		WHERE status = 'pending' AND effective_at <= $1
+		ORDER BY effective_at ASC
+		LIMIT $2`
+
+	rows, err := r.db.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cancellations []domain.ContractCancellation
+	for rows.Next() {
+		var cancellation domain.ContractCancellation
+		if err := rows.Scan(
+			&cancellation.ID, &cancellation.ContractID, &cancellation.RequestedBy, &cancellation.Mode,
+			&cancellation.Reason, &cancellation.Status, &cancellation.EffectiveAt,
+			&cancellation.RespondedBy, &cancellation.RespondedAt, &cancellation.CreatedAt, &cancellation.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		cancellations = append(cancellations, cancellation)
+	}
+
+	return cancellations, rows.Err()
+}
+
+// Update saves a response to a request. Only a pending request can be
+// answered, so a second response loses with ErrVersionConflict.
+func (r *ContractCancellationRepository) Update(ctx context.Context, cancellation *domain.ContractCancellation) error {
+	query := `
+		UPDATE contract_cancellations SET
+			status = $2, responded_by = $3, responded_at = $4, updated_at = $5
+		WHERE id = $1 AND status = 'pending'`
+
+	cancellation.UpdatedAt = time.Now()
+	result, err := r.db.Exec(ctx, query,
+		cancellation.ID, cancellation.Status, cancellation.RespondedBy,
+		cancellation.RespondedAt, cancellation.UpdatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return staleOrMissing(ctx, r.db, "contract_cancellations", cancellation.ID)
+	}
+	return nil
+}
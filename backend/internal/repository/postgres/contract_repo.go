@@ -11,15 +11,16 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/trenchjob/backend/internal/domain"
+	"github.com/trenchjob/backend/internal/pkg/database"
 	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
 )
 
 type ContractRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewContractRepository(db *pgxpool.Pool) *ContractRepository {
-	return &ContractRepository{db: db}
+	return &ContractRepository{db: database.NewConn(db)}
 }
 
 func (r *ContractRepository) Create(ctx context.Context, contract *domain.Contract) error {
@@ -36,6 +37,7 @@ func (r *ContractRepository) Create(ctx context.Context, contract *domain.Contra
 	contract.ID = uuid.New()
 	contract.CreatedAt = time.Now()
 	contract.UpdatedAt = time.Now()
+	contract.Version = 1
 	if contract.Status == "" {
 		contract.Status = domain.ContractStatusPending
 	}
@@ -56,7 +58,7 @@ func (r *ContractRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain
 		SELECT c.id, c.proposal_id, c.job_id, c.client_id, c.freelancer_id, c.title, c.description,
 			   c.payment_type, c.total_amount_sol, c.hourly_rate_sol, c.weekly_hour_limit,
 			   c.escrow_account_address, c.escrow_amount_sol, c.released_amount_sol,
-			   c.status, c.started_at, c.ended_at, c.created_at, c.updated_at,
+			   c.status, c.started_at, c.ended_at, c.created_at, c.updated_at, c.version,
 			   u1.username as client_username, u2.username as freelancer_username
 		FROM contracts c
 		JOIN users u1 ON c.client_id = u1.id
@@ -71,7 +73,7 @@ func (r *ContractRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain
 		&contract.Title, &contract.Description, &contract.PaymentType, &contract.TotalAmountSOL,
 		&contract.HourlyRateSOL, &contract.WeeklyHourLimit, &contract.EscrowAccountAddress,
 		&contract.EscrowAmountSOL, &contract.ReleasedAmountSOL, &contract.Status,
-		&contract.StartedAt, &contract.EndedAt, &contract.CreatedAt, &contract.UpdatedAt,
+		&contract.StartedAt, &contract.EndedAt, &contract.CreatedAt, &contract.UpdatedAt, &contract.Version,
 		&clientUsername, &freelancerUsername,
 	)
 
@@ -111,7 +113,7 @@ func (r *ContractRepository) GetByClientID(ctx context.Context, clientID uuid.UU
 		SELECT c.id, c.proposal_id, c.job_id, c.client_id, c.freelancer_id, c.title, c.description,
 			   c.payment_type, c.total_amount_sol, c.hourly_rate_sol, c.weekly_hour_limit,
 			   c.escrow_account_address, c.escrow_amount_sol, c.released_amount_sol,
-			   c.status, c.started_at, c.ended_at, c.created_at, c.updated_at,
+			   c.status, c.started_at, c.ended_at, c.created_at, c.updated_at, c.version,
 			   u.username as freelancer_username
 		FROM contracts c
 		JOIN users u ON c.freelancer_id = u.id` +
@@ -135,7 +137,7 @@ func (r *ContractRepository) GetByClientID(ctx context.Context, clientID uuid.UU
 			&contract.Title, &contract.Description, &contract.PaymentType, &contract.TotalAmountSOL,
 			&contract.HourlyRateSOL, &contract.WeeklyHourLimit, &contract.EscrowAccountAddress,
 			&contract.EscrowAmountSOL, &contract.ReleasedAmountSOL, &contract.Status,
-			&contract.StartedAt, &contract.EndedAt, &contract.CreatedAt, &contract.UpdatedAt,
+			&contract.StartedAt, &contract.EndedAt, &contract.CreatedAt, &contract.UpdatedAt, &contract.Version,
 			&freelancerUsername,
 		); err != nil {
 			return nil, 0, err
@@ -176,7 +178,7 @@ func (r *ContractRepository) GetByFreelancerID(ctx context.Context, freelancerID
 		SELECT c.id, c.proposal_id, c.job_id, c.client_id, c.freelancer_id, c.title, c.description,
 			   c.payment_type, c.total_amount_sol, c.hourly_rate_sol, c.weekly_hour_limit,
 			   c.escrow_account_address, c.escrow_amount_sol, c.released_amount_sol,
-			   c.status, c.started_at, c.ended_at, c.created_at, c.updated_at,
+			   c.status, c.started_at, c.ended_at, c.created_at, c.updated_at, c.version,
 			   u.username as client_username
 		FROM contracts c
 		JOIN users u ON c.client_id = u.id` +
@@ -200,7 +202,7 @@ func (r *ContractRepository) GetByFreelancerID(ctx context.Context, freelancerID
 			&contract.Title, &contract.Description, &contract.PaymentType, &contract.TotalAmountSOL,
 			&contract.HourlyRateSOL, &contract.WeeklyHourLimit, &contract.EscrowAccountAddress,
 			&contract.EscrowAmountSOL, &contract.ReleasedAmountSOL, &contract.Status,
-			&contract.StartedAt, &contract.EndedAt, &contract.CreatedAt, &contract.UpdatedAt,
+			&contract.StartedAt, &contract.EndedAt, &contract.CreatedAt, &contract.UpdatedAt, &contract.Version,
 			&clientUsername,
 		); err != nil {
 			return nil, 0, err
@@ -217,8 +219,8 @@ func (r *ContractRepository) Update(ctx context.Context, contract *domain.Contra
 			title = $2, description = $3, payment_type = $4, total_amount_sol = $5,
 			hourly_rate_sol = $6, weekly_hour_limit = $7, escrow_account_address = $8,
 			escrow_amount_sol = $9, released_amount_sol = $10, status = $11,
-			started_at = $12, ended_at = $13, updated_at = $14
-		WHERE id = $1`
+			started_at = $12, ended_at = $13, updated_at = $14, version = version + 1
+		WHERE id = $1 AND version = $15`
 
 	contract.UpdatedAt = time.Now()
 	result, err := r.db.Exec(ctx, query,
@@ -226,24 +228,26 @@ func (r *ContractRepository) Update(ctx context.Context, contract *domain.Contra
 		contract.TotalAmountSOL, contract.HourlyRateSOL, contract.WeeklyHourLimit,
 		contract.EscrowAccountAddress, contract.EscrowAmountSOL, contract.ReleasedAmountSOL,
 		contract.Status, contract.StartedAt, contract.EndedAt, contract.UpdatedAt,
+		contract.Version,
 	)
 
 	if err != nil {
 		return err
 	}
 	if result.RowsAffected() == 0 {
-		return apperrors.ErrNotFound
+		return staleOrMissing(ctx, r.db, "contracts", contract.ID)
 	}
+	contract.Version++
 	return nil
 }
 
 // MilestoneRepository implementation
 type MilestoneRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewMilestoneRepository(db *pgxpool.Pool) *MilestoneRepository {
-	return &MilestoneRepository{db: db}
+	return &MilestoneRepository{db: database.NewConn(db)}
 }
 
 func (r *MilestoneRepository) Create(ctx context.Context, milestone *domain.Milestone) error {
@@ -251,14 +255,15 @@ func (r *MilestoneRepository) Create(ctx context.Context, milestone *domain.Mile
 		INSERT INTO milestones (
 			id, contract_id, title, description, amount_sol, due_date, sort_order,
 			status, submission_text, submission_urls, submitted_at, approved_at,
-			payment_id, paid_at, created_at, updated_at
+			payment_id, paid_at, max_revisions, created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
 		)`
 
 	milestone.ID = uuid.New()
 	milestone.CreatedAt = time.Now()
 	milestone.UpdatedAt = time.Now()
+	milestone.Version = 1
 	if milestone.Status == "" {
 		milestone.Status = domain.MilestoneStatusPending
 	}
@@ -268,7 +273,7 @@ func (r *MilestoneRepository) Create(ctx context.Context, milestone *domain.Mile
 		milestone.AmountSOL, milestone.DueDate, milestone.SortOrder, milestone.Status,
 		milestone.SubmissionText, milestone.SubmissionURLs, milestone.SubmittedAt,
 		milestone.ApprovedAt, milestone.PaymentID, milestone.PaidAt,
-		milestone.CreatedAt, milestone.UpdatedAt,
+		milestone.MaxRevisions, milestone.CreatedAt, milestone.UpdatedAt,
 	)
 
 	return err
@@ -278,7 +283,8 @@ func (r *MilestoneRepository) GetByID(ctx context.Context, id uuid.UUID) (*domai
 	query := `
 		SELECT id, contract_id, title, description, amount_sol, due_date, sort_order,
 			   status, submission_text, submission_urls, submitted_at, approved_at,
-			   payment_id, paid_at, created_at, updated_at
+			   payment_id, paid_at, max_revisions, due_reminder_sent_at, overdue_at,
+			   auto_approve_notice_sent_at, created_at, updated_at, version
 		FROM milestones
 		WHERE id = $1`
 
@@ -288,7 +294,8 @@ func (r *MilestoneRepository) GetByID(ctx context.Context, id uuid.UUID) (*domai
 		&milestone.AmountSOL, &milestone.DueDate, &milestone.SortOrder, &milestone.Status,
 		&milestone.SubmissionText, &milestone.SubmissionURLs, &milestone.SubmittedAt,
 		&milestone.ApprovedAt, &milestone.PaymentID, &milestone.PaidAt,
-		&milestone.CreatedAt, &milestone.UpdatedAt,
+		&milestone.MaxRevisions, &milestone.DueReminderSentAt, &milestone.OverdueAt,
+		&milestone.AutoApproveNoticeSentAt, &milestone.CreatedAt, &milestone.UpdatedAt, &milestone.Version,
 	)
 
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -301,7 +308,8 @@ func (r *MilestoneRepository) GetByContractID(ctx context.Context, contractID uu
 	query := `
 		SELECT id, contract_id, title, description, amount_sol, due_date, sort_order,
 			   status, submission_text, submission_urls, submitted_at, approved_at,
-			   payment_id, paid_at, created_at, updated_at
+			   payment_id, paid_at, max_revisions, due_reminder_sent_at, overdue_at,
+			   auto_approve_notice_sent_at, created_at, updated_at, version
 		FROM milestones
 		WHERE contract_id = $1
 		ORDER BY sort_order ASC`
@@ -320,7 +328,97 @@ func (r *MilestoneRepository) GetByContractID(ctx context.Context, contractID uu
 			&milestone.AmountSOL, &milestone.DueDate, &milestone.SortOrder, &milestone.Status,
 			&milestone.SubmissionText, &milestone.SubmissionURLs, &milestone.SubmittedAt,
 			&milestone.ApprovedAt, &milestone.PaymentID, &milestone.PaidAt,
-			&milestone.CreatedAt, &milestone.UpdatedAt,
+			&milestone.MaxRevisions, &milestone.DueReminderSentAt, &milestone.OverdueAt,
+			&milestone.AutoApproveNoticeSentAt, &milestone.CreatedAt, &milestone.UpdatedAt, &milestone.Version,
+		); err != nil {
+			return nil, err
+		}
+		milestones = append(milestones, milestone)
+	}
+
+	return milestones, rows.Err()
+}
+
+// ListPastDue returns undelivered milestones on active contracts that the
+// scheduler still has to act on: those past their due date without a
+// reminder, and those due before overdueBefore not yet flagged overdue.
+// Milestones already reminded and still in their grace period are left out
+// so they cannot crowd the rest out of the batch.
+func (r *MilestoneRepository) ListPastDue(ctx context.Context, now, overdueBefore time.Time, limit int) ([]domain.Milestone, error) {
+	query := `
+		SELECT m.id, m.contract_id, m.title, m.description, m.amount_sol, m.due_date, m.sort_order,
+			   m.status, m.submission_text, m.submission_urls, m.submitted_at, m.approved_at,
+			   m.payment_id, m.paid_at, m.max_revisions, m.due_reminder_sent_at, m.overdue_at,
+			   m.auto_approve_notice_sent_at, m.created_at, m.updated_at, m.version
+		FROM milestones m
+		JOIN contracts c ON c.id = m.contract_id
+		WHERE c.status = 'active'
+		  AND m.status IN ('pending', 'in_progress', 'revision_requested')
+		  AND m.overdue_at IS NULL
+		  AND ((m.due_reminder_sent_at IS NULL AND m.due_date < $1) OR m.due_date < $2)
+		ORDER BY m.due_date ASC
+		LIMIT $3`
+
+	rows, err := r.db.Query(ctx, query, now, overdueBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var milestones []domain.Milestone
+	for rows.Next() {
+		var milestone domain.Milestone
+		if err := rows.Scan(
+			&milestone.ID, &milestone.ContractID, &milestone.Title, &milestone.Description,
+			&milestone.AmountSOL, &milestone.DueDate, &milestone.SortOrder, &milestone.Status,
+			&milestone.SubmissionText, &milestone.SubmissionURLs, &milestone.SubmittedAt,
+			&milestone.ApprovedAt, &milestone.PaymentID, &milestone.PaidAt,
+			&milestone.MaxRevisions, &milestone.DueReminderSentAt, &milestone.OverdueAt,
+			&milestone.AutoApproveNoticeSentAt, &milestone.CreatedAt, &milestone.UpdatedAt, &milestone.Version,
+		); err != nil {
+			return nil, err
+		}
+		milestones = append(milestones, milestone)
+	}
+
+	return milestones, rows.Err()
+}
+
+// ListSubmittedBefore returns milestones waiting in review, on active
+// contracts or cancelled ones that kept them, that the scheduler still has
+// to act on: those submitted before noticeBefore whose parties have not
+// been warned, and those submitted before approveBefore whose parties were
+// warned before noticedBefore, which are due for approval
+func (r *MilestoneRepository) ListSubmittedBefore(ctx context.Context, noticeBefore, approveBefore, noticedBefore time.Time, limit int) ([]domain.Milestone, error) {
+	query := `
+		SELECT m.id, m.contract_id, m.title, m.description, m.amount_sol, m.due_date, m.sort_order,
+			   m.status, m.submission_text, m.submission_urls, m.submitted_at, m.approved_at,
+			   m.payment_id, m.paid_at, m.max_revisions, m.due_reminder_sent_at, m.overdue_at,
+			   m.auto_approve_notice_sent_at, m.created_at, m.updated_at, m.version
+		FROM milestones m
+		JOIN contracts c ON c.id = m.contract_id
+		WHERE c.status IN ('active', 'cancelled') AND m.status = 'submitted'
+		  AND ((m.auto_approve_notice_sent_at IS NULL AND m.submitted_at < $1)
+		    OR (m.submitted_at < $2 AND m.auto_approve_notice_sent_at < $3))
+		ORDER BY m.submitted_at ASC
+		LIMIT $4`
+
+	rows, err := r.db.Query(ctx, query, noticeBefore, approveBefore, noticedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var milestones []domain.Milestone
+	for rows.Next() {
+		var milestone domain.Milestone
+		if err := rows.Scan(
+			&milestone.ID, &milestone.ContractID, &milestone.Title, &milestone.Description,
+			&milestone.AmountSOL, &milestone.DueDate, &milestone.SortOrder, &milestone.Status,
+			&milestone.SubmissionText, &milestone.SubmissionURLs, &milestone.SubmittedAt,
+			&milestone.ApprovedAt, &milestone.PaymentID, &milestone.PaidAt,
+			&milestone.MaxRevisions, &milestone.DueReminderSentAt, &milestone.OverdueAt,
+			&milestone.AutoApproveNoticeSentAt, &milestone.CreatedAt, &milestone.UpdatedAt, &milestone.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -335,23 +433,28 @@ func (r *MilestoneRepository) Update(ctx context.Context, milestone *domain.Mile
 		UPDATE milestones SET
 			title = $2, description = $3, amount_sol = $4, due_date = $5,
 			sort_order = $6, status = $7, submission_text = $8, submission_urls = $9,
-			submitted_at = $10, approved_at = $11, payment_id = $12, paid_at = $13, updated_at = $14
-		WHERE id = $1`
+			submitted_at = $10, approved_at = $11, payment_id = $12, paid_at = $13, updated_at = $14,
+			max_revisions = $16, due_reminder_sent_at = $17, overdue_at = $18,
+			auto_approve_notice_sent_at = $19, version = version + 1
+		WHERE id = $1 AND version = $15`
 
 	milestone.UpdatedAt = time.Now()
 	result, err := r.db.Exec(ctx, query,
 		milestone.ID, milestone.Title, milestone.Description, milestone.AmountSOL,
 		milestone.DueDate, milestone.SortOrder, milestone.Status, milestone.SubmissionText,
 		milestone.SubmissionURLs, milestone.SubmittedAt, milestone.ApprovedAt,
-		milestone.PaymentID, milestone.PaidAt, milestone.UpdatedAt,
+		milestone.PaymentID, milestone.PaidAt, milestone.UpdatedAt, milestone.Version,
+		milestone.MaxRevisions, milestone.DueReminderSentAt, milestone.OverdueAt,
+		milestone.AutoApproveNoticeSentAt,
 	)
 
 	if err != nil {
 		return err
 	}
 	if result.RowsAffected() == 0 {
-		return apperrors.ErrNotFound
+		return staleOrMissing(ctx, r.db, "milestones", milestone.ID)
 	}
+	milestone.Version++
 	return nil
 }
 
@@ -367,13 +470,84 @@ func (r *MilestoneRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// MilestoneRevisionRepository implementation
+type MilestoneRevisionRepository struct {
+	db *database.Conn
+}
+
+func NewMilestoneRevisionRepository(db *pgxpool.Pool) *MilestoneRevisionRepository {
+	return &MilestoneRevisionRepository{db: database.NewConn(db)}
+}
+
+func (r *MilestoneRevisionRepository) Create(ctx context.Context, revision *domain.MilestoneRevision) error {
+	query := `
+		INSERT INTO milestone_revisions (
+			id, milestone_id, revision_number, action, requested_by, revision_notes,
+			previous_submission_text, previous_submission_urls, previous_submitted_at, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+		)`
+
+	revision.ID = uuid.New()
+	revision.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(ctx, query,
+		revision.ID, revision.MilestoneID, revision.RevisionNumber, revision.Action,
+		revision.RequestedBy, revision.RevisionNotes, revision.PreviousSubmissionText,
+		revision.PreviousSubmissionURLs, revision.PreviousSubmittedAt, revision.CreatedAt,
+	)
+
+	return err
+}
+
+// CountRequested returns how many revision rounds the milestone has had
+func (r *MilestoneRevisionRepository) CountRequested(ctx context.Context, milestoneID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM milestone_revisions WHERE milestone_id = $1 AND action = 'requested'`
+
+	var count int
+	err := r.db.QueryRow(ctx, query, milestoneID).Scan(&count)
+	return count, err
+}
+
+func (r *MilestoneRevisionRepository) ListByContract(ctx context.Context, contractID uuid.UUID) ([]domain.MilestoneRevision, error) {
+	query := `
+		SELECT mr.id, mr.milestone_id, mr.revision_number, mr.action, mr.requested_by,
+			   mr.revision_notes, mr.previous_submission_text, mr.previous_submission_urls,
+			   mr.previous_submitted_at, mr.created_at
+		FROM milestone_revisions mr
+		JOIN milestones m ON m.id = mr.milestone_id
+		WHERE m.contract_id = $1
+		ORDER BY mr.created_at ASC`
+
+	rows, err := r.db.Query(ctx, query, contractID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []domain.MilestoneRevision
+	for rows.Next() {
+		var revision domain.MilestoneRevision
+		if err := rows.Scan(
+			&revision.ID, &revision.MilestoneID, &revision.RevisionNumber, &revision.Action,
+			&revision.RequestedBy, &revision.RevisionNotes, &revision.PreviousSubmissionText,
+			&revision.PreviousSubmissionURLs, &revision.PreviousSubmittedAt, &revision.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
 // EscrowRepository implementation
 type EscrowRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewEscrowRepository(db *pgxpool.Pool) *EscrowRepository {
-	return &EscrowRepository{db: db}
+	return &EscrowRepository{db: database.NewConn(db)}
 }
 
 func (r *EscrowRepository) Create(ctx context.Context, escrow *domain.Escrow) error {
@@ -389,6 +563,7 @@ func (r *EscrowRepository) Create(ctx context.Context, escrow *domain.Escrow) er
 	escrow.ID = uuid.New()
 	escrow.CreatedAt = time.Now()
 	escrow.UpdatedAt = time.Now()
+	escrow.Version = 1
 	if escrow.Status == "" {
 		escrow.Status = domain.EscrowStatusCreated
 	}
@@ -407,7 +582,7 @@ func (r *EscrowRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.E
 	query := `
 		SELECT id, contract_id, escrow_pda, vault_address, client_wallet, freelancer_wallet,
 			   total_amount_sol, funded_amount_sol, released_amount_sol, refunded_amount_sol,
-			   status, init_tx_signature, created_at, updated_at
+			   status, init_tx_signature, created_at, updated_at, version
 		FROM escrows
 		WHERE id = $1`
 
@@ -416,7 +591,7 @@ func (r *EscrowRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.E
 		&escrow.ID, &escrow.ContractID, &escrow.EscrowPDA, &escrow.VaultAddress,
 		&escrow.ClientWallet, &escrow.FreelancerWallet, &escrow.TotalAmountSOL,
 		&escrow.FundedAmountSOL, &escrow.ReleasedAmountSOL, &escrow.RefundedAmountSOL,
-		&escrow.Status, &escrow.InitTxSignature, &escrow.CreatedAt, &escrow.UpdatedAt,
+		&escrow.Status, &escrow.InitTxSignature, &escrow.CreatedAt, &escrow.UpdatedAt, &escrow.Version,
 	)
 
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -429,7 +604,7 @@ func (r *EscrowRepository) GetByContractID(ctx context.Context, contractID uuid.
 	query := `
 		SELECT id, contract_id, escrow_pda, vault_address, client_wallet, freelancer_wallet,
 			   total_amount_sol, funded_amount_sol, released_amount_sol, refunded_amount_sol,
-			   status, init_tx_signature, created_at, updated_at
+			   status, init_tx_signature, created_at, updated_at, version
 		FROM escrows
 		WHERE contract_id = $1`
 
@@ -438,7 +613,7 @@ func (r *EscrowRepository) GetByContractID(ctx context.Context, contractID uuid.
 		&escrow.ID, &escrow.ContractID, &escrow.EscrowPDA, &escrow.VaultAddress,
 		&escrow.ClientWallet, &escrow.FreelancerWallet, &escrow.TotalAmountSOL,
 		&escrow.FundedAmountSOL, &escrow.ReleasedAmountSOL, &escrow.RefundedAmountSOL,
-		&escrow.Status, &escrow.InitTxSignature, &escrow.CreatedAt, &escrow.UpdatedAt,
+		&escrow.Status, &escrow.InitTxSignature, &escrow.CreatedAt, &escrow.UpdatedAt, &escrow.Version,
 	)
 
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -451,7 +626,7 @@ func (r *EscrowRepository) GetByPDA(ctx context.Context, pda string) (*domain.Es
 	query := `
 		SELECT id, contract_id, escrow_pda, vault_address, client_wallet, freelancer_wallet,
 			   total_amount_sol, funded_amount_sol, released_amount_sol, refunded_amount_sol,
-			   status, init_tx_signature, created_at, updated_at
+			   status, init_tx_signature, created_at, updated_at, version
 		FROM escrows
 		WHERE escrow_pda = $1`
 
@@ -460,7 +635,7 @@ func (r *EscrowRepository) GetByPDA(ctx context.Context, pda string) (*domain.Es
 		&escrow.ID, &escrow.ContractID, &escrow.EscrowPDA, &escrow.VaultAddress,
 		&escrow.ClientWallet, &escrow.FreelancerWallet, &escrow.TotalAmountSOL,
 		&escrow.FundedAmountSOL, &escrow.ReleasedAmountSOL, &escrow.RefundedAmountSOL,
-		&escrow.Status, &escrow.InitTxSignature, &escrow.CreatedAt, &escrow.UpdatedAt,
+		&escrow.Status, &escrow.InitTxSignature, &escrow.CreatedAt, &escrow.UpdatedAt, &escrow.Version,
 	)
 
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -474,23 +649,25 @@ func (r *EscrowRepository) Update(ctx context.Context, escrow *domain.Escrow) er
 		UPDATE escrows SET
 			escrow_pda = $2, vault_address = $3, client_wallet = $4, freelancer_wallet = $5,
 			total_amount_sol = $6, funded_amount_sol = $7, released_amount_sol = $8,
-			refunded_amount_sol = $9, status = $10, init_tx_signature = $11, updated_at = $12
-		WHERE id = $1`
+			refunded_amount_sol = $9, status = $10, init_tx_signature = $11, updated_at = $12,
+			version = version + 1
+		WHERE id = $1 AND version = $13`
 
 	escrow.UpdatedAt = time.Now()
 	result, err := r.db.Exec(ctx, query,
 		escrow.ID, escrow.EscrowPDA, escrow.VaultAddress, escrow.ClientWallet,
 		escrow.FreelancerWallet, escrow.TotalAmountSOL, escrow.FundedAmountSOL,
 		escrow.ReleasedAmountSOL, escrow.RefundedAmountSOL, escrow.Status,
-		escrow.InitTxSignature, escrow.UpdatedAt,
+		escrow.InitTxSignature, escrow.UpdatedAt, escrow.Version,
 	)
 
 	if err != nil {
 		return err
 	}
 	if result.RowsAffected() == 0 {
-		return apperrors.ErrNotFound
+		return staleOrMissing(ctx, r.db, "escrows", escrow.ID)
 	}
+	escrow.Version++
 	return nil
 }
 
@@ -500,7 +677,8 @@ func (r *EscrowRepository) CreateLog(ctx context.Context, log *domain.EscrowLog)
 			id, escrow_id, action, amount_sol, tx_signature, performed_by, notes, created_at
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8
-		)`
+		)
+		ON CONFLICT DO NOTHING`
 
 	log.ID = uuid.New()
 	log.CreatedAt = time.Now()
@@ -513,13 +691,46 @@ func (r *EscrowRepository) CreateLog(ctx context.Context, log *domain.EscrowLog)
 	return err
 }
 
+// ListUnclosed returns every escrow whose on-chain account is still open
+func (r *EscrowRepository) ListUnclosed(ctx context.Context) ([]domain.Escrow, error) {
+	query := `
+		SELECT id, contract_id, escrow_pda, vault_address, client_wallet, freelancer_wallet,
+			   total_amount_sol, funded_amount_sol, released_amount_sol, refunded_amount_sol,
+			   status, init_tx_signature, created_at, updated_at, version
+		FROM escrows
+		WHERE status <> 'closed'
+		ORDER BY created_at ASC`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var escrows []domain.Escrow
+	for rows.Next() {
+		var escrow domain.Escrow
+		if err := rows.Scan(
+			&escrow.ID, &escrow.ContractID, &escrow.EscrowPDA, &escrow.VaultAddress,
+			&escrow.ClientWallet, &escrow.FreelancerWallet, &escrow.TotalAmountSOL,
+			&escrow.FundedAmountSOL, &escrow.ReleasedAmountSOL, &escrow.RefundedAmountSOL,
+			&escrow.Status, &escrow.InitTxSignature, &escrow.CreatedAt, &escrow.UpdatedAt, &escrow.Version,
+		); err != nil {
+			return nil, err
+		}
+		escrows = append(escrows, escrow)
+	}
+
+	return escrows, rows.Err()
+}
+
 // PaymentRepository implementation
 type PaymentRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewPaymentRepository(db *pgxpool.Pool) *PaymentRepository {
-	return &PaymentRepository{db: db}
+	return &PaymentRepository{db: database.NewConn(db)}
 }
 
 func (r *PaymentRepository) Create(ctx context.Context, payment *domain.Payment) error {
@@ -608,7 +819,9 @@ func (r *PaymentRepository) GetByTxSignature(ctx context.Context, txSignature st
 			   amount_sol, platform_fee_sol, net_amount_sol, tx_signature, slot, block_time,
 			   status, initiated_at, confirmed_at
 		FROM payments
-		WHERE tx_signature = $1`
+		WHERE tx_signature = $1
+		ORDER BY initiated_at
+		LIMIT 1`
 
 	payment := &domain.Payment{}
 	err := r.db.QueryRow(ctx, query, txSignature).Scan(
@@ -624,15 +837,88 @@ func (r *PaymentRepository) GetByTxSignature(ctx context.Context, txSignature st
 	return payment, err
 }
 
+// ListByTxSignature returns every payment settled by one transaction; a
+// dispute settlement can carry both a payout and a refund
+func (r *PaymentRepository) ListByTxSignature(ctx context.Context, txSignature string) ([]domain.Payment, error) {
+	query := `
+		SELECT id, escrow_id, contract_id, milestone_id, payment_type, from_wallet, to_wallet,
+			   amount_sol, platform_fee_sol, net_amount_sol, tx_signature, slot, block_time,
+			   status, initiated_at, confirmed_at
+		FROM payments
+		WHERE tx_signature = $1
+		ORDER BY initiated_at`
+
+	rows, err := r.db.Query(ctx, query, txSignature)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []domain.Payment
+	for rows.Next() {
+		var payment domain.Payment
+		if err := rows.Scan(
+			&payment.ID, &payment.EscrowID, &payment.ContractID, &payment.MilestoneID,
+			&payment.PaymentType, &payment.FromWallet, &payment.ToWallet, &payment.AmountSOL,
+			&payment.PlatformFeeSOL, &payment.NetAmountSOL, &payment.TxSignature,
+			&payment.Slot, &payment.BlockTime, &payment.Status, &payment.InitiatedAt, &payment.ConfirmedAt,
+		); err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
+
+// ListPendingSubmitted returns pending payments that already carry a
+// transaction signature, oldest first
+func (r *PaymentRepository) ListPendingSubmitted(ctx context.Context, limit int) ([]domain.Payment, error) {
+	query := `
+		SELECT id, escrow_id, contract_id, milestone_id, payment_type, from_wallet, to_wallet,
+			   amount_sol, platform_fee_sol, net_amount_sol, tx_signature, slot, block_time,
+			   status, initiated_at, confirmed_at
+		FROM payments
+		WHERE status = 'pending' AND tx_signature IS NOT NULL
+		ORDER BY initiated_at ASC
+		LIMIT $1`
+
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []domain.Payment
+	for rows.Next() {
+		var payment domain.Payment
+		if err := rows.Scan(
+			&payment.ID, &payment.EscrowID, &payment.ContractID, &payment.MilestoneID,
+			&payment.PaymentType, &payment.FromWallet, &payment.ToWallet, &payment.AmountSOL,
+			&payment.PlatformFeeSOL, &payment.NetAmountSOL, &payment.TxSignature,
+			&payment.Slot, &payment.BlockTime, &payment.Status, &payment.InitiatedAt, &payment.ConfirmedAt,
+		); err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
+
 func (r *PaymentRepository) Update(ctx context.Context, payment *domain.Payment) error {
 	query := `
 		UPDATE payments SET
-			tx_signature = $2, slot = $3, block_time = $4, status = $5, confirmed_at = $6
+			tx_signature = $2, slot = $3, block_time = $4, status = $5, confirmed_at = $6,
+			escrow_id = $7, from_wallet = $8, to_wallet = $9, amount_sol = $10,
+			platform_fee_sol = $11, net_amount_sol = $12
 		WHERE id = $1`
 
 	result, err := r.db.Exec(ctx, query,
 		payment.ID, payment.TxSignature, payment.Slot, payment.BlockTime,
 		payment.Status, payment.ConfirmedAt,
+		payment.EscrowID, payment.FromWallet, payment.ToWallet, payment.AmountSOL,
+		payment.PlatformFeeSOL, payment.NetAmountSOL,
 	)
 
 	if err != nil {
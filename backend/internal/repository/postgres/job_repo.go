@@ -11,15 +11,16 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/trenchjob/backend/internal/domain"
+	"github.com/trenchjob/backend/internal/pkg/database"
 	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
 )
 
 type JobRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewJobRepository(db *pgxpool.Pool) *JobRepository {
-	return &JobRepository{db: db}
+	return &JobRepository{db: database.NewConn(db)}
 }
 
 func (r *JobRepository) Create(ctx context.Context, job *domain.Job) error {
@@ -307,11 +308,11 @@ func (r *JobRepository) GetSkills(ctx context.Context, jobID uuid.UUID) ([]domai
 
 // ProposalRepository implementation
 type ProposalRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewProposalRepository(db *pgxpool.Pool) *ProposalRepository {
-	return &ProposalRepository{db: db}
+	return &ProposalRepository{db: database.NewConn(db)}
 }
 
 func (r *ProposalRepository) Create(ctx context.Context, proposal *domain.Proposal) error {
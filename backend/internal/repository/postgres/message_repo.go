@@ -11,15 +11,16 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/trenchjob/backend/internal/domain"
+	"github.com/trenchjob/backend/internal/pkg/database"
 	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
 )
 
 type ConversationRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewConversationRepository(db *pgxpool.Pool) *ConversationRepository {
-	return &ConversationRepository{db: db}
+	return &ConversationRepository{db: database.NewConn(db)}
 }
 
 func (r *ConversationRepository) Create(ctx context.Context, conversation *domain.Conversation) error {
@@ -179,6 +180,32 @@ func (r *ConversationRepository) IsParticipant(ctx context.Context, conversation
 	return exists, err
 }
 
+// GetContactIDs returns everyone who shares a conversation with the user
+func (r *ConversationRepository) GetContactIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT DISTINCT other.user_id
+		FROM conversation_participants mine
+		JOIN conversation_participants other ON other.conversation_id = mine.conversation_id
+		WHERE mine.user_id = $1 AND other.user_id <> $1`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contactIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		contactIDs = append(contactIDs, id)
+	}
+
+	return contactIDs, rows.Err()
+}
+
 // Helper to find or create conversation for a contract
 func (r *ConversationRepository) FindOrCreateForContract(ctx context.Context, contractID, clientID, freelancerID uuid.UUID) (*domain.Conversation, error) {
 	// Try to find existing
@@ -281,20 +308,20 @@ func (r *ConversationRepository) Search(ctx context.Context, userID uuid.UUID, q
 
 // MessageRepository implementation
 type MessageRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewMessageRepository(db *pgxpool.Pool) *MessageRepository {
-	return &MessageRepository{db: db}
+	return &MessageRepository{db: database.NewConn(db)}
 }
 
 func (r *MessageRepository) Create(ctx context.Context, message *domain.Message) error {
 	query := `
 		INSERT INTO messages (
 			id, conversation_id, sender_id, message_text, message_type,
-			is_edited, edited_at, created_at
+			is_edited, edited_at, created_at, reply_to_message_id
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
 		)`
 
 	message.ID = uuid.New()
@@ -306,6 +333,7 @@ func (r *MessageRepository) Create(ctx context.Context, message *domain.Message)
 	_, err := r.db.Exec(ctx, query,
 		message.ID, message.ConversationID, message.SenderID, message.MessageText,
 		message.MessageType, message.IsEdited, message.EditedAt, message.CreatedAt,
+		message.ReplyToMessageID,
 	)
 
 	if err == nil {
@@ -320,7 +348,7 @@ func (r *MessageRepository) Create(ctx context.Context, message *domain.Message)
 func (r *MessageRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Message, error) {
 	query := `
 		SELECT m.id, m.conversation_id, m.sender_id, m.message_text, m.message_type,
-			   m.is_edited, m.edited_at, m.created_at,
+			   m.is_edited, m.edited_at, m.created_at, m.reply_to_message_id, m.deleted_at,
 			   u.username as sender_username
 		FROM messages m
 		JOIN users u ON m.sender_id = u.id
@@ -332,7 +360,7 @@ func (r *MessageRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&message.ID, &message.ConversationID, &message.SenderID, &message.MessageText,
 		&message.MessageType, &message.IsEdited, &message.EditedAt, &message.CreatedAt,
-		&senderUsername,
+		&message.ReplyToMessageID, &message.DeletedAt, &senderUsername,
 	)
 
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -351,7 +379,7 @@ func (r *MessageRepository) GetByConversationID(ctx context.Context, conversatio
 
 	query := `
 		SELECT m.id, m.conversation_id, m.sender_id, m.message_text, m.message_type,
-			   m.is_edited, m.edited_at, m.created_at,
+			   m.is_edited, m.edited_at, m.created_at, m.reply_to_message_id, m.deleted_at,
 			   u.username as sender_username
 		FROM messages m
 		JOIN users u ON m.sender_id = u.id
@@ -372,7 +400,7 @@ func (r *MessageRepository) GetByConversationID(ctx context.Context, conversatio
 		if err := rows.Scan(
 			&msg.ID, &msg.ConversationID, &msg.SenderID, &msg.MessageText,
 			&msg.MessageType, &msg.IsEdited, &msg.EditedAt, &msg.CreatedAt,
-			&senderUsername,
+			&msg.ReplyToMessageID, &msg.DeletedAt, &senderUsername,
 		); err != nil {
 			return nil, 0, err
 		}
@@ -386,7 +414,7 @@ func (r *MessageRepository) Update(ctx context.Context, message *domain.Message)
 	query := `
 		UPDATE messages SET
 			message_text = $2, is_edited = $3, edited_at = $4
-		WHERE id = $1`
+		WHERE id = $1 AND deleted_at IS NULL`
 
 	now := time.Now()
 	message.IsEdited = true
@@ -417,6 +445,72 @@ func (r *MessageRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// SoftDelete leaves a tombstone in place of the message, dropping its text,
+// attachments and edit history
+func (r *MessageRepository) SoftDelete(ctx context.Context, message *domain.Message) error {
+	query := `
+		WITH dropped_edits AS (
+			DELETE FROM message_edits WHERE message_id = $1
+		), dropped_attachments AS (
+			DELETE FROM message_attachments WHERE message_id = $1
+		)
+		UPDATE messages SET message_text = '', deleted_at = $2
+		WHERE id = $1 AND deleted_at IS NULL`
+
+	now := time.Now()
+	result, err := r.db.Exec(ctx, query, message.ID, now)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return apperrors.ErrNotFound
+	}
+
+	message.MessageText = ""
+	message.DeletedAt = &now
+	message.Attachments = nil
+	return nil
+}
+
+// CreateEdit records the text a message had before an edit
+func (r *MessageRepository) CreateEdit(ctx context.Context, edit *domain.MessageEdit) error {
+	query := `
+		INSERT INTO message_edits (id, message_id, previous_text, edited_at)
+		VALUES ($1, $2, $3, $4)`
+
+	edit.ID = uuid.New()
+	edit.EditedAt = time.Now()
+
+	_, err := r.db.Exec(ctx, query, edit.ID, edit.MessageID, edit.PreviousText, edit.EditedAt)
+	return err
+}
+
+// GetEdits returns a message's earlier versions, oldest first
+func (r *MessageRepository) GetEdits(ctx context.Context, messageID uuid.UUID) ([]domain.MessageEdit, error) {
+	query := `
+		SELECT id, message_id, previous_text, edited_at
+		FROM message_edits
+		WHERE message_id = $1
+		ORDER BY edited_at ASC`
+
+	rows, err := r.db.Query(ctx, query, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edits []domain.MessageEdit
+	for rows.Next() {
+		var edit domain.MessageEdit
+		if err := rows.Scan(&edit.ID, &edit.MessageID, &edit.PreviousText, &edit.EditedAt); err != nil {
+			return nil, err
+		}
+		edits = append(edits, edit)
+	}
+
+	return edits, rows.Err()
+}
+
 func (r *MessageRepository) GetUnreadCount(ctx context.Context, userID uuid.UUID) (int, error) {
 	// Count messages in conversations where user is participant
 	// and message was sent after user's last_read_at
@@ -426,6 +520,7 @@ func (r *MessageRepository) GetUnreadCount(ctx context.Context, userID uuid.UUID
 		JOIN conversation_participants cp ON m.conversation_id = cp.conversation_id
 		WHERE cp.user_id = $1
 		AND m.sender_id != $1
+		AND m.deleted_at IS NULL
 		AND (cp.last_read_at IS NULL OR m.created_at > cp.last_read_at)`
 
 	var count int
@@ -437,7 +532,7 @@ func (r *MessageRepository) GetUnreadCount(ctx context.Context, userID uuid.UUID
 func (r *MessageRepository) GetLastMessage(ctx context.Context, conversationID uuid.UUID) (*domain.Message, error) {
 	query := `
 		SELECT m.id, m.conversation_id, m.sender_id, m.message_text, m.message_type,
-			   m.is_edited, m.edited_at, m.created_at
+			   m.is_edited, m.edited_at, m.created_at, m.reply_to_message_id, m.deleted_at
 		FROM messages m
 		WHERE m.conversation_id = $1
 		ORDER BY m.created_at DESC
@@ -447,6 +542,7 @@ func (r *MessageRepository) GetLastMessage(ctx context.Context, conversationID u
 	err := r.db.QueryRow(ctx, query, conversationID).Scan(
 		&message.ID, &message.ConversationID, &message.SenderID, &message.MessageText,
 		&message.MessageType, &message.IsEdited, &message.EditedAt, &message.CreatedAt,
+		&message.ReplyToMessageID, &message.DeletedAt,
 	)
 
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -522,3 +618,19 @@ func (r *MessageRepository) GetByConversationIDWithAttachments(ctx context.Conte
 
 	return messages, total, nil
 }
+
+// PresenceRepository implementation
+type PresenceRepository struct {
+	db *database.Conn
+}
+
+func NewPresenceRepository(db *pgxpool.Pool) *PresenceRepository {
+	return &PresenceRepository{db: database.NewConn(db)}
+}
+
+// SetOnline records whether the user has a live connection; going offline
+// also stamps last_seen_at
+func (r *PresenceRepository) SetOnline(ctx context.Context, userID uuid.UUID, isOnline bool) error {
+	_, err := r.db.Exec(ctx, `SELECT update_user_presence($1, $2)`, userID, isOnline)
+	return err
+}
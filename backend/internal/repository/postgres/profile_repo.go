@@ -11,15 +11,16 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/trenchjob/backend/internal/domain"
+	"github.com/trenchjob/backend/internal/pkg/database"
 	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
 )
 
 type ProfileRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewProfileRepository(db *pgxpool.Pool) *ProfileRepository {
-	return &ProfileRepository{db: db}
+	return &ProfileRepository{db: database.NewConn(db)}
 }
 
 func (r *ProfileRepository) Create(ctx context.Context, profile *domain.Profile) error {
@@ -295,11 +296,11 @@ func (r *ProfileRepository) SetSkills(ctx context.Context, profileID uuid.UUID,
 
 // SkillRepository implementation
 type SkillRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewSkillRepository(db *pgxpool.Pool) *SkillRepository {
-	return &SkillRepository{db: db}
+	return &SkillRepository{db: database.NewConn(db)}
 }
 
 func (r *SkillRepository) GetAll(ctx context.Context) ([]domain.Skill, error) {
@@ -384,11 +385,11 @@ func (r *SkillRepository) Search(ctx context.Context, query string) ([]domain.Sk
 
 // PortfolioRepository implementation
 type PortfolioRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewPortfolioRepository(db *pgxpool.Pool) *PortfolioRepository {
-	return &PortfolioRepository{db: db}
+	return &PortfolioRepository{db: database.NewConn(db)}
 }
 
 func (r *PortfolioRepository) Create(ctx context.Context, item *domain.PortfolioItem) error {
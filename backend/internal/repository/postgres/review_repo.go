@@ -2,20 +2,25 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/trenchjob/backend/internal/domain"
+	"github.com/trenchjob/backend/internal/pkg/database"
+	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
 	"github.com/trenchjob/backend/internal/repository"
 )
 
 type ReviewRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewReviewRepository(db *pgxpool.Pool) repository.ReviewRepository {
-	return &ReviewRepository{db: db}
+	return &ReviewRepository{db: database.NewConn(db)}
 }
 
 func (r *ReviewRepository) Create(ctx context.Context, review *domain.Review) error {
@@ -186,11 +191,11 @@ func (r *ReviewRepository) Exists(ctx context.Context, contractID, reviewerID uu
 }
 
 type NotificationRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewNotificationRepository(db *pgxpool.Pool) repository.NotificationRepository {
-	return &NotificationRepository{db: db}
+	return &NotificationRepository{db: database.NewConn(db)}
 }
 
 func (r *NotificationRepository) Create(ctx context.Context, notification *domain.Notification) error {
@@ -310,11 +315,11 @@ func (r *NotificationRepository) GetUnreadCount(ctx context.Context, userID uuid
 }
 
 type DisputeRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
-func NewDisputeRepository(db *pgxpool.Pool) *DisputeRepository {
-	return &DisputeRepository{db: db}
+func NewDisputeRepository(db *pgxpool.Pool) repository.DisputeRepository {
+	return &DisputeRepository{db: database.NewConn(db)}
 }
 
 func (r *DisputeRepository) Create(ctx context.Context, dispute *domain.Dispute) error {
@@ -328,6 +333,7 @@ func (r *DisputeRepository) Create(ctx context.Context, dispute *domain.Dispute)
 
 	dispute.ID = uuid.New()
 	dispute.Status = domain.DisputeStatusOpen
+	dispute.Version = 1
 	dispute.CreatedAt = time.Now()
 
 	_, err := r.db.Exec(ctx, query,
@@ -349,7 +355,7 @@ func (r *DisputeRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.
 		SELECT id, contract_id, milestone_id, initiated_by, reason,
 			description, evidence_urls, status, resolved_by, resolution_type,
 			resolution_notes, client_refund_sol, freelancer_payment_sol,
-			created_at, resolved_at
+			version, created_at, resolved_at
 		FROM disputes
 		WHERE id = $1`
 
@@ -368,9 +374,13 @@ func (r *DisputeRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.
 		&dispute.ResolutionNotes,
 		&dispute.ClientRefundSOL,
 		&dispute.FreelancerPaymentSOL,
+		&dispute.Version,
 		&dispute.CreatedAt,
 		&dispute.ResolvedAt,
 	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperrors.ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -382,7 +392,7 @@ func (r *DisputeRepository) GetByContractID(ctx context.Context, contractID uuid
 		SELECT id, contract_id, milestone_id, initiated_by, reason,
 			description, evidence_urls, status, resolved_by, resolution_type,
 			resolution_notes, client_refund_sol, freelancer_payment_sol,
-			created_at, resolved_at
+			version, created_at, resolved_at
 		FROM disputes
 		WHERE contract_id = $1
 		ORDER BY created_at DESC`
@@ -410,6 +420,7 @@ func (r *DisputeRepository) GetByContractID(ctx context.Context, contractID uuid
 			&d.ResolutionNotes,
 			&d.ClientRefundSOL,
 			&d.FreelancerPaymentSOL,
+			&d.Version,
 			&d.CreatedAt,
 			&d.ResolvedAt,
 		)
@@ -421,6 +432,67 @@ func (r *DisputeRepository) GetByContractID(ctx context.Context, contractID uuid
 	return disputes, nil
 }
 
+// List returns disputes for the admin queue, oldest first. An empty status
+// matches every unresolved dispute (open, under review or escalated).
+func (r *DisputeRepository) List(ctx context.Context, status string, limit, offset int) ([]domain.Dispute, int, error) {
+	where := `WHERE status IN ('open', 'under_review', 'escalated')`
+	args := []interface{}{}
+	if status != "" {
+		where = `WHERE status = $1`
+		args = append(args, status)
+	}
+
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM disputes `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, contract_id, milestone_id, initiated_by, reason,
+			description, evidence_urls, status, resolved_by, resolution_type,
+			resolution_notes, client_refund_sol, freelancer_payment_sol,
+			version, created_at, resolved_at
+		FROM disputes
+		%s
+		ORDER BY created_at ASC
+		LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var disputes []domain.Dispute
+	for rows.Next() {
+		var d domain.Dispute
+		err := rows.Scan(
+			&d.ID,
+			&d.ContractID,
+			&d.MilestoneID,
+			&d.InitiatedBy,
+			&d.Reason,
+			&d.Description,
+			&d.EvidenceURLs,
+			&d.Status,
+			&d.ResolvedBy,
+			&d.ResolutionType,
+			&d.ResolutionNotes,
+			&d.ClientRefundSOL,
+			&d.FreelancerPaymentSOL,
+			&d.Version,
+			&d.CreatedAt,
+			&d.ResolvedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		disputes = append(disputes, d)
+	}
+	return disputes, total, rows.Err()
+}
+
 func (r *DisputeRepository) Update(ctx context.Context, dispute *domain.Dispute) error {
 	query := `
 		UPDATE disputes SET
@@ -430,10 +502,12 @@ func (r *DisputeRepository) Update(ctx context.Context, dispute *domain.Dispute)
 			resolution_notes = $4,
 			client_refund_sol = $5,
 			freelancer_payment_sol = $6,
-			resolved_at = $7
-		WHERE id = $8`
+			resolved_at = $7,
+			evidence_urls = $8,
+			version = version + 1
+		WHERE id = $9 AND version = $10`
 
-	_, err := r.db.Exec(ctx, query,
+	result, err := r.db.Exec(ctx, query,
 		dispute.Status,
 		dispute.ResolvedBy,
 		dispute.ResolutionType,
@@ -441,7 +515,16 @@ func (r *DisputeRepository) Update(ctx context.Context, dispute *domain.Dispute)
 		dispute.ClientRefundSOL,
 		dispute.FreelancerPaymentSOL,
 		dispute.ResolvedAt,
+		dispute.EvidenceURLs,
 		dispute.ID,
+		dispute.Version,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return staleOrMissing(ctx, r.db, "disputes", dispute.ID)
+	}
+	dispute.Version++
+	return nil
 }
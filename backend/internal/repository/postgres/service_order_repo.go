@@ -11,15 +11,16 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/trenchjob/backend/internal/domain"
+	"github.com/trenchjob/backend/internal/pkg/database"
 	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
 )
 
 type ServiceOrderRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewServiceOrderRepository(db *pgxpool.Pool) *ServiceOrderRepository {
-	return &ServiceOrderRepository{db: db}
+	return &ServiceOrderRepository{db: database.NewConn(db)}
 }
 
 func (r *ServiceOrderRepository) Create(ctx context.Context, order *domain.ServiceOrder) error {
@@ -38,6 +39,7 @@ func (r *ServiceOrderRepository) Create(ctx context.Context, order *domain.Servi
 	order.ID = uuid.New()
 	order.CreatedAt = time.Now()
 	order.UpdatedAt = time.Now()
+	order.Version = 1
 	if order.Status == "" {
 		order.Status = domain.ServiceOrderStatusPending
 	}
@@ -61,7 +63,7 @@ func (r *ServiceOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*do
 			   o.requirements, o.status,
 			   o.started_at, o.expected_delivery_at, o.delivered_at, o.completed_at,
 			   o.escrow_account_address, o.escrow_funded,
-			   o.created_at, o.updated_at,
+			   o.created_at, o.updated_at, o.version,
 			   s.id as service_id, s.title as service_title, s.thumbnail_url,
 			   cu.id as client_user_id, cu.username as client_username,
 			   fu.id as freelancer_user_id, fu.username as freelancer_username,
@@ -87,7 +89,7 @@ func (r *ServiceOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*do
 		&order.Requirements, &order.Status,
 		&order.StartedAt, &order.ExpectedDeliveryAt, &order.DeliveredAt, &order.CompletedAt,
 		&order.EscrowAccountAddress, &order.EscrowFunded,
-		&order.CreatedAt, &order.UpdatedAt,
+		&order.CreatedAt, &order.UpdatedAt, &order.Version,
 		&service.ID, &service.Title, &service.ThumbnailURL,
 		&client.ID, &client.Username,
 		&freelancer.ID, &freelancer.Username,
@@ -159,7 +161,7 @@ func (r *ServiceOrderRepository) GetByClientID(ctx context.Context, clientID uui
 			   o.requirements, o.status,
 			   o.started_at, o.expected_delivery_at, o.delivered_at, o.completed_at,
 			   o.escrow_account_address, o.escrow_funded,
-			   o.created_at, o.updated_at,
+			   o.created_at, o.updated_at, o.version,
 			   s.title as service_title, s.thumbnail_url,
 			   fu.username as freelancer_username,
 			   fp.display_name as freelancer_display_name, fp.avatar_url as freelancer_avatar
@@ -191,7 +193,7 @@ func (r *ServiceOrderRepository) GetByClientID(ctx context.Context, clientID uui
 			&order.Requirements, &order.Status,
 			&order.StartedAt, &order.ExpectedDeliveryAt, &order.DeliveredAt, &order.CompletedAt,
 			&order.EscrowAccountAddress, &order.EscrowFunded,
-			&order.CreatedAt, &order.UpdatedAt,
+			&order.CreatedAt, &order.UpdatedAt, &order.Version,
 			&serviceTitle, &thumbnailURL,
 			&freelancerUsername,
 			&freelancerDisplayName, &freelancerAvatar,
@@ -255,7 +257,7 @@ func (r *ServiceOrderRepository) GetByFreelancerID(ctx context.Context, freelanc
 			   o.requirements, o.status,
 			   o.started_at, o.expected_delivery_at, o.delivered_at, o.completed_at,
 			   o.escrow_account_address, o.escrow_funded,
-			   o.created_at, o.updated_at,
+			   o.created_at, o.updated_at, o.version,
 			   s.title as service_title, s.thumbnail_url,
 			   cu.username as client_username,
 			   cp.display_name as client_display_name, cp.avatar_url as client_avatar
@@ -287,7 +289,7 @@ func (r *ServiceOrderRepository) GetByFreelancerID(ctx context.Context, freelanc
 			&order.Requirements, &order.Status,
 			&order.StartedAt, &order.ExpectedDeliveryAt, &order.DeliveredAt, &order.CompletedAt,
 			&order.EscrowAccountAddress, &order.EscrowFunded,
-			&order.CreatedAt, &order.UpdatedAt,
+			&order.CreatedAt, &order.UpdatedAt, &order.Version,
 			&serviceTitle, &thumbnailURL,
 			&clientUsername,
 			&clientDisplayName, &clientAvatar,
@@ -335,7 +337,7 @@ func (r *ServiceOrderRepository) GetByServiceID(ctx context.Context, serviceID u
 			   o.requirements, o.status,
 			   o.started_at, o.expected_delivery_at, o.delivered_at, o.completed_at,
 			   o.escrow_account_address, o.escrow_funded,
-			   o.created_at, o.updated_at
+			   o.created_at, o.updated_at, o.version
 		FROM service_orders o
 		WHERE o.service_id = $1
 		ORDER BY o.created_at DESC
@@ -356,7 +358,7 @@ func (r *ServiceOrderRepository) GetByServiceID(ctx context.Context, serviceID u
 			&order.Requirements, &order.Status,
 			&order.StartedAt, &order.ExpectedDeliveryAt, &order.DeliveredAt, &order.CompletedAt,
 			&order.EscrowAccountAddress, &order.EscrowFunded,
-			&order.CreatedAt, &order.UpdatedAt,
+			&order.CreatedAt, &order.UpdatedAt, &order.Version,
 		); err != nil {
 			return nil, 0, err
 		}
@@ -372,23 +374,24 @@ func (r *ServiceOrderRepository) Update(ctx context.Context, order *domain.Servi
 			status = $2, revisions_used = $3,
 			started_at = $4, expected_delivery_at = $5, delivered_at = $6, completed_at = $7,
 			escrow_account_address = $8, escrow_funded = $9,
-			updated_at = $10
-		WHERE id = $1`
+			updated_at = $10, version = version + 1
+		WHERE id = $1 AND version = $11`
 
 	order.UpdatedAt = time.Now()
 	result, err := r.db.Exec(ctx, query,
 		order.ID, order.Status, order.RevisionsUsed,
 		order.StartedAt, order.ExpectedDeliveryAt, order.DeliveredAt, order.CompletedAt,
 		order.EscrowAccountAddress, order.EscrowFunded,
-		order.UpdatedAt,
+		order.UpdatedAt, order.Version,
 	)
 
 	if err != nil {
 		return err
 	}
 	if result.RowsAffected() == 0 {
-		return apperrors.ErrNotFound
+		return staleOrMissing(ctx, r.db, "service_orders", order.ID)
 	}
+	order.Version++
 	return nil
 }
 
@@ -11,15 +11,16 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/trenchjob/backend/internal/domain"
+	"github.com/trenchjob/backend/internal/pkg/database"
 	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
 )
 
 type ServiceRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewServiceRepository(db *pgxpool.Pool) *ServiceRepository {
-	return &ServiceRepository{db: db}
+	return &ServiceRepository{db: database.NewConn(db)}
 }
 
 func (r *ServiceRepository) Create(ctx context.Context, service *domain.Service) error {
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/trenchjob/backend/internal/domain"
+	"github.com/trenchjob/backend/internal/pkg/database"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+type PlatformSettingsRepository struct {
+	db *database.Conn
+}
+
+func NewPlatformSettingsRepository(db *pgxpool.Pool) repository.PlatformSettingsRepository {
+	return &PlatformSettingsRepository{db: database.NewConn(db)}
+}
+
+func (r *PlatformSettingsRepository) List(ctx context.Context) ([]domain.PlatformSetting, error) {
+	query := `
+		SELECT key, value, description, updated_at
+		FROM platform_settings
+		ORDER BY key`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var settings []domain.PlatformSetting
+	for rows.Next() {
+		var s domain.PlatformSetting
+		if err := rows.Scan(&s.Key, &s.Value, &s.Description, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		settings = append(settings, s)
+	}
+
+	return settings, rows.Err()
+}
+
+// ListFeeOverrides returns every override that has not ended by since,
+// including ones that have yet to start
+func (r *PlatformSettingsRepository) ListFeeOverrides(ctx context.Context, since time.Time) ([]domain.FeeOverride, error) {
+	query := `
+		SELECT id, category_id, user_id, fee_percentage, starts_at, ends_at, description, created_at
+		FROM fee_overrides
+		WHERE ends_at IS NULL OR ends_at > $1
+		ORDER BY created_at ASC`
+
+	rows, err := r.db.Query(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var overrides []domain.FeeOverride
+	for rows.Next() {
+		var o domain.FeeOverride
+		if err := rows.Scan(
+			&o.ID, &o.CategoryID, &o.UserID, &o.FeePercentage,
+			&o.StartsAt, &o.EndsAt, &o.Description, &o.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+
+	return overrides, rows.Err()
+}
@@ -6,15 +6,16 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/trenchjob/backend/internal/domain"
+	"github.com/trenchjob/backend/internal/pkg/database"
 )
 
 // SocialRepository implements repository.SocialRepository
 type SocialRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewSocialRepository(db *pgxpool.Pool) *SocialRepository {
-	return &SocialRepository{db: db}
+	return &SocialRepository{db: database.NewConn(db)}
 }
 
 func (r *SocialRepository) GetByProfileID(ctx context.Context, profileID uuid.UUID) ([]domain.ProfileSocial, error) {
@@ -0,0 +1,325 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/trenchjob/backend/internal/domain"
+	"github.com/trenchjob/backend/internal/pkg/database"
+	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+type TimeEntryRepository struct {
+	db *database.Conn
+}
+
+func NewTimeEntryRepository(db *pgxpool.Pool) repository.TimeEntryRepository {
+	return &TimeEntryRepository{db: database.NewConn(db)}
+}
+
+func (r *TimeEntryRepository) Create(ctx context.Context, entry *domain.TimeEntry) error {
+	query := `
+		INSERT INTO time_entries (
+			id, contract_id, freelancer_id, week_start, started_at, ended_at,
+			minutes, description, source, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+		)`
+
+	entry.ID = uuid.New()
+	entry.CreatedAt = time.Now()
+	entry.UpdatedAt = time.Now()
+	if entry.Source == "" {
+		entry.Source = domain.TimeEntrySourceManual
+	}
+
+	_, err := r.db.Exec(ctx, query,
+		entry.ID, entry.ContractID, entry.FreelancerID, entry.WeekStart, entry.StartedAt,
+		entry.EndedAt, entry.Minutes, entry.Description, entry.Source,
+		entry.CreatedAt, entry.UpdatedAt,
+	)
+
+	return err
+}
+
+func (r *TimeEntryRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.TimeEntry, error) {
+	query := `
+		SELECT id, contract_id, freelancer_id, week_start, started_at, ended_at,
+			   minutes, description, source, created_at, updated_at
+		FROM time_entries
+		WHERE id = $1`
+
+	entry := &domain.TimeEntry{}
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&entry.ID, &entry.ContractID, &entry.FreelancerID, &entry.WeekStart,
+		&entry.StartedAt, &entry.EndedAt, &entry.Minutes, &entry.Description,
+		&entry.Source, &entry.CreatedAt, &entry.UpdatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperrors.ErrNotFound
+	}
+	return entry, err
+}
+
+// GetRunning returns the contract's timer that has not been stopped
+func (r *TimeEntryRepository) GetRunning(ctx context.Context, contractID uuid.UUID) (*domain.TimeEntry, error) {
+	query := `
+		SELECT id, contract_id, freelancer_id, week_start, started_at, ended_at,
+			   minutes, description, source, created_at, updated_at
+		FROM time_entries
+		WHERE contract_id = $1 AND ended_at IS NULL`
+
+	entry := &domain.TimeEntry{}
+	err := r.db.QueryRow(ctx, query, contractID).Scan(
+		&entry.ID, &entry.ContractID, &entry.FreelancerID, &entry.WeekStart,
+		&entry.StartedAt, &entry.EndedAt, &entry.Minutes, &entry.Description,
+		&entry.Source, &entry.CreatedAt, &entry.UpdatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperrors.ErrNotFound
+	}
+	return entry, err
+}
+
+func (r *TimeEntryRepository) ListByWeek(ctx context.Context, contractID uuid.UUID, weekStart time.Time) ([]domain.TimeEntry, error) {
+	query := `
+		SELECT id, contract_id, freelancer_id, week_start, started_at, ended_at,
+			   minutes, description, source, created_at, updated_at
+		FROM time_entries
+		WHERE contract_id = $1 AND week_start = $2
+		ORDER BY started_at ASC`
+
+	rows, err := r.db.Query(ctx, query, contractID, weekStart)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.TimeEntry
+	for rows.Next() {
+		var entry domain.TimeEntry
+		if err := rows.Scan(
+			&entry.ID, &entry.ContractID, &entry.FreelancerID, &entry.WeekStart,
+			&entry.StartedAt, &entry.EndedAt, &entry.Minutes, &entry.Description,
+			&entry.Source, &entry.CreatedAt, &entry.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// SumMinutes totals the minutes logged on a contract, within one week when
+// weekStart is set and across the whole contract otherwise
+func (r *TimeEntryRepository) SumMinutes(ctx context.Context, contractID uuid.UUID, weekStart *time.Time) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(minutes), 0)
+		FROM time_entries
+		WHERE contract_id = $1 AND ($2::date IS NULL OR week_start = $2)`
+
+	var minutes int
+	err := r.db.QueryRow(ctx, query, contractID, weekStart).Scan(&minutes)
+	return minutes, err
+}
+
+// HasOverlap reports whether the freelancer already has time on the
+// contract between start and end. A running timer covers everything from
+// its start onwards.
+func (r *TimeEntryRepository) HasOverlap(ctx context.Context, contractID, freelancerID uuid.UUID, start, end time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM time_entries
+			WHERE contract_id = $1 AND freelancer_id = $2
+			  AND started_at < $4 AND (ended_at IS NULL OR ended_at > $3)
+		)`
+
+	var overlaps bool
+	err := r.db.QueryRow(ctx, query, contractID, freelancerID, start, end).Scan(&overlaps)
+	return overlaps, err
+}
+
+func (r *TimeEntryRepository) Update(ctx context.Context, entry *domain.TimeEntry) error {
+	query := `
+		UPDATE time_entries SET
+			ended_at = $2, minutes = $3, description = $4, updated_at = $5
+		WHERE id = $1`
+
+	entry.UpdatedAt = time.Now()
+	result, err := r.db.Exec(ctx, query, entry.ID, entry.EndedAt, entry.Minutes, entry.Description, entry.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return apperrors.ErrNotFound
+	}
+	return nil
+}
+
+func (r *TimeEntryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM time_entries WHERE id = $1`
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return apperrors.ErrNotFound
+	}
+	return nil
+}
+
+// LockContract holds the contract row until the surrounding transaction
+// ends, so concurrent writes cannot both fit under the same hour limit
+func (r *TimeEntryRepository) LockContract(ctx context.Context, contractID uuid.UUID) error {
+	var id uuid.UUID
+	err := r.db.QueryRow(ctx, `SELECT id FROM contracts WHERE id = $1 FOR UPDATE`, contractID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return apperrors.ErrNotFound
+	}
+	return err
+}
+
+type TimesheetRepository struct {
+	db *database.Conn
+}
+
+func NewTimesheetRepository(db *pgxpool.Pool) repository.TimesheetRepository {
+	return &TimesheetRepository{db: database.NewConn(db)}
+}
+
+func (r *TimesheetRepository) Create(ctx context.Context, timesheet *domain.Timesheet) error {
+	query := `
+		INSERT INTO timesheets (
+			id, contract_id, week_start, total_minutes, hourly_rate_sol, amount_sol,
+			status, submitted_at, reviewed_at, dispute_reason, milestone_id,
+			version, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+		)`
+
+	timesheet.ID = uuid.New()
+	timesheet.CreatedAt = time.Now()
+	timesheet.UpdatedAt = time.Now()
+	timesheet.Version = 1
+	if timesheet.Status == "" {
+		timesheet.Status = domain.TimesheetStatusSubmitted
+	}
+
+	_, err := r.db.Exec(ctx, query,
+		timesheet.ID, timesheet.ContractID, timesheet.WeekStart, timesheet.TotalMinutes,
+		timesheet.HourlyRateSOL, timesheet.AmountSOL, timesheet.Status, timesheet.SubmittedAt,
+		timesheet.ReviewedAt, timesheet.DisputeReason, timesheet.MilestoneID,
+		timesheet.Version, timesheet.CreatedAt, timesheet.UpdatedAt,
+	)
+
+	return err
+}
+
+func (r *TimesheetRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Timesheet, error) {
+	query := `
+		SELECT id, contract_id, week_start, total_minutes, hourly_rate_sol, amount_sol,
+			   status, submitted_at, reviewed_at, dispute_reason, milestone_id,
+			   version, created_at, updated_at
+		FROM timesheets
+		WHERE id = $1`
+
+	timesheet := &domain.Timesheet{}
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&timesheet.ID, &timesheet.ContractID, &timesheet.WeekStart, &timesheet.TotalMinutes,
+		&timesheet.HourlyRateSOL, &timesheet.AmountSOL, &timesheet.Status, &timesheet.SubmittedAt,
+		&timesheet.ReviewedAt, &timesheet.DisputeReason, &timesheet.MilestoneID,
+		&timesheet.Version, &timesheet.CreatedAt, &timesheet.UpdatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperrors.ErrNotFound
+	}
+	return timesheet, err
+}
+
+func (r *TimesheetRepository) GetByWeek(ctx context.Context, contractID uuid.UUID, weekStart time.Time) (*domain.Timesheet, error) {
+	query := `
+		SELECT id, contract_id, week_start, total_minutes, hourly_rate_sol, amount_sol,
+			   status, submitted_at, reviewed_at, dispute_reason, milestone_id,
+			   version, created_at, updated_at
+		FROM timesheets
+		WHERE contract_id = $1 AND week_start = $2`
+
+	timesheet := &domain.Timesheet{}
+	err := r.db.QueryRow(ctx, query, contractID, weekStart).Scan(
+		&timesheet.ID, &timesheet.ContractID, &timesheet.WeekStart, &timesheet.TotalMinutes,
+		&timesheet.HourlyRateSOL, &timesheet.AmountSOL, &timesheet.Status, &timesheet.SubmittedAt,
+		&timesheet.ReviewedAt, &timesheet.DisputeReason, &timesheet.MilestoneID,
+		&timesheet.Version, &timesheet.CreatedAt, &timesheet.UpdatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperrors.ErrNotFound
+	}
+	return timesheet, err
+}
+
+func (r *TimesheetRepository) ListByContract(ctx context.Context, contractID uuid.UUID) ([]domain.Timesheet, error) {
+	query := `
+		SELECT id, contract_id, week_start, total_minutes, hourly_rate_sol, amount_sol,
+			   status, submitted_at, reviewed_at, dispute_reason, milestone_id,
+			   version, created_at, updated_at
+		FROM timesheets
+		WHERE contract_id = $1
+		ORDER BY week_start DESC`
+
+	rows, err := r.db.Query(ctx, query, contractID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var timesheets []domain.Timesheet
+	for rows.Next() {
+		var timesheet domain.Timesheet
+		if err := rows.Scan(
+			&timesheet.ID, &timesheet.ContractID, &timesheet.WeekStart, &timesheet.TotalMinutes,
+			&timesheet.HourlyRateSOL, &timesheet.AmountSOL, &timesheet.Status, &timesheet.SubmittedAt,
+			&timesheet.ReviewedAt, &timesheet.DisputeReason, &timesheet.MilestoneID,
+			&timesheet.Version, &timesheet.CreatedAt, &timesheet.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		timesheets = append(timesheets, timesheet)
+	}
+
+	return timesheets, rows.Err()
+}
+
+func (r *TimesheetRepository) Update(ctx context.Context, timesheet *domain.Timesheet) error {
+	query := `
+		UPDATE timesheets SET
+			total_minutes = $2, hourly_rate_sol = $3, amount_sol = $4, status = $5,
+			submitted_at = $6, reviewed_at = $7, dispute_reason = $8, milestone_id = $9,
+			updated_at = $10, version = version + 1
+		WHERE id = $1 AND version = $11`
+
+	timesheet.UpdatedAt = time.Now()
+	result, err := r.db.Exec(ctx, query,
+		timesheet.ID, timesheet.TotalMinutes, timesheet.HourlyRateSOL, timesheet.AmountSOL,
+		timesheet.Status, timesheet.SubmittedAt, timesheet.ReviewedAt, timesheet.DisputeReason,
+		timesheet.MilestoneID, timesheet.UpdatedAt, timesheet.Version,
+	)
+
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return staleOrMissing(ctx, r.db, "timesheets", timesheet.ID)
+	}
+	timesheet.Version++
+	return nil
+}
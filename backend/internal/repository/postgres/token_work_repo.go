@@ -6,16 +6,17 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/trenchjob/backend/internal/domain"
+	"github.com/trenchjob/backend/internal/pkg/database"
 	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
 )
 
 // TokenWorkRepository implements repository.TokenWorkRepository
 type TokenWorkRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewTokenWorkRepository(db *pgxpool.Pool) *TokenWorkRepository {
-	return &TokenWorkRepository{db: db}
+	return &TokenWorkRepository{db: database.NewConn(db)}
 }
 
 func (r *TokenWorkRepository) Create(ctx context.Context, item *domain.TokenWorkItem) error {
@@ -3,21 +3,24 @@ package postgres
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/trenchjob/backend/internal/domain"
+	"github.com/trenchjob/backend/internal/pkg/database"
 	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
 )
 
 type UserRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewUserRepository(db *pgxpool.Pool) *UserRepository {
-	return &UserRepository{db: db}
+	return &UserRepository{db: database.NewConn(db)}
 }
 
 func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
@@ -51,14 +54,14 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Use
 	query := `
 		SELECT id, email, username, password_hash, primary_wallet_address,
 			   is_client, is_freelancer, email_verified, wallet_verified,
-			   account_status, created_at, updated_at, last_login_at
+			   account_status, is_admin, created_at, updated_at, last_login_at
 		FROM users WHERE id = $1`
 
 	user := &domain.User{}
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&user.ID, &user.Email, &user.Username, &user.PasswordHash,
 		&user.PrimaryWalletAddress, &user.IsClient, &user.IsFreelancer,
-		&user.EmailVerified, &user.WalletVerified, &user.AccountStatus,
+		&user.EmailVerified, &user.WalletVerified, &user.AccountStatus, &user.IsAdmin,
 		&user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
 	)
 
@@ -72,14 +75,14 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.
 	query := `
 		SELECT id, email, username, password_hash, primary_wallet_address,
 			   is_client, is_freelancer, email_verified, wallet_verified,
-			   account_status, created_at, updated_at, last_login_at
+			   account_status, is_admin, created_at, updated_at, last_login_at
 		FROM users WHERE LOWER(email) = LOWER($1)`
 
 	user := &domain.User{}
 	err := r.db.QueryRow(ctx, query, email).Scan(
 		&user.ID, &user.Email, &user.Username, &user.PasswordHash,
 		&user.PrimaryWalletAddress, &user.IsClient, &user.IsFreelancer,
-		&user.EmailVerified, &user.WalletVerified, &user.AccountStatus,
+		&user.EmailVerified, &user.WalletVerified, &user.AccountStatus, &user.IsAdmin,
 		&user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
 	)
 
@@ -93,14 +96,14 @@ func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*d
 	query := `
 		SELECT id, email, username, password_hash, primary_wallet_address,
 			   is_client, is_freelancer, email_verified, wallet_verified,
-			   account_status, created_at, updated_at, last_login_at
+			   account_status, is_admin, created_at, updated_at, last_login_at
 		FROM users WHERE LOWER(username) = LOWER($1)`
 
 	user := &domain.User{}
 	err := r.db.QueryRow(ctx, query, username).Scan(
 		&user.ID, &user.Email, &user.Username, &user.PasswordHash,
 		&user.PrimaryWalletAddress, &user.IsClient, &user.IsFreelancer,
-		&user.EmailVerified, &user.WalletVerified, &user.AccountStatus,
+		&user.EmailVerified, &user.WalletVerified, &user.AccountStatus, &user.IsAdmin,
 		&user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
 	)
 
@@ -114,17 +117,18 @@ func (r *UserRepository) GetByWalletAddress(ctx context.Context, walletAddress s
 	query := `
 		SELECT u.id, u.email, u.username, u.password_hash, u.primary_wallet_address,
 			   u.is_client, u.is_freelancer, u.email_verified, u.wallet_verified,
-			   u.account_status, u.created_at, u.updated_at, u.last_login_at
+			   u.account_status, u.is_admin, u.created_at, u.updated_at, u.last_login_at
 		FROM users u
 		LEFT JOIN user_wallets w ON u.id = w.user_id
-		WHERE u.primary_wallet_address = $1 OR w.wallet_address = $1
+		WHERE (u.primary_wallet_address = $1 AND u.wallet_verified)
+		   OR (w.wallet_address = $1 AND w.verified_at IS NOT NULL)
 		LIMIT 1`
 
 	user := &domain.User{}
 	err := r.db.QueryRow(ctx, query, walletAddress).Scan(
 		&user.ID, &user.Email, &user.Username, &user.PasswordHash,
 		&user.PrimaryWalletAddress, &user.IsClient, &user.IsFreelancer,
-		&user.EmailVerified, &user.WalletVerified, &user.AccountStatus,
+		&user.EmailVerified, &user.WalletVerified, &user.AccountStatus, &user.IsAdmin,
 		&user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
 	)
 
@@ -160,6 +164,69 @@ func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
 	return nil
 }
 
+// Search finds users by email, username or wallet address for moderation.
+// An empty status matches every account status.
+func (r *UserRepository) Search(ctx context.Context, query, status string, limit, offset int) ([]domain.User, int, error) {
+	var conditions []string
+	var args []interface{}
+	argNum := 1
+
+	if query != "" {
+		conditions = append(conditions, fmt.Sprintf(`(
+			email ILIKE $%d OR
+			username ILIKE $%d OR
+			primary_wallet_address = $%d
+		)`, argNum, argNum, argNum+1))
+		args = append(args, "%"+query+"%", query)
+		argNum += 2
+	}
+
+	if status != "" {
+		conditions = append(conditions, fmt.Sprintf("account_status = $%d", argNum))
+		args = append(args, status)
+		argNum++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM users`+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	fullQuery := `
+		SELECT id, email, username, password_hash, primary_wallet_address,
+			   is_client, is_freelancer, email_verified, wallet_verified,
+			   account_status, is_admin, created_at, updated_at, last_login_at
+		FROM users` + whereClause + fmt.Sprintf(` ORDER BY created_at DESC LIMIT $%d OFFSET $%d`, argNum, argNum+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(ctx, fullQuery, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		var user domain.User
+		if err := rows.Scan(
+			&user.ID, &user.Email, &user.Username, &user.PasswordHash,
+			&user.PrimaryWalletAddress, &user.IsClient, &user.IsFreelancer,
+			&user.EmailVerified, &user.WalletVerified, &user.AccountStatus, &user.IsAdmin,
+			&user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+
+	return users, total, rows.Err()
+}
+
 func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM users WHERE id = $1`
 	result, err := r.db.Exec(ctx, query, id)
@@ -188,11 +255,11 @@ func (r *UserRepository) UsernameExists(ctx context.Context, username string) (b
 
 // WalletRepository implementation
 type WalletRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewWalletRepository(db *pgxpool.Pool) *WalletRepository {
-	return &WalletRepository{db: db}
+	return &WalletRepository{db: database.NewConn(db)}
 }
 
 func (r *WalletRepository) Create(ctx context.Context, wallet *domain.UserWallet) error {
@@ -210,10 +277,14 @@ func (r *WalletRepository) Create(ctx context.Context, wallet *domain.UserWallet
 	return err
 }
 
+// GetByAddress returns the wallet row for an address. Several accounts may
+// hold unverified claims on the same address, so a verified row wins.
 func (r *WalletRepository) GetByAddress(ctx context.Context, address string) (*domain.UserWallet, error) {
 	query := `
 		SELECT id, user_id, wallet_address, wallet_type, is_primary, verified_at, created_at
-		FROM user_wallets WHERE wallet_address = $1`
+		FROM user_wallets WHERE wallet_address = $1
+		ORDER BY verified_at IS NULL, created_at
+		LIMIT 1`
 
 	wallet := &domain.UserWallet{}
 	err := r.db.QueryRow(ctx, query, address).Scan(
@@ -227,6 +298,23 @@ func (r *WalletRepository) GetByAddress(ctx context.Context, address string) (*d
 	return wallet, err
 }
 
+func (r *WalletRepository) GetByUserIDAndAddress(ctx context.Context, userID uuid.UUID, address string) (*domain.UserWallet, error) {
+	query := `
+		SELECT id, user_id, wallet_address, wallet_type, is_primary, verified_at, created_at
+		FROM user_wallets WHERE user_id = $1 AND wallet_address = $2`
+
+	wallet := &domain.UserWallet{}
+	err := r.db.QueryRow(ctx, query, userID, address).Scan(
+		&wallet.ID, &wallet.UserID, &wallet.WalletAddress, &wallet.WalletType,
+		&wallet.IsPrimary, &wallet.VerifiedAt, &wallet.CreatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperrors.ErrNotFound
+	}
+	return wallet, err
+}
+
 func (r *WalletRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]domain.UserWallet, error) {
 	query := `
 		SELECT id, user_id, wallet_address, wallet_type, is_primary, verified_at, created_at
@@ -252,6 +340,20 @@ func (r *WalletRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([
 	return wallets, rows.Err()
 }
 
+func (r *WalletRepository) MarkVerified(ctx context.Context, id uuid.UUID, verifiedAt time.Time) error {
+	query := `UPDATE user_wallets SET verified_at = $2 WHERE id = $1`
+	result, err := r.db.Exec(ctx, query, id, verifiedAt)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return apperrors.ErrNotFound
+	}
+	return nil
+}
+
+// SetPrimary makes a verified wallet the user's primary wallet. Returns
+// ErrWalletNotVerified if the wallet has not proven ownership.
 func (r *WalletRepository) SetPrimary(ctx context.Context, userID uuid.UUID, walletID uuid.UUID) error {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
@@ -259,6 +361,21 @@ func (r *WalletRepository) SetPrimary(ctx context.Context, userID uuid.UUID, wal
 	}
 	defer tx.Rollback(ctx)
 
+	var verified bool
+	err = tx.QueryRow(ctx,
+		`SELECT verified_at IS NOT NULL FROM user_wallets WHERE id = $1 AND user_id = $2`,
+		walletID, userID,
+	).Scan(&verified)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return apperrors.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return apperrors.ErrWalletNotVerified
+	}
+
 	// Unset all primary wallets for user
 	_, err = tx.Exec(ctx, `UPDATE user_wallets SET is_primary = FALSE WHERE user_id = $1`, userID)
 	if err != nil {
@@ -286,13 +403,12 @@ func (r *WalletRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-func (r *WalletRepository) SaveNonce(ctx context.Context, walletAddress, nonce string) error {
+func (r *WalletRepository) SaveNonce(ctx context.Context, walletAddress, nonce string, expiresAt time.Time) error {
 	query := `
 		INSERT INTO wallet_nonces (id, wallet_address, nonce, expires_at, created_at)
 		VALUES ($1, $2, $3, $4, $5)
 		ON CONFLICT (wallet_address) DO UPDATE SET nonce = $3, expires_at = $4`
 
-	expiresAt := time.Now().Add(5 * time.Minute)
 	_, err := r.db.Exec(ctx, query, uuid.New(), walletAddress, nonce, expiresAt, time.Now())
 	return err
 }
@@ -314,19 +430,33 @@ func (r *WalletRepository) DeleteNonce(ctx context.Context, walletAddress string
 	return err
 }
 
+// ConsumeNonce atomically deletes a matching, unexpired nonce so it can only
+// be redeemed once. Returns ErrNotFound if the nonce is stale or already used.
+func (r *WalletRepository) ConsumeNonce(ctx context.Context, walletAddress, nonce string) error {
+	query := `DELETE FROM wallet_nonces WHERE wallet_address = $1 AND nonce = $2 AND expires_at > NOW()`
+	result, err := r.db.Exec(ctx, query, walletAddress, nonce)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return apperrors.ErrNotFound
+	}
+	return nil
+}
+
 // SessionRepository implementation
 type SessionRepository struct {
-	db *pgxpool.Pool
+	db *database.Conn
 }
 
 func NewSessionRepository(db *pgxpool.Pool) *SessionRepository {
-	return &SessionRepository{db: db}
+	return &SessionRepository{db: database.NewConn(db)}
 }
 
 func (r *SessionRepository) Create(ctx context.Context, session *domain.AuthSession) error {
 	query := `
 		INSERT INTO auth_sessions (id, user_id, session_token, wallet_address, ip_address, user_agent, expires_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+		VALUES ($1, $2, $3, $4, $5::text::inet, $6, $7, $8)`
 
 	session.ID = uuid.New()
 	session.CreatedAt = time.Now()
@@ -338,9 +468,26 @@ func (r *SessionRepository) Create(ctx context.Context, session *domain.AuthSess
 	return err
 }
 
+func (r *SessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.AuthSession, error) {
+	query := `
+		SELECT id, user_id, session_token, wallet_address, host(ip_address), user_agent, expires_at, created_at
+		FROM auth_sessions WHERE id = $1 AND expires_at > NOW()`
+
+	session := &domain.AuthSession{}
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&session.ID, &session.UserID, &session.SessionToken, &session.WalletAddress,
+		&session.IPAddress, &session.UserAgent, &session.ExpiresAt, &session.CreatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperrors.ErrNotFound
+	}
+	return session, err
+}
+
 func (r *SessionRepository) GetByToken(ctx context.Context, token string) (*domain.AuthSession, error) {
 	query := `
-		SELECT id, user_id, session_token, wallet_address, ip_address, user_agent, expires_at, created_at
+		SELECT id, user_id, session_token, wallet_address, host(ip_address), user_agent, expires_at, created_at
 		FROM auth_sessions WHERE session_token = $1 AND expires_at > NOW()`
 
 	session := &domain.AuthSession{}
@@ -355,20 +502,135 @@ func (r *SessionRepository) GetByToken(ctx context.Context, token string) (*doma
 	return session, err
 }
 
+func (r *SessionRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]domain.AuthSession, error) {
+	query := `
+		SELECT id, user_id, session_token, wallet_address, host(ip_address), user_agent, expires_at, created_at
+		FROM auth_sessions WHERE user_id = $1 AND expires_at > NOW()
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []domain.AuthSession
+	for rows.Next() {
+		var session domain.AuthSession
+		if err := rows.Scan(
+			&session.ID, &session.UserID, &session.SessionToken, &session.WalletAddress,
+			&session.IPAddress, &session.UserAgent, &session.ExpiresAt, &session.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
 func (r *SessionRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM auth_sessions WHERE id = $1`
 	_, err := r.db.Exec(ctx, query, id)
 	return err
 }
 
+func (r *SessionRepository) DeleteByToken(ctx context.Context, token string) error {
+	query := `DELETE FROM auth_sessions WHERE session_token = $1`
+	_, err := r.db.Exec(ctx, query, token)
+	return err
+}
+
 func (r *SessionRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
 	query := `DELETE FROM auth_sessions WHERE user_id = $1`
 	_, err := r.db.Exec(ctx, query, userID)
 	return err
 }
 
-func (r *SessionRepository) DeleteExpired(ctx context.Context) error {
+func (r *SessionRepository) DeleteExpired(ctx context.Context) (int64, error) {
 	query := `DELETE FROM auth_sessions WHERE expires_at < NOW()`
-	_, err := r.db.Exec(ctx, query)
+	result, err := r.db.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+func (r *SessionRepository) Extend(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
+	query := `UPDATE auth_sessions SET expires_at = $2 WHERE id = $1`
+	result, err := r.db.Exec(ctx, query, id, expiresAt)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return apperrors.ErrNotFound
+	}
+	return nil
+}
+
+// RefreshTokenRepository implementation
+type RefreshTokenRepository struct {
+	db *database.Conn
+}
+
+func NewRefreshTokenRepository(db *pgxpool.Pool) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: database.NewConn(db)}
+}
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (id, session_id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	token.ID = uuid.New()
+	token.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(ctx, query,
+		token.ID, token.SessionID, token.UserID, token.TokenHash, token.ExpiresAt, token.CreatedAt,
+	)
 	return err
 }
+
+// GetByHash returns a token whether or not it has been used, so callers can
+// detect reuse of rotated tokens
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	query := `
+		SELECT id, session_id, user_id, token_hash, expires_at, used_at, replaced_by, created_at
+		FROM refresh_tokens WHERE token_hash = $1`
+
+	token := &domain.RefreshToken{}
+	err := r.db.QueryRow(ctx, query, tokenHash).Scan(
+		&token.ID, &token.SessionID, &token.UserID, &token.TokenHash,
+		&token.ExpiresAt, &token.UsedAt, &token.ReplacedBy, &token.CreatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, apperrors.ErrNotFound
+	}
+	return token, err
+}
+
+// MarkUsed atomically claims an unused token. It returns ErrNotFound if the
+// token was already used, so two concurrent refreshes cannot both succeed.
+func (r *RefreshTokenRepository) MarkUsed(ctx context.Context, id, replacedBy uuid.UUID) error {
+	query := `
+		UPDATE refresh_tokens SET used_at = NOW(), replaced_by = $2
+		WHERE id = $1 AND used_at IS NULL`
+
+	result, err := r.db.Exec(ctx, query, id, replacedBy)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return apperrors.ErrNotFound
+	}
+	return nil
+}
+
+func (r *RefreshTokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	query := `DELETE FROM refresh_tokens WHERE expires_at < NOW()`
+	result, err := r.db.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,24 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/trenchjob/backend/internal/pkg/database"
+	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
+)
+
+// staleOrMissing explains a versioned update that matched no row: the row
+// is either gone or was changed since the caller read it
+func staleOrMissing(ctx context.Context, db *database.Conn, table string, id uuid.UUID) error {
+	var exists bool
+	query := fmt.Sprintf(`SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1)`, table)
+	if err := db.QueryRow(ctx, query, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return apperrors.ErrNotFound
+	}
+	return apperrors.ErrVersionConflict
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/trenchjob/backend/internal/domain"
+	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+type AdminService struct {
+	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	jobRepo     repository.JobRepository
+	serviceRepo repository.ServiceRepository
+	auditRepo   repository.AuditLogRepository
+	tx          repository.Transactor
+}
+
+func NewAdminService(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	jobRepo repository.JobRepository,
+	serviceRepo repository.ServiceRepository,
+	auditRepo repository.AuditLogRepository,
+	tx repository.Transactor,
+) *AdminService {
+	return &AdminService{
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		jobRepo:     jobRepo,
+		serviceRepo: serviceRepo,
+		auditRepo:   auditRepo,
+		tx:          tx,
+	}
+}
+
+// AdminActor identifies the admin performing an action, for the audit log
+type AdminActor struct {
+	AdminID   uuid.UUID
+	IPAddress string
+}
+
+// ModerationRequest carries the mandatory reason for a moderation action
+type ModerationRequest struct {
+	Reason string `json:"reason"`
+}
+
+// SearchUsersRequest represents an admin user search
+type SearchUsersRequest struct {
+	Query  string `json:"query"`
+	Status string `json:"status"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+// SearchUsersResponse represents a paginated user search result
+type SearchUsersResponse struct {
+	Users  []domain.User `json:"users"`
+	Total  int           `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+// AuditLogRequest filters the audit log
+type AuditLogRequest struct {
+	AdminID    *uuid.UUID `json:"admin_id"`
+	TargetType string     `json:"target_type"`
+	TargetID   *uuid.UUID `json:"target_id"`
+	Limit      int        `json:"limit"`
+	Offset     int        `json:"offset"`
+}
+
+// AuditLogResponse represents a page of audit log entries
+type AuditLogResponse struct {
+	Entries []domain.AuditLogEntry `json:"entries"`
+	Total   int                    `json:"total"`
+	Limit   int                    `json:"limit"`
+	Offset  int                    `json:"offset"`
+}
+
+// SearchUsers finds users by email, username or wallet address
+func (s *AdminService) SearchUsers(ctx context.Context, req *SearchUsersRequest) (*SearchUsersResponse, error) {
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 20
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	switch req.Status {
+	case "", domain.AccountStatusActive, domain.AccountStatusSuspended, domain.AccountStatusBanned:
+	default:
+		return nil, apperrors.NewBadRequest("invalid account status")
+	}
+
+	users, total, err := s.userRepo.Search(ctx, strings.TrimSpace(req.Query), req.Status, req.Limit, req.Offset)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+
+	return &SearchUsersResponse{
+		Users:  users,
+		Total:  total,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}, nil
+}
+
+// SuspendUser suspends an account and revokes all of its sessions
+func (s *AdminService) SuspendUser(ctx context.Context, actor AdminActor, userID uuid.UUID, req *ModerationRequest) (*domain.User, error) {
+	return s.setAccountStatus(ctx, actor, userID, domain.AccountStatusSuspended, domain.AuditActionSuspendUser, req.Reason)
+}
+
+// BanUser bans an account and revokes all of its sessions
+func (s *AdminService) BanUser(ctx context.Context, actor AdminActor, userID uuid.UUID, req *ModerationRequest) (*domain.User, error) {
+	return s.setAccountStatus(ctx, actor, userID, domain.AccountStatusBanned, domain.AuditActionBanUser, req.Reason)
+}
+
+// ReinstateUser restores a suspended or banned account to active
+func (s *AdminService) ReinstateUser(ctx context.Context, actor AdminActor, userID uuid.UUID, req *ModerationRequest) (*domain.User, error) {
+	return s.setAccountStatus(ctx, actor, userID, domain.AccountStatusActive, domain.AuditActionReinstateUser, req.Reason)
+}
+
+func (s *AdminService) setAccountStatus(ctx context.Context, actor AdminActor, userID uuid.UUID, status, action, reason string) (*domain.User, error) {
+	if err := requireReason(reason); err != nil {
+		return nil, err
+	}
+
+	if userID == actor.AdminID {
+		return nil, apperrors.NewBadRequest("admins cannot moderate their own account")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewNotFound("user")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+
+	if user.IsAdmin {
+		return nil, apperrors.NewForbidden("admin accounts cannot be moderated")
+	}
+	if user.AccountStatus == status {
+		return nil, apperrors.NewBadRequest("account is already " + status)
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.record(ctx, actor, action, domain.AuditTargetUser, user.ID, reason, user.AccountStatus, status); err != nil {
+			return err
+		}
+
+		user.AccountStatus = status
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return apperrors.NewInternal(err)
+		}
+
+		// Suspended and banned users are logged out everywhere immediately
+		if status != domain.AccountStatusActive {
+			if err := s.sessionRepo.DeleteByUserID(ctx, user.ID); err != nil {
+				return apperrors.NewInternal(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ForceCloseJob closes a draft or open job regardless of its owner
+func (s *AdminService) ForceCloseJob(ctx context.Context, actor AdminActor, jobID uuid.UUID, req *ModerationRequest) (*domain.Job, error) {
+	if err := requireReason(req.Reason); err != nil {
+		return nil, err
+	}
+
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewNotFound("job")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+
+	if job.Status != domain.JobStatusDraft && job.Status != domain.JobStatusOpen {
+		return nil, apperrors.NewBadRequest("only draft or open jobs can be closed")
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.record(ctx, actor, domain.AuditActionCloseJob, domain.AuditTargetJob, job.ID, req.Reason, job.Status, domain.JobStatusClosed); err != nil {
+			return err
+		}
+
+		job.Status = domain.JobStatusClosed
+		if err := s.jobRepo.Update(ctx, job); err != nil {
+			return apperrors.NewInternal(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// ForceCloseService archives a service regardless of its owner. Archived
+// services cannot be republished by the freelancer.
+func (s *AdminService) ForceCloseService(ctx context.Context, actor AdminActor, serviceID uuid.UUID, req *ModerationRequest) (*domain.Service, error) {
+	if err := requireReason(req.Reason); err != nil {
+		return nil, err
+	}
+
+	svc, err := s.serviceRepo.GetByID(ctx, serviceID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewNotFound("service")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+
+	if svc.Status == domain.ServiceStatusArchived {
+		return nil, apperrors.NewBadRequest("service is already archived")
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.record(ctx, actor, domain.AuditActionCloseService, domain.AuditTargetService, svc.ID, req.Reason, svc.Status, domain.ServiceStatusArchived); err != nil {
+			return err
+		}
+
+		svc.Status = domain.ServiceStatusArchived
+		if err := s.serviceRepo.Update(ctx, svc); err != nil {
+			return apperrors.NewInternal(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return svc, nil
+}
+
+// GetAuditLog returns audit log entries, newest first
+func (s *AdminService) GetAuditLog(ctx context.Context, req *AuditLogRequest) (*AuditLogResponse, error) {
+	if req.Limit <= 0 || req.Limit > 100 {
+		req.Limit = 50
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	entries, total, err := s.auditRepo.List(ctx, req.AdminID, req.TargetID, req.TargetType, req.Limit, req.Offset)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+
+	return &AuditLogResponse{
+		Entries: entries,
+		Total:   total,
+		Limit:   req.Limit,
+		Offset:  req.Offset,
+	}, nil
+}
+
+// record writes the audit entry in the same transaction as the action, so
+// an action can never take effect without being logged
+func (s *AdminService) record(ctx context.Context, actor AdminActor, action, targetType string, targetID uuid.UUID, reason, previous, next string) error {
+	return recordAdminAction(ctx, s.auditRepo, actor, action, targetType, targetID, reason, previous, next)
+}
+
+// recordAdminAction appends an entry to the admin audit log. Shared by every
+// service that exposes admin-only actions.
+func recordAdminAction(ctx context.Context, auditRepo repository.AuditLogRepository, actor AdminActor, action, targetType string, targetID uuid.UUID, reason, previous, next string) error {
+	entry := &domain.AuditLogEntry{
+		AdminID:       actor.AdminID,
+		Action:        action,
+		TargetType:    targetType,
+		TargetID:      targetID,
+		Reason:        strings.TrimSpace(reason),
+		PreviousValue: &previous,
+		NewValue:      &next,
+		IPAddress:     optionalString(actor.IPAddress),
+	}
+	if err := auditRepo.Create(ctx, entry); err != nil {
+		return apperrors.NewInternal(err)
+	}
+	return nil
+}
+
+func requireReason(reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return apperrors.NewBadRequest("reason is required")
+	}
+	return nil
+}
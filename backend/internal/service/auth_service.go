@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,27 +15,52 @@ import (
 	"github.com/trenchjob/backend/internal/repository"
 )
 
+const (
+	// walletNonceTTL is how long a sign-in challenge stays redeemable
+	walletNonceTTL = 5 * time.Minute
+	// walletClockSkew tolerates small clock differences on issued-at checks
+	walletClockSkew = time.Minute
+)
+
+// errRefreshTokenSpent aborts a rotation whose token was spent concurrently
+var errRefreshTokenSpent = errors.New("refresh token already spent")
+
 type AuthService struct {
-	userRepo    repository.UserRepository
-	walletRepo  repository.WalletRepository
-	sessionRepo repository.SessionRepository
-	profileRepo repository.ProfileRepository
-	jwtManager  *utils.JWTManager
+	userRepo         repository.UserRepository
+	walletRepo       repository.WalletRepository
+	sessionRepo      repository.SessionRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	profileRepo      repository.ProfileRepository
+	tx               repository.Transactor
+	jwtManager       *utils.JWTManager
+	refreshTokenTTL  time.Duration
+	domain           string
+	chainID          string
 }
 
 func NewAuthService(
 	userRepo repository.UserRepository,
 	walletRepo repository.WalletRepository,
 	sessionRepo repository.SessionRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
 	profileRepo repository.ProfileRepository,
+	tx repository.Transactor,
 	jwtManager *utils.JWTManager,
+	refreshTokenTTL time.Duration,
+	domain string,
+	chainID string,
 ) *AuthService {
 	return &AuthService{
-		userRepo:    userRepo,
-		walletRepo:  walletRepo,
-		sessionRepo: sessionRepo,
-		profileRepo: profileRepo,
-		jwtManager:  jwtManager,
+		userRepo:         userRepo,
+		walletRepo:       walletRepo,
+		sessionRepo:      sessionRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		profileRepo:      profileRepo,
+		tx:               tx,
+		jwtManager:       jwtManager,
+		refreshTokenTTL:  refreshTokenTTL,
+		domain:           domain,
+		chainID:          chainID,
 	}
 }
 
@@ -45,6 +71,10 @@ type SignupRequest struct {
 	IsClient      bool   `json:"is_client"`
 	IsFreelancer  bool   `json:"is_freelancer"`
 	WalletAddress string `json:"wallet_address,omitempty"`
+	// Optional proof of ownership for WalletAddress: a signed sign-in message
+	// from GET /wallet/nonce. Without it the wallet is stored unverified.
+	WalletMessage   string `json:"wallet_message,omitempty"`
+	WalletSignature string `json:"wallet_signature,omitempty"`
 }
 
 type LoginRequest struct {
@@ -52,20 +82,124 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
+type ConnectWalletRequest struct {
+	WalletAddress string `json:"wallet_address"`
+	WalletType    string `json:"wallet_type"`
+	Signature     string `json:"signature"`
+	Message       string `json:"message"`
+}
+
 type WalletLoginRequest struct {
 	WalletAddress string `json:"wallet_address"`
 	Signature     string `json:"signature"`
 	Message       string `json:"message"`
 }
 
+// WalletChallenge is the sign-in-with-Solana message a wallet must sign
+type WalletChallenge struct {
+	Nonce     string    `json:"nonce"`
+	Message   string    `json:"message"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
 type AuthResponse struct {
 	User      *domain.User `json:"user"`
 	Token     string       `json:"token"`
 	ExpiresAt time.Time    `json:"expires_at"`
+	// Set when a refresh token is issued or rotated; the previous refresh
+	// token stops working once this one is returned
+	RefreshToken     string     `json:"refresh_token,omitempty"`
+	RefreshExpiresAt *time.Time `json:"refresh_expires_at,omitempty"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// SessionMeta describes the client a session is issued to
+type SessionMeta struct {
+	IPAddress string
+	UserAgent string
+}
+
+// issueSession starts a server-side session and returns an access token and
+// the first refresh token of the session's token family
+func (s *AuthService) issueSession(ctx context.Context, user *domain.User, meta SessionMeta, walletAddress *string) (*AuthResponse, error) {
+	sessionToken, err := utils.GenerateSessionToken()
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+
+	session := &domain.AuthSession{
+		UserID:        user.ID,
+		SessionToken:  sessionToken,
+		WalletAddress: walletAddress,
+		IPAddress:     optionalString(meta.IPAddress),
+		UserAgent:     optionalString(meta.UserAgent),
+		ExpiresAt:     time.Now().Add(s.refreshTokenTTL),
+	}
+	var refreshToken string
+	var stored *domain.RefreshToken
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.sessionRepo.Create(ctx, session); err != nil {
+			return apperrors.NewInternal(err)
+		}
+
+		var err error
+		refreshToken, stored, err = s.createRefreshToken(ctx, session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.signAccessToken(user, sessionToken)
+	if err != nil {
+		return nil, err
+	}
+	resp.RefreshToken = refreshToken
+	resp.RefreshExpiresAt = &stored.ExpiresAt
+	return resp, nil
+}
+
+// createRefreshToken generates a refresh token for a session and stores its hash
+func (s *AuthService) createRefreshToken(ctx context.Context, session *domain.AuthSession) (string, *domain.RefreshToken, error) {
+	token, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return "", nil, apperrors.NewInternal(err)
+	}
+
+	stored := &domain.RefreshToken{
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+	}
+	if err := s.refreshTokenRepo.Create(ctx, stored); err != nil {
+		return "", nil, apperrors.NewInternal(err)
+	}
+	return token, stored, nil
+}
+
+// signAccessToken signs a short-lived JWT for an existing session
+func (s *AuthService) signAccessToken(user *domain.User, sessionToken string) (*AuthResponse, error) {
+	token, expiresAt, err := s.jwtManager.GenerateToken(
+		sessionToken, user.ID, user.Email, user.Username, user.IsClient, user.IsFreelancer, user.IsAdmin,
+	)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+
+	return &AuthResponse{
+		User:      user,
+		Token:     token,
+		ExpiresAt: expiresAt,
+	}, nil
 }
 
 // Signup creates a new user account
-func (s *AuthService) Signup(ctx context.Context, req *SignupRequest) (*AuthResponse, error) {
+func (s *AuthService) Signup(ctx context.Context, req *SignupRequest, meta SessionMeta) (*AuthResponse, error) {
 	// Validate input
 	v := validator.New().
 		Required(req.Email, "email").
@@ -106,6 +240,20 @@ func (s *AuthService) Signup(ctx context.Context, req *SignupRequest) (*AuthResp
 		return nil, apperrors.NewConflict("username already taken")
 	}
 
+	// Prove wallet ownership before the address is attached to the account
+	walletVerified := false
+	if req.WalletAddress != "" {
+		if err := s.ensureWalletAvailable(ctx, uuid.Nil, req.WalletAddress); err != nil {
+			return nil, err
+		}
+		if req.WalletSignature != "" {
+			if err := s.verifyWalletSignature(ctx, req.WalletAddress, req.WalletMessage, req.WalletSignature); err != nil {
+				return nil, err
+			}
+			walletVerified = true
+		}
+	}
+
 	// Hash password
 	passwordHash, err := utils.HashPassword(req.Password)
 	if err != nil {
@@ -122,58 +270,64 @@ func (s *AuthService) Signup(ctx context.Context, req *SignupRequest) (*AuthResp
 		AccountStatus:        domain.AccountStatusActive,
 	}
 
-	if req.WalletAddress != "" {
+	if walletVerified {
 		user.PrimaryWalletAddress = &req.WalletAddress
+		user.WalletVerified = true
 	}
 
-	if err := s.userRepo.Create(ctx, user); err != nil {
-		return nil, apperrors.NewInternal(err)
-	}
-
-	// Auto-create profile for the new user
-	profile := &domain.Profile{
-		UserID:           user.ID,
-		DisplayName:      &req.Username, // Use username as initial display name
-		AvailableForHire: true,
-	}
-	if err := s.profileRepo.Create(ctx, profile); err != nil {
-		// Log error but don't fail signup - profile can be created later
-		fmt.Printf("failed to create initial profile: %v\n", err)
-	}
+	var resp *AuthResponse
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return apperrors.NewInternal(err)
+		}
 
-	// If wallet address provided, create wallet entry
-	if req.WalletAddress != "" {
-		now := time.Now()
-		wallet := &domain.UserWallet{
-			UserID:        user.ID,
-			WalletAddress: req.WalletAddress,
-			WalletType:    domain.WalletTypePhantom, // Default, can be updated
-			IsPrimary:     true,
-			VerifiedAt:    &now,
+		// Auto-create profile for the new user
+		profile := &domain.Profile{
+			UserID:           user.ID,
+			DisplayName:      &req.Username, // Use username as initial display name
+			AvailableForHire: true,
 		}
-		if err := s.walletRepo.Create(ctx, wallet); err != nil {
-			// Log error but don't fail signup
-			fmt.Printf("failed to create wallet entry: %v\n", err)
+		err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			return s.profileRepo.Create(ctx, profile)
+		})
+		if err != nil {
+			// Log error but don't fail signup - profile can be created later
+			fmt.Printf("failed to create initial profile: %v\n", err)
 		}
-	}
 
-	// Generate JWT token
-	token, expiresAt, err := s.jwtManager.GenerateToken(
-		user.ID, user.Email, user.Username, user.IsClient, user.IsFreelancer,
-	)
+		// If wallet address provided, create wallet entry (unverified unless signed)
+		if req.WalletAddress != "" {
+			wallet := &domain.UserWallet{
+				UserID:        user.ID,
+				WalletAddress: req.WalletAddress,
+				WalletType:    domain.WalletTypePhantom, // Default, can be updated
+				IsPrimary:     walletVerified,
+			}
+			if walletVerified {
+				now := time.Now()
+				wallet.VerifiedAt = &now
+			}
+			err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+				return s.walletRepo.Create(ctx, wallet)
+			})
+			if err != nil {
+				// Log error but don't fail signup
+				fmt.Printf("failed to create wallet entry: %v\n", err)
+			}
+		}
+
+		resp, err = s.issueSession(ctx, user, meta, nil)
+		return err
+	})
 	if err != nil {
-		return nil, apperrors.NewInternal(err)
+		return nil, err
 	}
 
-	return &AuthResponse{
-		User:      user,
-		Token:     token,
-		ExpiresAt: expiresAt,
-	}, nil
+	return resp, nil
 }
 
 // Login authenticates a user with email and password
-func (s *AuthService) Login(ctx context.Context, req *LoginRequest) (*AuthResponse, error) {
+func (s *AuthService) Login(ctx context.Context, req *LoginRequest, meta SessionMeta) (*AuthResponse, error) {
 	// Validate input
 	v := validator.New().
 		Required(req.Email, "email").
@@ -210,68 +364,88 @@ func (s *AuthService) Login(ctx context.Context, req *LoginRequest) (*AuthRespon
 		fmt.Printf("failed to update last login: %v\n", err)
 	}
 
-	// Generate JWT token
-	token, expiresAt, err := s.jwtManager.GenerateToken(
-		user.ID, user.Email, user.Username, user.IsClient, user.IsFreelancer,
-	)
+	return s.issueSession(ctx, user, meta, nil)
+}
+
+// GetNonce issues a sign-in challenge for wallet authentication
+func (s *AuthService) GetNonce(ctx context.Context, walletAddress string) (*WalletChallenge, error) {
+	if !validator.ValidateWalletAddress(walletAddress) {
+		return nil, apperrors.NewBadRequest("invalid wallet address")
+	}
+
+	nonce, err := utils.GenerateNonce()
 	if err != nil {
 		return nil, apperrors.NewInternal(err)
 	}
 
-	return &AuthResponse{
-		User:      user,
-		Token:     token,
-		ExpiresAt: expiresAt,
+	msg := utils.NewSIWSMessage(s.domain, walletAddress, s.chainID, nonce, walletNonceTTL)
+	if err := s.walletRepo.SaveNonce(ctx, walletAddress, nonce, msg.ExpiresAt); err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+
+	return &WalletChallenge{
+		Nonce:     nonce,
+		Message:   msg.String(),
+		IssuedAt:  msg.IssuedAt,
+		ExpiresAt: msg.ExpiresAt,
 	}, nil
 }
 
-// GetNonce generates a nonce for wallet authentication
-func (s *AuthService) GetNonce(ctx context.Context, walletAddress string) (string, error) {
-	if !validator.ValidateWalletAddress(walletAddress) {
-		return "", apperrors.NewBadRequest("invalid wallet address")
+// verifyWalletSignature checks a signed sign-in message and redeems its nonce
+func (s *AuthService) verifyWalletSignature(ctx context.Context, walletAddress, message, signature string) error {
+	if message == "" || signature == "" {
+		return apperrors.NewBadRequest("message and signature are required")
 	}
 
-	nonce, err := utils.GenerateNonce()
+	msg, err := utils.ParseSIWSMessage(message)
 	if err != nil {
-		return "", apperrors.NewInternal(err)
+		return apperrors.NewBadRequest("malformed sign-in message: " + err.Error())
 	}
 
-	if err := s.walletRepo.SaveNonce(ctx, walletAddress, nonce); err != nil {
-		return "", apperrors.NewInternal(err)
+	if msg.Domain != s.domain {
+		return apperrors.NewInvalidSignature("sign-in message was issued for a different domain")
+	}
+	if msg.Address != walletAddress {
+		return apperrors.NewInvalidSignature("sign-in message was issued for a different wallet")
+	}
+	if s.chainID != "" && msg.ChainID != s.chainID {
+		return apperrors.NewInvalidSignature("sign-in message was issued for a different network")
 	}
 
-	return nonce, nil
-}
+	now := time.Now()
+	if msg.IssuedAt.After(now.Add(walletClockSkew)) || now.Sub(msg.IssuedAt) > walletNonceTTL+walletClockSkew {
+		return apperrors.NewBadRequest("sign-in message is stale, please request a new nonce")
+	}
+	if !msg.ExpiresAt.After(now) {
+		return apperrors.NewBadRequest("sign-in message has expired, please request a new nonce")
+	}
 
-// WalletLogin authenticates a user with their wallet signature
-func (s *AuthService) WalletLogin(ctx context.Context, req *WalletLoginRequest) (*AuthResponse, error) {
-	// Validate wallet address
-	if !validator.ValidateWalletAddress(req.WalletAddress) {
-		return nil, apperrors.NewBadRequest("invalid wallet address")
+	if err := utils.VerifyWalletSignature(walletAddress, []byte(message), signature); err != nil {
+		return apperrors.NewInvalidSignature("")
 	}
 
-	// Get stored nonce
-	storedNonce, err := s.walletRepo.GetNonce(ctx, req.WalletAddress)
-	if err != nil {
+	// Redeem the nonce only after the signature checks out so a forged request
+	// can't burn a legitimate user's challenge. Deleting it makes it single-use.
+	if err := s.walletRepo.ConsumeNonce(ctx, walletAddress, msg.Nonce); err != nil {
 		if errors.Is(err, apperrors.ErrNotFound) {
-			return nil, apperrors.NewBadRequest("nonce expired or not found, please request a new one")
+			return apperrors.NewBadRequest("nonce expired or already used, please request a new one")
 		}
-		return nil, apperrors.NewInternal(err)
+		return apperrors.NewInternal(err)
 	}
 
-	// Verify the message contains the nonce
-	// In production, you'd verify the actual signature using ed25519
-	// For now, we'll do a simplified check
-	if req.Message == "" || req.Signature == "" {
-		return nil, apperrors.NewBadRequest("message and signature are required")
-	}
+	return nil
+}
 
-	// TODO: Implement actual signature verification using ed25519
-	// The signature should be verified against the message using the wallet's public key
-	_ = storedNonce // Use nonce in actual verification
+// WalletLogin authenticates a user with their wallet signature
+func (s *AuthService) WalletLogin(ctx context.Context, req *WalletLoginRequest, meta SessionMeta) (*AuthResponse, error) {
+	// Validate wallet address
+	if !validator.ValidateWalletAddress(req.WalletAddress) {
+		return nil, apperrors.NewBadRequest("invalid wallet address")
+	}
 
-	// Delete the nonce (one-time use)
-	_ = s.walletRepo.DeleteNonce(ctx, req.WalletAddress)
+	if err := s.verifyWalletSignature(ctx, req.WalletAddress, req.Message, req.Signature); err != nil {
+		return nil, err
+	}
 
 	// Get user by wallet address
 	user, err := s.userRepo.GetByWalletAddress(ctx, req.WalletAddress)
@@ -294,71 +468,122 @@ func (s *AuthService) WalletLogin(ctx context.Context, req *WalletLoginRequest)
 		fmt.Printf("failed to update last login: %v\n", err)
 	}
 
-	// Generate JWT token
-	token, expiresAt, err := s.jwtManager.GenerateToken(
-		user.ID, user.Email, user.Username, user.IsClient, user.IsFreelancer,
-	)
+	return s.issueSession(ctx, user, meta, &req.WalletAddress)
+}
+
+// ensureWalletAvailable rejects an address that another account has already
+// verified. Unverified claims by other accounts don't block a new claim.
+func (s *AuthService) ensureWalletAvailable(ctx context.Context, userID uuid.UUID, walletAddress string) error {
+	existing, err := s.walletRepo.GetByAddress(ctx, walletAddress)
 	if err != nil {
-		return nil, apperrors.NewInternal(err)
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil
+		}
+		return apperrors.NewInternal(err)
 	}
-
-	return &AuthResponse{
-		User:      user,
-		Token:     token,
-		ExpiresAt: expiresAt,
-	}, nil
+	if existing.VerifiedAt != nil && existing.UserID != userID {
+		return apperrors.NewConflict("wallet already connected to another account")
+	}
+	return nil
 }
 
-// ConnectWallet links a wallet to an existing user account
-func (s *AuthService) ConnectWallet(ctx context.Context, userID uuid.UUID, walletAddress, walletType string) error {
-	if !validator.ValidateWalletAddress(walletAddress) {
+// ConnectWallet links a wallet to an existing user account after verifying
+// a signed sign-in message proving the user controls it
+func (s *AuthService) ConnectWallet(ctx context.Context, userID uuid.UUID, req *ConnectWalletRequest) error {
+	if !validator.ValidateWalletAddress(req.WalletAddress) {
 		return apperrors.NewBadRequest("invalid wallet address")
 	}
 
-	// Check if wallet is already connected to another account
-	existingWallet, err := s.walletRepo.GetByAddress(ctx, walletAddress)
-	if err == nil && existingWallet != nil {
-		if existingWallet.UserID != userID {
-			return apperrors.NewConflict("wallet already connected to another account")
-		}
-		return nil // Already connected to this user
+	if err := s.ensureWalletAvailable(ctx, userID, req.WalletAddress); err != nil {
+		return err
 	}
 
-	// Get user's existing wallets
-	existingWallets, err := s.walletRepo.GetByUserID(ctx, userID)
-	if err != nil {
+	wallet, err := s.walletRepo.GetByUserIDAndAddress(ctx, userID, req.WalletAddress)
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
 		return apperrors.NewInternal(err)
 	}
+	if wallet != nil && wallet.VerifiedAt != nil {
+		return nil // Already connected and verified for this user
+	}
 
-	isPrimary := len(existingWallets) == 0 // First wallet is primary
-
-	now := time.Now()
-	wallet := &domain.UserWallet{
-		UserID:        userID,
-		WalletAddress: walletAddress,
-		WalletType:    walletType,
-		IsPrimary:     isPrimary,
-		VerifiedAt:    &now,
+	if err := s.verifyWalletSignature(ctx, req.WalletAddress, req.Message, req.Signature); err != nil {
+		return err
 	}
 
-	if err := s.walletRepo.Create(ctx, wallet); err != nil {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		if wallet != nil {
+			// Previously claimed without proof (e.g. at signup); mark it verified now
+			if err := s.walletRepo.MarkVerified(ctx, wallet.ID, now); err != nil {
+				return apperrors.NewInternal(err)
+			}
+			wallet.VerifiedAt = &now
+		} else {
+			wallet = &domain.UserWallet{
+				UserID:        userID,
+				WalletAddress: req.WalletAddress,
+				WalletType:    req.WalletType,
+				VerifiedAt:    &now,
+			}
+			if err := s.walletRepo.Create(ctx, wallet); err != nil {
+				return apperrors.NewInternal(err)
+			}
+		}
+
+		// The first verified wallet becomes the primary wallet
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return apperrors.NewInternal(err)
+		}
+		if user.PrimaryWalletAddress == nil || !user.WalletVerified {
+			if err := s.walletRepo.SetPrimary(ctx, userID, wallet.ID); err != nil {
+				return apperrors.NewInternal(err)
+			}
+			user.PrimaryWalletAddress = &wallet.WalletAddress
+			user.WalletVerified = true
+			if err := s.userRepo.Update(ctx, user); err != nil {
+				return apperrors.NewInternal(err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// SetPrimaryWallet makes one of the user's verified wallets their primary wallet
+func (s *AuthService) SetPrimaryWallet(ctx context.Context, userID uuid.UUID, walletAddress string) error {
+	wallet, err := s.walletRepo.GetByUserIDAndAddress(ctx, userID, walletAddress)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return apperrors.NewNotFound("wallet")
+		}
 		return apperrors.NewInternal(err)
 	}
 
-	// If this is the first/primary wallet, update user's primary wallet address
-	if isPrimary {
+	if wallet.VerifiedAt == nil {
+		return apperrors.NewForbidden("wallet ownership has not been verified")
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.walletRepo.SetPrimary(ctx, userID, wallet.ID); err != nil {
+			if errors.Is(err, apperrors.ErrWalletNotVerified) {
+				return apperrors.NewForbidden("wallet ownership has not been verified")
+			}
+			return apperrors.NewInternal(err)
+		}
+
 		user, err := s.userRepo.GetByID(ctx, userID)
 		if err != nil {
 			return apperrors.NewInternal(err)
 		}
-		user.PrimaryWalletAddress = &walletAddress
+		user.PrimaryWalletAddress = &wallet.WalletAddress
 		user.WalletVerified = true
 		if err := s.userRepo.Update(ctx, user); err != nil {
 			return apperrors.NewInternal(err)
 		}
-	}
 
-	return nil
+		return nil
+	})
 }
 
 // GetUserWallets returns all wallets for a user
@@ -372,7 +597,7 @@ func (s *AuthService) GetUserWallets(ctx context.Context, userID uuid.UUID) ([]d
 
 // DisconnectWallet removes a wallet from a user account
 func (s *AuthService) DisconnectWallet(ctx context.Context, userID uuid.UUID, walletAddress string) error {
-	wallet, err := s.walletRepo.GetByAddress(ctx, walletAddress)
+	wallet, err := s.walletRepo.GetByUserIDAndAddress(ctx, userID, walletAddress)
 	if err != nil {
 		if errors.Is(err, apperrors.ErrNotFound) {
 			return apperrors.NewNotFound("wallet")
@@ -380,10 +605,6 @@ func (s *AuthService) DisconnectWallet(ctx context.Context, userID uuid.UUID, wa
 		return apperrors.NewInternal(err)
 	}
 
-	if wallet.UserID != userID {
-		return apperrors.NewForbidden("wallet does not belong to this user")
-	}
-
 	// Get all user wallets
 	wallets, err := s.walletRepo.GetByUserID(ctx, userID)
 	if err != nil {
@@ -394,41 +615,57 @@ func (s *AuthService) DisconnectWallet(ctx context.Context, userID uuid.UUID, wa
 		return apperrors.NewBadRequest("cannot remove the only wallet")
 	}
 
-	if err := s.walletRepo.Delete(ctx, wallet.ID); err != nil {
-		return apperrors.NewInternal(err)
-	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.walletRepo.Delete(ctx, wallet.ID); err != nil {
+			return apperrors.NewInternal(err)
+		}
+		if !wallet.IsPrimary {
+			return nil
+		}
 
-	// If this was the primary wallet, set another as primary
-	if wallet.IsPrimary {
-		for _, w := range wallets {
-			if w.ID != wallet.ID {
-				if err := s.walletRepo.SetPrimary(ctx, userID, w.ID); err != nil {
-					fmt.Printf("failed to set new primary wallet: %v\n", err)
-				}
-
-				// Update user's primary wallet address
-				user, err := s.userRepo.GetByID(ctx, userID)
-				if err == nil {
-					user.PrimaryWalletAddress = &w.WalletAddress
-					_ = s.userRepo.Update(ctx, user)
-				}
+		// This was the primary wallet, so promote another verified wallet
+		var next *domain.UserWallet
+		for i := range wallets {
+			if wallets[i].ID != wallet.ID && wallets[i].VerifiedAt != nil {
+				next = &wallets[i]
 				break
 			}
 		}
-	}
 
-	return nil
+		if next != nil {
+			if err := s.walletRepo.SetPrimary(ctx, userID, next.ID); err != nil {
+				return apperrors.NewInternal(err)
+			}
+		}
+
+		// Update user's primary wallet address
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return apperrors.NewInternal(err)
+		}
+		if next != nil {
+			user.PrimaryWalletAddress = &next.WalletAddress
+		} else {
+			user.PrimaryWalletAddress = nil
+			user.WalletVerified = false
+		}
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return apperrors.NewInternal(err)
+		}
+		return nil
+	})
 }
 
-// EnableRole enables an additional role for a user
-func (s *AuthService) EnableRole(ctx context.Context, userID uuid.UUID, role string) (*AuthResponse, error) {
+// EnableRole enables an additional role for a user and reissues the access
+// token of the current session with the updated claims
+func (s *AuthService) EnableRole(ctx context.Context, claims *utils.JWTClaims, role string) (*AuthResponse, error) {
 	// Validate role
 	if role != "client" && role != "freelancer" {
 		return nil, apperrors.NewBadRequest("role must be 'client' or 'freelancer'")
 	}
 
 	// Get current user
-	user, err := s.userRepo.GetByID(ctx, userID)
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
 	if err != nil {
 		if errors.Is(err, apperrors.ErrNotFound) {
 			return nil, apperrors.NewUnauthorized("user not found")
@@ -456,25 +693,45 @@ func (s *AuthService) EnableRole(ctx context.Context, userID uuid.UUID, role str
 		return nil, apperrors.NewInternal(err)
 	}
 
-	// Generate new JWT token with updated claims
-	token, expiresAt, err := s.jwtManager.GenerateToken(
-		user.ID, user.Email, user.Username, user.IsClient, user.IsFreelancer,
-	)
+	// Issue a new access token with updated claims
+	return s.signAccessToken(user, claims.ID)
+}
+
+// RefreshToken rotates a refresh token: the presented token is spent and a
+// new access token and refresh token are issued for the same session. A token
+// that was already spent means it has leaked, so its whole family (the
+// session) is revoked.
+func (s *AuthService) RefreshToken(ctx context.Context, req *RefreshTokenRequest) (*AuthResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, apperrors.NewBadRequest("refresh_token is required")
+	}
+
+	stored, err := s.refreshTokenRepo.GetByHash(ctx, utils.HashToken(req.RefreshToken))
 	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewUnauthorized("invalid refresh token")
+		}
 		return nil, apperrors.NewInternal(err)
 	}
 
-	return &AuthResponse{
-		User:      user,
-		Token:     token,
-		ExpiresAt: expiresAt,
-	}, nil
-}
+	if stored.UsedAt != nil {
+		s.revokeTokenFamily(ctx, stored)
+		return nil, apperrors.NewUnauthorized("refresh token has already been used; session revoked")
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, apperrors.NewUnauthorized("refresh token has expired")
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, stored.SessionID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewUnauthorized("session has been revoked or expired")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
 
-// RefreshToken generates a new token from an existing valid token
-func (s *AuthService) RefreshToken(ctx context.Context, claims *utils.JWTClaims) (*AuthResponse, error) {
 	// Get fresh user data
-	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	user, err := s.userRepo.GetByID(ctx, session.UserID)
 	if err != nil {
 		if errors.Is(err, apperrors.ErrNotFound) {
 			return nil, apperrors.NewUnauthorized("user not found")
@@ -487,17 +744,162 @@ func (s *AuthService) RefreshToken(ctx context.Context, claims *utils.JWTClaims)
 		return nil, apperrors.NewForbidden("account is " + user.AccountStatus)
 	}
 
-	// Generate new token
-	token, expiresAt, err := s.jwtManager.GenerateToken(
-		user.ID, user.Email, user.Username, user.IsClient, user.IsFreelancer,
-	)
+	var refreshToken string
+	var next *domain.RefreshToken
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		refreshToken, next, err = s.createRefreshToken(ctx, session)
+		if err != nil {
+			return err
+		}
+
+		// Spend the presented token. Losing this race means another request
+		// already rotated it, which is treated the same as reuse.
+		if err := s.refreshTokenRepo.MarkUsed(ctx, stored.ID, next.ID); err != nil {
+			if errors.Is(err, apperrors.ErrNotFound) {
+				return errRefreshTokenSpent
+			}
+			return apperrors.NewInternal(err)
+		}
+
+		// Active sessions slide forward with each rotation
+		if err := s.sessionRepo.Extend(ctx, session.ID, next.ExpiresAt); err != nil {
+			return apperrors.NewInternal(err)
+		}
+		return nil
+	})
+	if err != nil {
+		// The family is revoked after the rollback so the revocation sticks
+		if errors.Is(err, errRefreshTokenSpent) {
+			s.revokeTokenFamily(ctx, stored)
+			return nil, apperrors.NewUnauthorized("refresh token has already been used; session revoked")
+		}
+		return nil, err
+	}
+
+	resp, err := s.signAccessToken(user, session.SessionToken)
+	if err != nil {
+		return nil, err
+	}
+	resp.RefreshToken = refreshToken
+	resp.RefreshExpiresAt = &next.ExpiresAt
+	return resp, nil
+}
+
+// revokeTokenFamily ends the session a reused refresh token belongs to,
+// invalidating its access tokens and every refresh token issued for it
+func (s *AuthService) revokeTokenFamily(ctx context.Context, token *domain.RefreshToken) {
+	log.Printf("refresh token reuse detected for user %s, revoking session %s", token.UserID, token.SessionID)
+	if err := s.sessionRepo.Delete(ctx, token.SessionID); err != nil {
+		log.Printf("failed to revoke session %s after refresh token reuse: %v", token.SessionID, err)
+	}
+}
+
+// ValidateSession checks that the session a token was issued for still exists.
+// Used by the auth middleware so logged-out or revoked tokens are rejected.
+func (s *AuthService) ValidateSession(ctx context.Context, claims *utils.JWTClaims) error {
+	if claims.ID == "" {
+		return apperrors.NewUnauthorized("token is not bound to a session")
+	}
+
+	session, err := s.sessionRepo.GetByToken(ctx, claims.ID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return apperrors.NewUnauthorized("session has been revoked or expired")
+		}
+		return apperrors.NewInternal(err)
+	}
+
+	if session.UserID != claims.UserID {
+		return apperrors.NewUnauthorized("session does not match token")
+	}
+	return nil
+}
+
+// Logout revokes the session behind the current token
+func (s *AuthService) Logout(ctx context.Context, claims *utils.JWTClaims) error {
+	if err := s.sessionRepo.DeleteByToken(ctx, claims.ID); err != nil {
+		return apperrors.NewInternal(err)
+	}
+	return nil
+}
+
+// ListSessions returns the user's active sessions, flagging the current one
+func (s *AuthService) ListSessions(ctx context.Context, claims *utils.JWTClaims) ([]domain.AuthSession, error) {
+	sessions, err := s.sessionRepo.GetByUserID(ctx, claims.UserID)
 	if err != nil {
 		return nil, apperrors.NewInternal(err)
 	}
 
-	return &AuthResponse{
-		User:      user,
-		Token:     token,
-		ExpiresAt: expiresAt,
-	}, nil
+	for i := range sessions {
+		sessions[i].IsCurrent = sessions[i].SessionToken == claims.ID
+	}
+	return sessions, nil
+}
+
+// RevokeSession revokes one of the user's own sessions
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return apperrors.NewNotFound("session")
+		}
+		return apperrors.NewInternal(err)
+	}
+
+	if session.UserID != userID {
+		return apperrors.NewNotFound("session")
+	}
+
+	if err := s.sessionRepo.Delete(ctx, sessionID); err != nil {
+		return apperrors.NewInternal(err)
+	}
+	return nil
+}
+
+// RevokeAllSessions logs the user out everywhere, including the current session
+func (s *AuthService) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	if err := s.sessionRepo.DeleteByUserID(ctx, userID); err != nil {
+		return apperrors.NewInternal(err)
+	}
+	return nil
+}
+
+// RunSessionSweeper periodically deletes expired sessions and refresh tokens
+// until ctx is cancelled
+func (s *AuthService) RunSessionSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.sessionRepo.DeleteExpired(ctx)
+			if err != nil {
+				log.Printf("session sweeper: failed to delete expired sessions: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("session sweeper: deleted %d expired sessions", deleted)
+			}
+
+			deleted, err = s.refreshTokenRepo.DeleteExpired(ctx)
+			if err != nil {
+				log.Printf("session sweeper: failed to delete expired refresh tokens: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("session sweeper: deleted %d expired refresh tokens", deleted)
+			}
+		}
+	}
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
 }
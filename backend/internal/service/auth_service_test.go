@@ -0,0 +1,426 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trenchjob/backend/internal/domain"
+	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
+	"github.com/trenchjob/backend/internal/pkg/utils"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+const (
+	testAuthDomain  = "trenchjob.io"
+	testAuthChainID = "devnet"
+)
+
+type fakeNonceRepo struct {
+	repository.WalletRepository
+	nonces map[string]string
+}
+
+func (r *fakeNonceRepo) SaveNonce(_ context.Context, walletAddress, nonce string, _ time.Time) error {
+	r.nonces[walletAddress] = nonce
+	return nil
+}
+
+func (r *fakeNonceRepo) ConsumeNonce(_ context.Context, walletAddress, nonce string) error {
+	if r.nonces[walletAddress] != nonce {
+		return apperrors.ErrNotFound
+	}
+	delete(r.nonces, walletAddress)
+	return nil
+}
+
+type fakeUserRepo struct {
+	repository.UserRepository
+	user *domain.User
+}
+
+func (r *fakeUserRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	if r.user.ID != id {
+		return nil, apperrors.ErrNotFound
+	}
+	return r.user, nil
+}
+
+type fakeSessionRepo struct {
+	repository.SessionRepository
+	sessions map[uuid.UUID]*domain.AuthSession
+}
+
+func (r *fakeSessionRepo) Create(_ context.Context, s *domain.AuthSession) error {
+	s.ID = uuid.New()
+	stored := *s
+	r.sessions[s.ID] = &stored
+	return nil
+}
+
+func (r *fakeSessionRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.AuthSession, error) {
+	s, ok := r.sessions[id]
+	if !ok {
+		return nil, apperrors.ErrNotFound
+	}
+	copied := *s
+	return &copied, nil
+}
+
+func (r *fakeSessionRepo) Delete(_ context.Context, id uuid.UUID) error {
+	delete(r.sessions, id)
+	return nil
+}
+
+func (r *fakeSessionRepo) Extend(_ context.Context, id uuid.UUID, expiresAt time.Time) error {
+	if s, ok := r.sessions[id]; ok {
+		s.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+type fakeRefreshTokenRepo struct {
+	repository.RefreshTokenRepository
+	tokens []*domain.RefreshToken
+}
+
+func (r *fakeRefreshTokenRepo) Create(_ context.Context, t *domain.RefreshToken) error {
+	t.ID = uuid.New()
+	t.CreatedAt = time.Now()
+	stored := *t
+	r.tokens = append(r.tokens, &stored)
+	return nil
+}
+
+func (r *fakeRefreshTokenRepo) GetByHash(_ context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	for _, t := range r.tokens {
+		if t.TokenHash == tokenHash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, apperrors.ErrNotFound
+}
+
+func (r *fakeRefreshTokenRepo) MarkUsed(_ context.Context, id, replacedBy uuid.UUID) error {
+	for _, t := range r.tokens {
+		if t.ID == id && t.UsedAt == nil {
+			now := time.Now()
+			t.UsedAt = &now
+			t.ReplacedBy = &replacedBy
+			return nil
+		}
+	}
+	return apperrors.ErrNotFound
+}
+
+type authFixture struct {
+	service  *AuthService
+	nonces   *fakeNonceRepo
+	sessions *fakeSessionRepo
+	tokens   *fakeRefreshTokenRepo
+	user     *domain.User
+}
+
+func newAuthFixture() *authFixture {
+	f := &authFixture{
+		nonces:   &fakeNonceRepo{nonces: map[string]string{}},
+		sessions: &fakeSessionRepo{sessions: map[uuid.UUID]*domain.AuthSession{}},
+		tokens:   &fakeRefreshTokenRepo{},
+		user: &domain.User{
+			ID:            uuid.New(),
+			Email:         "dev@example.com",
+			Username:      "dev",
+			AccountStatus: domain.AccountStatusActive,
+		},
+	}
+	f.service = NewAuthService(
+		&fakeUserRepo{user: f.user}, f.nonces, f.sessions, f.tokens, nil, fakeTransactor{},
+		utils.NewJWTManager("test-secret", 15), time.Hour, testAuthDomain, testAuthChainID,
+	)
+	return f
+}
+
+// statusOf is the HTTP status err would be reported with
+func statusOf(err error) int {
+	if appErr := apperrors.GetAppError(err); appErr != nil {
+		return appErr.StatusCode
+	}
+	return http.StatusInternalServerError
+}
+
+func TestVerifyWalletSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	wallet := utils.EncodeBase58(pub)
+
+	tests := []struct {
+		name string
+		// edit adjusts the issued challenge before it is signed
+		edit func(m *utils.SIWSMessage)
+		// tamper rewrites the message after it was signed
+		tamper     func(raw string) string
+		wallet     string
+		wantStatus int
+		// nonceLeft is whether the challenge can still be redeemed afterwards
+		nonceLeft bool
+	}{
+		{name: "valid", wantStatus: http.StatusOK},
+		{
+			name:       "wrong domain",
+			edit:       func(m *utils.SIWSMessage) { m.Domain = "trenchjob.io.evil.example" },
+			wantStatus: http.StatusUnauthorized,
+			nonceLeft:  true,
+		},
+		{
+			name:       "wrong network",
+			edit:       func(m *utils.SIWSMessage) { m.ChainID = "mainnet" },
+			wantStatus: http.StatusUnauthorized,
+			nonceLeft:  true,
+		},
+		{
+			name:       "issued for another wallet",
+			wallet:     utils.EncodeBase58(otherPub),
+			wantStatus: http.StatusUnauthorized,
+			nonceLeft:  true,
+		},
+		{
+			name: "expired nonce",
+			edit: func(m *utils.SIWSMessage) {
+				m.IssuedAt = m.IssuedAt.Add(-walletNonceTTL - 2*walletClockSkew)
+				m.ExpiresAt = m.IssuedAt.Add(walletNonceTTL)
+			},
+			wantStatus: http.StatusBadRequest,
+			nonceLeft:  true,
+		},
+		{
+			name:       "issued in the future",
+			edit:       func(m *utils.SIWSMessage) { m.IssuedAt = m.IssuedAt.Add(2 * walletClockSkew) },
+			wantStatus: http.StatusBadRequest,
+			nonceLeft:  true,
+		},
+		{
+			name:       "past its expiration time",
+			edit:       func(m *utils.SIWSMessage) { m.ExpiresAt = m.IssuedAt.Add(-time.Second) },
+			wantStatus: http.StatusBadRequest,
+			nonceLeft:  true,
+		},
+		{
+			name:       "unknown nonce",
+			edit:       func(m *utils.SIWSMessage) { m.Nonce = "not-issued" },
+			wantStatus: http.StatusBadRequest,
+			nonceLeft:  true,
+		},
+		{
+			name: "tampered message",
+			tamper: func(raw string) string {
+				return strings.Replace(raw, "Sign in to TrenchJobs", "Sign in to TrenchJobs and approve a transfer", 1)
+			},
+			wantStatus: http.StatusUnauthorized,
+			nonceLeft:  true,
+		},
+		{
+			name:       "malformed message",
+			tamper:     func(raw string) string { return strings.SplitN(raw, "\n", 2)[1] },
+			wantStatus: http.StatusBadRequest,
+			nonceLeft:  true,
+		},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		f := newAuthFixture()
+		challenge, err := f.service.GetNonce(ctx, wallet)
+		if err != nil {
+			t.Fatalf("%s: GetNonce: %v", tt.name, err)
+		}
+
+		msg, err := utils.ParseSIWSMessage(challenge.Message)
+		if err != nil {
+			t.Fatalf("%s: ParseSIWSMessage: %v", tt.name, err)
+		}
+		if tt.edit != nil {
+			tt.edit(msg)
+		}
+		raw := msg.String()
+		signature := utils.EncodeBase58(ed25519.Sign(priv, []byte(raw)))
+		if tt.tamper != nil {
+			raw = tt.tamper(raw)
+		}
+		presented := wallet
+		if tt.wallet != "" {
+			presented = tt.wallet
+			f.nonces.nonces[presented] = challenge.Nonce
+		}
+
+		err = f.service.verifyWalletSignature(ctx, presented, raw, signature)
+		got := http.StatusOK
+		if err != nil {
+			got = statusOf(err)
+		}
+		if got != tt.wantStatus {
+			t.Errorf("%s: verifyWalletSignature = %v (status %d), want status %d", tt.name, err, got, tt.wantStatus)
+		}
+		if _, left := f.nonces.nonces[presented]; left != tt.nonceLeft {
+			t.Errorf("%s: nonce left = %v, want %v", tt.name, left, tt.nonceLeft)
+		}
+	}
+}
+
+func TestVerifyWalletSignatureNonceIsSingleUse(t *testing.T) {
+	ctx := context.Background()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	wallet := utils.EncodeBase58(pub)
+
+	f := newAuthFixture()
+	challenge, err := f.service.GetNonce(ctx, wallet)
+	if err != nil {
+		t.Fatalf("GetNonce: %v", err)
+	}
+	signature := utils.EncodeBase58(ed25519.Sign(priv, []byte(challenge.Message)))
+
+	if err := f.service.verifyWalletSignature(ctx, wallet, challenge.Message, signature); err != nil {
+		t.Fatalf("first verifyWalletSignature: %v", err)
+	}
+	err = f.service.verifyWalletSignature(ctx, wallet, challenge.Message, signature)
+	if statusOf(err) != http.StatusBadRequest {
+		t.Errorf("replayed verifyWalletSignature = %v, want the nonce rejected", err)
+	}
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	ctx := context.Background()
+	f := newAuthFixture()
+
+	login, err := f.service.issueSession(ctx, f.user, SessionMeta{}, nil)
+	if err != nil {
+		t.Fatalf("issueSession: %v", err)
+	}
+
+	first, err := f.service.RefreshToken(ctx, &RefreshTokenRequest{RefreshToken: login.RefreshToken})
+	if err != nil {
+		t.Fatalf("first RefreshToken: %v", err)
+	}
+	if first.RefreshToken == "" || first.RefreshToken == login.RefreshToken || first.Token == "" {
+		t.Fatalf("first RefreshToken = %+v, want a new access and refresh token", first)
+	}
+	second, err := f.service.RefreshToken(ctx, &RefreshTokenRequest{RefreshToken: first.RefreshToken})
+	if err != nil {
+		t.Fatalf("second RefreshToken: %v", err)
+	}
+
+	// Each spent token points at the one that replaced it
+	if len(f.tokens.tokens) != 3 {
+		t.Fatalf("stored %d refresh tokens, want 3", len(f.tokens.tokens))
+	}
+	for i, token := range f.tokens.tokens[:2] {
+		if token.UsedAt == nil || token.ReplacedBy == nil || *token.ReplacedBy != f.tokens.tokens[i+1].ID {
+			t.Errorf("token %d = %+v, want spent and replaced by token %d", i, token, i+1)
+		}
+	}
+	if f.tokens.tokens[2].UsedAt != nil {
+		t.Errorf("latest token is already spent")
+	}
+
+	// Replaying a spent token revokes the session, taking the latest token with it
+	_, err = f.service.RefreshToken(ctx, &RefreshTokenRequest{RefreshToken: login.RefreshToken})
+	if statusOf(err) != http.StatusUnauthorized {
+		t.Fatalf("replayed RefreshToken = %v, want unauthorized", err)
+	}
+	if len(f.sessions.sessions) != 0 {
+		t.Errorf("%d sessions left after reuse, want the family revoked", len(f.sessions.sessions))
+	}
+	_, err = f.service.RefreshToken(ctx, &RefreshTokenRequest{RefreshToken: second.RefreshToken})
+	if statusOf(err) != http.StatusUnauthorized {
+		t.Errorf("RefreshToken with the latest token after reuse = %v, want unauthorized", err)
+	}
+}
+
+func TestRefreshTokenRejects(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		token      func(f *authFixture, issued string) string
+		wantStatus int
+		// revoked is whether the session is expected to be gone afterwards
+		revoked bool
+	}{
+		{
+			name:       "missing",
+			token:      func(*authFixture, string) string { return "" },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown",
+			token:      func(*authFixture, string) string { return "not-a-token" },
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "expired",
+			token: func(f *authFixture, issued string) string {
+				f.tokens.tokens[0].ExpiresAt = time.Now().Add(-time.Minute)
+				return issued
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "session revoked",
+			token: func(f *authFixture, issued string) string {
+				f.sessions.sessions = map[uuid.UUID]*domain.AuthSession{}
+				return issued
+			},
+			wantStatus: http.StatusUnauthorized,
+			revoked:    true,
+		},
+		{
+			name: "account suspended",
+			token: func(f *authFixture, issued string) string {
+				f.user.AccountStatus = domain.AccountStatusSuspended
+				return issued
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "spent",
+			token: func(f *authFixture, issued string) string {
+				now := time.Now()
+				f.tokens.tokens[0].UsedAt = &now
+				return issued
+			},
+			wantStatus: http.StatusUnauthorized,
+			revoked:    true,
+		},
+	}
+	for _, tt := range tests {
+		f := newAuthFixture()
+		login, err := f.service.issueSession(ctx, f.user, SessionMeta{}, nil)
+		if err != nil {
+			t.Fatalf("%s: issueSession: %v", tt.name, err)
+		}
+
+		resp, err := f.service.RefreshToken(ctx, &RefreshTokenRequest{RefreshToken: tt.token(f, login.RefreshToken)})
+		if err == nil || statusOf(err) != tt.wantStatus {
+			t.Errorf("%s: RefreshToken = %+v, %v; want status %d", tt.name, resp, err, tt.wantStatus)
+		}
+		if revoked := len(f.sessions.sessions) == 0; revoked != tt.revoked {
+			t.Errorf("%s: session revoked = %v, want %v", tt.name, revoked, tt.revoked)
+		}
+		if len(f.tokens.tokens) != 1 {
+			t.Errorf("%s: %d refresh tokens stored, want no new token", tt.name, len(f.tokens.tokens))
+		}
+	}
+}
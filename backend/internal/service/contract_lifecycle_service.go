@@ -0,0 +1,612 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/trenchjob/backend/internal/domain"
+	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+// cancellationNoticePeriod is how long a unilateral cancellation waits
+// before it takes effect
+const cancellationNoticePeriod = 7 * 24 * time.Hour
+
+// dueCancellationBatch caps how many expired notices one pass executes
+const dueCancellationBatch = 50
+
+// ContractLifecycleService pauses, resumes and cancels contracts. Either
+// party can pause an active contract and resume it. A contract is cancelled
+// by mutual agreement, or unilaterally with notice; one whose escrow holds
+// nothing yet is cancelled straight away. Cancelling cancels the milestones
+// not yet started or not covered by the escrow; submitted and funded work
+// in progress can still be approved and paid, and whatever the escrow holds
+// beyond those milestones goes back to the client.
+type ContractLifecycleService struct {
+	contractRepo        repository.ContractRepository
+	milestoneRepo       repository.MilestoneRepository
+	escrowRepo          repository.EscrowRepository
+	jobRepo             repository.JobRepository
+	statusChangeRepo    repository.ContractStatusChangeRepository
+	cancellationRepo    repository.ContractCancellationRepository
+	tx                  repository.Transactor
+	escrowService       *EscrowService
+	notificationService *NotificationService
+}
+
+func NewContractLifecycleService(
+	contractRepo repository.ContractRepository,
+	milestoneRepo repository.MilestoneRepository,
+	escrowRepo repository.EscrowRepository,
+	jobRepo repository.JobRepository,
+	statusChangeRepo repository.ContractStatusChangeRepository,
+	cancellationRepo repository.ContractCancellationRepository,
+	tx repository.Transactor,
+	escrowService *EscrowService,
+	notificationService *NotificationService,
+) *ContractLifecycleService {
+	return &ContractLifecycleService{
+		contractRepo:        contractRepo,
+		milestoneRepo:       milestoneRepo,
+		escrowRepo:          escrowRepo,
+		jobRepo:             jobRepo,
+		statusChangeRepo:    statusChangeRepo,
+		cancellationRepo:    cancellationRepo,
+		tx:                  tx,
+		escrowService:       escrowService,
+		notificationService: notificationService,
+	}
+}
+
+// ContractStatusRequest carries the reason for a pause or resume
+type ContractStatusRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CancelContractRequest asks to end a contract early
+type CancelContractRequest struct {
+	Mode   string `json:"mode"`
+	Reason string `json:"reason"`
+}
+
+// CancellationResponse is a cancellation request and, once the contract is
+// cancelled, what the client gets back from escrow. RefundTransaction is
+// the unsigned refund, built when the client is the caller.
+type CancellationResponse struct {
+	Cancellation      *domain.ContractCancellation `json:"cancellation"`
+	Contract          *domain.Contract             `json:"contract"`
+	RefundSOL         *decimal.Decimal             `json:"refund_sol,omitempty"`
+	RefundTransaction *UnsignedTransactionResponse `json:"refund_transaction,omitempty"`
+}
+
+// PauseContract puts an active contract on hold
+func (s *ContractLifecycleService) PauseContract(ctx context.Context, contractID, userID uuid.UUID, req *ContractStatusRequest) (*domain.Contract, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, apperrors.NewBadRequest("reason is required")
+	}
+
+	contract, err := s.partyContract(ctx, contractID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureNotDisputed(contract); err != nil {
+		return nil, err
+	}
+	if contract.Status != domain.ContractStatusActive {
+		return nil, apperrors.NewBadRequest("only an active contract can be paused")
+	}
+
+	if err := s.changeStatus(ctx, contract, domain.ContractStatusPaused, &userID, &reason); err != nil {
+		return nil, err
+	}
+	s.notificationService.PublishContractUpdate(ctx, contract)
+
+	if err := s.notificationService.NotifyContractPaused(ctx, otherParty(contract, userID), contract.ID, reason); err != nil {
+		fmt.Printf("Failed to notify of paused contract %s: %v\n", contract.ID, err)
+	}
+
+	return contract, nil
+}
+
+// ResumeContract puts a paused contract back to work
+func (s *ContractLifecycleService) ResumeContract(ctx context.Context, contractID, userID uuid.UUID, req *ContractStatusRequest) (*domain.Contract, error) {
+	contract, err := s.partyContract(ctx, contractID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if contract.Status != domain.ContractStatusPaused {
+		return nil, apperrors.NewBadRequest("contract is not paused")
+	}
+
+	var reason *string
+	if r := strings.TrimSpace(req.Reason); r != "" {
+		reason = &r
+	}
+	if err := s.changeStatus(ctx, contract, domain.ContractStatusActive, &userID, reason); err != nil {
+		return nil, err
+	}
+	s.notificationService.PublishContractUpdate(ctx, contract)
+
+	if err := s.notificationService.NotifyContractResumed(ctx, otherParty(contract, userID), contract.ID); err != nil {
+		fmt.Printf("Failed to notify of resumed contract %s: %v\n", contract.ID, err)
+	}
+
+	return contract, nil
+}
+
+// RequestCancellation opens a mutual or notice cancellation, or cancels
+// outright when the escrow holds no funds
+func (s *ContractLifecycleService) RequestCancellation(ctx context.Context, contractID, userID uuid.UUID, req *CancelContractRequest) (*CancellationResponse, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, apperrors.NewBadRequest("reason is required")
+	}
+	if req.Mode != domain.CancellationModeMutual && req.Mode != domain.CancellationModeNotice {
+		return nil, apperrors.NewBadRequest("mode must be mutual or notice")
+	}
+
+	contract, err := s.partyContract(ctx, contractID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureNotDisputed(contract); err != nil {
+		return nil, err
+	}
+	if !cancellable(contract) {
+		return nil, apperrors.NewBadRequest("contract has already ended")
+	}
+
+	_, err = s.cancellationRepo.GetPending(ctx, contract.ID)
+	if err == nil {
+		return nil, apperrors.NewConflict("a cancellation request is already open on this contract")
+	}
+	if !errors.Is(err, apperrors.ErrNotFound) {
+		return nil, apperrors.NewInternal(err)
+	}
+
+	cancellation := &domain.ContractCancellation{
+		ContractID:  contract.ID,
+		RequestedBy: userID,
+		Mode:        req.Mode,
+		Reason:      reason,
+		Status:      domain.CancellationStatusPending,
+	}
+
+	funded, err := s.escrowFunded(ctx, contract.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !funded {
+		err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			if err := s.cancellationRepo.Create(ctx, cancellation); err != nil {
+				return apperrors.NewInternal(err)
+			}
+			return s.execute(ctx, contract, cancellation, &userID)
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.notifyCancelled(ctx, contract)
+		return s.cancellationResponse(ctx, contract, cancellation, userID), nil
+	}
+
+	var message string
+	if req.Mode == domain.CancellationModeNotice {
+		effectiveAt := time.Now().Add(cancellationNoticePeriod)
+		cancellation.EffectiveAt = &effectiveAt
+		message = "The other party has given notice; the contract will be cancelled on " + effectiveAt.UTC().Format("2006-01-02") + ": " + reason
+	} else {
+		message = "The other party has asked to cancel the contract: " + reason
+	}
+	if err := s.cancellationRepo.Create(ctx, cancellation); err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+
+	if err := s.notificationService.NotifyCancellationRequested(ctx, otherParty(contract, userID), contract.ID, message); err != nil {
+		fmt.Printf("Failed to notify of cancellation request %s: %v\n", cancellation.ID, err)
+	}
+
+	return &CancellationResponse{Cancellation: cancellation, Contract: contract}, nil
+}
+
+// GetCancellation returns the contract's open cancellation request
+func (s *ContractLifecycleService) GetCancellation(ctx context.Context, contractID, userID uuid.UUID) (*domain.ContractCancellation, error) {
+	contract, err := s.partyContract(ctx, contractID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.pendingCancellation(ctx, contract.ID)
+}
+
+// AcceptCancellation lets the other party agree to a cancellation, which
+// also cuts a notice period short
+func (s *ContractLifecycleService) AcceptCancellation(ctx context.Context, contractID, userID uuid.UUID) (*CancellationResponse, error) {
+	contract, err := s.partyContract(ctx, contractID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureNotDisputed(contract); err != nil {
+		return nil, err
+	}
+	if !cancellable(contract) {
+		return nil, apperrors.NewBadRequest("contract has already ended")
+	}
+	cancellation, err := s.pendingCancellation(ctx, contract.ID)
+	if err != nil {
+		return nil, err
+	}
+	if cancellation.RequestedBy == userID {
+		return nil, apperrors.NewForbidden("the other party must accept this cancellation")
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		return s.execute(ctx, contract, cancellation, &userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notifyCancelled(ctx, contract)
+
+	return s.cancellationResponse(ctx, contract, cancellation, userID), nil
+}
+
+// DeclineCancellation turns down a mutual cancellation. Notice cannot be
+// declined.
+func (s *ContractLifecycleService) DeclineCancellation(ctx context.Context, contractID, userID uuid.UUID) (*domain.ContractCancellation, error) {
+	contract, err := s.partyContract(ctx, contractID, userID)
+	if err != nil {
+		return nil, err
+	}
+	cancellation, err := s.pendingCancellation(ctx, contract.ID)
+	if err != nil {
+		return nil, err
+	}
+	if cancellation.RequestedBy == userID {
+		return nil, apperrors.NewBadRequest("withdraw your own request instead of declining it")
+	}
+	if cancellation.Mode == domain.CancellationModeNotice {
+		return nil, apperrors.NewBadRequest("a cancellation with notice cannot be declined")
+	}
+
+	return cancellation, s.respond(ctx, cancellation, domain.CancellationStatusDeclined, userID)
+}
+
+// WithdrawCancellation lets the requester take back an open request
+func (s *ContractLifecycleService) WithdrawCancellation(ctx context.Context, contractID, userID uuid.UUID) (*domain.ContractCancellation, error) {
+	contract, err := s.partyContract(ctx, contractID, userID)
+	if err != nil {
+		return nil, err
+	}
+	cancellation, err := s.pendingCancellation(ctx, contract.ID)
+	if err != nil {
+		return nil, err
+	}
+	if cancellation.RequestedBy != userID {
+		return nil, apperrors.NewForbidden("only the requester can withdraw this cancellation")
+	}
+
+	return cancellation, s.respond(ctx, cancellation, domain.CancellationStatusWithdrawn, userID)
+}
+
+// GetStatusHistory returns the contract's pauses, resumes and cancellation
+func (s *ContractLifecycleService) GetStatusHistory(ctx context.Context, contractID, userID uuid.UUID) ([]domain.ContractStatusChange, error) {
+	if _, err := s.partyContract(ctx, contractID, userID); err != nil {
+		return nil, err
+	}
+
+	changes, err := s.statusChangeRepo.ListByContract(ctx, contractID)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+	return changes, nil
+}
+
+// Run cancels contracts whose notice period has run out, every interval
+// until ctx is cancelled
+func (s *ContractLifecycleService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.ExecuteDueCancellations(ctx)
+		}
+	}
+}
+
+// ExecuteDueCancellations cancels contracts whose notice period has run
+// out. A contract under dispute waits until the dispute is settled, and a
+// notice on a contract that has since ended is closed instead.
+func (s *ContractLifecycleService) ExecuteDueCancellations(ctx context.Context) {
+	due, err := s.cancellationRepo.ListDue(ctx, time.Now(), dueCancellationBatch)
+	if err != nil {
+		log.Printf("contract lifecycle: failed to list due cancellations: %v", err)
+		return
+	}
+
+	for i := range due {
+		cancellation := &due[i]
+		contract, err := s.contractRepo.GetByID(ctx, cancellation.ContractID)
+		if err != nil {
+			log.Printf("contract lifecycle: failed to load contract %s: %v", cancellation.ContractID, err)
+			continue
+		}
+		if contract.Status == domain.ContractStatusDisputed {
+			continue
+		}
+		if !cancellable(contract) {
+			if err := closePendingCancellation(ctx, s.cancellationRepo, contract.ID); err != nil {
+				log.Printf("contract lifecycle: failed to close cancellation on contract %s: %v", contract.ID, err)
+			}
+			continue
+		}
+
+		err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			return s.execute(ctx, contract, cancellation, nil)
+		})
+		if err != nil {
+			log.Printf("contract lifecycle: failed to cancel contract %s: %v", contract.ID, err)
+			continue
+		}
+		s.notifyCancelled(ctx, contract)
+	}
+}
+
+// execute closes the request and cancels the contract. actor is nil when
+// a notice ran out.
+func (s *ContractLifecycleService) execute(ctx context.Context, contract *domain.Contract, cancellation *domain.ContractCancellation, actor *uuid.UUID) error {
+	now := time.Now()
+	cancellation.Status = domain.CancellationStatusExecuted
+	cancellation.RespondedBy = actor
+	cancellation.RespondedAt = &now
+	if err := s.cancellationRepo.Update(ctx, cancellation); err != nil {
+		return saveError(err)
+	}
+
+	milestones, err := s.milestoneRepo.GetByContractID(ctx, contract.ID)
+	if err != nil {
+		return apperrors.NewInternal(err)
+	}
+	funded := decimal.Zero
+	escrow, err := s.escrowRepo.GetByContractID(ctx, contract.ID)
+	if err == nil {
+		funded = escrow.FundedAmountSOL
+	} else if !errors.Is(err, apperrors.ErrNotFound) {
+		return apperrors.NewInternal(err)
+	}
+	for i, m := range milestones {
+		if !cancelsWithContract(milestones, i, funded) {
+			continue
+		}
+		m.Status = domain.MilestoneStatusCancelled
+		if err := s.milestoneRepo.Update(ctx, &m); err != nil {
+			return saveError(err)
+		}
+	}
+
+	job, err := s.jobRepo.GetByID(ctx, contract.JobID)
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		return apperrors.NewInternal(err)
+	}
+	if job != nil && job.Status == domain.JobStatusInProgress {
+		job.Status = domain.JobStatusCancelled
+		if err := s.jobRepo.Update(ctx, job); err != nil {
+			return apperrors.NewInternal(err)
+		}
+	}
+
+	contract.EndedAt = &now
+	reason := cancellation.Mode + " cancellation: " + cancellation.Reason
+	return s.changeStatus(ctx, contract, domain.ContractStatusCancelled, actor, &reason)
+}
+
+// changeStatus moves the contract to status and records who did it and why
+func (s *ContractLifecycleService) changeStatus(ctx context.Context, contract *domain.Contract, status string, actor *uuid.UUID, reason *string) error {
+	change := &domain.ContractStatusChange{
+		ContractID: contract.ID,
+		FromStatus: contract.Status,
+		ToStatus:   status,
+		ChangedBy:  actor,
+		Reason:     reason,
+	}
+	contract.Status = status
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.contractRepo.Update(ctx, contract); err != nil {
+			return saveError(err)
+		}
+		if err := s.statusChangeRepo.Create(ctx, change); err != nil {
+			return apperrors.NewInternal(err)
+		}
+		return nil
+	})
+}
+
+func (s *ContractLifecycleService) respond(ctx context.Context, cancellation *domain.ContractCancellation, status string, userID uuid.UUID) error {
+	now := time.Now()
+	cancellation.Status = status
+	cancellation.RespondedBy = &userID
+	cancellation.RespondedAt = &now
+	if err := s.cancellationRepo.Update(ctx, cancellation); err != nil {
+		return saveError(err)
+	}
+	return nil
+}
+
+// cancellationResponse reports the refund owed on a cancelled contract and,
+// when the client is asking, builds the refund transaction for them to
+// sign. Building it can fail on RPC trouble without undoing the
+// cancellation; the client can build it again from the escrow routes.
+func (s *ContractLifecycleService) cancellationResponse(ctx context.Context, contract *domain.Contract, cancellation *domain.ContractCancellation, userID uuid.UUID) *CancellationResponse {
+	resp := &CancellationResponse{Cancellation: cancellation, Contract: contract}
+
+	escrow, err := s.escrowRepo.GetByContractID(ctx, contract.ID)
+	if err != nil {
+		if !errors.Is(err, apperrors.ErrNotFound) {
+			fmt.Printf("Failed to load escrow for cancelled contract %s: %v\n", contract.ID, err)
+		}
+		return resp
+	}
+	milestones, err := s.milestoneRepo.GetByContractID(ctx, contract.ID)
+	if err != nil {
+		fmt.Printf("Failed to load milestones for cancelled contract %s: %v\n", contract.ID, err)
+		return resp
+	}
+
+	refund := cancellationRefund(escrow, milestones)
+	resp.RefundSOL = &refund
+	if refund.IsPositive() && userID == contract.ClientID {
+		tx, err := s.escrowService.BuildRefund(ctx, contract.ID, userID)
+		if err != nil {
+			fmt.Printf("Failed to build refund for cancelled contract %s: %v\n", contract.ID, err)
+			return resp
+		}
+		resp.RefundTransaction = tx
+	}
+	return resp
+}
+
+func (s *ContractLifecycleService) notifyCancelled(ctx context.Context, contract *domain.Contract) {
+	s.notificationService.PublishContractUpdate(ctx, contract)
+	for _, userID := range []uuid.UUID{contract.ClientID, contract.FreelancerID} {
+		if err := s.notificationService.NotifyContractCancelled(ctx, userID, contract.ID); err != nil {
+			fmt.Printf("Failed to notify of cancelled contract %s: %v\n", contract.ID, err)
+		}
+	}
+}
+
+// escrowFunded reports whether any money has reached the contract's escrow
+func (s *ContractLifecycleService) escrowFunded(ctx context.Context, contractID uuid.UUID) (bool, error) {
+	escrow, err := s.escrowRepo.GetByContractID(ctx, contractID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return false, nil
+		}
+		return false, apperrors.NewInternal(err)
+	}
+	return escrow.FundedAmountSOL.IsPositive(), nil
+}
+
+func (s *ContractLifecycleService) pendingCancellation(ctx context.Context, contractID uuid.UUID) (*domain.ContractCancellation, error) {
+	cancellation, err := s.cancellationRepo.GetPending(ctx, contractID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewNotFound("cancellation request")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	return cancellation, nil
+}
+
+func (s *ContractLifecycleService) partyContract(ctx context.Context, contractID, userID uuid.UUID) (*domain.Contract, error) {
+	contract, err := s.contractRepo.GetByID(ctx, contractID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewNotFound("contract")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	if contract.ClientID != userID && contract.FreelancerID != userID {
+		return nil, apperrors.NewForbidden("you are not part of this contract")
+	}
+	return contract, nil
+}
+
+// closePendingCancellation closes the contract's open cancellation request,
+// if any, once the contract has ended some other way
+func closePendingCancellation(ctx context.Context, cancellationRepo repository.ContractCancellationRepository, contractID uuid.UUID) error {
+	cancellation, err := cancellationRepo.GetPending(ctx, contractID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil
+		}
+		return apperrors.NewInternal(err)
+	}
+
+	now := time.Now()
+	cancellation.Status = domain.CancellationStatusClosed
+	cancellation.RespondedAt = &now
+	if err := cancellationRepo.Update(ctx, cancellation); err != nil {
+		return saveError(err)
+	}
+	return nil
+}
+
+// cancellable reports whether the contract is still running and so can be
+// cancelled
+func cancellable(contract *domain.Contract) bool {
+	switch contract.Status {
+	case domain.ContractStatusPending, domain.ContractStatusActive, domain.ContractStatusPaused:
+		return true
+	}
+	return false
+}
+
+// cancelsWithContract reports whether cancelling the contract cancels the
+// i-th milestone: one not yet started, or work in progress the escrow does
+// not cover. Escrow funds milestones in order, so a milestone is covered
+// when funded reaches the running total up to and including it. Submitted,
+// approved and paid milestones are kept.
+func cancelsWithContract(milestones []domain.Milestone, i int, funded decimal.Decimal) bool {
+	switch milestones[i].Status {
+	case domain.MilestoneStatusPending:
+		return true
+	case domain.MilestoneStatusInProgress, domain.MilestoneStatusRevisionRequested:
+		total := decimal.Zero
+		for _, m := range milestones[:i+1] {
+			total = total.Add(m.AmountSOL)
+		}
+		return funded.LessThan(total)
+	}
+	return false
+}
+
+// cancellationRefund is what a cancelled contract's escrow owes the client:
+// everything left except milestones the cancellation kept, which are still
+// owed to the freelancer once approved
+func cancellationRefund(escrow *domain.Escrow, milestones []domain.Milestone) decimal.Decimal {
+	refund := escrowRemaining(escrow)
+	for _, m := range milestones {
+		if keptUnpaid(&m) {
+			refund = refund.Sub(m.AmountSOL)
+		}
+	}
+	if refund.IsNegative() {
+		return decimal.Zero
+	}
+	return refund
+}
+
+// keptUnpaid reports whether a milestone of a cancelled contract survived
+// the cancellation and is still owed to the freelancer. Such milestones can
+// still be submitted, approved (by the client or the review timer) and
+// disputed.
+func keptUnpaid(m *domain.Milestone) bool {
+	if m.PaymentID != nil {
+		return false
+	}
+	switch m.Status {
+	case domain.MilestoneStatusInProgress, domain.MilestoneStatusRevisionRequested,
+		domain.MilestoneStatusSubmitted, domain.MilestoneStatusApproved:
+		return true
+	}
+	return false
+}
+
+func otherParty(contract *domain.Contract, userID uuid.UUID) uuid.UUID {
+	if userID == contract.ClientID {
+		return contract.FreelancerID
+	}
+	return contract.ClientID
+}
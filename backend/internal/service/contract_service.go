@@ -2,6 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,32 +15,49 @@ import (
 )
 
 type ContractService struct {
-	contractRepo  repository.ContractRepository
-	milestoneRepo repository.MilestoneRepository
-	escrowRepo    repository.EscrowRepository
-	paymentRepo   repository.PaymentRepository
-	proposalRepo  repository.ProposalRepository
-	jobRepo       repository.JobRepository
-	userRepo      repository.UserRepository
+	contractRepo    repository.ContractRepository
+	milestoneRepo   repository.MilestoneRepository
+	revisionRepo    repository.MilestoneRevisionRepository
+	escrowRepo      repository.EscrowRepository
+	paymentRepo     repository.PaymentRepository
+	proposalRepo    repository.ProposalRepository
+	jobRepo         repository.JobRepository
+	userRepo        repository.UserRepository
+	tx              repository.Transactor
+	settingsService *SettingsService
+
+	notificationService *NotificationService
+	cancellationRepo    repository.ContractCancellationRepository
 }
 
 func NewContractService(
 	contractRepo repository.ContractRepository,
 	milestoneRepo repository.MilestoneRepository,
+	revisionRepo repository.MilestoneRevisionRepository,
 	escrowRepo repository.EscrowRepository,
 	paymentRepo repository.PaymentRepository,
 	proposalRepo repository.ProposalRepository,
 	jobRepo repository.JobRepository,
 	userRepo repository.UserRepository,
+	tx repository.Transactor,
+	settingsService *SettingsService,
+	notificationService *NotificationService,
+	cancellationRepo repository.ContractCancellationRepository,
 ) *ContractService {
 	return &ContractService{
-		contractRepo:  contractRepo,
-		milestoneRepo: milestoneRepo,
-		escrowRepo:    escrowRepo,
-		paymentRepo:   paymentRepo,
-		proposalRepo:  proposalRepo,
-		jobRepo:       jobRepo,
-		userRepo:      userRepo,
+		contractRepo:    contractRepo,
+		milestoneRepo:   milestoneRepo,
+		revisionRepo:    revisionRepo,
+		escrowRepo:      escrowRepo,
+		paymentRepo:     paymentRepo,
+		proposalRepo:    proposalRepo,
+		jobRepo:         jobRepo,
+		userRepo:        userRepo,
+		tx:              tx,
+		settingsService: settingsService,
+
+		notificationService: notificationService,
+		cancellationRepo:    cancellationRepo,
 	}
 }
 
@@ -47,6 +67,12 @@ type CreateContractRequest struct {
 	Title       string              `json:"title"`
 	Description *string             `json:"description,omitempty"`
 	Milestones  []CreateMilestoneRequest `json:"milestones"`
+
+	// Hourly jobs only. The rate defaults to the proposed rate and the
+	// budget, which is what gets escrowed, to the proposed amount.
+	HourlyRateSOL   *decimal.Decimal `json:"hourly_rate_sol,omitempty"`
+	WeeklyHourLimit *int             `json:"weekly_hour_limit,omitempty"`
+	BudgetSOL       *decimal.Decimal `json:"budget_sol,omitempty"`
 }
 
 type CreateMilestoneRequest struct {
@@ -54,6 +80,10 @@ type CreateMilestoneRequest struct {
 	Description *string         `json:"description,omitempty"`
 	AmountSOL   decimal.Decimal `json:"amount_sol"`
 	DueDate     *time.Time      `json:"due_date,omitempty"`
+
+	// MaxRevisions caps how many times the client may send the milestone
+	// back. Unlimited when omitted.
+	MaxRevisions *int `json:"max_revisions,omitempty"`
 }
 
 // ContractResponse represents a contract response with related data
@@ -88,8 +118,36 @@ func (s *ContractService) HireFreelancer(ctx context.Context, clientID uuid.UUID
 		return nil, apperrors.NewBadRequest("job is not open for hiring")
 	}
 
+	// Hourly contracts escrow a budget up front and bill it from approved
+	// timesheets instead of fixed milestones
+	var hourlyRate, budget *decimal.Decimal
+	var weeklyLimit *int
+	if job.PaymentType == domain.PaymentTypeHourly {
+		if len(req.Milestones) > 0 {
+			return nil, apperrors.NewBadRequest("hourly contracts are billed from timesheets and take no milestones")
+		}
+		hourlyRate = req.HourlyRateSOL
+		if hourlyRate == nil {
+			hourlyRate = proposal.ProposedRateSOL
+		}
+		if hourlyRate == nil || !hourlyRate.IsPositive() {
+			return nil, apperrors.NewBadRequest("hourly_rate_sol is required for hourly contracts")
+		}
+		budget = req.BudgetSOL
+		if budget == nil {
+			budget = proposal.ProposedAmountSOL
+		}
+		if budget == nil || !budget.IsPositive() {
+			return nil, apperrors.NewBadRequest("budget_sol is required for hourly contracts")
+		}
+		if req.WeeklyHourLimit != nil && (*req.WeeklyHourLimit < 1 || *req.WeeklyHourLimit > 168) {
+			return nil, apperrors.NewBadRequest("weekly_hour_limit must be between 1 and 168")
+		}
+		weeklyLimit = req.WeeklyHourLimit
+	}
+
 	// If no milestones provided, create a default one using the proposal's rate
-	if len(req.Milestones) == 0 {
+	if len(req.Milestones) == 0 && hourlyRate == nil {
 		amount := decimal.Zero
 		if proposal.ProposedRateSOL != nil {
 			amount = *proposal.ProposedRateSOL
@@ -105,8 +163,17 @@ func (s *ContractService) HireFreelancer(ctx context.Context, clientID uuid.UUID
 	// Calculate total amount from milestones
 	totalAmount := decimal.Zero
 	for _, m := range req.Milestones {
+		if m.MaxRevisions != nil && *m.MaxRevisions < 0 {
+			return nil, apperrors.NewBadRequest("max_revisions cannot be negative")
+		}
 		totalAmount = totalAmount.Add(m.AmountSOL)
 	}
+	if budget != nil {
+		totalAmount = *budget
+	}
+	if err := s.settingsService.CheckMinEscrow(totalAmount); err != nil {
+		return nil, err
+	}
 
 	// Skip wallet validation in test mode
 	// Note: In production, verify both client and freelancer have wallets
@@ -129,44 +196,54 @@ func (s *ContractService) HireFreelancer(ctx context.Context, clientID uuid.UUID
 		Description:       req.Description,
 		PaymentType:       job.PaymentType,
 		TotalAmountSOL:    totalAmount,
+		HourlyRateSOL:     hourlyRate,
+		WeeklyHourLimit:   weeklyLimit,
 		EscrowAmountSOL:   decimal.Zero,
 		ReleasedAmountSOL: decimal.Zero,
 		Status:            domain.ContractStatusPending,
 	}
 
-	if err := s.contractRepo.Create(ctx, contract); err != nil {
-		return nil, apperrors.NewInternal(err)
-	}
-
-	// Create milestones
 	var milestones []domain.Milestone
-	for i, m := range req.Milestones {
-		milestone := &domain.Milestone{
-			ContractID:  contract.ID,
-			Title:       m.Title,
-			Description: m.Description,
-			AmountSOL:   m.AmountSOL,
-			DueDate:     m.DueDate,
-			SortOrder:   i + 1,
-			Status:      domain.MilestoneStatusPending,
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.contractRepo.Create(ctx, contract); err != nil {
+			return apperrors.NewInternal(err)
 		}
-		if err := s.milestoneRepo.Create(ctx, milestone); err != nil {
-			return nil, apperrors.NewInternal(err)
+
+		// Create milestones
+		for i, m := range req.Milestones {
+			milestone := &domain.Milestone{
+				ContractID:   contract.ID,
+				Title:        m.Title,
+				Description:  m.Description,
+				AmountSOL:    m.AmountSOL,
+				DueDate:      m.DueDate,
+				SortOrder:    i + 1,
+				Status:       domain.MilestoneStatusPending,
+				MaxRevisions: m.MaxRevisions,
+			}
+			if err := s.milestoneRepo.Create(ctx, milestone); err != nil {
+				return apperrors.NewInternal(err)
+			}
+			milestones = append(milestones, *milestone)
 		}
-		milestones = append(milestones, *milestone)
-	}
 
-	// Update proposal status to accepted
-	proposal.Status = domain.ProposalStatusAccepted
-	if err := s.proposalRepo.Update(ctx, proposal); err != nil {
-		return nil, apperrors.NewInternal(err)
-	}
+		// Update proposal status to accepted
+		proposal.Status = domain.ProposalStatusAccepted
+		if err := s.proposalRepo.Update(ctx, proposal); err != nil {
+			return apperrors.NewInternal(err)
+		}
 
-	// Update job status to in_progress
-	job.Status = domain.JobStatusInProgress
-	if err := s.jobRepo.Update(ctx, job); err != nil {
-		return nil, apperrors.NewInternal(err)
+		// Update job status to in_progress
+		job.Status = domain.JobStatusInProgress
+		if err := s.jobRepo.Update(ctx, job); err != nil {
+			return apperrors.NewInternal(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	s.notificationService.PublishContractUpdate(ctx, contract)
 
 	return &ContractResponse{
 		Contract:   contract,
@@ -192,6 +269,20 @@ func (s *ContractService) GetContract(ctx context.Context, contractID, userID uu
 		return nil, err
 	}
 
+	// Attach each milestone's revision history
+	revisions, err := s.revisionRepo.ListByContract(ctx, contractID)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+	for _, rev := range revisions {
+		for i := range milestones {
+			if milestones[i].ID == rev.MilestoneID {
+				milestones[i].Revisions = append(milestones[i].Revisions, rev)
+				break
+			}
+		}
+	}
+
 	// Get escrow if exists
 	escrow, _ := s.escrowRepo.GetByContractID(ctx, contractID)
 
@@ -276,6 +367,15 @@ func (s *ContractService) AddMilestone(ctx context.Context, contractID, clientID
 	if contract.ClientID != clientID {
 		return nil, apperrors.NewForbidden("you are not the client for this contract")
 	}
+	if err := ensureNotDisputed(contract); err != nil {
+		return nil, err
+	}
+	if contract.PaymentType == domain.PaymentTypeHourly {
+		return nil, apperrors.NewBadRequest("hourly contracts are billed from approved timesheets")
+	}
+	if req.MaxRevisions != nil && *req.MaxRevisions < 0 {
+		return nil, apperrors.NewBadRequest("max_revisions cannot be negative")
+	}
 
 	// Get existing milestones to determine sort order
 	milestones, err := s.milestoneRepo.GetByContractID(ctx, contractID)
@@ -284,24 +384,32 @@ func (s *ContractService) AddMilestone(ctx context.Context, contractID, clientID
 	}
 
 	milestone := &domain.Milestone{
-		ContractID:  contractID,
-		Title:       req.Title,
-		Description: req.Description,
-		AmountSOL:   req.AmountSOL,
-		DueDate:     req.DueDate,
-		SortOrder:   len(milestones) + 1,
-		Status:      domain.MilestoneStatusPending,
+		ContractID:   contractID,
+		Title:        req.Title,
+		Description:  req.Description,
+		AmountSOL:    req.AmountSOL,
+		DueDate:      req.DueDate,
+		SortOrder:    len(milestones) + 1,
+		Status:       domain.MilestoneStatusPending,
+		MaxRevisions: req.MaxRevisions,
 	}
 
-	if err := s.milestoneRepo.Create(ctx, milestone); err != nil {
-		return nil, apperrors.NewInternal(err)
-	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.milestoneRepo.Create(ctx, milestone); err != nil {
+			return apperrors.NewInternal(err)
+		}
 
-	// Update contract total amount
-	contract.TotalAmountSOL = contract.TotalAmountSOL.Add(req.AmountSOL)
-	if err := s.contractRepo.Update(ctx, contract); err != nil {
-		return nil, apperrors.NewInternal(err)
+		// Update contract total amount
+		contract.TotalAmountSOL = contract.TotalAmountSOL.Add(req.AmountSOL)
+		if err := s.contractRepo.Update(ctx, contract); err != nil {
+			return saveError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	s.notificationService.PublishMilestoneUpdate(ctx, contract, milestone)
 
 	return milestone, nil
 }
@@ -328,21 +436,59 @@ func (s *ContractService) SubmitMilestone(ctx context.Context, milestoneID, free
 	if contract.FreelancerID != freelancerID {
 		return nil, apperrors.NewForbidden("you are not the freelancer for this contract")
 	}
+	if err := ensureNotDisputed(contract); err != nil {
+		return nil, err
+	}
+	if contract.Status == domain.ContractStatusPaused {
+		return nil, apperrors.NewBadRequest("contract is paused")
+	}
 
 	// Verify milestone can be submitted
 	if milestone.Status != domain.MilestoneStatusPending && milestone.Status != domain.MilestoneStatusInProgress && milestone.Status != domain.MilestoneStatusRevisionRequested {
 		return nil, apperrors.NewBadRequest("milestone cannot be submitted in current status")
 	}
 
+	// A resubmission closes the open revision round and keeps the work it
+	// replaces
+	var resubmission *domain.MilestoneRevision
+	if milestone.Status == domain.MilestoneStatusRevisionRequested {
+		rounds, err := s.revisionRepo.CountRequested(ctx, milestone.ID)
+		if err != nil {
+			return nil, apperrors.NewInternal(err)
+		}
+		resubmission = &domain.MilestoneRevision{
+			MilestoneID:            milestone.ID,
+			RevisionNumber:         rounds,
+			Action:                 domain.MilestoneRevisionResubmitted,
+			RequestedBy:            freelancerID,
+			PreviousSubmissionText: milestone.SubmissionText,
+			PreviousSubmissionURLs: milestone.SubmissionURLs,
+			PreviousSubmittedAt:    milestone.SubmittedAt,
+		}
+	}
+
 	now := time.Now()
 	milestone.Status = domain.MilestoneStatusSubmitted
 	milestone.SubmissionText = &req.SubmissionText
 	milestone.SubmissionURLs = req.SubmissionURLs
 	milestone.SubmittedAt = &now
+	milestone.AutoApproveNoticeSentAt = nil
 
-	if err := s.milestoneRepo.Update(ctx, milestone); err != nil {
-		return nil, apperrors.NewInternal(err)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.milestoneRepo.Update(ctx, milestone); err != nil {
+			return saveError(err)
+		}
+		if resubmission != nil {
+			if err := s.revisionRepo.Create(ctx, resubmission); err != nil {
+				return apperrors.NewInternal(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	s.notificationService.PublishMilestoneUpdate(ctx, contract, milestone)
 
 	return milestone, nil
 }
@@ -363,6 +509,9 @@ func (s *ContractService) ApproveMilestone(ctx context.Context, milestoneID, cli
 	if contract.ClientID != clientID {
 		return nil, apperrors.NewForbidden("you are not the client for this contract")
 	}
+	if err := ensureNotDisputed(contract); err != nil {
+		return nil, err
+	}
 
 	// Verify milestone is submitted
 	if milestone.Status != domain.MilestoneStatusSubmitted {
@@ -374,8 +523,9 @@ func (s *ContractService) ApproveMilestone(ctx context.Context, milestoneID, cli
 	milestone.ApprovedAt = &now
 
 	if err := s.milestoneRepo.Update(ctx, milestone); err != nil {
-		return nil, apperrors.NewInternal(err)
+		return nil, saveError(err)
 	}
+	s.notificationService.PublishMilestoneUpdate(ctx, contract, milestone)
 
 	return milestone, nil
 }
@@ -383,6 +533,9 @@ func (s *ContractService) ApproveMilestone(ctx context.Context, milestoneID, cli
 // RequestMilestoneRevision requests revision for a submitted milestone
 type RequestRevisionRequest struct {
 	Notes string `json:"notes"`
+
+	// Feedback is what the web app sends; it is used when Notes is empty
+	Feedback string `json:"feedback"`
 }
 
 func (s *ContractService) RequestMilestoneRevision(ctx context.Context, milestoneID, clientID uuid.UUID, req *RequestRevisionRequest) (*domain.Milestone, error) {
@@ -400,17 +553,56 @@ func (s *ContractService) RequestMilestoneRevision(ctx context.Context, mileston
 	if contract.ClientID != clientID {
 		return nil, apperrors.NewForbidden("you are not the client for this contract")
 	}
+	if err := ensureNotDisputed(contract); err != nil {
+		return nil, err
+	}
 
 	// Verify milestone is submitted
 	if milestone.Status != domain.MilestoneStatusSubmitted {
 		return nil, apperrors.NewBadRequest("milestone is not submitted")
 	}
 
-	milestone.Status = domain.MilestoneStatusRevisionRequested
+	notes := strings.TrimSpace(req.Notes)
+	if notes == "" {
+		notes = strings.TrimSpace(req.Feedback)
+	}
+	if notes == "" {
+		return nil, apperrors.NewBadRequest("notes are required")
+	}
 
-	if err := s.milestoneRepo.Update(ctx, milestone); err != nil {
+	rounds, err := s.revisionRepo.CountRequested(ctx, milestone.ID)
+	if err != nil {
 		return nil, apperrors.NewInternal(err)
 	}
+	if milestone.MaxRevisions != nil && rounds >= *milestone.MaxRevisions {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("milestone has used all %d revision rounds", *milestone.MaxRevisions))
+	}
+
+	revision := &domain.MilestoneRevision{
+		MilestoneID:            milestone.ID,
+		RevisionNumber:         rounds + 1,
+		Action:                 domain.MilestoneRevisionRequested,
+		RequestedBy:            clientID,
+		RevisionNotes:          &notes,
+		PreviousSubmissionText: milestone.SubmissionText,
+		PreviousSubmissionURLs: milestone.SubmissionURLs,
+		PreviousSubmittedAt:    milestone.SubmittedAt,
+	}
+	milestone.Status = domain.MilestoneStatusRevisionRequested
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.milestoneRepo.Update(ctx, milestone); err != nil {
+			return saveError(err)
+		}
+		if err := s.revisionRepo.Create(ctx, revision); err != nil {
+			return apperrors.NewInternal(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notificationService.PublishMilestoneUpdate(ctx, contract, milestone)
 
 	return milestone, nil
 }
@@ -435,14 +627,29 @@ func (s *ContractService) ActivateContract(ctx context.Context, contractID uuid.
 	if err != nil {
 		return err
 	}
-	if len(milestones) > 0 {
-		milestones[0].Status = domain.MilestoneStatusInProgress
-		if err := s.milestoneRepo.Update(ctx, &milestones[0]); err != nil {
-			return err
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if len(milestones) > 0 {
+			milestones[0].Status = domain.MilestoneStatusInProgress
+			if err := s.milestoneRepo.Update(ctx, &milestones[0]); err != nil {
+				return saveError(err)
+			}
+		}
+
+		if err := s.contractRepo.Update(ctx, contract); err != nil {
+			return saveError(err)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	return s.contractRepo.Update(ctx, contract)
+	s.notificationService.PublishContractUpdate(ctx, contract)
+	if len(milestones) > 0 {
+		s.notificationService.PublishMilestoneUpdate(ctx, contract, &milestones[0])
+	}
+	return nil
 }
 
 // CompleteContract marks a contract as completed
@@ -456,6 +663,9 @@ func (s *ContractService) CompleteContract(ctx context.Context, contractID, user
 	if contract.ClientID != userID && contract.FreelancerID != userID {
 		return apperrors.NewForbidden("you are not part of this contract")
 	}
+	if err := ensureNotDisputed(contract); err != nil {
+		return err
+	}
 
 	// Check all milestones are paid
 	milestones, err := s.milestoneRepo.GetByContractID(ctx, contractID)
@@ -473,12 +683,45 @@ func (s *ContractService) CompleteContract(ctx context.Context, contractID, user
 	contract.Status = domain.ContractStatusCompleted
 	contract.EndedAt = &now
 
-	// Update the associated job
-	job, _ := s.jobRepo.GetByID(ctx, contract.JobID)
-	if job != nil {
-		job.Status = domain.JobStatusCompleted
-		s.jobRepo.Update(ctx, job)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// Update the associated job
+		job, _ := s.jobRepo.GetByID(ctx, contract.JobID)
+		if job != nil {
+			job.Status = domain.JobStatusCompleted
+			if err := s.jobRepo.Update(ctx, job); err != nil {
+				return err
+			}
+		}
+
+		if err := s.contractRepo.Update(ctx, contract); err != nil {
+			return saveError(err)
+		}
+		return closePendingCancellation(ctx, s.cancellationRepo, contract.ID)
+	})
+	if err != nil {
+		return err
 	}
 
-	return s.contractRepo.Update(ctx, contract)
+	s.notificationService.PublishContractUpdate(ctx, contract)
+	return nil
+}
+
+// saveError reports a versioned update that lost a race with another request
+// as a 409 so the caller can reload and retry; other failures stay a 500
+func saveError(err error) error {
+	if errors.Is(err, apperrors.ErrVersionConflict) {
+		return apperrors.NewConflict("this record was changed by another request; reload it and try again")
+	}
+	if apperrors.IsAppError(err) {
+		return err
+	}
+	return apperrors.NewInternal(err)
+}
+
+// ensureNotDisputed blocks changes to a contract that is frozen by a dispute
+func ensureNotDisputed(contract *domain.Contract) error {
+	if contract.Status == domain.ContractStatusDisputed {
+		return apperrors.NewConflict("contract is frozen while a dispute is open")
+	}
+	return nil
 }
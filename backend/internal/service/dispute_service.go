@@ -0,0 +1,753 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/trenchjob/backend/internal/domain"
+	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
+	"github.com/trenchjob/backend/internal/pkg/validator"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+const maxDisputeEvidence = 20
+
+type DisputeService struct {
+	disputeRepo         repository.DisputeRepository
+	contractRepo        repository.ContractRepository
+	milestoneRepo       repository.MilestoneRepository
+	escrowRepo          repository.EscrowRepository
+	paymentRepo         repository.PaymentRepository
+	auditRepo           repository.AuditLogRepository
+	tx                  repository.Transactor
+	notificationService *NotificationService
+
+	escrowService    *EscrowService
+	cancellationRepo repository.ContractCancellationRepository
+}
+
+func NewDisputeService(
+	disputeRepo repository.DisputeRepository,
+	contractRepo repository.ContractRepository,
+	milestoneRepo repository.MilestoneRepository,
+	escrowRepo repository.EscrowRepository,
+	paymentRepo repository.PaymentRepository,
+	auditRepo repository.AuditLogRepository,
+	tx repository.Transactor,
+	notificationService *NotificationService,
+	escrowService *EscrowService,
+	cancellationRepo repository.ContractCancellationRepository,
+) *DisputeService {
+	return &DisputeService{
+		disputeRepo:         disputeRepo,
+		contractRepo:        contractRepo,
+		milestoneRepo:       milestoneRepo,
+		escrowRepo:          escrowRepo,
+		paymentRepo:         paymentRepo,
+		auditRepo:           auditRepo,
+		tx:                  tx,
+		notificationService: notificationService,
+
+		escrowService:    escrowService,
+		cancellationRepo: cancellationRepo,
+	}
+}
+
+// OpenDisputeRequest represents a request to open a dispute on a contract,
+// or on a single milestone when MilestoneID is set
+type OpenDisputeRequest struct {
+	MilestoneID  *uuid.UUID `json:"milestone_id,omitempty"`
+	Reason       string     `json:"reason"`
+	Description  string     `json:"description"`
+	EvidenceURLs []string   `json:"evidence_urls"`
+}
+
+// AddEvidenceRequest represents additional evidence submitted by a party
+type AddEvidenceRequest struct {
+	EvidenceURLs []string `json:"evidence_urls"`
+}
+
+// ResolveDisputeRequest represents an admin's resolution of a dispute. The
+// refund and payment must together account for the whole disputed amount.
+type ResolveDisputeRequest struct {
+	ResolutionType       string          `json:"resolution_type"`
+	ClientRefundSOL      decimal.Decimal `json:"client_refund_sol"`
+	FreelancerPaymentSOL decimal.Decimal `json:"freelancer_payment_sol"`
+	Notes                string          `json:"notes"`
+}
+
+// ListDisputesResponse represents a page of the admin dispute queue
+type ListDisputesResponse struct {
+	Disputes []domain.Dispute `json:"disputes"`
+	Total    int              `json:"total"`
+	Limit    int              `json:"limit"`
+	Offset   int              `json:"offset"`
+}
+
+// DisputeResolutionResponse is returned when a dispute is resolved
+type DisputeResolutionResponse struct {
+	Dispute  *domain.Dispute  `json:"dispute"`
+	Payments []domain.Payment `json:"payments"`
+}
+
+// OpenDispute opens a dispute and freezes the contract and its escrow
+func (s *DisputeService) OpenDispute(ctx context.Context, contractID, userID uuid.UUID, req *OpenDisputeRequest) (*domain.Dispute, error) {
+	v := validator.New().
+		Required(req.Reason, "reason").
+		Required(req.Description, "description").
+		MaxLength(req.Description, "description", 5000)
+	if !v.Valid() {
+		return nil, apperrors.NewValidation(map[string]interface{}{"errors": v.Errors().ToMap()})
+	}
+
+	switch req.Reason {
+	case domain.DisputeReasonQualityIssue, domain.DisputeReasonNonDelivery,
+		domain.DisputeReasonScopeDisagreement, domain.DisputeReasonPaymentIssue,
+		domain.DisputeReasonOther:
+	default:
+		return nil, apperrors.NewBadRequest("invalid dispute reason")
+	}
+
+	if err := validateEvidenceURLs(req.EvidenceURLs); err != nil {
+		return nil, err
+	}
+
+	contract, err := s.getContract(ctx, contractID)
+	if err != nil {
+		return nil, err
+	}
+
+	if contract.ClientID != userID && contract.FreelancerID != userID {
+		return nil, apperrors.NewForbidden("you are not part of this contract")
+	}
+
+	if err := s.ensureDisputable(ctx, contract); err != nil {
+		return nil, err
+	}
+
+	if req.MilestoneID != nil {
+		milestone, err := s.milestoneRepo.GetByID(ctx, *req.MilestoneID)
+		if err != nil || milestone.ContractID != contract.ID {
+			return nil, apperrors.NewNotFound("milestone")
+		}
+		if milestone.Status == domain.MilestoneStatusPaid || milestone.Status == domain.MilestoneStatusCancelled {
+			return nil, apperrors.NewBadRequest("paid or cancelled milestones cannot be disputed")
+		}
+	}
+
+	existing, err := s.disputeRepo.GetByContractID(ctx, contract.ID)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+	for _, d := range existing {
+		if isDisputeUnresolved(d.Status) {
+			return nil, apperrors.NewConflict("this contract already has an open dispute")
+		}
+	}
+
+	dispute := &domain.Dispute{
+		ContractID:   contract.ID,
+		MilestoneID:  req.MilestoneID,
+		InitiatedBy:  userID,
+		Reason:       req.Reason,
+		Description:  strings.TrimSpace(req.Description),
+		EvidenceURLs: req.EvidenceURLs,
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.disputeRepo.Create(ctx, dispute); err != nil {
+			return apperrors.NewInternal(err)
+		}
+
+		// Freeze the contract and escrow until an admin resolves the dispute
+		contract.Status = domain.ContractStatusDisputed
+		if err := s.contractRepo.Update(ctx, contract); err != nil {
+			return saveError(err)
+		}
+
+		escrow, err := s.getEscrow(ctx, contract.ID)
+		if err != nil {
+			return err
+		}
+		if escrow != nil {
+			escrow.Status = domain.EscrowStatusDisputed
+			if err := s.escrowRepo.Update(ctx, escrow); err != nil {
+				return saveError(err)
+			}
+			s.logEscrow(ctx, escrow.ID, domain.EscrowLogActionDisputed, nil, &userID, "dispute "+dispute.ID.String()+" opened")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notificationService.PublishContractUpdate(ctx, contract)
+
+	otherParty := contract.ClientID
+	if userID == contract.ClientID {
+		otherParty = contract.FreelancerID
+	}
+	if err := s.notificationService.NotifyDisputeOpened(ctx, otherParty, contract.ID); err != nil {
+		fmt.Printf("failed to send dispute notification: %v\n", err)
+	}
+
+	return dispute, nil
+}
+
+// GetDispute returns a dispute to one of the contract parties
+func (s *DisputeService) GetDispute(ctx context.Context, disputeID, userID uuid.UUID) (*domain.Dispute, error) {
+	dispute, contract, err := s.getDisputeWithContract(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if contract.ClientID != userID && contract.FreelancerID != userID {
+		return nil, apperrors.NewForbidden("you are not part of this contract")
+	}
+	return dispute, nil
+}
+
+// GetContractDisputes lists the disputes on a contract for one of its parties
+func (s *DisputeService) GetContractDisputes(ctx context.Context, contractID, userID uuid.UUID) ([]domain.Dispute, error) {
+	contract, err := s.getContract(ctx, contractID)
+	if err != nil {
+		return nil, err
+	}
+
+	if contract.ClientID != userID && contract.FreelancerID != userID {
+		return nil, apperrors.NewForbidden("you are not part of this contract")
+	}
+
+	disputes, err := s.disputeRepo.GetByContractID(ctx, contractID)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+	return disputes, nil
+}
+
+// AddEvidence appends evidence URLs to an unresolved dispute
+func (s *DisputeService) AddEvidence(ctx context.Context, disputeID, userID uuid.UUID, req *AddEvidenceRequest) (*domain.Dispute, error) {
+	if len(req.EvidenceURLs) == 0 {
+		return nil, apperrors.NewBadRequest("evidence_urls is required")
+	}
+
+	dispute, contract, err := s.getDisputeWithContract(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if contract.ClientID != userID && contract.FreelancerID != userID {
+		return nil, apperrors.NewForbidden("you are not part of this contract")
+	}
+	if !isDisputeUnresolved(dispute.Status) {
+		return nil, apperrors.NewBadRequest("dispute is no longer open")
+	}
+
+	evidence := append(dispute.EvidenceURLs, req.EvidenceURLs...)
+	if err := validateEvidenceURLs(evidence); err != nil {
+		return nil, err
+	}
+
+	dispute.EvidenceURLs = evidence
+	if err := s.disputeRepo.Update(ctx, dispute); err != nil {
+		return nil, saveError(err)
+	}
+	return dispute, nil
+}
+
+// ListDisputes returns the admin dispute queue
+func (s *DisputeService) ListDisputes(ctx context.Context, status string, limit, offset int) (*ListDisputesResponse, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	disputes, total, err := s.disputeRepo.List(ctx, status, limit, offset)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+
+	return &ListDisputesResponse{
+		Disputes: disputes,
+		Total:    total,
+		Limit:    limit,
+		Offset:   offset,
+	}, nil
+}
+
+// AdminGetDispute returns a dispute with its contract and milestone for review
+func (s *DisputeService) AdminGetDispute(ctx context.Context, disputeID uuid.UUID) (*domain.Dispute, error) {
+	dispute, contract, err := s.getDisputeWithContract(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	dispute.Contract = contract
+	if dispute.MilestoneID != nil {
+		dispute.Milestone, _ = s.milestoneRepo.GetByID(ctx, *dispute.MilestoneID)
+	}
+	return dispute, nil
+}
+
+// StartReview moves an open dispute to under_review
+func (s *DisputeService) StartReview(ctx context.Context, actor AdminActor, disputeID uuid.UUID, req *ModerationRequest) (*domain.Dispute, error) {
+	return s.transition(ctx, actor, disputeID, req.Reason, domain.AuditActionReviewDispute,
+		domain.DisputeStatusUnderReview, domain.DisputeStatusOpen)
+}
+
+// Escalate moves a dispute under review to escalated
+func (s *DisputeService) Escalate(ctx context.Context, actor AdminActor, disputeID uuid.UUID, req *ModerationRequest) (*domain.Dispute, error) {
+	return s.transition(ctx, actor, disputeID, req.Reason, domain.AuditActionEscalateDispute,
+		domain.DisputeStatusEscalated, domain.DisputeStatusOpen, domain.DisputeStatusUnderReview)
+}
+
+func (s *DisputeService) transition(ctx context.Context, actor AdminActor, disputeID uuid.UUID, reason, action, to string, from ...string) (*domain.Dispute, error) {
+	if err := requireReason(reason); err != nil {
+		return nil, err
+	}
+
+	dispute, err := s.getDispute(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := false
+	for _, status := range from {
+		if dispute.Status == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("dispute cannot move from %s to %s", dispute.Status, to))
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := recordAdminAction(ctx, s.auditRepo, actor, action, domain.AuditTargetDispute, dispute.ID, reason, dispute.Status, to); err != nil {
+			return err
+		}
+
+		dispute.Status = to
+		if err := s.disputeRepo.Update(ctx, dispute); err != nil {
+			return saveError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dispute, nil
+}
+
+// Close dismisses a dispute without moving any funds and unfreezes the
+// contract and escrow
+func (s *DisputeService) Close(ctx context.Context, actor AdminActor, disputeID uuid.UUID, req *ModerationRequest) (*domain.Dispute, error) {
+	if err := requireReason(req.Reason); err != nil {
+		return nil, err
+	}
+
+	dispute, contract, err := s.getDisputeWithContract(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if !isDisputeUnresolved(dispute.Status) {
+		return nil, apperrors.NewBadRequest("dispute is already " + dispute.Status)
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := recordAdminAction(ctx, s.auditRepo, actor, domain.AuditActionCloseDispute, domain.AuditTargetDispute,
+			dispute.ID, req.Reason, dispute.Status, domain.DisputeStatusClosed); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		notes := strings.TrimSpace(req.Reason)
+		dispute.Status = domain.DisputeStatusClosed
+		dispute.ResolvedBy = &actor.AdminID
+		dispute.ResolutionNotes = &notes
+		dispute.ResolvedAt = &now
+		if err := s.disputeRepo.Update(ctx, dispute); err != nil {
+			return saveError(err)
+		}
+
+		escrow, err := s.getEscrow(ctx, contract.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.unfreeze(ctx, contract, escrow, false); err != nil {
+			return err
+		}
+		if escrow != nil {
+			s.logEscrow(ctx, escrow.ID, domain.EscrowLogActionResolved, nil, &actor.AdminID, "dispute "+dispute.ID.String()+" closed")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyResolved(ctx, contract, "closed without a fund transfer")
+	return dispute, nil
+}
+
+// Resolve settles a dispute by splitting the disputed amount between a
+// refund to the client and a payment to the freelancer. Each non-zero share
+// produces a pending Payment out of the escrow vault. The client executes
+// them on-chain with release_milestone and refund (see BuildSettlement), and
+// the escrow indexer confirms them and updates the balances from its events.
+func (s *DisputeService) Resolve(ctx context.Context, actor AdminActor, disputeID uuid.UUID, req *ResolveDisputeRequest) (*DisputeResolutionResponse, error) {
+	if err := requireReason(req.Notes); err != nil {
+		return nil, apperrors.NewBadRequest("notes are required")
+	}
+	if req.ClientRefundSOL.IsNegative() || req.FreelancerPaymentSOL.IsNegative() {
+		return nil, apperrors.NewBadRequest("amounts cannot be negative")
+	}
+
+	dispute, contract, err := s.getDisputeWithContract(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if !isDisputeUnresolved(dispute.Status) {
+		return nil, apperrors.NewBadRequest("dispute is already " + dispute.Status)
+	}
+
+	escrow, err := s.getEscrow(ctx, contract.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var milestone *domain.Milestone
+	if dispute.MilestoneID != nil {
+		milestone, err = s.milestoneRepo.GetByID(ctx, *dispute.MilestoneID)
+		if err != nil {
+			return nil, apperrors.NewInternal(err)
+		}
+	}
+
+	disputed := disputedAmount(escrow, milestone)
+	refund, payout := req.ClientRefundSOL, req.FreelancerPaymentSOL
+
+	switch req.ResolutionType {
+	case domain.ResolutionTypeFullRefund:
+		refund, payout = disputed, decimal.Zero
+	case domain.ResolutionTypeReleaseToFreelancer:
+		refund, payout = decimal.Zero, disputed
+	case domain.ResolutionTypePartialRefund, domain.ResolutionTypeSplit:
+		if !refund.Add(payout).Equal(disputed) {
+			return nil, apperrors.NewBadRequest(fmt.Sprintf(
+				"client_refund_sol and freelancer_payment_sol must add up to the disputed amount of %s SOL", disputed.String()))
+		}
+	default:
+		return nil, apperrors.NewBadRequest("invalid resolution type")
+	}
+
+	var payments []domain.Payment
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := recordAdminAction(ctx, s.auditRepo, actor, domain.AuditActionResolveDispute, domain.AuditTargetDispute,
+			dispute.ID, req.Notes, dispute.Status, req.ResolutionType); err != nil {
+			return err
+		}
+
+		// Create the payments out of escrow
+		if escrow != nil {
+			if payout.IsPositive() {
+				p, err := s.createPayment(ctx, escrow, contract, dispute, domain.PaymentTypeDisputeResolution, escrow.FreelancerWallet, payout)
+				if err != nil {
+					return err
+				}
+				payments = append(payments, *p)
+			}
+			if refund.IsPositive() {
+				p, err := s.createPayment(ctx, escrow, contract, dispute, domain.PaymentTypeRefund, escrow.ClientWallet, refund)
+				if err != nil {
+					return err
+				}
+				payments = append(payments, *p)
+			}
+			s.logEscrow(ctx, escrow.ID, domain.EscrowLogActionResolved, &disputed, &actor.AdminID,
+				fmt.Sprintf("dispute %s resolved: %s SOL to freelancer, %s SOL refunded", dispute.ID, payout, refund))
+		}
+
+		// Record the outcome on the dispute
+		now := time.Now()
+		notes := strings.TrimSpace(req.Notes)
+		dispute.Status = domain.DisputeStatusResolved
+		dispute.ResolvedBy = &actor.AdminID
+		dispute.ResolutionType = &req.ResolutionType
+		dispute.ResolutionNotes = &notes
+		dispute.ClientRefundSOL = &refund
+		dispute.FreelancerPaymentSOL = &payout
+		dispute.ResolvedAt = &now
+		if err := s.disputeRepo.Update(ctx, dispute); err != nil {
+			return saveError(err)
+		}
+
+		// The escrow stays disputed until the settlement moves the funds
+		settling := len(payments) > 0
+		if milestone != nil {
+			// A milestone dispute settles that milestone and the contract
+			// carries on; the milestone is paid once the payout confirms
+			if payout.IsPositive() {
+				milestone.Status = domain.MilestoneStatusApproved
+				milestone.ApprovedAt = &now
+			} else {
+				milestone.Status = domain.MilestoneStatusCancelled
+			}
+			if err := s.milestoneRepo.Update(ctx, milestone); err != nil {
+				return saveError(err)
+			}
+			if err := s.unfreeze(ctx, contract, escrow, settling); err != nil {
+				return err
+			}
+		} else {
+			// A contract dispute settles everything left in escrow and ends
+			// it; a contract that was already cancelled stays cancelled
+			contract.Status = domain.ContractStatusCancelled
+			if contract.EndedAt == nil {
+				if payout.IsPositive() {
+					contract.Status = domain.ContractStatusCompleted
+				}
+				contract.EndedAt = &now
+			}
+			if err := s.contractRepo.Update(ctx, contract); err != nil {
+				return saveError(err)
+			}
+			if escrow != nil && !settling {
+				escrow.Status = escrowStatusFor(escrow)
+				if err := s.escrowRepo.Update(ctx, escrow); err != nil {
+					return saveError(err)
+				}
+			}
+		}
+		// Whatever the dispute decided supersedes an open cancellation
+		return closePendingCancellation(ctx, s.cancellationRepo, contract.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if milestone != nil {
+		s.notificationService.PublishMilestoneUpdate(ctx, contract, milestone)
+	}
+	s.notifyResolved(ctx, contract, fmt.Sprintf("%s SOL to the freelancer, %s SOL refunded to the client", payout, refund))
+
+	return &DisputeResolutionResponse{
+		Dispute:  dispute,
+		Payments: payments,
+	}, nil
+}
+
+// BuildSettlement builds the transaction that executes a resolved
+// dispute's payments, for the contract's client to sign
+func (s *DisputeService) BuildSettlement(ctx context.Context, disputeID, userID uuid.UUID) (*UnsignedTransactionResponse, error) {
+	dispute, err := s.getDispute(ctx, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status != domain.DisputeStatusResolved {
+		return nil, apperrors.NewBadRequest("dispute must be resolved before it is settled")
+	}
+	return s.escrowService.BuildDisputeSettlement(ctx, dispute.ContractID, dispute.MilestoneID, userID)
+}
+
+// ensureDisputable allows disputes on running contracts, and on cancelled
+// ones while a milestone the cancellation kept is still unpaid, so a silent
+// client can't leave the freelancer's share locked in escrow
+func (s *DisputeService) ensureDisputable(ctx context.Context, contract *domain.Contract) error {
+	switch contract.Status {
+	case domain.ContractStatusActive, domain.ContractStatusPaused:
+		return nil
+	case domain.ContractStatusCancelled:
+		milestones, err := s.milestoneRepo.GetByContractID(ctx, contract.ID)
+		if err != nil {
+			return apperrors.NewInternal(err)
+		}
+		for i := range milestones {
+			if keptUnpaid(&milestones[i]) {
+				return nil
+			}
+		}
+		return apperrors.NewBadRequest("this cancelled contract has no unpaid milestones left to dispute")
+	}
+	return apperrors.NewBadRequest("only active or paused contracts, or cancelled ones with unpaid milestones, can be disputed")
+}
+
+// unfreeze returns a disputed contract to active, or to cancelled when the
+// dispute was opened after it ended, and restores the escrow status from
+// its balances. An escrow still settling the dispute keeps its status; the
+// indexer takes the new one from the settlement's events.
+func (s *DisputeService) unfreeze(ctx context.Context, contract *domain.Contract, escrow *domain.Escrow, settling bool) error {
+	contract.Status = domain.ContractStatusActive
+	if contract.EndedAt != nil {
+		contract.Status = domain.ContractStatusCancelled
+	}
+	if err := s.contractRepo.Update(ctx, contract); err != nil {
+		return saveError(err)
+	}
+
+	if escrow == nil || settling {
+		return nil
+	}
+	escrow.Status = escrowStatusFor(escrow)
+	if err := s.escrowRepo.Update(ctx, escrow); err != nil {
+		return saveError(err)
+	}
+	return nil
+}
+
+func (s *DisputeService) createPayment(ctx context.Context, escrow *domain.Escrow, contract *domain.Contract, dispute *domain.Dispute, paymentType, toWallet string, amount decimal.Decimal) (*domain.Payment, error) {
+	payment := &domain.Payment{
+		EscrowID:       &escrow.ID,
+		ContractID:     contract.ID,
+		MilestoneID:    dispute.MilestoneID,
+		PaymentType:    paymentType,
+		FromWallet:     escrow.VaultAddress,
+		ToWallet:       toWallet,
+		AmountSOL:      amount,
+		PlatformFeeSOL: decimal.Zero,
+		NetAmountSOL:   amount,
+		Status:         domain.PaymentStatusPending,
+	}
+	if err := s.paymentRepo.Create(ctx, payment); err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+	return payment, nil
+}
+
+func (s *DisputeService) notifyResolved(ctx context.Context, contract *domain.Contract, resolution string) {
+	s.notificationService.PublishContractUpdate(ctx, contract)
+	for _, userID := range []uuid.UUID{contract.ClientID, contract.FreelancerID} {
+		if err := s.notificationService.NotifyDisputeResolved(ctx, userID, contract.ID, resolution); err != nil {
+			fmt.Printf("failed to send dispute resolution notification: %v\n", err)
+		}
+	}
+}
+
+func (s *DisputeService) logEscrow(ctx context.Context, escrowID uuid.UUID, action string, amount *decimal.Decimal, performedBy *uuid.UUID, notes string) {
+	log := &domain.EscrowLog{
+		EscrowID:    escrowID,
+		Action:      action,
+		AmountSOL:   amount,
+		PerformedBy: performedBy,
+		Notes:       &notes,
+	}
+	// A savepoint keeps a failed log write from aborting the caller's transaction
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		return s.escrowRepo.CreateLog(ctx, log)
+	})
+	if err != nil {
+		fmt.Printf("failed to write escrow log: %v\n", err)
+	}
+}
+
+func (s *DisputeService) getDispute(ctx context.Context, disputeID uuid.UUID) (*domain.Dispute, error) {
+	dispute, err := s.disputeRepo.GetByID(ctx, disputeID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewNotFound("dispute")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	return dispute, nil
+}
+
+func (s *DisputeService) getDisputeWithContract(ctx context.Context, disputeID uuid.UUID) (*domain.Dispute, *domain.Contract, error) {
+	dispute, err := s.getDispute(ctx, disputeID)
+	if err != nil {
+		return nil, nil, err
+	}
+	contract, err := s.getContract(ctx, dispute.ContractID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dispute, contract, nil
+}
+
+func (s *DisputeService) getContract(ctx context.Context, contractID uuid.UUID) (*domain.Contract, error) {
+	contract, err := s.contractRepo.GetByID(ctx, contractID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewNotFound("contract")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	return contract, nil
+}
+
+// getEscrow returns the contract's escrow, or nil if it was never created
+func (s *DisputeService) getEscrow(ctx context.Context, contractID uuid.UUID) (*domain.Escrow, error) {
+	escrow, err := s.escrowRepo.GetByContractID(ctx, contractID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	return escrow, nil
+}
+
+// disputedAmount is what a resolution distributes: the milestone amount for
+// a milestone dispute, capped by what is left in escrow
+func disputedAmount(escrow *domain.Escrow, milestone *domain.Milestone) decimal.Decimal {
+	if escrow == nil {
+		return decimal.Zero
+	}
+	remaining := escrowRemaining(escrow)
+	if milestone != nil && milestone.AmountSOL.LessThan(remaining) {
+		return milestone.AmountSOL
+	}
+	return remaining
+}
+
+func escrowRemaining(escrow *domain.Escrow) decimal.Decimal {
+	remaining := escrow.FundedAmountSOL.Sub(escrow.ReleasedAmountSOL).Sub(escrow.RefundedAmountSOL)
+	if remaining.IsNegative() {
+		return decimal.Zero
+	}
+	return remaining
+}
+
+// escrowStatusFor derives an escrow's status from its balances
+func escrowStatusFor(escrow *domain.Escrow) string {
+	switch {
+	case escrow.FundedAmountSOL.IsZero():
+		return domain.EscrowStatusCreated
+	case escrowRemaining(escrow).IsZero() && escrow.ReleasedAmountSOL.IsZero():
+		return domain.EscrowStatusRefunded
+	case escrowRemaining(escrow).IsZero():
+		return domain.EscrowStatusFullyReleased
+	case escrow.ReleasedAmountSOL.IsPositive():
+		return domain.EscrowStatusPartiallyReleased
+	case escrow.FundedAmountSOL.LessThan(escrow.TotalAmountSOL) && escrow.RefundedAmountSOL.IsZero():
+		return domain.EscrowStatusPartiallyFunded
+	default:
+		return domain.EscrowStatusFunded
+	}
+}
+
+func isDisputeUnresolved(status string) bool {
+	return status == domain.DisputeStatusOpen ||
+		status == domain.DisputeStatusUnderReview ||
+		status == domain.DisputeStatusEscalated
+}
+
+func validateEvidenceURLs(urls []string) error {
+	if len(urls) > maxDisputeEvidence {
+		return apperrors.NewBadRequest(fmt.Sprintf("at most %d evidence URLs are allowed", maxDisputeEvidence))
+	}
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return apperrors.NewBadRequest("invalid evidence URL: " + raw)
+		}
+	}
+	return nil
+}
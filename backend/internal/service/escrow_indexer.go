@@ -0,0 +1,592 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/trenchjob/backend/internal/domain"
+	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
+	"github.com/trenchjob/backend/internal/pkg/solana"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+// escrowIndexerCursor names the chain_cursors row for the escrow program
+const escrowIndexerCursor = "escrow_program"
+
+// escrowIndexerPageSize is the largest page getSignaturesForAddress returns
+const escrowIndexerPageSize = 1000
+
+// escrowIndexerMaxAttempts is how many passes may fail to apply the same
+// transaction before the indexer skips past it
+const escrowIndexerMaxAttempts = 5
+
+// EscrowIndexer follows the escrow program's transaction history and
+// applies the events it emits to escrows, payments and escrow_logs, so the
+// database catches up with transactions the API was never told about.
+// Every write is keyed by transaction signature, so replaying a
+// transaction the API (or an earlier pass) already recorded is a no-op.
+type EscrowIndexer struct {
+	escrowService *EscrowService
+	cursorRepo    repository.ChainCursorRepository
+
+	// failures counts failed attempts per signature; Poll is never run
+	// concurrently, so it needs no lock
+	failures map[string]int
+}
+
+func NewEscrowIndexer(escrowService *EscrowService, cursorRepo repository.ChainCursorRepository) *EscrowIndexer {
+	return &EscrowIndexer{
+		escrowService: escrowService,
+		cursorRepo:    cursorRepo,
+		failures:      make(map[string]int),
+	}
+}
+
+// Run indexes new program transactions every interval until ctx is cancelled
+func (i *EscrowIndexer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			indexed, err := i.Poll(ctx)
+			if err != nil {
+				log.Printf("escrow indexer: %v", err)
+			}
+			if indexed > 0 {
+				log.Printf("escrow indexer: indexed %d transactions", indexed)
+			}
+		}
+	}
+}
+
+// Poll indexes every program transaction newer than the stored cursor,
+// oldest first, advancing the cursor after each one in the same database
+// transaction as its effects. It stops at the first transaction it cannot
+// process so the next pass retries it; one that fails to apply on
+// escrowIndexerMaxAttempts passes is skipped and recorded in the logs of
+// the escrows it touches, so it can't halt indexing for good.
+func (i *EscrowIndexer) Poll(ctx context.Context) (int, error) {
+	s := i.escrowService
+	if s.programID.IsZero() {
+		return 0, nil
+	}
+
+	cursor, err := i.cursorRepo.Get(ctx, escrowIndexerCursor)
+	if err != nil {
+		if !errors.Is(err, apperrors.ErrNotFound) {
+			return 0, fmt.Errorf("failed to load cursor: %w", err)
+		}
+		cursor = &domain.ChainCursor{Name: escrowIndexerCursor}
+	}
+
+	pending, err := i.newSignatures(ctx, cursor.LastSignature)
+	if err != nil {
+		return 0, err
+	}
+
+	indexed := 0
+	for n := len(pending) - 1; n >= 0; n-- {
+		sig := pending[n]
+		var tx *solana.Transaction
+		if !sig.Failed() {
+			if tx, err = s.solanaClient.GetTransaction(ctx, sig.Signature); err != nil {
+				return indexed, fmt.Errorf("transaction %s: %w", sig.Signature, err)
+			}
+		}
+
+		var applyErr error
+		err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			if tx != nil {
+				if applyErr = i.indexTransaction(ctx, sig.Signature, tx); applyErr != nil {
+					return fmt.Errorf("transaction %s: %w", sig.Signature, applyErr)
+				}
+			}
+			return i.advance(ctx, cursor, sig)
+		})
+		if err != nil {
+			if applyErr == nil {
+				return indexed, err
+			}
+			i.failures[sig.Signature]++
+			if i.failures[sig.Signature] < escrowIndexerMaxAttempts {
+				return indexed, err
+			}
+			if err := i.skip(ctx, cursor, sig, tx, applyErr); err != nil {
+				return indexed, err
+			}
+		}
+		delete(i.failures, sig.Signature)
+		indexed++
+	}
+	return indexed, nil
+}
+
+// advance moves the cursor past sig
+func (i *EscrowIndexer) advance(ctx context.Context, cursor *domain.ChainCursor, sig solana.SignatureInfo) error {
+	cursor.LastSignature = sig.Signature
+	cursor.LastSlot = int64(sig.Slot)
+	if err := i.cursorRepo.Save(ctx, cursor); err != nil {
+		return fmt.Errorf("failed to save cursor: %w", err)
+	}
+	return nil
+}
+
+// skip gives up on a transaction that keeps failing to apply: it is logged
+// against every known escrow its events name, for an admin to reconcile by
+// hand, and the cursor moves past it
+func (i *EscrowIndexer) skip(ctx context.Context, cursor *domain.ChainCursor, sig solana.SignatureInfo, tx *solana.Transaction, cause error) error {
+	s := i.escrowService
+	log.Printf("escrow indexer: skipping %s after %d failed attempts: %v", sig.Signature, escrowIndexerMaxAttempts, cause)
+
+	events, _ := solana.ParseEscrowEvents(tx, s.programID)
+	notes := fmt.Sprintf("Indexer skipped this transaction after %d failed attempts: %v", escrowIndexerMaxAttempts, cause)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		logged := map[solana.PublicKey]bool{}
+		for n := range events {
+			if logged[events[n].Escrow] {
+				continue
+			}
+			logged[events[n].Escrow] = true
+
+			escrow, err := s.escrowRepo.GetByPDA(ctx, events[n].Escrow.String())
+			if err != nil {
+				if errors.Is(err, apperrors.ErrNotFound) {
+					continue
+				}
+				return err
+			}
+			if err := i.log(ctx, escrow, domain.EscrowLogActionIndexSkipped, nil, tx, notes); err != nil {
+				return err
+			}
+		}
+		return i.advance(ctx, cursor, sig)
+	})
+}
+
+// newSignatures pages backwards through the program's history until it
+// reaches until (or the beginning), returning signatures newest first
+func (i *EscrowIndexer) newSignatures(ctx context.Context, until string) ([]solana.SignatureInfo, error) {
+	program := i.escrowService.programID.String()
+
+	var out []solana.SignatureInfo
+	opts := solana.SignaturesOptions{Until: until, Limit: escrowIndexerPageSize}
+	for {
+		page, err := i.escrowService.solanaClient.GetSignaturesForAddress(ctx, program, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list program signatures: %w", err)
+		}
+		out = append(out, page...)
+		if len(page) < escrowIndexerPageSize {
+			return out, nil
+		}
+		opts.Before = page[len(page)-1].Signature
+	}
+}
+
+func (i *EscrowIndexer) indexTransaction(ctx context.Context, signature string, tx *solana.Transaction) error {
+	s := i.escrowService
+	if tx.Failed() {
+		return nil
+	}
+
+	events, err := solana.ParseEscrowEvents(tx, s.programID)
+	if err != nil {
+		// Undecodable logs will not improve on retry; skip the transaction
+		log.Printf("escrow indexer: skipping %s: %v", signature, err)
+		return nil
+	}
+	for n := range events {
+		if err := i.applyEvent(ctx, tx, &events[n]); err != nil {
+			return fmt.Errorf("%s: %w", events[n].Name, err)
+		}
+	}
+	return nil
+}
+
+func (i *EscrowIndexer) applyEvent(ctx context.Context, tx *solana.Transaction, ev *solana.EscrowEvent) error {
+	if ev.Name == solana.EventEscrowCreated {
+		return i.applyEscrowCreated(ctx, tx, ev)
+	}
+
+	escrow, err := i.escrowService.escrowRepo.GetByPDA(ctx, ev.Escrow.String())
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			log.Printf("escrow indexer: %s for unknown escrow %s in %s", ev.Name, ev.Escrow, tx.Signature)
+			return nil
+		}
+		return err
+	}
+
+	switch ev.Name {
+	case solana.EventEscrowFunded:
+		return i.applyEscrowFunded(ctx, tx, ev, escrow)
+	case solana.EventMilestoneReleased:
+		return i.applyMilestoneReleased(ctx, tx, ev, escrow)
+	case solana.EventEscrowRefunded:
+		return i.applyEscrowRefunded(ctx, tx, ev, escrow)
+	case solana.EventDisputeOpened:
+		if escrow.Status != domain.EscrowStatusDisputed {
+			escrow.Status = domain.EscrowStatusDisputed
+			if err := i.escrowService.escrowRepo.Update(ctx, escrow); err != nil {
+				return err
+			}
+		}
+		return i.log(ctx, escrow, domain.EscrowLogActionDisputed, nil, tx,
+			fmt.Sprintf("Dispute opened on-chain by %s", ev.Initiator))
+	case solana.EventEscrowClosed:
+		if escrow.Status != domain.EscrowStatusClosed {
+			escrow.Status = domain.EscrowStatusClosed
+			if err := i.escrowService.escrowRepo.Update(ctx, escrow); err != nil {
+				return err
+			}
+		}
+		return i.log(ctx, escrow, domain.EscrowLogActionClosed, nil, tx, "Escrow closed on-chain")
+	}
+	return nil
+}
+
+func (i *EscrowIndexer) applyEscrowCreated(ctx context.Context, tx *solana.Transaction, ev *solana.EscrowEvent) error {
+	s := i.escrowService
+	addrs, err := s.escrowAddresses(ev.ContractID)
+	if err != nil {
+		return err
+	}
+	if addrs.Escrow != ev.Escrow {
+		log.Printf("escrow indexer: escrow %s in %s does not match contract %s", ev.Escrow, tx.Signature, ev.ContractID)
+		return nil
+	}
+
+	if _, err := s.escrowRepo.GetByContractID(ctx, ev.ContractID); err == nil {
+		return nil
+	} else if !errors.Is(err, apperrors.ErrNotFound) {
+		return err
+	}
+	if _, err := s.contractRepo.GetByID(ctx, ev.ContractID); err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			log.Printf("escrow indexer: escrow %s references unknown contract %s", ev.Escrow, ev.ContractID)
+			return nil
+		}
+		return err
+	}
+
+	account := solana.EscrowAccount{
+		Client:      ev.Client,
+		Freelancer:  ev.Freelancer,
+		ContractID:  ev.ContractID,
+		TotalAmount: ev.Amount,
+		Status:      solana.EscrowStatusCreated,
+	}
+	escrow := account.ToDomain(addrs)
+	escrow.InitTxSignature = &tx.Signature
+	if err := s.escrowRepo.Create(ctx, escrow); err != nil {
+		return err
+	}
+	return i.log(ctx, escrow, domain.EscrowLogActionCreated, nil, tx, "Escrow initialized on-chain")
+}
+
+func (i *EscrowIndexer) applyEscrowFunded(ctx context.Context, tx *solana.Transaction, ev *solana.EscrowEvent, escrow *domain.Escrow) error {
+	s := i.escrowService
+	contract, err := s.contractRepo.GetByID(ctx, escrow.ContractID)
+	if err != nil {
+		return err
+	}
+
+	amount := solana.LamportsToSOL(ev.Amount)
+	payment, err := i.recordedPayment(ctx, tx, ev, escrow, domain.PaymentTypeEscrowFund)
+	switch {
+	case errors.Is(err, apperrors.ErrNotFound):
+		payment = &domain.Payment{ContractID: contract.ID, PaymentType: domain.PaymentTypeEscrowFund}
+		fillPayment(payment, escrow, ev.Client.String(), escrow.VaultAddress, amount, tx)
+		if err := s.paymentRepo.Create(ctx, payment); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case payment.Status == domain.PaymentStatusPending:
+		fillPayment(payment, escrow, ev.Client.String(), escrow.VaultAddress, amount, tx)
+		if err := s.paymentRepo.Update(ctx, payment); err != nil {
+			return err
+		}
+	}
+
+	total := solana.LamportsToSOL(ev.Total)
+	if total.GreaterThanOrEqual(escrow.FundedAmountSOL) {
+		escrow.FundedAmountSOL = total
+		escrow.Status = ev.Status.DomainStatus()
+		if err := s.escrowRepo.Update(ctx, escrow); err != nil {
+			return err
+		}
+	}
+	if err := i.log(ctx, escrow, domain.EscrowLogActionFunded, &amount, tx,
+		fmt.Sprintf("Funded %s SOL (%s of %s SOL)", amount, total, escrow.TotalAmountSOL)); err != nil {
+		return err
+	}
+
+	if escrow.FundedAmountSOL.GreaterThan(contract.EscrowAmountSOL) {
+		contract.EscrowAccountAddress = &escrow.EscrowPDA
+		contract.EscrowAmountSOL = escrow.FundedAmountSOL
+		if err := s.contractRepo.Update(ctx, contract); err != nil {
+			return err
+		}
+	}
+	if contract.Status == domain.ContractStatusPending && escrow.FundedAmountSOL.GreaterThanOrEqual(escrow.TotalAmountSOL) {
+		if err := s.contractService.ActivateContract(ctx, contract.ID); err != nil {
+			return err
+		}
+		s.notifyContractStarted(ctx, contract)
+	}
+	return nil
+}
+
+func (i *EscrowIndexer) applyMilestoneReleased(ctx context.Context, tx *solana.Transaction, ev *solana.EscrowEvent, escrow *domain.Escrow) error {
+	s := i.escrowService
+	amount := solana.LamportsToSOL(ev.Amount)
+
+	payment, err := i.recordedPayment(ctx, tx, ev, escrow, domain.PaymentTypeMilestoneRelease, domain.PaymentTypeDisputeResolution)
+	switch {
+	case errors.Is(err, apperrors.ErrNotFound):
+		err = i.recordRelease(ctx, tx, ev, escrow, amount, nil)
+	case err == nil && payment.Status == domain.PaymentStatusPending:
+		err = i.recordRelease(ctx, tx, ev, escrow, amount, payment)
+	}
+	if err != nil {
+		return err
+	}
+
+	total := solana.LamportsToSOL(ev.Total)
+	if total.GreaterThanOrEqual(escrow.ReleasedAmountSOL) {
+		escrow.ReleasedAmountSOL = total
+		escrow.Status = ev.Status.DomainStatus()
+		if err := s.escrowRepo.Update(ctx, escrow); err != nil {
+			return err
+		}
+	}
+	return i.log(ctx, escrow, domain.EscrowLogActionMilestoneReleased, &amount, tx,
+		fmt.Sprintf("Released %s SOL for milestone %s", amount, ev.MilestoneID))
+}
+
+// recordRelease stores a release the API has not confirmed: it settles the
+// pending dispute payout for the milestone (or, for a zero milestone, the
+// whole contract) if there is one, and otherwise records a regular
+// milestone payment, completing submitted when the client reported the
+// transaction before it landed, and marks the milestone paid
+func (i *EscrowIndexer) recordRelease(ctx context.Context, tx *solana.Transaction, ev *solana.EscrowEvent, escrow *domain.Escrow, amount decimal.Decimal, submitted *domain.Payment) error {
+	s := i.escrowService
+	if submitted == nil {
+		settled, err := i.settlePending(ctx, escrow.ContractID, domain.PaymentTypeDisputeResolution, &ev.MilestoneID, amount, tx)
+		if err != nil {
+			return err
+		}
+		if settled != nil {
+			return s.applyDisputePayout(ctx, settled)
+		}
+	}
+
+	milestone, err := s.milestoneRepo.GetByID(ctx, ev.MilestoneID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			log.Printf("escrow indexer: release in %s references unknown milestone %s", tx.Signature, ev.MilestoneID)
+			return nil
+		}
+		return err
+	}
+	if milestone.ContractID != escrow.ContractID {
+		log.Printf("escrow indexer: milestone %s in %s belongs to another contract", milestone.ID, tx.Signature)
+		return nil
+	}
+
+	if milestone.PaymentID != nil {
+		log.Printf("escrow indexer: milestone %s was already paid; %s not recorded", milestone.ID, tx.Signature)
+		return nil
+	}
+
+	contract, err := s.contractRepo.GetByID(ctx, escrow.ContractID)
+	if err != nil {
+		return err
+	}
+
+	quote, err := s.feeCalculator.QuoteForContract(ctx, contract, amount)
+	if err != nil {
+		return err
+	}
+	payment := submitted
+	if payment == nil {
+		payment = &domain.Payment{
+			ContractID:  contract.ID,
+			MilestoneID: &milestone.ID,
+			PaymentType: domain.PaymentTypeMilestoneRelease,
+		}
+	}
+	fillPayment(payment, escrow, escrow.VaultAddress, ev.Freelancer.String(), amount, tx)
+	chargeFee(payment, quote)
+	if submitted != nil {
+		err = s.paymentRepo.Update(ctx, payment)
+	} else {
+		err = s.paymentRepo.Create(ctx, payment)
+	}
+	if err != nil {
+		return err
+	}
+
+	milestone.Status = domain.MilestoneStatusPaid
+	milestone.PaymentID = &payment.ID
+	milestone.PaidAt = payment.ConfirmedAt
+	if err := s.milestoneRepo.Update(ctx, milestone); err != nil {
+		return err
+	}
+	contract.ReleasedAmountSOL = contract.ReleasedAmountSOL.Add(amount)
+	if err := s.contractRepo.Update(ctx, contract); err != nil {
+		return err
+	}
+
+	s.startNextMilestone(ctx, contract.ID)
+	s.notificationService.PublishMilestoneUpdate(ctx, contract, milestone)
+	if err := s.notificationService.NotifyPaymentReceived(ctx, contract.FreelancerID, contract.ID, payment.ID, payment.NetAmountSOL.String()); err != nil {
+		fmt.Printf("Failed to notify freelancer of payment: %v\n", err)
+	}
+	return nil
+}
+
+func (i *EscrowIndexer) applyEscrowRefunded(ctx context.Context, tx *solana.Transaction, ev *solana.EscrowEvent, escrow *domain.Escrow) error {
+	s := i.escrowService
+	amount := solana.LamportsToSOL(ev.Amount)
+
+	payment, err := i.recordedPayment(ctx, tx, ev, escrow, domain.PaymentTypeRefund)
+	switch {
+	case errors.Is(err, apperrors.ErrNotFound):
+		var settled *domain.Payment
+		settled, err = i.settlePending(ctx, escrow.ContractID, domain.PaymentTypeRefund, nil, amount, tx)
+		if err == nil && settled == nil {
+			payment = &domain.Payment{ContractID: escrow.ContractID, PaymentType: domain.PaymentTypeRefund}
+			fillPayment(payment, escrow, escrow.VaultAddress, ev.Client.String(), amount, tx)
+			err = s.paymentRepo.Create(ctx, payment)
+		}
+	case err == nil && payment.Status == domain.PaymentStatusPending:
+		fillPayment(payment, escrow, escrow.VaultAddress, ev.Client.String(), amount, tx)
+		err = s.paymentRepo.Update(ctx, payment)
+	}
+	if err != nil {
+		return err
+	}
+
+	total := solana.LamportsToSOL(ev.Total)
+	if total.GreaterThanOrEqual(escrow.RefundedAmountSOL) {
+		escrow.RefundedAmountSOL = total
+		escrow.Status = ev.Status.DomainStatus()
+		if err := s.escrowRepo.Update(ctx, escrow); err != nil {
+			return err
+		}
+	}
+	return i.log(ctx, escrow, domain.EscrowLogActionRefunded, &amount, tx,
+		fmt.Sprintf("Refunded %s SOL to %s", amount, ev.Client))
+}
+
+// settlePending confirms the contract's oldest unsigned pending payment of
+// paymentType for exactly amount with tx and returns it, or nil when there
+// is none. A non-nil milestoneID must match exactly, uuid.Nil standing for
+// a contract-wide payment.
+func (i *EscrowIndexer) settlePending(ctx context.Context, contractID uuid.UUID, paymentType string, milestoneID *uuid.UUID, amount decimal.Decimal, tx *solana.Transaction) (*domain.Payment, error) {
+	s := i.escrowService
+	payments, err := s.paymentRepo.GetByContractID(ctx, contractID)
+	if err != nil {
+		return nil, err
+	}
+	for n := len(payments) - 1; n >= 0; n-- {
+		p := &payments[n]
+		if p.PaymentType != paymentType || p.Status != domain.PaymentStatusPending || p.TxSignature != nil || !p.AmountSOL.Equal(amount) {
+			continue
+		}
+		if milestoneID != nil && paymentMilestone(p) != *milestoneID {
+			continue
+		}
+		confirmPayment(p, tx)
+		return p, s.paymentRepo.Update(ctx, p)
+	}
+	return nil, nil
+}
+
+// recordedPayment returns the payment of one of paymentTypes stored for
+// tx. A pending one was submitted before the transaction landed and is for
+// the caller to complete. One submitted for another contract or milestone
+// is failed and gives up the signature, so the event is recorded as unseen.
+func (i *EscrowIndexer) recordedPayment(ctx context.Context, tx *solana.Transaction, ev *solana.EscrowEvent, escrow *domain.Escrow, paymentTypes ...string) (*domain.Payment, error) {
+	s := i.escrowService
+	payments, err := s.paymentRepo.ListByTxSignature(ctx, tx.Signature)
+	if err != nil {
+		return nil, err
+	}
+	for n := range payments {
+		p := &payments[n]
+		if !slices.Contains(paymentTypes, p.PaymentType) {
+			continue
+		}
+		if p.Status != domain.PaymentStatusPending {
+			return p, nil
+		}
+		if p.ContractID == escrow.ContractID &&
+			(ev.Name != solana.EventMilestoneReleased || paymentMilestone(p) == ev.MilestoneID) {
+			return p, nil
+		}
+
+		log.Printf("escrow indexer: payment %s was submitted with %s, which pays another escrow", p.ID, tx.Signature)
+		p.Status = domain.PaymentStatusFailed
+		p.TxSignature = nil
+		if err := s.paymentRepo.Update(ctx, p); err != nil {
+			return nil, err
+		}
+		if contract, err := s.contractRepo.GetByID(ctx, p.ContractID); err == nil {
+			if err := s.notificationService.NotifyPaymentFailed(ctx, contract.ClientID, contract.ID, p.ID, p.AmountSOL.String(),
+				"the transaction does not match the payment"); err != nil {
+				fmt.Printf("Failed to send payment notification: %v\n", err)
+			}
+		}
+		return nil, apperrors.ErrNotFound
+	}
+	return nil, apperrors.ErrNotFound
+}
+
+// log writes an escrow log for tx; a log already written for the same
+// escrow, action and signature is left as it is
+func (i *EscrowIndexer) log(ctx context.Context, escrow *domain.Escrow, action string, amount *decimal.Decimal, tx *solana.Transaction, notes string) error {
+	return i.escrowService.escrowRepo.CreateLog(ctx, &domain.EscrowLog{
+		EscrowID:    escrow.ID,
+		Action:      action,
+		AmountSOL:   amount,
+		TxSignature: &tx.Signature,
+		Notes:       &notes,
+	})
+}
+
+// fillPayment completes a payment from an indexed event and confirms it
+func fillPayment(payment *domain.Payment, escrow *domain.Escrow, from, to string, amount decimal.Decimal, tx *solana.Transaction) {
+	payment.EscrowID = &escrow.ID
+	payment.FromWallet = from
+	payment.ToWallet = to
+	payment.AmountSOL = amount
+	payment.NetAmountSOL = amount
+	confirmPayment(payment, tx)
+}
+
+// confirmPayment marks a payment as settled by tx
+func confirmPayment(payment *domain.Payment, tx *solana.Transaction) {
+	slot := int64(tx.Slot)
+	now := time.Now()
+	payment.TxSignature = &tx.Signature
+	payment.Slot = &slot
+	payment.Status = domain.PaymentStatusConfirmed
+	payment.ConfirmedAt = &now
+	if tx.BlockTime != nil {
+		blockTime := time.Unix(*tx.BlockTime, 0)
+		payment.BlockTime = &blockTime
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/trenchjob/backend/internal/domain"
+	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
+	"github.com/trenchjob/backend/internal/pkg/solana"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+type fakeCursorRepo struct {
+	repository.ChainCursorRepository
+	cursor *domain.ChainCursor
+}
+
+func (r *fakeCursorRepo) Get(context.Context, string) (*domain.ChainCursor, error) {
+	if r.cursor == nil {
+		return nil, apperrors.ErrNotFound
+	}
+	copied := *r.cursor
+	return &copied, nil
+}
+
+func (r *fakeCursorRepo) Save(_ context.Context, c *domain.ChainCursor) error {
+	copied := *c
+	r.cursor = &copied
+	return nil
+}
+
+func TestEscrowIndexerSkipsPoisonTransaction(t *testing.T) {
+	ctx := context.Background()
+	f := newTrackerFixture(t)
+	cursors := &fakeCursorRepo{}
+	indexer := NewEscrowIndexer(f.escrowService, cursors)
+
+	milestone := f.milestones.milestone
+	lamports := solana.SOLToLamports(milestone.AmountSOL)
+	poison := testSignature(1)
+	f.chain.landed[poison] = f.releaseTx(poison, milestone.ID, lamports)
+	f.chain.history = append(f.chain.history, solana.SignatureInfo{Signature: poison, Slot: 10})
+	f.milestones.getErr = errors.New("milestone row is corrupt")
+
+	// Every pass until the last attempt retries it without moving on
+	for attempt := 1; attempt < escrowIndexerMaxAttempts; attempt++ {
+		if indexed, err := indexer.Poll(ctx); err == nil || indexed != 0 {
+			t.Fatalf("Poll attempt %d = %d, %v; want the failure reported", attempt, indexed, err)
+		}
+		if cursors.cursor != nil {
+			t.Fatalf("cursor advanced to %+v on attempt %d", cursors.cursor, attempt)
+		}
+	}
+
+	indexed, err := indexer.Poll(ctx)
+	if err != nil || indexed != 1 {
+		t.Fatalf("Poll on the last attempt = %d, %v; want the transaction skipped", indexed, err)
+	}
+	if cursors.cursor == nil || cursors.cursor.LastSignature != poison {
+		t.Fatalf("cursor = %+v, want it past %s", cursors.cursor, poison)
+	}
+	logs := f.escrows.logs
+	if len(logs) != 1 || logs[0].Action != domain.EscrowLogActionIndexSkipped || logs[0].TxSignature == nil || *logs[0].TxSignature != poison {
+		t.Fatalf("escrow logs = %+v, want the skipped transaction recorded", logs)
+	}
+
+	// Later transactions are indexed again
+	f.milestones.getErr = nil
+	next := testSignature(2)
+	f.chain.landed[next] = f.releaseTx(next, milestone.ID, lamports)
+	f.chain.history = append(f.chain.history, solana.SignatureInfo{Signature: next, Slot: 11})
+	if indexed, err := indexer.Poll(ctx); err != nil || indexed != 1 {
+		t.Fatalf("Poll after the skip = %d, %v; want 1 indexed", indexed, err)
+	}
+	if got := f.milestones.milestone; got.Status != domain.MilestoneStatusPaid {
+		t.Errorf("milestone = %s, want paid by %s", got.Status, next)
+	}
+	if len(indexer.failures) != 0 {
+		t.Errorf("failures = %v, want none left", indexer.failures)
+	}
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/trenchjob/backend/internal/domain"
+	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
+	"github.com/trenchjob/backend/internal/pkg/solana"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+// Reconciliation outcomes for a single escrow
+const (
+	reconcileOK         = "ok"
+	reconcileHealed     = "healed"
+	reconcileMismatched = "mismatched"
+)
+
+// EscrowReconciler compares escrow rows with their on-chain Escrow accounts
+// and vault balances. The program's funded, released and refunded counters
+// only ever grow, so a row that is behind the chain simply missed events
+// and is brought up to date. A row that is ahead of the chain, or that
+// disagrees on anything else, is recorded as drift for an admin to look at.
+type EscrowReconciler struct {
+	escrowService *EscrowService
+	driftRepo     repository.EscrowDriftRepository
+}
+
+func NewEscrowReconciler(escrowService *EscrowService, driftRepo repository.EscrowDriftRepository) *EscrowReconciler {
+	return &EscrowReconciler{
+		escrowService: escrowService,
+		driftRepo:     driftRepo,
+	}
+}
+
+// Run reconciles every unclosed escrow each interval until ctx is cancelled
+func (r *EscrowReconciler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run, err := r.Reconcile(ctx)
+			if err != nil {
+				log.Printf("escrow reconciler: %v", err)
+				continue
+			}
+			if run.Healed > 0 || run.Mismatched > 0 || run.Failed > 0 {
+				log.Printf("escrow reconciler: checked %d escrows: %d ok, %d healed, %d mismatched, %d failed",
+					run.Checked, run.OK, run.Healed, run.Mismatched, run.Failed)
+			}
+		}
+	}
+}
+
+// Reconcile makes one pass over every unclosed escrow and records its summary
+func (r *EscrowReconciler) Reconcile(ctx context.Context) (*domain.EscrowReconciliationRun, error) {
+	s := r.escrowService
+	if s.programID.IsZero() {
+		return nil, fmt.Errorf("escrow program ID is not configured")
+	}
+
+	escrows, err := s.escrowRepo.ListUnclosed(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list escrows: %w", err)
+	}
+
+	run := &domain.EscrowReconciliationRun{StartedAt: time.Now()}
+	for n := range escrows {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		run.Checked++
+		outcome, err := r.reconcileEscrow(ctx, &escrows[n])
+		if err != nil {
+			log.Printf("escrow reconciler: escrow %s: %v", escrows[n].ID, err)
+			run.Failed++
+			continue
+		}
+		switch outcome {
+		case reconcileOK:
+			run.OK++
+		case reconcileHealed:
+			run.Healed++
+		case reconcileMismatched:
+			run.Mismatched++
+		}
+	}
+	run.FinishedAt = time.Now()
+
+	if err := r.driftRepo.CreateRun(ctx, run); err != nil {
+		return nil, fmt.Errorf("failed to record run: %w", err)
+	}
+	return run, nil
+}
+
+func (r *EscrowReconciler) reconcileEscrow(ctx context.Context, escrow *domain.Escrow) (string, error) {
+	s := r.escrowService
+	account, addrs, err := solana.FetchEscrowAccount(ctx, s.solanaClient, s.programID, escrow.ContractID)
+	switch {
+	case errors.Is(err, solana.ErrAccountNotFound):
+		return r.reconcileMissingAccount(ctx, escrow)
+	case errors.Is(err, solana.ErrNotEscrowAccount):
+		return r.recordDrifts(ctx, escrow, []domain.EscrowDrift{
+			{Field: domain.EscrowDriftFieldAccount, DBValue: escrow.EscrowPDA, ChainValue: err.Error()},
+		})
+	case err != nil:
+		return "", err
+	}
+
+	var drifts []domain.EscrowDrift
+	drift := func(field string, dbValue, chainValue fmt.Stringer) {
+		drifts = append(drifts, domain.EscrowDrift{Field: field, DBValue: dbValue.String(), ChainValue: chainValue.String()})
+	}
+
+	if escrow.EscrowPDA != addrs.Escrow.String() || escrow.VaultAddress != addrs.Vault.String() ||
+		escrow.ClientWallet != account.Client.String() || escrow.FreelancerWallet != account.Freelancer.String() {
+		drifts = append(drifts, domain.EscrowDrift{
+			Field:      domain.EscrowDriftFieldWallets,
+			DBValue:    fmt.Sprintf("client=%s freelancer=%s", escrow.ClientWallet, escrow.FreelancerWallet),
+			ChainValue: fmt.Sprintf("client=%s freelancer=%s", account.Client, account.Freelancer),
+		})
+	}
+	if total := solana.LamportsToSOL(account.TotalAmount); !escrow.TotalAmountSOL.Equal(total) {
+		drift(domain.EscrowDriftFieldTotal, escrow.TotalAmountSOL, total)
+	}
+
+	healed := false
+	for _, counter := range []struct {
+		field string
+		db    *decimal.Decimal
+		chain uint64
+	}{
+		{domain.EscrowDriftFieldFunded, &escrow.FundedAmountSOL, account.FundedAmount},
+		{domain.EscrowDriftFieldReleased, &escrow.ReleasedAmountSOL, account.ReleasedAmount},
+		{domain.EscrowDriftFieldRefunded, &escrow.RefundedAmountSOL, account.RefundedAmount},
+	} {
+		chain := solana.LamportsToSOL(counter.chain)
+		switch {
+		case counter.db.LessThan(chain):
+			*counter.db = chain
+			healed = true
+		case counter.db.GreaterThan(chain):
+			drift(counter.field, *counter.db, chain)
+		}
+	}
+
+	// A dispute opened through the API freezes the row before (or without)
+	// the on-chain open_dispute, so that one status is left alone
+	if status := account.Status.DomainStatus(); escrow.Status != status && escrow.Status != domain.EscrowStatusDisputed {
+		escrow.Status = status
+		healed = true
+	}
+
+	balance, err := s.solanaClient.GetBalance(ctx, addrs.Vault.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to load vault balance: %w", err)
+	}
+	if available := account.Available(); balance < available {
+		drift(domain.EscrowDriftFieldVaultBalance, solana.LamportsToSOL(available), solana.LamportsToSOL(balance))
+	}
+
+	var outcome string
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if healed {
+			if err := s.escrowRepo.Update(ctx, escrow); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		outcome, err = r.recordDrifts(ctx, escrow, drifts)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	if healed {
+		log.Printf("escrow reconciler: escrow %s updated from chain state", escrow.ID)
+	}
+
+	if outcome == reconcileMismatched || !healed {
+		return outcome, nil
+	}
+	return reconcileHealed, nil
+}
+
+// reconcileMissingAccount handles an escrow whose account is gone: one
+// that has paid out everything was closed and is marked so, while one
+// still holding funds is drift
+func (r *EscrowReconciler) reconcileMissingAccount(ctx context.Context, escrow *domain.Escrow) (string, error) {
+	if escrow.FundedAmountSOL.IsPositive() && !escrowRemaining(escrow).IsPositive() {
+		escrow.Status = domain.EscrowStatusClosed
+		err := r.escrowService.tx.WithinTx(ctx, func(ctx context.Context) error {
+			if err := r.escrowService.escrowRepo.Update(ctx, escrow); err != nil {
+				return err
+			}
+			return r.driftRepo.ResolveExcept(ctx, escrow.ID, nil)
+		})
+		if err != nil {
+			return "", err
+		}
+		return reconcileHealed, nil
+	}
+
+	return r.recordDrifts(ctx, escrow, []domain.EscrowDrift{
+		{Field: domain.EscrowDriftFieldAccount, DBValue: escrow.EscrowPDA, ChainValue: "account not found"},
+	})
+}
+
+// recordDrifts stores the escrow's current drifts and resolves any it no
+// longer has
+func (r *EscrowReconciler) recordDrifts(ctx context.Context, escrow *domain.Escrow, drifts []domain.EscrowDrift) (string, error) {
+	err := r.escrowService.tx.WithinTx(ctx, func(ctx context.Context) error {
+		fields := make([]string, 0, len(drifts))
+		for n := range drifts {
+			drifts[n].EscrowID = escrow.ID
+			if err := r.driftRepo.Upsert(ctx, &drifts[n]); err != nil {
+				return err
+			}
+			fields = append(fields, drifts[n].Field)
+		}
+		return r.driftRepo.ResolveExcept(ctx, escrow.ID, fields)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(drifts) > 0 {
+		return reconcileMismatched, nil
+	}
+	return reconcileOK, nil
+}
+
+// EscrowReconciliationReport is the latest run summary and the open drift
+type EscrowReconciliationReport struct {
+	LatestRun *domain.EscrowReconciliationRun `json:"latest_run"`
+	Drifts    []domain.EscrowDrift            `json:"drifts"`
+	Total     int                             `json:"total"`
+	Limit     int                             `json:"limit"`
+	Offset    int                             `json:"offset"`
+}
+
+// GetReport returns the most recent reconciliation summary with a page of open drifts
+func (r *EscrowReconciler) GetReport(ctx context.Context, limit, offset int) (*EscrowReconciliationReport, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	run, err := r.driftRepo.GetLatestRun(ctx)
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		return nil, apperrors.NewInternal(err)
+	}
+
+	drifts, total, err := r.driftRepo.ListOpen(ctx, limit, offset)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+
+	return &EscrowReconciliationReport{
+		LatestRun: run,
+		Drifts:    drifts,
+		Total:     total,
+		Limit:     limit,
+		Offset:    offset,
+	}, nil
+}
@@ -0,0 +1,1399 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/trenchjob/backend/internal/domain"
+	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
+	"github.com/trenchjob/backend/internal/pkg/solana"
+	"github.com/trenchjob/backend/internal/pkg/utils"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+// solanaSignatureSize is the length of a decoded transaction signature
+const solanaSignatureSize = 64
+
+// errSignatureClaimed rolls back a confirmation whose payment row could not
+// be created, usually because a concurrent request recorded the signature
+var errSignatureClaimed = errors.New("transaction signature already claimed")
+
+type EscrowService struct {
+	contractRepo        repository.ContractRepository
+	milestoneRepo       repository.MilestoneRepository
+	escrowRepo          repository.EscrowRepository
+	paymentRepo         repository.PaymentRepository
+	walletRepo          repository.WalletRepository
+	userRepo            repository.UserRepository
+	tx                  repository.Transactor
+	contractService     *ContractService
+	notificationService *NotificationService
+	solanaClient        solana.Client
+	programID           solana.PublicKey
+	feeCalculator       *FeeCalculator
+}
+
+func NewEscrowService(
+	contractRepo repository.ContractRepository,
+	milestoneRepo repository.MilestoneRepository,
+	escrowRepo repository.EscrowRepository,
+	paymentRepo repository.PaymentRepository,
+	walletRepo repository.WalletRepository,
+	userRepo repository.UserRepository,
+	tx repository.Transactor,
+	contractService *ContractService,
+	notificationService *NotificationService,
+	solanaClient solana.Client,
+	programID solana.PublicKey,
+	feeCalculator *FeeCalculator,
+) *EscrowService {
+	return &EscrowService{
+		contractRepo:        contractRepo,
+		milestoneRepo:       milestoneRepo,
+		escrowRepo:          escrowRepo,
+		paymentRepo:         paymentRepo,
+		walletRepo:          walletRepo,
+		userRepo:            userRepo,
+		tx:                  tx,
+		contractService:     contractService,
+		notificationService: notificationService,
+		solanaClient:        solanaClient,
+		programID:           programID,
+		feeCalculator:       feeCalculator,
+	}
+}
+
+// ConfirmFundingRequest carries the signature of a fund_escrow transaction
+type ConfirmFundingRequest struct {
+	TxSignature string `json:"tx_signature"`
+}
+
+// EscrowFundingResponse is the contract's escrow state after a funding
+// transaction has been recorded
+type EscrowFundingResponse struct {
+	Contract *domain.Contract `json:"contract"`
+	Escrow   *domain.Escrow   `json:"escrow"`
+	Payment  *domain.Payment  `json:"payment"`
+}
+
+// ConfirmFunding verifies a fund_escrow transaction on-chain and records it.
+// Once the escrow is fully funded the contract is activated. Submitting the
+// same signature again returns the recorded result without side effects.
+func (s *EscrowService) ConfirmFunding(ctx context.Context, contractID, clientID uuid.UUID, req *ConfirmFundingRequest) (*EscrowFundingResponse, error) {
+	signature := strings.TrimSpace(req.TxSignature)
+	if err := validateTxSignature(signature); err != nil {
+		return nil, err
+	}
+
+	contract, err := s.contractRepo.GetByID(ctx, contractID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewNotFound("contract")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	if contract.ClientID != clientID {
+		return nil, apperrors.NewForbidden("only the client can fund this contract")
+	}
+
+	if resp, err := s.recordedFunding(ctx, contract, signature); resp != nil || err != nil {
+		return resp, err
+	}
+
+	if contract.Status != domain.ContractStatusPending {
+		return nil, apperrors.NewBadRequest("contract is not awaiting escrow funding")
+	}
+	if s.programID.IsZero() {
+		return nil, apperrors.NewInternal(fmt.Errorf("escrow program ID is not configured"))
+	}
+
+	tx, err := s.solanaClient.GetTransaction(ctx, signature)
+	if err != nil {
+		if errors.Is(err, solana.ErrTransactionNotFound) {
+			return s.submitFunding(ctx, contract, signature)
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	return s.applyFunding(ctx, contract, tx, nil)
+}
+
+// submitFunding records a funding transaction that is not visible at the
+// configured commitment yet as a pending payment. The payment tracker
+// verifies and applies it with applyFunding once it lands, or fails it.
+func (s *EscrowService) submitFunding(ctx context.Context, contract *domain.Contract, signature string) (*EscrowFundingResponse, error) {
+	addrs, err := s.escrowAddresses(contract.ID)
+	if err != nil {
+		return nil, err
+	}
+	amount := contract.TotalAmountSOL.Sub(contract.EscrowAmountSOL)
+	payment := &domain.Payment{
+		ContractID:   contract.ID,
+		PaymentType:  domain.PaymentTypeEscrowFund,
+		ToWallet:     addrs.Vault.String(),
+		AmountSOL:    amount,
+		NetAmountSOL: amount,
+		TxSignature:  &signature,
+		Status:       domain.PaymentStatusPending,
+	}
+	if err := s.paymentRepo.Create(ctx, payment); err != nil {
+		if resp, lookupErr := s.recordedFunding(ctx, contract, signature); resp != nil || lookupErr != nil {
+			return resp, lookupErr
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	return s.fundingResponse(ctx, contract.ID, payment)
+}
+
+// applyFunding verifies a landed funding transaction and records it,
+// confirming the pending payment submitFunding stored when there is one
+func (s *EscrowService) applyFunding(ctx context.Context, contract *domain.Contract, tx *solana.Transaction, pending *domain.Payment) (*EscrowFundingResponse, error) {
+	signature := tx.Signature
+	if tx.Failed() {
+		return nil, apperrors.NewBadRequest("transaction failed on-chain")
+	}
+
+	addrs, err := solana.DeriveEscrowAddresses(s.programID, contract.ID)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+
+	funding, err := s.verifyFundingTx(ctx, tx, contract, addrs)
+	if err != nil {
+		return nil, err
+	}
+
+	account, _, err := solana.FetchEscrowAccount(ctx, s.solanaClient, s.programID, contract.ID)
+	if err != nil {
+		if errors.Is(err, solana.ErrAccountNotFound) || errors.Is(err, solana.ErrNotEscrowAccount) {
+			return nil, apperrors.NewBadRequest("escrow account not found on-chain")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	if err := s.verifyEscrowAccount(ctx, account, contract, funding.signer); err != nil {
+		return nil, err
+	}
+
+	// The escrow, payment, log and contract writes land together. The
+	// payment row claims the signature; a concurrent request for the same
+	// transaction loses there and returns the recorded result instead.
+	var payment *domain.Payment
+	activated := false
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// Make sure the escrow row exists so the payment can reference it
+		escrow, err := s.escrowRepo.GetByContractID(ctx, contract.ID)
+		isNewEscrow := errors.Is(err, apperrors.ErrNotFound)
+		if err != nil && !isNewEscrow {
+			return apperrors.NewInternal(err)
+		}
+		if isNewEscrow {
+			escrow = account.ToDomain(addrs)
+			if funding.initialized {
+				escrow.InitTxSignature = &signature
+			}
+			if err := s.escrowRepo.Create(ctx, escrow); err != nil {
+				return apperrors.NewInternal(err)
+			}
+		}
+
+		payment, err = s.createFundingPayment(ctx, escrow, contract, tx, funding, pending)
+		if err != nil {
+			if pending != nil {
+				return apperrors.NewInternal(err)
+			}
+			return fmt.Errorf("%w: %v", errSignatureClaimed, err)
+		}
+
+		account.ApplyTo(escrow, addrs)
+		if err := s.escrowRepo.Update(ctx, escrow); err != nil {
+			return saveError(err)
+		}
+
+		if isNewEscrow {
+			s.logEscrow(ctx, escrow.ID, domain.EscrowLogActionCreated, nil, &signature, contract.ClientID, "Escrow initialized on-chain")
+		}
+		fundedAmount := payment.AmountSOL
+		s.logEscrow(ctx, escrow.ID, domain.EscrowLogActionFunded, &fundedAmount, &signature, contract.ClientID,
+			fmt.Sprintf("Funded %s SOL (%s of %s SOL)", payment.AmountSOL, escrow.FundedAmountSOL, escrow.TotalAmountSOL))
+
+		contract.EscrowAccountAddress = &escrow.EscrowPDA
+		contract.EscrowAmountSOL = escrow.FundedAmountSOL
+		if err := s.contractRepo.Update(ctx, contract); err != nil {
+			return saveError(err)
+		}
+
+		if account.FundedAmount >= account.TotalAmount && contract.Status == domain.ContractStatusPending {
+			if err := s.contractService.ActivateContract(ctx, contract.ID); err != nil {
+				return err
+			}
+			activated = true
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errSignatureClaimed) {
+			if resp, lookupErr := s.recordedFunding(ctx, contract, signature); resp != nil || lookupErr != nil {
+				return resp, lookupErr
+			}
+			return nil, apperrors.NewInternal(err)
+		}
+		return nil, err
+	}
+
+	if activated {
+		s.notifyContractStarted(ctx, contract)
+	}
+
+	return s.fundingResponse(ctx, contract.ID, payment)
+}
+
+// ReleaseMilestoneRequest carries the signature of a release_milestone transaction
+type ReleaseMilestoneRequest struct {
+	TxSignature string `json:"tx_signature"`
+}
+
+// MilestoneReleaseResponse is the milestone and its payment after a release
+// transaction has been recorded
+type MilestoneReleaseResponse struct {
+	Milestone *domain.Milestone `json:"milestone"`
+	Payment   *domain.Payment   `json:"payment"`
+	Escrow    *domain.Escrow    `json:"escrow"`
+}
+
+// ReleaseMilestone records the client's release_milestone transaction for an
+// approved milestone. The MilestoneReleased event the program emitted must
+// name this milestone and its exact amount. Submitting the same signature
+// again returns the recorded result.
+func (s *EscrowService) ReleaseMilestone(ctx context.Context, milestoneID, clientID uuid.UUID, req *ReleaseMilestoneRequest) (*MilestoneReleaseResponse, error) {
+	signature := strings.TrimSpace(req.TxSignature)
+	if err := validateTxSignature(signature); err != nil {
+		return nil, err
+	}
+
+	milestone, err := s.milestoneRepo.GetByID(ctx, milestoneID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewNotFound("milestone")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	contract, err := s.contractRepo.GetByID(ctx, milestone.ContractID)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+	if contract.ClientID != clientID {
+		return nil, apperrors.NewForbidden("you are not the client for this contract")
+	}
+
+	if resp, err := s.recordedRelease(ctx, milestone, signature); resp != nil || err != nil {
+		return resp, err
+	}
+
+	if milestone.PaymentID != nil || milestone.Status == domain.MilestoneStatusPaid {
+		return nil, apperrors.NewConflict("milestone has already been paid")
+	}
+	if milestone.Status != domain.MilestoneStatusApproved {
+		return nil, apperrors.NewBadRequest("milestone must be approved before funds are released")
+	}
+	if err := ensureNotDisputed(contract); err != nil {
+		return nil, err
+	}
+	if err := s.ensureNoDisputePayout(ctx, milestone); err != nil {
+		return nil, err
+	}
+	if err := s.ensureNoPendingRelease(ctx, milestone); err != nil {
+		return nil, err
+	}
+	if s.programID.IsZero() {
+		return nil, apperrors.NewInternal(fmt.Errorf("escrow program ID is not configured"))
+	}
+
+	escrow, err := s.escrowRepo.GetByContractID(ctx, contract.ID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewBadRequest("escrow has not been funded")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+
+	tx, err := s.solanaClient.GetTransaction(ctx, signature)
+	if err != nil {
+		if errors.Is(err, solana.ErrTransactionNotFound) {
+			return s.submitRelease(ctx, contract, milestone, escrow, signature)
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	return s.applyRelease(ctx, contract, milestone, escrow, tx, nil)
+}
+
+// submitRelease records a release transaction that is not visible at the
+// configured commitment yet as a pending payment. The milestone stays
+// approved until the payment tracker applies it with applyRelease.
+func (s *EscrowService) submitRelease(ctx context.Context, contract *domain.Contract, milestone *domain.Milestone, escrow *domain.Escrow, signature string) (*MilestoneReleaseResponse, error) {
+	quote, err := s.feeCalculator.QuoteForContract(ctx, contract, milestone.AmountSOL)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+	payment := &domain.Payment{
+		EscrowID:    &escrow.ID,
+		ContractID:  contract.ID,
+		MilestoneID: &milestone.ID,
+		PaymentType: domain.PaymentTypeMilestoneRelease,
+		FromWallet:  escrow.VaultAddress,
+		ToWallet:    escrow.FreelancerWallet,
+		AmountSOL:   milestone.AmountSOL,
+		TxSignature: &signature,
+		Status:      domain.PaymentStatusPending,
+	}
+	chargeFee(payment, quote)
+	if err := s.paymentRepo.Create(ctx, payment); err != nil {
+		if resp, lookupErr := s.recordedRelease(ctx, milestone, signature); resp != nil || lookupErr != nil {
+			return resp, lookupErr
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	return &MilestoneReleaseResponse{
+		Milestone: milestone,
+		Payment:   payment,
+		Escrow:    escrow,
+	}, nil
+}
+
+// applyRelease verifies a landed release transaction and records it,
+// confirming the pending payment submitRelease stored when there is one
+func (s *EscrowService) applyRelease(ctx context.Context, contract *domain.Contract, milestone *domain.Milestone, escrow *domain.Escrow, tx *solana.Transaction, pending *domain.Payment) (*MilestoneReleaseResponse, error) {
+	signature := tx.Signature
+	if tx.Failed() {
+		return nil, apperrors.NewBadRequest("transaction failed on-chain")
+	}
+
+	event, err := s.verifyReleaseTx(ctx, tx, contract, milestone, escrow)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := solana.LamportsToSOL(event.Amount)
+	quote, err := s.feeCalculator.QuoteForContract(ctx, contract, amount)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+	slot := int64(tx.Slot)
+	now := time.Now()
+
+	payment := pending
+	if payment == nil {
+		payment = &domain.Payment{
+			EscrowID:    &escrow.ID,
+			ContractID:  contract.ID,
+			MilestoneID: &milestone.ID,
+			PaymentType: domain.PaymentTypeMilestoneRelease,
+		}
+	}
+	payment.FromWallet = escrow.VaultAddress
+	payment.ToWallet = event.Freelancer.String()
+	payment.AmountSOL = amount
+	chargeFee(payment, quote)
+	payment.TxSignature = &signature
+	payment.Slot = &slot
+	payment.Status = domain.PaymentStatusConfirmed
+	payment.ConfirmedAt = &now
+	if tx.BlockTime != nil {
+		blockTime := time.Unix(*tx.BlockTime, 0)
+		payment.BlockTime = &blockTime
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if pending != nil {
+			if err := s.paymentRepo.Update(ctx, payment); err != nil {
+				return apperrors.NewInternal(err)
+			}
+		} else if err := s.paymentRepo.Create(ctx, payment); err != nil {
+			return fmt.Errorf("%w: %v", errSignatureClaimed, err)
+		}
+
+		milestone.Status = domain.MilestoneStatusPaid
+		milestone.PaymentID = &payment.ID
+		milestone.PaidAt = &now
+		if err := s.milestoneRepo.Update(ctx, milestone); err != nil {
+			return saveError(err)
+		}
+
+		contract.ReleasedAmountSOL = contract.ReleasedAmountSOL.Add(amount)
+		if err := s.contractRepo.Update(ctx, contract); err != nil {
+			return saveError(err)
+		}
+
+		// The event carries the program's running total, which stays correct
+		// even if an earlier release was never reported to the backend
+		escrow.ReleasedAmountSOL = solana.LamportsToSOL(event.Total)
+		escrow.Status = event.Status.DomainStatus()
+		if err := s.escrowRepo.Update(ctx, escrow); err != nil {
+			return saveError(err)
+		}
+		s.logEscrow(ctx, escrow.ID, domain.EscrowLogActionMilestoneReleased, &amount, &signature, contract.ClientID,
+			fmt.Sprintf("Released %s SOL for milestone %q", amount, milestone.Title))
+
+		s.startNextMilestone(ctx, contract.ID)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errSignatureClaimed) {
+			if resp, lookupErr := s.recordedRelease(ctx, milestone, signature); resp != nil || lookupErr != nil {
+				return resp, lookupErr
+			}
+			return nil, apperrors.NewInternal(err)
+		}
+		return nil, err
+	}
+	s.notificationService.PublishMilestoneUpdate(ctx, contract, milestone)
+
+	if err := s.notificationService.NotifyPaymentReceived(ctx, contract.FreelancerID, contract.ID, payment.ID, payment.NetAmountSOL.String()); err != nil {
+		fmt.Printf("Failed to notify freelancer of payment: %v\n", err)
+	}
+
+	return &MilestoneReleaseResponse{
+		Milestone: milestone,
+		Payment:   payment,
+		Escrow:    escrow,
+	}, nil
+}
+
+// verifyReleaseTx finds the release_milestone call for this milestone and
+// checks it against the program's MilestoneReleased event: same escrow,
+// milestone ID and amount, signed by one of the client's wallets and paid
+// to the escrow's freelancer
+func (s *EscrowService) verifyReleaseTx(ctx context.Context, tx *solana.Transaction, contract *domain.Contract, milestone *domain.Milestone, escrow *domain.Escrow) (*solana.EscrowEvent, error) {
+	instructions, err := solana.ParseEscrowInstructions(tx, s.programID)
+	if err != nil {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("could not decode escrow instructions: %v", err))
+	}
+	events, err := solana.ParseEscrowEvents(tx, s.programID)
+	if err != nil {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("could not decode escrow events: %v", err))
+	}
+
+	var signer string
+	for _, ix := range instructions {
+		if ix.Name == solana.InstructionReleaseMilestone && ix.MilestoneID == milestone.ID && ix.Escrow() == escrow.EscrowPDA {
+			signer = ix.Signer()
+			break
+		}
+	}
+	if signer == "" {
+		return nil, apperrors.NewBadRequest("transaction does not release this milestone")
+	}
+
+	var event *solana.EscrowEvent
+	for i := range events {
+		ev := &events[i]
+		if ev.Name == solana.EventMilestoneReleased && ev.MilestoneID == milestone.ID && ev.Escrow.String() == escrow.EscrowPDA {
+			event = ev
+			break
+		}
+	}
+	if event == nil {
+		return nil, apperrors.NewBadRequest("transaction did not emit a release event for this milestone")
+	}
+
+	if event.Amount != solana.SOLToLamports(milestone.AmountSOL) {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("released %s SOL but the milestone is %s SOL",
+			solana.LamportsToSOL(event.Amount), milestone.AmountSOL))
+	}
+	if event.Freelancer.String() != escrow.FreelancerWallet {
+		return nil, apperrors.NewBadRequest("funds were released to a different wallet")
+	}
+
+	if !tx.IsSigner(signer) {
+		return nil, apperrors.NewBadRequest("releasing wallet did not sign the transaction")
+	}
+	if _, err := s.walletRepo.GetByUserIDAndAddress(ctx, contract.ClientID, signer); err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewForbidden("transaction was not signed by one of your wallets")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+
+	return event, nil
+}
+
+// recordedRelease returns the stored result when signature has already
+// been recorded, and nil when it has not been seen
+func (s *EscrowService) recordedRelease(ctx context.Context, milestone *domain.Milestone, signature string) (*MilestoneReleaseResponse, error) {
+	payment, err := s.paymentRepo.GetByTxSignature(ctx, signature)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	if payment.PaymentType != domain.PaymentTypeMilestoneRelease || payment.MilestoneID == nil || *payment.MilestoneID != milestone.ID {
+		return nil, apperrors.NewConflict("transaction has already been recorded for another payment")
+	}
+
+	escrow, err := s.escrowRepo.GetByContractID(ctx, milestone.ContractID)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+	return &MilestoneReleaseResponse{
+		Milestone: milestone,
+		Payment:   payment,
+		Escrow:    escrow,
+	}, nil
+}
+
+// startNextMilestone moves the first pending milestone into progress once
+// nothing else on the contract is being worked on
+func (s *EscrowService) startNextMilestone(ctx context.Context, contractID uuid.UUID) {
+	milestones, err := s.milestoneRepo.GetByContractID(ctx, contractID)
+	if err != nil {
+		fmt.Printf("Failed to load milestones for contract %s: %v\n", contractID, err)
+		return
+	}
+
+	for i := range milestones {
+		switch milestones[i].Status {
+		case domain.MilestoneStatusInProgress, domain.MilestoneStatusSubmitted, domain.MilestoneStatusRevisionRequested:
+			return
+		}
+	}
+	for i := range milestones {
+		if milestones[i].Status == domain.MilestoneStatusPending {
+			milestones[i].Status = domain.MilestoneStatusInProgress
+			err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+				return s.milestoneRepo.Update(ctx, &milestones[i])
+			})
+			if err != nil {
+				fmt.Printf("Failed to start milestone %s: %v\n", milestones[i].ID, err)
+			}
+			return
+		}
+	}
+}
+
+// fundingTx is what a verified transaction contributed to an escrow
+type fundingTx struct {
+	signer      string
+	lamports    uint64
+	initialized bool
+}
+
+// verifyFundingTx checks that tx funds this contract's escrow: every
+// fund_escrow call must target the derived escrow and vault PDAs, be signed
+// by one of the client's wallets, and the vault must have received the
+// amounts the instructions claim
+func (s *EscrowService) verifyFundingTx(ctx context.Context, tx *solana.Transaction, contract *domain.Contract, addrs *solana.EscrowAddresses) (*fundingTx, error) {
+	instructions, err := solana.ParseEscrowInstructions(tx, s.programID)
+	if err != nil {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("could not decode escrow instructions: %v", err))
+	}
+
+	funding := &fundingTx{}
+	escrowPDA, vault := addrs.Escrow.String(), addrs.Vault.String()
+	for _, ix := range instructions {
+		switch ix.Name {
+		case solana.InstructionInitializeEscrow:
+			if ix.Escrow() == escrowPDA && ix.ContractID == contract.ID {
+				funding.initialized = true
+			}
+		case solana.InstructionFundEscrow:
+			if ix.Escrow() != escrowPDA || ix.Vault() != vault {
+				return nil, apperrors.NewBadRequest("transaction funds a different escrow")
+			}
+			if funding.signer != "" && funding.signer != ix.Signer() {
+				return nil, apperrors.NewBadRequest("transaction is funded by more than one wallet")
+			}
+			funding.signer = ix.Signer()
+			funding.lamports += ix.Amount
+		}
+	}
+	if funding.lamports == 0 {
+		return nil, apperrors.NewBadRequest("transaction does not fund this contract's escrow")
+	}
+
+	if !tx.IsSigner(funding.signer) {
+		return nil, apperrors.NewBadRequest("funding wallet did not sign the transaction")
+	}
+	if _, err := s.walletRepo.GetByUserIDAndAddress(ctx, contract.ClientID, funding.signer); err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewForbidden("transaction was not signed by one of your wallets")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+
+	received, ok := tx.BalanceChange(vault)
+	if !ok || received < 0 || uint64(received) != funding.lamports {
+		return nil, apperrors.NewBadRequest("vault balance change does not match the funded amount")
+	}
+
+	return funding, nil
+}
+
+// verifyEscrowAccount checks the on-chain escrow was set up for this
+// contract's parties and total
+func (s *EscrowService) verifyEscrowAccount(ctx context.Context, account *solana.EscrowAccount, contract *domain.Contract, signer string) error {
+	if account.Client.String() != signer {
+		return apperrors.NewBadRequest("escrow client does not match the funding wallet")
+	}
+	if account.TotalAmount != solana.SOLToLamports(contract.TotalAmountSOL) {
+		return apperrors.NewBadRequest("escrow total does not match the contract amount")
+	}
+	wallet, err := s.walletRepo.GetByUserIDAndAddress(ctx, contract.FreelancerID, account.Freelancer.String())
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return apperrors.NewBadRequest("escrow freelancer wallet does not belong to the contract's freelancer")
+		}
+		return apperrors.NewInternal(err)
+	}
+	if wallet.VerifiedAt == nil {
+		return apperrors.NewBadRequest("escrow freelancer wallet has not been verified")
+	}
+	return nil
+}
+
+// createFundingPayment stores the confirmed funding payment, completing
+// pending in place when the transaction was submitted before it landed
+func (s *EscrowService) createFundingPayment(ctx context.Context, escrow *domain.Escrow, contract *domain.Contract, tx *solana.Transaction, funding *fundingTx, pending *domain.Payment) (*domain.Payment, error) {
+	amount := solana.LamportsToSOL(funding.lamports)
+	slot := int64(tx.Slot)
+	now := time.Now()
+
+	payment := pending
+	if payment == nil {
+		payment = &domain.Payment{ContractID: contract.ID, PaymentType: domain.PaymentTypeEscrowFund}
+	}
+	payment.EscrowID = &escrow.ID
+	payment.FromWallet = funding.signer
+	payment.ToWallet = escrow.VaultAddress
+	payment.AmountSOL = amount
+	payment.NetAmountSOL = amount
+	payment.TxSignature = &tx.Signature
+	payment.Slot = &slot
+	payment.Status = domain.PaymentStatusConfirmed
+	payment.ConfirmedAt = &now
+	if tx.BlockTime != nil {
+		blockTime := time.Unix(*tx.BlockTime, 0)
+		payment.BlockTime = &blockTime
+	}
+
+	if pending != nil {
+		return payment, s.paymentRepo.Update(ctx, payment)
+	}
+	if err := s.paymentRepo.Create(ctx, payment); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// recordedFunding returns the stored result when signature has already
+// been recorded, and nil when it has not been seen
+func (s *EscrowService) recordedFunding(ctx context.Context, contract *domain.Contract, signature string) (*EscrowFundingResponse, error) {
+	payment, err := s.paymentRepo.GetByTxSignature(ctx, signature)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	if payment.ContractID != contract.ID || payment.PaymentType != domain.PaymentTypeEscrowFund {
+		return nil, apperrors.NewConflict("transaction has already been recorded for another payment")
+	}
+
+	// Finish activation if an earlier attempt stopped after recording the payment
+	if contract.Status == domain.ContractStatusPending {
+		escrow, err := s.escrowRepo.GetByContractID(ctx, contract.ID)
+		if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewInternal(err)
+		}
+		if escrow != nil && escrow.FundedAmountSOL.GreaterThanOrEqual(escrow.TotalAmountSOL) {
+			if err := s.contractService.ActivateContract(ctx, contract.ID); err != nil {
+				return nil, err
+			}
+			s.notifyContractStarted(ctx, contract)
+		}
+	}
+
+	return s.fundingResponse(ctx, contract.ID, payment)
+}
+
+func (s *EscrowService) fundingResponse(ctx context.Context, contractID uuid.UUID, payment *domain.Payment) (*EscrowFundingResponse, error) {
+	contract, err := s.contractRepo.GetByID(ctx, contractID)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+	// A funding submitted before it landed may not have an escrow row yet
+	escrow, err := s.escrowRepo.GetByContractID(ctx, contractID)
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		return nil, apperrors.NewInternal(err)
+	}
+	return &EscrowFundingResponse{
+		Contract: contract,
+		Escrow:   escrow,
+		Payment:  payment,
+	}, nil
+}
+
+func (s *EscrowService) notifyContractStarted(ctx context.Context, contract *domain.Contract) {
+	client, err := s.userRepo.GetByID(ctx, contract.ClientID)
+	if err != nil {
+		fmt.Printf("Failed to load client for contract %s: %v\n", contract.ID, err)
+		return
+	}
+	freelancer, err := s.userRepo.GetByID(ctx, contract.FreelancerID)
+	if err != nil {
+		fmt.Printf("Failed to load freelancer for contract %s: %v\n", contract.ID, err)
+		return
+	}
+
+	if err := s.notificationService.NotifyContractStarted(ctx, contract.ClientID, contract.ID, freelancer.Username); err != nil {
+		fmt.Printf("Failed to notify client of contract start: %v\n", err)
+	}
+	if err := s.notificationService.NotifyContractStarted(ctx, contract.FreelancerID, contract.ID, client.Username); err != nil {
+		fmt.Printf("Failed to notify freelancer of contract start: %v\n", err)
+	}
+}
+
+func (s *EscrowService) logEscrow(ctx context.Context, escrowID uuid.UUID, action string, amount *decimal.Decimal, signature *string, performedBy uuid.UUID, notes string) {
+	log := &domain.EscrowLog{
+		EscrowID:    escrowID,
+		Action:      action,
+		AmountSOL:   amount,
+		TxSignature: signature,
+		PerformedBy: &performedBy,
+		Notes:       &notes,
+	}
+	// A savepoint keeps a failed log write from aborting the caller's transaction
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		return s.escrowRepo.CreateLog(ctx, log)
+	})
+	if err != nil {
+		fmt.Printf("Failed to write escrow log for %s: %v\n", escrowID, err)
+	}
+}
+
+func validateTxSignature(signature string) error {
+	if signature == "" {
+		return apperrors.NewBadRequest("tx_signature is required")
+	}
+	decoded, err := utils.DecodeBase58(signature)
+	if err != nil || len(decoded) != solanaSignatureSize {
+		return apperrors.NewBadRequest("tx_signature is not a valid transaction signature")
+	}
+	return nil
+}
+
+// BuildTransactionRequest optionally picks which of the caller's wallets
+// signs a transaction. Instructions that must come from the escrow's own
+// client or freelancer wallet ignore it.
+type BuildTransactionRequest struct {
+	Wallet string `json:"wallet,omitempty"`
+}
+
+// UnsignedTransactionResponse is a serialized, unsigned escrow transaction
+// for the caller's wallet to sign and send
+type UnsignedTransactionResponse struct {
+	Instruction          string           `json:"instruction"`
+	Transaction          string           `json:"transaction"` // base64 wire format
+	FeePayer             string           `json:"fee_payer"`
+	Blockhash            string           `json:"blockhash"`
+	LastValidBlockHeight uint64           `json:"last_valid_block_height"`
+	EscrowPDA            string           `json:"escrow_pda"`
+	VaultAddress         string           `json:"vault_address"`
+	AmountSOL            *decimal.Decimal `json:"amount_sol,omitempty"`
+}
+
+// BuildInitializeEscrow builds initialize_escrow for a pending contract,
+// locking in the contract total and the freelancer's primary wallet
+func (s *EscrowService) BuildInitializeEscrow(ctx context.Context, contractID, userID uuid.UUID, req *BuildTransactionRequest) (*UnsignedTransactionResponse, error) {
+	contract, addrs, err := s.clientContract(ctx, contractID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if contract.Status != domain.ContractStatusPending {
+		return nil, apperrors.NewBadRequest("contract is not awaiting escrow funding")
+	}
+
+	if _, err := s.solanaClient.GetAccountInfo(ctx, addrs.Escrow.String()); err == nil {
+		return nil, apperrors.NewConflict("escrow has already been initialized")
+	} else if !errors.Is(err, solana.ErrAccountNotFound) {
+		return nil, apperrors.NewInternal(err)
+	}
+
+	client, err := s.resolveWallet(ctx, userID, req.Wallet)
+	if err != nil {
+		return nil, err
+	}
+	initialize, total, err := s.initializeInstruction(ctx, contract, addrs, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildTransaction(ctx, solana.InstructionInitializeEscrow, client, addrs, &total, initialize)
+}
+
+// BuildFundEscrow builds fund_escrow for whatever the contract still needs.
+// If the escrow does not exist yet, initialize_escrow is prepended so the
+// client signs once.
+func (s *EscrowService) BuildFundEscrow(ctx context.Context, contractID, userID uuid.UUID, req *BuildTransactionRequest) (*UnsignedTransactionResponse, error) {
+	contract, addrs, err := s.clientContract(ctx, contractID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if contract.Status != domain.ContractStatusPending {
+		return nil, apperrors.NewBadRequest("contract is not awaiting escrow funding")
+	}
+
+	var instructions []solana.Instruction
+	var client solana.PublicKey
+	var remaining uint64
+
+	account, _, err := solana.FetchEscrowAccount(ctx, s.solanaClient, s.programID, contract.ID)
+	switch {
+	case errors.Is(err, solana.ErrAccountNotFound):
+		client, err = s.resolveWallet(ctx, userID, req.Wallet)
+		if err != nil {
+			return nil, err
+		}
+		initialize, total, err := s.initializeInstruction(ctx, contract, addrs, client)
+		if err != nil {
+			return nil, err
+		}
+		instructions = append(instructions, initialize)
+		remaining = solana.SOLToLamports(total)
+	case err != nil:
+		return nil, apperrors.NewInternal(err)
+	default:
+		if account.TotalAmount != solana.SOLToLamports(contract.TotalAmountSOL) {
+			return nil, apperrors.NewBadRequest("escrow total does not match the contract amount")
+		}
+		if account.FundedAmount >= account.TotalAmount {
+			return nil, apperrors.NewBadRequest("escrow is already fully funded")
+		}
+		client = account.Client
+		if err := s.requireOwnWallet(ctx, userID, client.String()); err != nil {
+			return nil, err
+		}
+		remaining = account.TotalAmount - account.FundedAmount
+	}
+
+	amount := solana.LamportsToSOL(remaining)
+	instructions = append(instructions, solana.NewFundEscrowInstruction(s.programID, addrs, client, remaining))
+	return s.buildTransaction(ctx, solana.InstructionFundEscrow, client, addrs, &amount, instructions...)
+}
+
+// BuildReleaseMilestone builds release_milestone for an approved milestone,
+// paying exactly the milestone amount to the escrow's freelancer
+func (s *EscrowService) BuildReleaseMilestone(ctx context.Context, milestoneID, userID uuid.UUID) (*UnsignedTransactionResponse, error) {
+	milestone, err := s.milestoneRepo.GetByID(ctx, milestoneID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewNotFound("milestone")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	contract, addrs, err := s.clientContract(ctx, milestone.ContractID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureNotDisputed(contract); err != nil {
+		return nil, err
+	}
+	if milestone.PaymentID != nil || milestone.Status == domain.MilestoneStatusPaid {
+		return nil, apperrors.NewConflict("milestone has already been paid")
+	}
+	if milestone.Status != domain.MilestoneStatusApproved {
+		return nil, apperrors.NewBadRequest("milestone must be approved before funds are released")
+	}
+	if err := s.ensureNoDisputePayout(ctx, milestone); err != nil {
+		return nil, err
+	}
+
+	escrow, client, err := s.clientEscrow(ctx, contract.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	freelancer, err := solana.PublicKeyFromBase58(escrow.FreelancerWallet)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+
+	amount := milestone.AmountSOL
+	ix := solana.NewReleaseMilestoneInstruction(s.programID, addrs, client, freelancer, milestone.ID, solana.SOLToLamports(amount))
+	return s.buildTransaction(ctx, solana.InstructionReleaseMilestone, client, addrs, &amount, ix)
+}
+
+// BuildRefund builds refund for the remaining balance once the contract is
+// cancelled. Dispute refunds are paid by BuildDisputeSettlement.
+func (s *EscrowService) BuildRefund(ctx context.Context, contractID, userID uuid.UUID) (*UnsignedTransactionResponse, error) {
+	contract, addrs, err := s.clientContract(ctx, contractID, userID)
+	if err != nil {
+		return nil, err
+	}
+	escrow, client, err := s.clientEscrow(ctx, contract.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := s.refundableAmount(ctx, contract, escrow)
+	if err != nil {
+		return nil, err
+	}
+
+	ix := solana.NewRefundInstruction(s.programID, addrs, client, solana.SOLToLamports(amount))
+	return s.buildTransaction(ctx, solana.InstructionRefund, client, addrs, &amount, ix)
+}
+
+// BuildDisputeSettlement builds the transaction that pays out a resolved
+// dispute on the whole contract or, when milestoneID is set, on that
+// milestone: release_milestone for the freelancer's award and refund for
+// the client's share, both signed by the client. uuid.Nil stands in for the
+// milestone of a contract-wide award.
+func (s *EscrowService) BuildDisputeSettlement(ctx context.Context, contractID uuid.UUID, milestoneID *uuid.UUID, userID uuid.UUID) (*UnsignedTransactionResponse, error) {
+	contract, addrs, err := s.clientContract(ctx, contractID, userID)
+	if err != nil {
+		return nil, err
+	}
+	escrow, client, err := s.clientEscrow(ctx, contract.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := s.pendingDisputePayments(ctx, contract.ID)
+	if err != nil {
+		return nil, err
+	}
+	var payout, refund decimal.Decimal
+	for _, p := range pending {
+		if p.TxSignature != nil || paymentMilestone(&p) != derefMilestone(milestoneID) {
+			continue
+		}
+		if p.PaymentType == domain.PaymentTypeRefund {
+			refund = refund.Add(p.AmountSOL)
+		} else {
+			payout = payout.Add(p.AmountSOL)
+		}
+	}
+	total := payout.Add(refund)
+	if !total.IsPositive() {
+		return nil, apperrors.NewBadRequest("dispute has no payments left to settle")
+	}
+
+	var name string
+	var instructions []solana.Instruction
+	if payout.IsPositive() {
+		// The program only releases from a funded escrow that was not frozen
+		// with open_dispute
+		account, _, err := solana.FetchEscrowAccount(ctx, s.solanaClient, s.programID, contract.ID)
+		if err != nil {
+			return nil, apperrors.NewInternal(err)
+		}
+		if account.Status != solana.EscrowStatusFullyFunded && account.Status != solana.EscrowStatusPartiallyReleased {
+			return nil, apperrors.NewConflict(fmt.Sprintf("the escrow is %s on-chain, so the program cannot release the award", account.Status))
+		}
+
+		freelancer, err := solana.PublicKeyFromBase58(escrow.FreelancerWallet)
+		if err != nil {
+			return nil, apperrors.NewInternal(err)
+		}
+		name = solana.InstructionReleaseMilestone
+		instructions = append(instructions, solana.NewReleaseMilestoneInstruction(s.programID, addrs, client, freelancer,
+			derefMilestone(milestoneID), solana.SOLToLamports(payout)))
+	}
+	if refund.IsPositive() {
+		if name != "" {
+			name += ","
+		}
+		name += solana.InstructionRefund
+		instructions = append(instructions, solana.NewRefundInstruction(s.programID, addrs, client, solana.SOLToLamports(refund)))
+	}
+	return s.buildTransaction(ctx, name, client, addrs, &total, instructions...)
+}
+
+// BuildOpenDispute builds open_dispute, signed by whichever escrow wallet
+// belongs to the caller
+func (s *EscrowService) BuildOpenDispute(ctx context.Context, contractID, userID uuid.UUID) (*UnsignedTransactionResponse, error) {
+	contract, err := s.getContract(ctx, contractID)
+	if err != nil {
+		return nil, err
+	}
+
+	var walletAddress string
+	escrow, err := s.getEscrow(ctx, contract.ID)
+	if err != nil {
+		return nil, err
+	}
+	switch userID {
+	case contract.ClientID:
+		walletAddress = escrow.ClientWallet
+	case contract.FreelancerID:
+		walletAddress = escrow.FreelancerWallet
+	default:
+		return nil, apperrors.NewForbidden("you are not part of this contract")
+	}
+	if escrow.Status == domain.EscrowStatusDisputed {
+		return nil, apperrors.NewConflict("escrow is already disputed")
+	}
+	if escrow.Status == domain.EscrowStatusFullyReleased || escrow.Status == domain.EscrowStatusRefunded {
+		return nil, apperrors.NewBadRequest("escrow has already been settled")
+	}
+
+	initiator, err := s.ownWalletKey(ctx, userID, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := s.escrowAddresses(contract.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	ix := solana.NewOpenDisputeInstruction(s.programID, addrs, initiator)
+	return s.buildTransaction(ctx, solana.InstructionOpenDispute, initiator, addrs, nil, ix)
+}
+
+// BuildCloseEscrow builds close_escrow once the escrow is fully settled
+func (s *EscrowService) BuildCloseEscrow(ctx context.Context, contractID, userID uuid.UUID) (*UnsignedTransactionResponse, error) {
+	contract, addrs, err := s.clientContract(ctx, contractID, userID)
+	if err != nil {
+		return nil, err
+	}
+	escrow, client, err := s.clientEscrow(ctx, contract.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if escrow.Status != domain.EscrowStatusFullyReleased && escrow.Status != domain.EscrowStatusRefunded {
+		return nil, apperrors.NewBadRequest("escrow can only be closed once fully released or refunded")
+	}
+
+	ix := solana.NewCloseEscrowInstruction(s.programID, addrs, client)
+	return s.buildTransaction(ctx, solana.InstructionCloseEscrow, client, addrs, nil, ix)
+}
+
+// initializeInstruction builds initialize_escrow for the contract total,
+// paying out to the freelancer's primary verified wallet
+func (s *EscrowService) initializeInstruction(ctx context.Context, contract *domain.Contract, addrs *solana.EscrowAddresses, client solana.PublicKey) (solana.Instruction, decimal.Decimal, error) {
+	total := contract.TotalAmountSOL
+	if !total.IsPositive() {
+		return solana.Instruction{}, total, apperrors.NewBadRequest("contract has no amount to escrow")
+	}
+
+	wallets, err := s.walletRepo.GetByUserID(ctx, contract.FreelancerID)
+	if err != nil {
+		return solana.Instruction{}, total, apperrors.NewInternal(err)
+	}
+	var payout *domain.UserWallet
+	for i := range wallets {
+		if wallets[i].VerifiedAt == nil {
+			continue
+		}
+		if payout == nil || wallets[i].IsPrimary {
+			payout = &wallets[i]
+		}
+	}
+	if payout == nil {
+		return solana.Instruction{}, total, apperrors.NewBadRequest("freelancer has no verified wallet to receive payments")
+	}
+	freelancer, err := solana.PublicKeyFromBase58(payout.WalletAddress)
+	if err != nil {
+		return solana.Instruction{}, total, apperrors.NewInternal(err)
+	}
+
+	ix := solana.NewInitializeEscrowInstruction(s.programID, addrs, client, freelancer, contract.ID, solana.SOLToLamports(total))
+	return ix, total, nil
+}
+
+// refundableAmount is what the client may pull back: the unspent balance
+// of a cancelled contract less the milestones the cancellation kept, which
+// are still owed to the freelancer. Nothing is refundable while a dispute settlement is pending,
+// since the settlement pays out of the same balance.
+func (s *EscrowService) refundableAmount(ctx context.Context, contract *domain.Contract, escrow *domain.Escrow) (decimal.Decimal, error) {
+	pending, err := s.pendingDisputePayments(ctx, contract.ID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if len(pending) > 0 {
+		return decimal.Zero, apperrors.NewConflict("escrow is waiting for a dispute settlement")
+	}
+
+	if contract.Status != domain.ContractStatusCancelled {
+		return decimal.Zero, apperrors.NewBadRequest("refunds are only available for cancelled contracts")
+	}
+	milestones, err := s.milestoneRepo.GetByContractID(ctx, contract.ID)
+	if err != nil {
+		return decimal.Zero, apperrors.NewInternal(err)
+	}
+	remaining := cancellationRefund(escrow, milestones)
+	if !remaining.IsPositive() {
+		return decimal.Zero, apperrors.NewBadRequest("escrow has no funds left to refund")
+	}
+	return remaining, nil
+}
+
+func (s *EscrowService) buildTransaction(ctx context.Context, name string, feePayer solana.PublicKey, addrs *solana.EscrowAddresses, amount *decimal.Decimal, instructions ...solana.Instruction) (*UnsignedTransactionResponse, error) {
+	blockhash, err := s.solanaClient.GetLatestBlockhash(ctx)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+	raw, err := solana.BuildUnsignedTransaction(feePayer, blockhash.Blockhash, instructions...)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+
+	return &UnsignedTransactionResponse{
+		Instruction:          name,
+		Transaction:          base64.StdEncoding.EncodeToString(raw),
+		FeePayer:             feePayer.String(),
+		Blockhash:            blockhash.Blockhash,
+		LastValidBlockHeight: blockhash.LastValidBlockHeight,
+		EscrowPDA:            addrs.Escrow.String(),
+		VaultAddress:         addrs.Vault.String(),
+		AmountSOL:            amount,
+	}, nil
+}
+
+// clientContract loads a contract the caller is the client of, along with
+// its escrow PDAs
+func (s *EscrowService) clientContract(ctx context.Context, contractID, userID uuid.UUID) (*domain.Contract, *solana.EscrowAddresses, error) {
+	contract, err := s.getContract(ctx, contractID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if contract.ClientID != userID {
+		return nil, nil, apperrors.NewForbidden("you are not the client for this contract")
+	}
+	addrs, err := s.escrowAddresses(contract.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return contract, addrs, nil
+}
+
+// clientEscrow loads the contract's escrow and the client wallet recorded
+// on it, which must still belong to the caller
+func (s *EscrowService) clientEscrow(ctx context.Context, contractID, userID uuid.UUID) (*domain.Escrow, solana.PublicKey, error) {
+	escrow, err := s.getEscrow(ctx, contractID)
+	if err != nil {
+		return nil, solana.PublicKey{}, err
+	}
+	client, err := s.ownWalletKey(ctx, userID, escrow.ClientWallet)
+	if err != nil {
+		return nil, solana.PublicKey{}, err
+	}
+	return escrow, client, nil
+}
+
+func (s *EscrowService) escrowAddresses(contractID uuid.UUID) (*solana.EscrowAddresses, error) {
+	if s.programID.IsZero() {
+		return nil, apperrors.NewInternal(fmt.Errorf("escrow program ID is not configured"))
+	}
+	addrs, err := solana.DeriveEscrowAddresses(s.programID, contractID)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+	return addrs, nil
+}
+
+func (s *EscrowService) getContract(ctx context.Context, contractID uuid.UUID) (*domain.Contract, error) {
+	contract, err := s.contractRepo.GetByID(ctx, contractID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewNotFound("contract")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	return contract, nil
+}
+
+func (s *EscrowService) getEscrow(ctx context.Context, contractID uuid.UUID) (*domain.Escrow, error) {
+	escrow, err := s.escrowRepo.GetByContractID(ctx, contractID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewBadRequest("escrow has not been funded")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	return escrow, nil
+}
+
+// resolveWallet picks the requested wallet, or the caller's primary
+// verified wallet when none is given
+func (s *EscrowService) resolveWallet(ctx context.Context, userID uuid.UUID, requested string) (solana.PublicKey, error) {
+	requested = strings.TrimSpace(requested)
+	if requested != "" {
+		return s.ownWalletKey(ctx, userID, requested)
+	}
+
+	wallets, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return solana.PublicKey{}, apperrors.NewInternal(err)
+	}
+	for _, w := range wallets {
+		if w.IsPrimary && w.VerifiedAt != nil {
+			return s.ownWalletKey(ctx, userID, w.WalletAddress)
+		}
+	}
+	return solana.PublicKey{}, apperrors.NewBadRequest("connect and verify a wallet first")
+}
+
+// ownWalletKey checks address is one of the caller's verified wallets
+func (s *EscrowService) ownWalletKey(ctx context.Context, userID uuid.UUID, address string) (solana.PublicKey, error) {
+	if err := s.requireOwnWallet(ctx, userID, address); err != nil {
+		return solana.PublicKey{}, err
+	}
+	key, err := solana.PublicKeyFromBase58(address)
+	if err != nil {
+		return solana.PublicKey{}, apperrors.NewBadRequest("invalid wallet address")
+	}
+	return key, nil
+}
+
+func (s *EscrowService) requireOwnWallet(ctx context.Context, userID uuid.UUID, address string) error {
+	wallet, err := s.walletRepo.GetByUserIDAndAddress(ctx, userID, address)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return apperrors.NewForbidden(fmt.Sprintf("wallet %s is not linked to your account", address))
+		}
+		return apperrors.NewInternal(err)
+	}
+	if wallet.VerifiedAt == nil {
+		return apperrors.NewForbidden(fmt.Sprintf("wallet %s has not been verified", address))
+	}
+	return nil
+}
+
+// pendingDisputePayments returns the payments of resolved disputes that
+// have not settled on-chain yet
+func (s *EscrowService) pendingDisputePayments(ctx context.Context, contractID uuid.UUID) ([]domain.Payment, error) {
+	payments, err := s.paymentRepo.GetByContractID(ctx, contractID)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+	var pending []domain.Payment
+	for _, p := range payments {
+		if p.Status == domain.PaymentStatusPending &&
+			(p.PaymentType == domain.PaymentTypeDisputeResolution || p.PaymentType == domain.PaymentTypeRefund) {
+			pending = append(pending, p)
+		}
+	}
+	return pending, nil
+}
+
+// ensureNoDisputePayout rejects a regular release for a milestone whose
+// dispute awarded a payout the client has yet to settle
+func (s *EscrowService) ensureNoDisputePayout(ctx context.Context, milestone *domain.Milestone) error {
+	pending, err := s.pendingDisputePayments(ctx, milestone.ContractID)
+	if err != nil {
+		return err
+	}
+	for _, p := range pending {
+		if p.PaymentType == domain.PaymentTypeDisputeResolution && paymentMilestone(&p) == milestone.ID {
+			return apperrors.NewConflict("milestone is being paid through its dispute resolution")
+		}
+	}
+	return nil
+}
+
+// ensureNoPendingRelease rejects a second release while one submitted for
+// the milestone is still waiting to land
+func (s *EscrowService) ensureNoPendingRelease(ctx context.Context, milestone *domain.Milestone) error {
+	payments, err := s.paymentRepo.GetByContractID(ctx, milestone.ContractID)
+	if err != nil {
+		return apperrors.NewInternal(err)
+	}
+	for _, p := range payments {
+		if p.PaymentType == domain.PaymentTypeMilestoneRelease && p.Status == domain.PaymentStatusPending && paymentMilestone(&p) == milestone.ID {
+			return apperrors.NewConflict("a release for this milestone is already waiting for confirmation")
+		}
+	}
+	return nil
+}
+
+// SettleSubmitted applies the transaction of a payment that was submitted
+// before it landed, once the payment tracker sees it reach the configured
+// commitment. A transaction that does not match the payment is reported
+// as a client error (4xx AppError) so the tracker can fail the payment.
+func (s *EscrowService) SettleSubmitted(ctx context.Context, payment *domain.Payment) error {
+	tx, err := s.solanaClient.GetTransaction(ctx, *payment.TxSignature)
+	if err != nil {
+		return err
+	}
+	contract, err := s.contractRepo.GetByID(ctx, payment.ContractID)
+	if err != nil {
+		return err
+	}
+
+	switch payment.PaymentType {
+	case domain.PaymentTypeEscrowFund:
+		_, err = s.applyFunding(ctx, contract, tx, payment)
+	case domain.PaymentTypeMilestoneRelease:
+		if payment.MilestoneID == nil || payment.EscrowID == nil {
+			return apperrors.NewBadRequest("payment does not reference a milestone release")
+		}
+		var milestone *domain.Milestone
+		if milestone, err = s.milestoneRepo.GetByID(ctx, *payment.MilestoneID); err != nil {
+			return err
+		}
+		if milestone.PaymentID != nil {
+			return apperrors.NewBadRequest("milestone has already been paid")
+		}
+		var escrow *domain.Escrow
+		if escrow, err = s.escrowRepo.GetByID(ctx, *payment.EscrowID); err != nil {
+			return err
+		}
+		_, err = s.applyRelease(ctx, contract, milestone, escrow, tx, payment)
+	default:
+		confirmPayment(payment, tx)
+		err = s.paymentRepo.Update(ctx, payment)
+	}
+	return err
+}
+
+// applyDisputePayout books a dispute payout that settled on-chain: the
+// contract's released total grows and a disputed milestone becomes paid
+func (s *EscrowService) applyDisputePayout(ctx context.Context, payment *domain.Payment) error {
+	contract, err := s.contractRepo.GetByID(ctx, payment.ContractID)
+	if err != nil {
+		return err
+	}
+	contract.ReleasedAmountSOL = contract.ReleasedAmountSOL.Add(payment.AmountSOL)
+	if err := s.contractRepo.Update(ctx, contract); err != nil {
+		return err
+	}
+
+	if payment.MilestoneID != nil {
+		milestone, err := s.milestoneRepo.GetByID(ctx, *payment.MilestoneID)
+		if err != nil {
+			return err
+		}
+		if milestone.PaymentID == nil {
+			milestone.Status = domain.MilestoneStatusPaid
+			milestone.PaymentID = &payment.ID
+			milestone.PaidAt = payment.ConfirmedAt
+			if err := s.milestoneRepo.Update(ctx, milestone); err != nil {
+				return err
+			}
+		}
+		s.startNextMilestone(ctx, contract.ID)
+		s.notificationService.PublishMilestoneUpdate(ctx, contract, milestone)
+	}
+
+	if err := s.notificationService.NotifyPaymentReceived(ctx, contract.FreelancerID, contract.ID, payment.ID, payment.NetAmountSOL.String()); err != nil {
+		fmt.Printf("Failed to notify freelancer of payment: %v\n", err)
+	}
+	return nil
+}
+
+// paymentMilestone returns the payment's milestone, or uuid.Nil for a
+// contract-wide payment, matching how the program encodes it
+func paymentMilestone(p *domain.Payment) uuid.UUID {
+	return derefMilestone(p.MilestoneID)
+}
+
+func derefMilestone(id *uuid.UUID) uuid.UUID {
+	if id == nil {
+		return uuid.Nil
+	}
+	return *id
+}
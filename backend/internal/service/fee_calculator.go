@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/trenchjob/backend/internal/domain"
+	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+// Fee sources reported on a FeeQuote
+const (
+	FeeSourcePlatform  = "platform"
+	FeeSourcePromotion = "promotion"
+	FeeSourceCategory  = "category"
+	FeeSourceUser      = "user"
+)
+
+// FeeSubject identifies who is charged and for what kind of work
+type FeeSubject struct {
+	UserID     uuid.UUID
+	CategoryID *int
+}
+
+// FeeQuote is the platform's cut of a payment
+type FeeQuote struct {
+	Percentage decimal.Decimal `json:"fee_percentage"`
+	FeeSOL     decimal.Decimal `json:"platform_fee_sol"`
+	Source     string          `json:"source"`
+}
+
+// chargeFee records quote on a milestone release. The escrow program pays
+// the whole milestone to the freelancer and has no fee account, so nothing
+// is deducted: the payment nets its full amount and PlatformFeeSOL is a
+// receivable the platform collects separately.
+func chargeFee(payment *domain.Payment, quote FeeQuote) {
+	payment.PlatformFeeSOL = quote.FeeSOL
+	payment.NetAmountSOL = payment.AmountSOL
+}
+
+// FeeCalculator works out platform fees from the cached settings. Every
+// fee override active for the subject competes and the lowest wins, so a
+// platform-wide promotion also undercuts a higher category or user rate;
+// with no override the platform_fee_percentage setting applies.
+type FeeCalculator struct {
+	settingsService *SettingsService
+	jobRepo         repository.JobRepository
+}
+
+func NewFeeCalculator(settingsService *SettingsService, jobRepo repository.JobRepository) *FeeCalculator {
+	return &FeeCalculator{
+		settingsService: settingsService,
+		jobRepo:         jobRepo,
+	}
+}
+
+// Quote prices amount for subject at time at
+func (c *FeeCalculator) Quote(subject FeeSubject, amount decimal.Decimal, at time.Time) FeeQuote {
+	quote := FeeQuote{
+		Percentage: c.settingsService.Settings().FeePercentage,
+		Source:     FeeSourcePlatform,
+	}
+
+	matched := false
+	for _, o := range c.settingsService.FeeOverrides() {
+		if !o.ActiveAt(at) {
+			continue
+		}
+
+		var source string
+		switch {
+		case o.UserID != nil:
+			if *o.UserID != subject.UserID {
+				continue
+			}
+			source = FeeSourceUser
+		case o.CategoryID != nil:
+			if subject.CategoryID == nil || *o.CategoryID != *subject.CategoryID {
+				continue
+			}
+			source = FeeSourceCategory
+		default:
+			source = FeeSourcePromotion
+		}
+
+		if !matched || o.FeePercentage.LessThan(quote.Percentage) {
+			quote.Percentage = o.FeePercentage
+			quote.Source = source
+			matched = true
+		}
+	}
+
+	quote.FeeSOL = amount.Mul(quote.Percentage).Div(decimal.NewFromInt(100)).Round(9)
+	return quote
+}
+
+// QuoteForContract prices a payment to the contract's freelancer, using the
+// category of the job the contract was hired from
+func (c *FeeCalculator) QuoteForContract(ctx context.Context, contract *domain.Contract, amount decimal.Decimal) (FeeQuote, error) {
+	subject := FeeSubject{UserID: contract.FreelancerID}
+
+	job, err := c.jobRepo.GetByID(ctx, contract.JobID)
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		return FeeQuote{}, err
+	}
+	if job != nil {
+		subject.CategoryID = job.CategoryID
+	}
+
+	return c.Quote(subject, amount, time.Now()), nil
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,17 +16,20 @@ type JobService struct {
 	jobRepo      repository.JobRepository
 	proposalRepo repository.ProposalRepository
 	userRepo     repository.UserRepository
+	tx           repository.Transactor
 }
 
 func NewJobService(
 	jobRepo repository.JobRepository,
 	proposalRepo repository.ProposalRepository,
 	userRepo repository.UserRepository,
+	tx repository.Transactor,
 ) *JobService {
 	return &JobService{
 		jobRepo:      jobRepo,
 		proposalRepo: proposalRepo,
 		userRepo:     userRepo,
+		tx:           tx,
 	}
 }
 
@@ -89,15 +93,25 @@ func (s *JobService) CreateJob(ctx context.Context, clientID uuid.UUID, req *Cre
 		job.Visibility = domain.VisibilityPublic
 	}
 
-	if err := s.jobRepo.Create(ctx, job); err != nil {
-		return nil, err
-	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.jobRepo.Create(ctx, job); err != nil {
+			return err
+		}
 
-	// Add skills if provided
-	if len(req.Skills) > 0 {
-		if err := s.jobRepo.AddSkills(ctx, job.ID, req.Skills); err != nil {
-			// Non-fatal error, log but continue
+		// Add skills if provided
+		if len(req.Skills) > 0 {
+			err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+				return s.jobRepo.AddSkills(ctx, job.ID, req.Skills)
+			})
+			if err != nil {
+				// Non-fatal error, log but continue
+				fmt.Printf("failed to add job skills: %v\n", err)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return job, nil
@@ -187,16 +201,27 @@ func (s *JobService) UpdateJob(ctx context.Context, clientID, jobID uuid.UUID, r
 		job.Visibility = *req.Visibility
 	}
 
-	if err := s.jobRepo.Update(ctx, job); err != nil {
-		return nil, err
-	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.jobRepo.Update(ctx, job); err != nil {
+			return err
+		}
 
-	// Update skills if provided
-	if req.Skills != nil {
-		s.jobRepo.RemoveSkills(ctx, jobID)
-		if len(req.Skills) > 0 {
-			s.jobRepo.AddSkills(ctx, jobID, req.Skills)
+		// Replace skills if provided; a failed replacement keeps the old set
+		if req.Skills != nil {
+			err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+				if err := s.jobRepo.RemoveSkills(ctx, jobID); err != nil {
+					return err
+				}
+				return s.jobRepo.AddSkills(ctx, jobID, req.Skills)
+			})
+			if err != nil {
+				fmt.Printf("failed to update job skills: %v\n", err)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return job, nil
@@ -503,14 +528,19 @@ func (s *JobService) AcceptProposal(ctx context.Context, clientID, proposalID uu
 		return nil, apperrors.NewBadRequest("cannot accept proposal in current status")
 	}
 
-	proposal.Status = domain.ProposalStatusAccepted
-	if err := s.proposalRepo.Update(ctx, proposal); err != nil {
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		proposal.Status = domain.ProposalStatusAccepted
+		if err := s.proposalRepo.Update(ctx, proposal); err != nil {
+			return err
+		}
+
+		// Update job status to in_progress
+		job.Status = domain.JobStatusInProgress
+		return s.jobRepo.Update(ctx, job)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// Update job status to in_progress
-	job.Status = domain.JobStatusInProgress
-	s.jobRepo.Update(ctx, job)
-
 	return proposal, nil
 }
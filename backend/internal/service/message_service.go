@@ -3,6 +3,9 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +20,12 @@ type MessageService struct {
 	userRepo         repository.UserRepository
 	contractRepo     repository.ContractRepository
 	profileRepo      repository.ProfileRepository
+	presenceRepo     repository.PresenceRepository
+	tx               repository.Transactor
+	editWindow       time.Duration
+
+	// Attachments must be files served from here by the upload endpoint
+	uploadURL string
 }
 
 func NewMessageService(
@@ -25,6 +34,10 @@ func NewMessageService(
 	userRepo repository.UserRepository,
 	contractRepo repository.ContractRepository,
 	profileRepo repository.ProfileRepository,
+	presenceRepo repository.PresenceRepository,
+	tx repository.Transactor,
+	editWindow time.Duration,
+	uploadURL string,
 ) *MessageService {
 	return &MessageService{
 		conversationRepo: conversationRepo,
@@ -32,6 +45,11 @@ func NewMessageService(
 		userRepo:         userRepo,
 		contractRepo:     contractRepo,
 		profileRepo:      profileRepo,
+		presenceRepo:     presenceRepo,
+		tx:               tx,
+		editWindow:       editWindow,
+
+		uploadURL: uploadURL,
 	}
 }
 
@@ -71,13 +89,37 @@ type MessageResponse struct {
 	MessageType    string    `json:"message_type"`
 	IsEdited       bool      `json:"is_edited"`
 	CreatedAt      time.Time `json:"created_at"`
+
+	Attachments []domain.MessageAttachment `json:"attachments,omitempty"`
+
+	// Edits, soft delete and quoted replies. A deleted message keeps its
+	// place in the conversation with its text and attachments removed.
+	EditedAt         *time.Time    `json:"edited_at,omitempty"`
+	IsDeleted        bool          `json:"is_deleted"`
+	ReplyToMessageID *uuid.UUID    `json:"reply_to_message_id,omitempty"`
+	ReplyTo          *MessageQuote `json:"reply_to,omitempty"`
+}
+
+// MessageQuote is the message a reply quotes
+type MessageQuote struct {
+	ID             uuid.UUID `json:"id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	SenderUsername string    `json:"sender_username"`
+	MessageText    string    `json:"message_text"`
+	IsDeleted      bool      `json:"is_deleted"`
 }
 
 // SendMessageRequest represents a request to send a message
 type SendMessageRequest struct {
-	ConversationID uuid.UUID `json:"conversation_id"`
-	MessageText    string    `json:"message_text"`
-	MessageType    string    `json:"message_type,omitempty"`
+	ConversationID   uuid.UUID  `json:"conversation_id"`
+	MessageText      string     `json:"message_text"`
+	MessageType      string     `json:"message_type,omitempty"`
+	ReplyToMessageID *uuid.UUID `json:"reply_to_message_id,omitempty"`
+}
+
+// EditMessageRequest represents a request to change a message's text
+type EditMessageRequest struct {
+	MessageText string `json:"message_text"`
 }
 
 // CreateConversationRequest represents a request to create a conversation
@@ -121,8 +163,9 @@ func (s *MessageService) GetConversation(ctx context.Context, conversationID, us
 		return nil, apperrors.NewInternal(err)
 	}
 
-	// Verify user is participant (we'd need to add IsParticipant check)
-	// For now, we'll proceed
+	if err := s.ensureParticipant(ctx, conversationID, userID); err != nil {
+		return nil, err
+	}
 
 	// Mark as read
 	s.conversationRepo.UpdateLastRead(ctx, conversationID, userID)
@@ -136,7 +179,11 @@ func (s *MessageService) GetMessages(ctx context.Context, conversationID, userID
 		limit = 50
 	}
 
-	messages, total, err := s.messageRepo.GetByConversationID(ctx, conversationID, limit, offset)
+	if err := s.ensureParticipant(ctx, conversationID, userID); err != nil {
+		return nil, 0, err
+	}
+
+	messages, total, err := s.messageRepo.GetByConversationIDWithAttachments(ctx, conversationID, limit, offset)
 	if err != nil {
 		return nil, 0, apperrors.NewInternal(err)
 	}
@@ -146,17 +193,7 @@ func (s *MessageService) GetMessages(ctx context.Context, conversationID, userID
 
 	var responses []MessageResponse
 	for _, msg := range messages {
-		resp := s.toMessageResponse(&msg)
-		// Enrich with sender info
-		user, err := s.userRepo.GetByID(ctx, msg.SenderID)
-		if err == nil {
-			resp.SenderUsername = user.Username
-		}
-		profile, err := s.profileRepo.GetByUserID(ctx, msg.SenderID)
-		if err == nil && profile != nil {
-			resp.SenderAvatar = profile.AvatarURL
-		}
-		responses = append(responses, resp)
+		responses = append(responses, s.messageResponse(ctx, &msg))
 	}
 
 	return responses, total, nil
@@ -164,15 +201,44 @@ func (s *MessageService) GetMessages(ctx context.Context, conversationID, userID
 
 // SendMessage sends a message in a conversation
 func (s *MessageService) SendMessage(ctx context.Context, userID uuid.UUID, req *SendMessageRequest) (*MessageResponse, error) {
+	return s.SendMessageWithAttachments(ctx, userID, req, nil)
+}
+
+// SendMessageWithAttachments sends a message along with files already
+// uploaded through the upload endpoint
+func (s *MessageService) SendMessageWithAttachments(ctx context.Context, userID uuid.UUID, req *SendMessageRequest, attachments []domain.MessageAttachment) (*MessageResponse, error) {
 	if req.MessageText == "" {
 		return nil, apperrors.NewBadRequest("message text is required")
 	}
+	for _, att := range attachments {
+		if att.FileName == "" || att.FileURL == "" {
+			return nil, apperrors.NewBadRequest("attachments need a file name and URL")
+		}
+		if !s.isUploadedFile(att.FileURL) {
+			return nil, apperrors.NewBadRequest("attachments must be files uploaded through the upload endpoint")
+		}
+	}
 
 	// Verify conversation exists
 	conv, err := s.conversationRepo.GetByID(ctx, req.ConversationID)
 	if err != nil {
 		return nil, apperrors.NewNotFound("conversation not found")
 	}
+	if err := s.ensureParticipant(ctx, conv.ID, userID); err != nil {
+		return nil, err
+	}
+	if req.ReplyToMessageID != nil {
+		quoted, err := s.messageRepo.GetByID(ctx, *req.ReplyToMessageID)
+		if errors.Is(err, apperrors.ErrNotFound) || (err == nil && quoted.ConversationID != conv.ID) {
+			return nil, apperrors.NewBadRequest("reply_to_message_id is not a message in this conversation")
+		}
+		if err != nil {
+			return nil, apperrors.NewInternal(err)
+		}
+		if quoted.DeletedAt != nil {
+			return nil, apperrors.NewBadRequest("cannot reply to a deleted message")
+		}
+	}
 
 	// Create message
 	msgType := req.MessageType
@@ -185,26 +251,129 @@ func (s *MessageService) SendMessage(ctx context.Context, userID uuid.UUID, req
 		SenderID:       userID,
 		MessageText:    req.MessageText,
 		MessageType:    msgType,
+
+		ReplyToMessageID: req.ReplyToMessageID,
 	}
 
-	if err := s.messageRepo.Create(ctx, message); err != nil {
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.messageRepo.Create(ctx, message); err != nil {
+			return apperrors.NewInternal(err)
+		}
+		for i := range attachments {
+			attachments[i].MessageID = message.ID
+			if err := s.messageRepo.CreateAttachment(ctx, &attachments[i]); err != nil {
+				return apperrors.NewInternal(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	message.Attachments = attachments
+
+	resp := s.messageResponse(ctx, message)
+	return &resp, nil
+}
+
+// EditMessage changes the text of the sender's own message while the edit
+// window is open. Each edit keeps the text it replaced.
+func (s *MessageService) EditMessage(ctx context.Context, userID, messageID uuid.UUID, req *EditMessageRequest) (*MessageResponse, error) {
+	if req.MessageText == "" {
+		return nil, apperrors.NewBadRequest("message text is required")
+	}
+
+	message, err := s.getMessage(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.SenderID != userID {
+		return nil, apperrors.NewForbidden("you can only edit your own messages")
+	}
+	if message.DeletedAt != nil {
+		return nil, apperrors.NewBadRequest("message has been deleted")
+	}
+	if message.MessageType != domain.MessageTypeText {
+		return nil, apperrors.NewBadRequest("only text messages can be edited")
+	}
+	if time.Since(message.CreatedAt) > s.editWindow {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("messages can only be edited within %d minutes of sending", int(s.editWindow.Minutes())))
+	}
+
+	if req.MessageText != message.MessageText {
+		edit := &domain.MessageEdit{
+			MessageID:    message.ID,
+			PreviousText: message.MessageText,
+		}
+		message.MessageText = req.MessageText
+
+		err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			if err := s.messageRepo.CreateEdit(ctx, edit); err != nil {
+				return apperrors.NewInternal(err)
+			}
+			if err := s.messageRepo.Update(ctx, message); err != nil {
+				if errors.Is(err, apperrors.ErrNotFound) {
+					return apperrors.NewConflict("message was deleted")
+				}
+				return apperrors.NewInternal(err)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	message.Attachments, err = s.messageRepo.GetAttachmentsByMessageID(ctx, message.ID)
+	if err != nil {
 		return nil, apperrors.NewInternal(err)
 	}
 
-	// Enrich response
-	resp := s.toMessageResponse(message)
-	user, err := s.userRepo.GetByID(ctx, userID)
-	if err == nil {
-		resp.SenderUsername = user.Username
+	resp := s.messageResponse(ctx, message)
+	return &resp, nil
+}
+
+// DeleteMessage replaces the sender's own message with a tombstone.
+// Deleting an already deleted message returns the tombstone again.
+func (s *MessageService) DeleteMessage(ctx context.Context, userID, messageID uuid.UUID) (*MessageResponse, error) {
+	message, err := s.getMessage(ctx, messageID)
+	if err != nil {
+		return nil, err
 	}
-	profile, err := s.profileRepo.GetByUserID(ctx, userID)
-	if err == nil && profile != nil {
-		resp.SenderAvatar = profile.AvatarURL
+	if message.SenderID != userID {
+		return nil, apperrors.NewForbidden("you can only delete your own messages")
 	}
 
+	if message.DeletedAt == nil {
+		if err := s.messageRepo.SoftDelete(ctx, message); err != nil {
+			if errors.Is(err, apperrors.ErrNotFound) {
+				return nil, apperrors.NewConflict("message was already deleted")
+			}
+			return nil, apperrors.NewInternal(err)
+		}
+	}
+
+	resp := s.messageResponse(ctx, message)
 	return &resp, nil
 }
 
+// GetMessageEdits returns the earlier versions of a message, oldest first
+func (s *MessageService) GetMessageEdits(ctx context.Context, userID, messageID uuid.UUID) ([]domain.MessageEdit, error) {
+	message, err := s.getMessage(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureParticipant(ctx, message.ConversationID, userID); err != nil {
+		return nil, err
+	}
+
+	edits, err := s.messageRepo.GetEdits(ctx, message.ID)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+	return edits, nil
+}
+
 // CreateConversation creates a new conversation
 func (s *MessageService) CreateConversation(ctx context.Context, userID uuid.UUID, req *CreateConversationRequest) (*ConversationResponse, error) {
 	// Verify participant exists
@@ -219,23 +388,32 @@ func (s *MessageService) CreateConversation(ctx context.Context, userID uuid.UUI
 		ContractID: req.ContractID,
 	}
 
-	if err := s.conversationRepo.Create(ctx, conv); err != nil {
-		return nil, apperrors.NewInternal(err)
-	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.conversationRepo.Create(ctx, conv); err != nil {
+			return apperrors.NewInternal(err)
+		}
 
-	// Add participants
-	s.conversationRepo.AddParticipant(ctx, conv.ID, userID)
-	s.conversationRepo.AddParticipant(ctx, conv.ID, req.ParticipantID)
+		// Add participants
+		if err := s.addParticipants(ctx, conv.ID, userID, req.ParticipantID); err != nil {
+			return err
+		}
 
-	// Send initial message if provided
-	if req.InitialMessage != "" {
-		msg := &domain.Message{
-			ConversationID: conv.ID,
-			SenderID:       userID,
-			MessageText:    req.InitialMessage,
-			MessageType:    domain.MessageTypeText,
+		// Send initial message if provided
+		if req.InitialMessage != "" {
+			msg := &domain.Message{
+				ConversationID: conv.ID,
+				SenderID:       userID,
+				MessageText:    req.InitialMessage,
+				MessageType:    domain.MessageTypeText,
+			}
+			if err := s.messageRepo.Create(ctx, msg); err != nil {
+				return apperrors.NewInternal(err)
+			}
 		}
-		s.messageRepo.Create(ctx, msg)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return s.enrichConversation(ctx, conv, userID)
@@ -265,26 +443,45 @@ func (s *MessageService) GetOrCreateContractConversation(ctx context.Context, co
 		ContractID: &contractID,
 	}
 
-	if err := s.conversationRepo.Create(ctx, conv); err != nil {
-		return nil, apperrors.NewInternal(err)
-	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.conversationRepo.Create(ctx, conv); err != nil {
+			return apperrors.NewInternal(err)
+		}
 
-	// Add participants
-	s.conversationRepo.AddParticipant(ctx, conv.ID, contract.ClientID)
-	s.conversationRepo.AddParticipant(ctx, conv.ID, contract.FreelancerID)
+		// Add participants
+		if err := s.addParticipants(ctx, conv.ID, contract.ClientID, contract.FreelancerID); err != nil {
+			return err
+		}
 
-	// Send system message
-	msg := &domain.Message{
-		ConversationID: conv.ID,
-		SenderID:       userID,
-		MessageText:    "Conversation started for contract: " + contract.Title,
-		MessageType:    domain.MessageTypeSystem,
+		// Send system message
+		msg := &domain.Message{
+			ConversationID: conv.ID,
+			SenderID:       userID,
+			MessageText:    "Conversation started for contract: " + contract.Title,
+			MessageType:    domain.MessageTypeSystem,
+		}
+		if err := s.messageRepo.Create(ctx, msg); err != nil {
+			return apperrors.NewInternal(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	s.messageRepo.Create(ctx, msg)
 
 	return s.enrichConversation(ctx, conv, userID)
 }
 
+// addParticipants adds each user to the conversation
+func (s *MessageService) addParticipants(ctx context.Context, conversationID uuid.UUID, userIDs ...uuid.UUID) error {
+	for _, userID := range userIDs {
+		if err := s.conversationRepo.AddParticipant(ctx, conversationID, userID); err != nil {
+			return apperrors.NewInternal(err)
+		}
+	}
+	return nil
+}
+
 // GetUnreadCount returns total unread messages for a user
 func (s *MessageService) GetUnreadCount(ctx context.Context, userID uuid.UUID) (int, error) {
 	count, err := s.messageRepo.GetUnreadCount(ctx, userID)
@@ -349,7 +546,7 @@ func (s *MessageService) enrichConversation(ctx context.Context, conv *domain.Co
 }
 
 func (s *MessageService) toMessageResponse(msg *domain.Message) MessageResponse {
-	return MessageResponse{
+	resp := MessageResponse{
 		ID:             msg.ID,
 		ConversationID: msg.ConversationID,
 		SenderID:       msg.SenderID,
@@ -357,7 +554,95 @@ func (s *MessageService) toMessageResponse(msg *domain.Message) MessageResponse
 		MessageType:    msg.MessageType,
 		IsEdited:       msg.IsEdited,
 		CreatedAt:      msg.CreatedAt,
+		Attachments:    msg.Attachments,
+
+		EditedAt:         msg.EditedAt,
+		ReplyToMessageID: msg.ReplyToMessageID,
+	}
+	if msg.DeletedAt != nil {
+		resp.IsDeleted = true
+		resp.MessageText = ""
+		resp.Attachments = nil
+	}
+	return resp
+}
+
+// messageResponse builds a message response with the sender's details and
+// the message it replies to
+func (s *MessageService) messageResponse(ctx context.Context, msg *domain.Message) MessageResponse {
+	resp := s.toMessageResponse(msg)
+	user, err := s.userRepo.GetByID(ctx, msg.SenderID)
+	if err == nil {
+		resp.SenderUsername = user.Username
+	}
+	profile, err := s.profileRepo.GetByUserID(ctx, msg.SenderID)
+	if err == nil && profile != nil {
+		resp.SenderAvatar = profile.AvatarURL
+	}
+
+	if msg.ReplyToMessageID != nil {
+		quoted, err := s.messageRepo.GetByID(ctx, *msg.ReplyToMessageID)
+		if err == nil {
+			resp.ReplyTo = &MessageQuote{
+				ID:          quoted.ID,
+				SenderID:    quoted.SenderID,
+				MessageText: quoted.MessageText,
+				IsDeleted:   quoted.DeletedAt != nil,
+			}
+			if sender, err := s.userRepo.GetByID(ctx, quoted.SenderID); err == nil {
+				resp.ReplyTo.SenderUsername = sender.Username
+			}
+		}
+	}
+	return resp
+}
+
+// getMessage loads a message, reporting a missing one as a 404
+func (s *MessageService) getMessage(ctx context.Context, messageID uuid.UUID) (*domain.Message, error) {
+	message, err := s.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewNotFound("message not found")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	return message, nil
+}
+
+// isUploadedFile reports whether url names a single file under the upload
+// endpoint's URL, so attachments cannot link to arbitrary sites
+func (s *MessageService) isUploadedFile(url string) bool {
+	name, ok := strings.CutPrefix(url, s.uploadURL)
+	return ok && name != "" && !strings.ContainsAny(name, "/\\?#") && !strings.Contains(name, "..")
+}
+
+// ensureParticipant rejects users who are not in the conversation
+func (s *MessageService) ensureParticipant(ctx context.Context, conversationID, userID uuid.UUID) error {
+	ok, err := s.conversationRepo.IsParticipant(ctx, conversationID, userID)
+	if err != nil {
+		return apperrors.NewInternal(err)
+	}
+	if !ok {
+		return apperrors.NewForbidden("you are not part of this conversation")
 	}
+	return nil
+}
+
+// UpdatePresence records a user going online or offline. It matches the
+// WebSocket hub's presence callback, which runs outside any request.
+func (s *MessageService) UpdatePresence(userID uuid.UUID, isOnline bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.presenceRepo.SetOnline(ctx, userID, isOnline); err != nil {
+		log.Printf("presence: failed to update user %s: %v", userID, err)
+	}
+}
+
+// GetContactIDs returns the users who share a conversation with userID and
+// so should hear about their presence
+func (s *MessageService) GetContactIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	return s.conversationRepo.GetContactIDs(ctx, userID)
 }
 
 // Add missing methods to repository interface
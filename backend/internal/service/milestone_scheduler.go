@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trenchjob/backend/internal/domain"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+// milestoneSchedulerBatchSize caps how many milestones each timer handles per pass
+const milestoneSchedulerBatchSize = 100
+
+// MilestoneScheduler runs the timers on milestones of active contracts.
+// Undelivered work past its due date gets a reminder to the freelancer and,
+// once the grace period runs out, an overdue flag both parties hear about.
+// Submitted work the client leaves unreviewed is approved automatically
+// after the review period, with both parties warned ahead of time, so
+// funds do not sit in escrow because a client went silent.
+type MilestoneScheduler struct {
+	milestoneRepo       repository.MilestoneRepository
+	contractRepo        repository.ContractRepository
+	notificationService *NotificationService
+	overdueGrace        time.Duration
+	autoApproveAfter    time.Duration
+	autoApproveNotice   time.Duration
+}
+
+// NewMilestoneScheduler builds a scheduler; an autoApproveAfter of zero
+// turns auto-approval off
+func NewMilestoneScheduler(
+	milestoneRepo repository.MilestoneRepository,
+	contractRepo repository.ContractRepository,
+	notificationService *NotificationService,
+	overdueGrace, autoApproveAfter, autoApproveNotice time.Duration,
+) *MilestoneScheduler {
+	if autoApproveNotice > autoApproveAfter {
+		autoApproveNotice = autoApproveAfter
+	}
+	return &MilestoneScheduler{
+		milestoneRepo:       milestoneRepo,
+		contractRepo:        contractRepo,
+		notificationService: notificationService,
+		overdueGrace:        overdueGrace,
+		autoApproveAfter:    autoApproveAfter,
+		autoApproveNotice:   autoApproveNotice,
+	}
+}
+
+// Run checks milestone timers every interval until ctx is cancelled
+func (s *MilestoneScheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			overdue, approved, err := s.Poll(ctx)
+			if err != nil {
+				log.Printf("milestone scheduler: %v", err)
+			}
+			if overdue > 0 || approved > 0 {
+				log.Printf("milestone scheduler: flagged %d milestones overdue, auto-approved %d", overdue, approved)
+			}
+		}
+	}
+}
+
+// Poll runs one pass of both timers
+func (s *MilestoneScheduler) Poll(ctx context.Context) (overdue, approved int, err error) {
+	now := time.Now()
+
+	overdue, err = s.checkDueDates(ctx, now)
+	if err != nil {
+		return overdue, 0, err
+	}
+	if s.autoApproveAfter <= 0 {
+		return overdue, 0, nil
+	}
+	approved, err = s.checkReviews(ctx, now)
+	return overdue, approved, err
+}
+
+// checkDueDates reminds the freelancer once a milestone passes its due
+// date and flags it overdue when the grace period has also passed
+func (s *MilestoneScheduler) checkDueDates(ctx context.Context, now time.Time) (int, error) {
+	milestones, err := s.milestoneRepo.ListPastDue(ctx, now, now.Add(-s.overdueGrace), milestoneSchedulerBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list past due milestones: %w", err)
+	}
+
+	flagged := 0
+	for i := range milestones {
+		milestone := &milestones[i]
+		contract, err := s.contractRepo.GetByID(ctx, milestone.ContractID)
+		if err != nil {
+			log.Printf("milestone scheduler: failed to load contract %s: %v", milestone.ContractID, err)
+			continue
+		}
+
+		if now.Before(milestone.DueDate.Add(s.overdueGrace)) {
+			if milestone.DueReminderSentAt != nil {
+				continue
+			}
+			milestone.DueReminderSentAt = &now
+			if err := s.milestoneRepo.Update(ctx, milestone); err != nil {
+				log.Printf("milestone scheduler: failed to record reminder for milestone %s: %v", milestone.ID, err)
+				continue
+			}
+			if err := s.notificationService.NotifyMilestoneDueReminder(ctx, contract.FreelancerID, contract.ID, milestone.Title); err != nil {
+				fmt.Printf("Failed to send due reminder for milestone %s: %v\n", milestone.ID, err)
+			}
+			continue
+		}
+
+		milestone.OverdueAt = &now
+		if err := s.milestoneRepo.Update(ctx, milestone); err != nil {
+			log.Printf("milestone scheduler: failed to flag milestone %s overdue: %v", milestone.ID, err)
+			continue
+		}
+		flagged++
+		s.notificationService.PublishMilestoneUpdate(ctx, contract, milestone)
+		s.notifyParties(contract, func(userID uuid.UUID) error {
+			return s.notificationService.NotifyMilestoneOverdue(ctx, userID, contract.ID, milestone.Title)
+		})
+	}
+
+	return flagged, nil
+}
+
+// checkReviews warns both parties when a submitted milestone nears the end
+// of its review period and approves it once the period is over. Approval
+// always comes a full notice period after the warning, so a milestone found
+// already past its review period (after an outage, a pause or a shorter
+// setting) is warned first and approved later.
+func (s *MilestoneScheduler) checkReviews(ctx context.Context, now time.Time) (int, error) {
+	milestones, err := s.milestoneRepo.ListSubmittedBefore(ctx, now.Add(s.autoApproveNotice-s.autoApproveAfter), now.Add(-s.autoApproveAfter), now.Add(-s.autoApproveNotice), milestoneSchedulerBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list milestones awaiting review: %w", err)
+	}
+
+	approved := 0
+	for i := range milestones {
+		milestone := &milestones[i]
+		contract, err := s.contractRepo.GetByID(ctx, milestone.ContractID)
+		if err != nil {
+			log.Printf("milestone scheduler: failed to load contract %s: %v", milestone.ContractID, err)
+			continue
+		}
+
+		approveAt := s.approveAt(milestone, now)
+		if milestone.AutoApproveNoticeSentAt != nil && now.Before(approveAt) {
+			continue
+		}
+		if milestone.AutoApproveNoticeSentAt == nil {
+			milestone.AutoApproveNoticeSentAt = &now
+			if err := s.milestoneRepo.Update(ctx, milestone); err != nil {
+				log.Printf("milestone scheduler: failed to record approval notice for milestone %s: %v", milestone.ID, err)
+				continue
+			}
+			date := approveAt.UTC().Format("2006-01-02 15:04 UTC")
+			s.notifyParties(contract, func(userID uuid.UUID) error {
+				return s.notificationService.NotifyAutoApprovalScheduled(ctx, userID, contract.ID, milestone.Title, date)
+			})
+			continue
+		}
+
+		milestone.Status = domain.MilestoneStatusApproved
+		milestone.ApprovedAt = &now
+		if err := s.milestoneRepo.Update(ctx, milestone); err != nil {
+			log.Printf("milestone scheduler: failed to auto-approve milestone %s: %v", milestone.ID, err)
+			continue
+		}
+		approved++
+		s.notificationService.PublishMilestoneUpdate(ctx, contract, milestone)
+		s.notifyParties(contract, func(userID uuid.UUID) error {
+			return s.notificationService.NotifyMilestoneAutoApproved(ctx, userID, contract.ID, milestone.Title)
+		})
+	}
+
+	return approved, nil
+}
+
+// approveAt is when a submitted milestone is auto-approved: the end of its
+// review period, but no sooner than a notice period after the parties were
+// warned, or would be warned at now
+func (s *MilestoneScheduler) approveAt(milestone *domain.Milestone, now time.Time) time.Time {
+	approveAt := milestone.SubmittedAt.Add(s.autoApproveAfter)
+	noticedAt := now
+	if milestone.AutoApproveNoticeSentAt != nil {
+		noticedAt = *milestone.AutoApproveNoticeSentAt
+	}
+	if earliest := noticedAt.Add(s.autoApproveNotice); approveAt.Before(earliest) {
+		return earliest
+	}
+	return approveAt
+}
+
+func (s *MilestoneScheduler) notifyParties(contract *domain.Contract, notify func(userID uuid.UUID) error) {
+	for _, userID := range []uuid.UUID{contract.ClientID, contract.FreelancerID} {
+		if err := notify(userID); err != nil {
+			fmt.Printf("Failed to notify user %s on contract %s: %v\n", userID, contract.ID, err)
+		}
+	}
+}
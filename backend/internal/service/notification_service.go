@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/trenchjob/backend/internal/domain"
@@ -10,11 +11,25 @@ import (
 
 type NotificationService struct {
 	notificationRepo repository.NotificationRepository
+	pusher           LivePusher
+	tx               repository.Transactor
 }
 
-func NewNotificationService(notificationRepo repository.NotificationRepository) *NotificationService {
+// LivePusher delivers to a user's open WebSocket connections on any
+// instance. The WebSocket hub implements it.
+type LivePusher interface {
+	PushNotification(userID uuid.UUID, notification interface{})
+	PushEvent(userID uuid.UUID, event interface{})
+}
+
+// NewNotificationService creates the service; a nil pusher keeps
+// notifications in the database only. Pushes made inside a transaction of
+// tx are sent once it commits.
+func NewNotificationService(notificationRepo repository.NotificationRepository, pusher LivePusher, tx repository.Transactor) *NotificationService {
 	return &NotificationService{
 		notificationRepo: notificationRepo,
+		pusher:           pusher,
+		tx:               tx,
 	}
 }
 
@@ -68,7 +83,7 @@ func (s *NotificationService) NotifyNewProposal(ctx context.Context, clientID, j
 		JobID:      &jobID,
 		ProposalID: &proposalID,
 	}
-	return s.notificationRepo.Create(ctx, notification)
+	return s.create(ctx, notification)
 }
 
 func (s *NotificationService) NotifyProposalAccepted(ctx context.Context, freelancerID, jobID, proposalID uuid.UUID, jobTitle string) error {
@@ -80,7 +95,7 @@ func (s *NotificationService) NotifyProposalAccepted(ctx context.Context, freela
 		JobID:      &jobID,
 		ProposalID: &proposalID,
 	}
-	return s.notificationRepo.Create(ctx, notification)
+	return s.create(ctx, notification)
 }
 
 func (s *NotificationService) NotifyContractStarted(ctx context.Context, userID, contractID uuid.UUID, otherPartyName string) error {
@@ -91,7 +106,7 @@ func (s *NotificationService) NotifyContractStarted(ctx context.Context, userID,
 		Message:    stringPtr("Your contract with " + otherPartyName + " has begun"),
 		ContractID: &contractID,
 	}
-	return s.notificationRepo.Create(ctx, notification)
+	return s.create(ctx, notification)
 }
 
 func (s *NotificationService) NotifyMilestoneSubmitted(ctx context.Context, clientID, contractID uuid.UUID, milestoneName string) error {
@@ -102,7 +117,7 @@ func (s *NotificationService) NotifyMilestoneSubmitted(ctx context.Context, clie
 		Message:    stringPtr("The milestone \"" + milestoneName + "\" has been submitted for your approval"),
 		ContractID: &contractID,
 	}
-	return s.notificationRepo.Create(ctx, notification)
+	return s.create(ctx, notification)
 }
 
 func (s *NotificationService) NotifyPaymentReceived(ctx context.Context, freelancerID, contractID, paymentID uuid.UUID, amount string) error {
@@ -114,7 +129,31 @@ func (s *NotificationService) NotifyPaymentReceived(ctx context.Context, freelan
 		ContractID: &contractID,
 		PaymentID:  &paymentID,
 	}
-	return s.notificationRepo.Create(ctx, notification)
+	return s.create(ctx, notification)
+}
+
+func (s *NotificationService) NotifyPaymentConfirmed(ctx context.Context, userID, contractID, paymentID uuid.UUID, amount string) error {
+	notification := &domain.Notification{
+		UserID:     userID,
+		Type:       domain.NotificationTypePaymentConfirmed,
+		Title:      "Payment Confirmed",
+		Message:    stringPtr("A payment of " + amount + " SOL has been confirmed on-chain"),
+		ContractID: &contractID,
+		PaymentID:  &paymentID,
+	}
+	return s.create(ctx, notification)
+}
+
+func (s *NotificationService) NotifyPaymentFailed(ctx context.Context, userID, contractID, paymentID uuid.UUID, amount, reason string) error {
+	notification := &domain.Notification{
+		UserID:     userID,
+		Type:       domain.NotificationTypePaymentFailed,
+		Title:      "Payment Failed",
+		Message:    stringPtr("A payment of " + amount + " SOL failed: " + reason),
+		ContractID: &contractID,
+		PaymentID:  &paymentID,
+	}
+	return s.create(ctx, notification)
 }
 
 func (s *NotificationService) NotifyContractCompleted(ctx context.Context, userID, contractID uuid.UUID) error {
@@ -125,7 +164,7 @@ func (s *NotificationService) NotifyContractCompleted(ctx context.Context, userI
 		Message:    stringPtr("The contract has been successfully completed"),
 		ContractID: &contractID,
 	}
-	return s.notificationRepo.Create(ctx, notification)
+	return s.create(ctx, notification)
 }
 
 func (s *NotificationService) NotifyNewMessage(ctx context.Context, userID uuid.UUID, senderName string) error {
@@ -135,7 +174,7 @@ func (s *NotificationService) NotifyNewMessage(ctx context.Context, userID uuid.
 		Title:   "New Message",
 		Message: stringPtr("You have a new message from " + senderName),
 	}
-	return s.notificationRepo.Create(ctx, notification)
+	return s.create(ctx, notification)
 }
 
 func (s *NotificationService) NotifyDisputeOpened(ctx context.Context, userID, contractID uuid.UUID) error {
@@ -146,7 +185,7 @@ func (s *NotificationService) NotifyDisputeOpened(ctx context.Context, userID, c
 		Message:    stringPtr("A dispute has been opened on your contract"),
 		ContractID: &contractID,
 	}
-	return s.notificationRepo.Create(ctx, notification)
+	return s.create(ctx, notification)
 }
 
 func (s *NotificationService) NotifyDisputeResolved(ctx context.Context, userID, contractID uuid.UUID, resolution string) error {
@@ -157,7 +196,227 @@ func (s *NotificationService) NotifyDisputeResolved(ctx context.Context, userID,
 		Message:    stringPtr("The dispute has been resolved: " + resolution),
 		ContractID: &contractID,
 	}
-	return s.notificationRepo.Create(ctx, notification)
+	return s.create(ctx, notification)
+}
+
+func (s *NotificationService) NotifyTimesheetSubmitted(ctx context.Context, clientID, contractID uuid.UUID, week string) error {
+	notification := &domain.Notification{
+		UserID:     clientID,
+		Type:       domain.NotificationTypeTimesheetSubmitted,
+		Title:      "Timesheet Ready for Review",
+		Message:    stringPtr("The timesheet for the week of " + week + " has been submitted for your approval"),
+		ContractID: &contractID,
+	}
+	return s.create(ctx, notification)
+}
+
+func (s *NotificationService) NotifyTimesheetApproved(ctx context.Context, freelancerID, contractID uuid.UUID, week, amount string) error {
+	notification := &domain.Notification{
+		UserID:     freelancerID,
+		Type:       domain.NotificationTypeTimesheetApproved,
+		Title:      "Timesheet Approved",
+		Message:    stringPtr("Your timesheet for the week of " + week + " was approved for " + amount + " SOL"),
+		ContractID: &contractID,
+	}
+	return s.create(ctx, notification)
+}
+
+func (s *NotificationService) NotifyTimesheetDisputed(ctx context.Context, freelancerID, contractID uuid.UUID, week, reason string) error {
+	notification := &domain.Notification{
+		UserID:     freelancerID,
+		Type:       domain.NotificationTypeTimesheetDisputed,
+		Title:      "Timesheet Disputed",
+		Message:    stringPtr("Your timesheet for the week of " + week + " was disputed: " + reason),
+		ContractID: &contractID,
+	}
+	return s.create(ctx, notification)
+}
+
+func (s *NotificationService) NotifyContractPaused(ctx context.Context, userID, contractID uuid.UUID, reason string) error {
+	notification := &domain.Notification{
+		UserID:     userID,
+		Type:       domain.NotificationTypeContractPaused,
+		Title:      "Contract Paused",
+		Message:    stringPtr("Your contract has been paused: " + reason),
+		ContractID: &contractID,
+	}
+	return s.create(ctx, notification)
+}
+
+func (s *NotificationService) NotifyContractResumed(ctx context.Context, userID, contractID uuid.UUID) error {
+	notification := &domain.Notification{
+		UserID:     userID,
+		Type:       domain.NotificationTypeContractResumed,
+		Title:      "Contract Resumed",
+		Message:    stringPtr("Your contract has been resumed"),
+		ContractID: &contractID,
+	}
+	return s.create(ctx, notification)
+}
+
+func (s *NotificationService) NotifyCancellationRequested(ctx context.Context, userID, contractID uuid.UUID, message string) error {
+	notification := &domain.Notification{
+		UserID:     userID,
+		Type:       domain.NotificationTypeCancellationRequested,
+		Title:      "Cancellation Requested",
+		Message:    stringPtr(message),
+		ContractID: &contractID,
+	}
+	return s.create(ctx, notification)
+}
+
+func (s *NotificationService) NotifyContractCancelled(ctx context.Context, userID, contractID uuid.UUID) error {
+	notification := &domain.Notification{
+		UserID:     userID,
+		Type:       domain.NotificationTypeContractCancelled,
+		Title:      "Contract Cancelled",
+		Message:    stringPtr("The contract has been cancelled"),
+		ContractID: &contractID,
+	}
+	return s.create(ctx, notification)
+}
+
+// NotifyMilestoneDueReminder reminds the freelancer that a milestone is past due
+func (s *NotificationService) NotifyMilestoneDueReminder(ctx context.Context, freelancerID, contractID uuid.UUID, milestoneName string) error {
+	notification := &domain.Notification{
+		UserID:     freelancerID,
+		Type:       domain.NotificationTypeMilestoneDueReminder,
+		Title:      "Milestone Past Due",
+		Message:    stringPtr("The milestone '" + milestoneName + "' is past its due date"),
+		ContractID: &contractID,
+	}
+	return s.create(ctx, notification)
+}
+
+// NotifyMilestoneOverdue tells a party that a milestone has been flagged overdue
+func (s *NotificationService) NotifyMilestoneOverdue(ctx context.Context, userID, contractID uuid.UUID, milestoneName string) error {
+	notification := &domain.Notification{
+		UserID:     userID,
+		Type:       domain.NotificationTypeMilestoneOverdue,
+		Title:      "Milestone Overdue",
+		Message:    stringPtr("The milestone '" + milestoneName + "' is overdue"),
+		ContractID: &contractID,
+	}
+	return s.create(ctx, notification)
+}
+
+// NotifyAutoApprovalScheduled warns a party that a milestone will be approved automatically
+func (s *NotificationService) NotifyAutoApprovalScheduled(ctx context.Context, userID, contractID uuid.UUID, milestoneName, date string) error {
+	notification := &domain.Notification{
+		UserID:     userID,
+		Type:       domain.NotificationTypeAutoApprovalScheduled,
+		Title:      "Milestone Approval Pending",
+		Message:    stringPtr("The milestone '" + milestoneName + "' will be approved automatically on " + date + " unless it is reviewed first"),
+		ContractID: &contractID,
+	}
+	return s.create(ctx, notification)
+}
+
+// NotifyMilestoneAutoApproved tells a party that a milestone was approved automatically
+func (s *NotificationService) NotifyMilestoneAutoApproved(ctx context.Context, userID, contractID uuid.UUID, milestoneName string) error {
+	notification := &domain.Notification{
+		UserID:     userID,
+		Type:       domain.NotificationTypeMilestoneAutoApproved,
+		Title:      "Milestone Approved Automatically",
+		Message:    stringPtr("The milestone '" + milestoneName + "' was approved automatically after the review period ran out"),
+		ContractID: &contractID,
+	}
+	return s.create(ctx, notification)
+}
+
+// NotifyNewReview tells a user someone reviewed them
+func (s *NotificationService) NotifyNewReview(ctx context.Context, revieweeID, contractID uuid.UUID, reviewerName, stars string) error {
+	notification := &domain.Notification{
+		UserID:     revieweeID,
+		Type:       domain.NotificationTypeNewReview,
+		Title:      "New Review Received",
+		Message:    stringPtr(reviewerName + " left you a " + stars + " review"),
+		ContractID: &contractID,
+	}
+	return s.create(ctx, notification)
+}
+
+// create stores a notification and pushes it to the user's open sockets
+func (s *NotificationService) create(ctx context.Context, notification *domain.Notification) error {
+	if err := s.notificationRepo.Create(ctx, notification); err != nil {
+		return err
+	}
+	if s.pusher != nil {
+		resp := s.toNotificationResponse(notification)
+		s.afterCommit(ctx, func() {
+			s.pusher.PushNotification(notification.UserID, resp)
+		})
+	}
+	return nil
+}
+
+// Live events tell both parties' dashboards that a record changed state, so
+// they can refresh it without polling
+const (
+	LiveEventContractUpdated  = "contract.updated"
+	LiveEventMilestoneUpdated = "milestone.updated"
+	LiveEventOrderUpdated     = "order.updated"
+)
+
+// LiveEvent is the payload of a pushed state change
+type LiveEvent struct {
+	Type        string     `json:"type"`
+	ContractID  *uuid.UUID `json:"contract_id,omitempty"`
+	MilestoneID *uuid.UUID `json:"milestone_id,omitempty"`
+	OrderID     *uuid.UUID `json:"order_id,omitempty"`
+	Status      string     `json:"status"`
+	OccurredAt  string     `json:"occurred_at"`
+}
+
+// PublishContractUpdate pushes a contract's current status to both parties
+func (s *NotificationService) PublishContractUpdate(ctx context.Context, contract *domain.Contract) {
+	s.publish(ctx, &LiveEvent{
+		Type:       LiveEventContractUpdated,
+		ContractID: &contract.ID,
+		Status:     contract.Status,
+	}, contract.ClientID, contract.FreelancerID)
+}
+
+// PublishMilestoneUpdate pushes a milestone's current status to both parties
+// of its contract
+func (s *NotificationService) PublishMilestoneUpdate(ctx context.Context, contract *domain.Contract, milestone *domain.Milestone) {
+	s.publish(ctx, &LiveEvent{
+		Type:        LiveEventMilestoneUpdated,
+		ContractID:  &contract.ID,
+		MilestoneID: &milestone.ID,
+		Status:      milestone.Status,
+	}, contract.ClientID, contract.FreelancerID)
+}
+
+// PublishOrderUpdate pushes a service order's current status to both parties
+func (s *NotificationService) PublishOrderUpdate(ctx context.Context, order *domain.ServiceOrder) {
+	s.publish(ctx, &LiveEvent{
+		Type:    LiveEventOrderUpdated,
+		OrderID: &order.ID,
+		Status:  order.Status,
+	}, order.ClientID, order.FreelancerID)
+}
+
+func (s *NotificationService) publish(ctx context.Context, event *LiveEvent, userIDs ...uuid.UUID) {
+	if s.pusher == nil {
+		return
+	}
+	event.OccurredAt = time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	s.afterCommit(ctx, func() {
+		for _, userID := range userIDs {
+			s.pusher.PushEvent(userID, event)
+		}
+	})
+}
+
+// afterCommit holds a live push back until the caller's transaction
+// commits, so clients are never told about changes that roll back
+func (s *NotificationService) afterCommit(ctx context.Context, push func()) {
+	if s.tx == nil {
+		push()
+		return
+	}
+	s.tx.AfterCommit(ctx, push)
 }
 
 func (s *NotificationService) toNotificationResponse(n *domain.Notification) NotificationResponse {
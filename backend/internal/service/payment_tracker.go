@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trenchjob/backend/internal/domain"
+	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
+	"github.com/trenchjob/backend/internal/pkg/solana"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+// paymentTrackerBatchSize is the most signatures getSignatureStatuses accepts per call
+const paymentTrackerBatchSize = 256
+
+// PaymentTracker moves submitted payments out of pending. Each pass looks
+// up the signatures of pending payments and settles the ones that reached
+// the configured commitment through the escrow service, which verifies the
+// transaction against the payment as it would have on submission. Payments
+// whose transaction errored on-chain, does not match, or never landed
+// within the timeout are failed.
+type PaymentTracker struct {
+	paymentRepo         repository.PaymentRepository
+	contractRepo        repository.ContractRepository
+	notificationService *NotificationService
+	solanaClient        solana.Client
+	commitment          solana.Commitment
+	timeout             time.Duration
+
+	escrowService *EscrowService
+}
+
+func NewPaymentTracker(
+	paymentRepo repository.PaymentRepository,
+	contractRepo repository.ContractRepository,
+	notificationService *NotificationService,
+	solanaClient solana.Client,
+	commitment solana.Commitment,
+	timeout time.Duration,
+	escrowService *EscrowService,
+) *PaymentTracker {
+	return &PaymentTracker{
+		paymentRepo:         paymentRepo,
+		contractRepo:        contractRepo,
+		notificationService: notificationService,
+		solanaClient:        solanaClient,
+		commitment:          commitment,
+		timeout:             timeout,
+
+		escrowService: escrowService,
+	}
+}
+
+// Run checks pending payments every interval until ctx is cancelled
+func (t *PaymentTracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			confirmed, failed, err := t.Poll(ctx)
+			if err != nil {
+				log.Printf("payment tracker: %v", err)
+			}
+			if confirmed > 0 || failed > 0 {
+				log.Printf("payment tracker: confirmed %d payments, failed %d", confirmed, failed)
+			}
+		}
+	}
+}
+
+// Poll settles one batch of the oldest pending payments
+func (t *PaymentTracker) Poll(ctx context.Context) (confirmed, failed int, err error) {
+	payments, err := t.paymentRepo.ListPendingSubmitted(ctx, paymentTrackerBatchSize)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list pending payments: %w", err)
+	}
+	if len(payments) == 0 {
+		return 0, 0, nil
+	}
+
+	signatures := make([]string, len(payments))
+	for n := range payments {
+		signatures[n] = *payments[n].TxSignature
+	}
+	statuses, err := t.solanaClient.GetSignatureStatuses(ctx, signatures...)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load signature statuses: %w", err)
+	}
+
+	for n := range payments {
+		payment := &payments[n]
+		status := statuses[n]
+
+		switch {
+		case status != nil && status.Failed():
+			err = t.fail(ctx, payment, "the transaction failed on-chain")
+			failed++
+		case status != nil && status.Reached(t.commitment):
+			var settled bool
+			if settled, err = t.confirm(ctx, payment); settled {
+				confirmed++
+			} else if err == nil {
+				failed++
+			}
+		case status == nil && time.Since(payment.InitiatedAt) > t.timeout:
+			err = t.fail(ctx, payment, "the transaction was not confirmed in time")
+			failed++
+		default:
+			// Landed but below the commitment, or still propagating
+			continue
+		}
+		if err != nil {
+			return confirmed, failed, fmt.Errorf("payment %s: %w", payment.ID, err)
+		}
+	}
+	return confirmed, failed, nil
+}
+
+// confirm settles a payment whose transaction reached the commitment,
+// reporting false when the transaction was rejected and the payment was
+// failed instead. Other errors leave it pending for the next pass.
+func (t *PaymentTracker) confirm(ctx context.Context, payment *domain.Payment) (bool, error) {
+	if err := t.escrowService.SettleSubmitted(ctx, payment); err != nil {
+		var appErr *apperrors.AppError
+		if !errors.As(err, &appErr) || (appErr.StatusCode != http.StatusBadRequest && appErr.StatusCode != http.StatusForbidden) {
+			return false, err
+		}
+		// Settling may have filled in the payment before it was rejected
+		stored, loadErr := t.paymentRepo.GetByID(ctx, payment.ID)
+		if loadErr != nil {
+			return false, loadErr
+		}
+		*payment = *stored
+		return false, t.fail(ctx, payment, appErr.Message)
+	}
+
+	t.notifyParties(ctx, payment, func(userID, contractID uuid.UUID) error {
+		return t.notificationService.NotifyPaymentConfirmed(ctx, userID, contractID, payment.ID, payment.AmountSOL.String())
+	})
+	return true, nil
+}
+
+func (t *PaymentTracker) fail(ctx context.Context, payment *domain.Payment, reason string) error {
+	payment.Status = domain.PaymentStatusFailed
+	if err := t.paymentRepo.Update(ctx, payment); err != nil {
+		return err
+	}
+
+	t.notifyParties(ctx, payment, func(userID, contractID uuid.UUID) error {
+		return t.notificationService.NotifyPaymentFailed(ctx, userID, contractID, payment.ID, payment.AmountSOL.String(), reason)
+	})
+	return nil
+}
+
+func (t *PaymentTracker) notifyParties(ctx context.Context, payment *domain.Payment, notify func(userID, contractID uuid.UUID) error) {
+	contract, err := t.contractRepo.GetByID(ctx, payment.ContractID)
+	if err != nil {
+		fmt.Printf("Failed to load contract %s for payment notification: %v\n", payment.ContractID, err)
+		return
+	}
+	for _, userID := range []uuid.UUID{contract.ClientID, contract.FreelancerID} {
+		if err := notify(userID, contract.ID); err != nil {
+			fmt.Printf("Failed to send payment notification: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,509 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/trenchjob/backend/internal/domain"
+	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
+	"github.com/trenchjob/backend/internal/pkg/solana"
+	"github.com/trenchjob/backend/internal/pkg/utils"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+// The fakes embed their repository interface so only the methods the
+// release and tracker paths touch need implementing
+
+type fakePaymentRepo struct {
+	repository.PaymentRepository
+	payments []*domain.Payment
+}
+
+func (r *fakePaymentRepo) Create(_ context.Context, p *domain.Payment) error {
+	for _, existing := range r.payments {
+		if p.TxSignature != nil && existing.TxSignature != nil && *existing.TxSignature == *p.TxSignature && existing.PaymentType == p.PaymentType {
+			return apperrors.ErrConflict
+		}
+	}
+	p.ID = uuid.New()
+	p.InitiatedAt = time.Now()
+	stored := *p
+	r.payments = append(r.payments, &stored)
+	return nil
+}
+
+func (r *fakePaymentRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.Payment, error) {
+	for _, p := range r.payments {
+		if p.ID == id {
+			copied := *p
+			return &copied, nil
+		}
+	}
+	return nil, apperrors.ErrNotFound
+}
+
+func (r *fakePaymentRepo) GetByContractID(_ context.Context, contractID uuid.UUID) ([]domain.Payment, error) {
+	var out []domain.Payment
+	for _, p := range r.payments {
+		if p.ContractID == contractID {
+			out = append(out, *p)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakePaymentRepo) GetByTxSignature(ctx context.Context, signature string) (*domain.Payment, error) {
+	payments, _ := r.ListByTxSignature(ctx, signature)
+	if len(payments) == 0 {
+		return nil, apperrors.ErrNotFound
+	}
+	return &payments[0], nil
+}
+
+func (r *fakePaymentRepo) ListByTxSignature(_ context.Context, signature string) ([]domain.Payment, error) {
+	var out []domain.Payment
+	for _, p := range r.payments {
+		if p.TxSignature != nil && *p.TxSignature == signature {
+			out = append(out, *p)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakePaymentRepo) ListPendingSubmitted(_ context.Context, limit int) ([]domain.Payment, error) {
+	var out []domain.Payment
+	for _, p := range r.payments {
+		if p.Status == domain.PaymentStatusPending && p.TxSignature != nil && len(out) < limit {
+			out = append(out, *p)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakePaymentRepo) Update(_ context.Context, p *domain.Payment) error {
+	for n, existing := range r.payments {
+		if existing.ID == p.ID {
+			stored := *p
+			r.payments[n] = &stored
+			return nil
+		}
+	}
+	return apperrors.ErrNotFound
+}
+
+type fakeContractRepo struct {
+	repository.ContractRepository
+	contract *domain.Contract
+}
+
+func (r *fakeContractRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.Contract, error) {
+	if r.contract.ID != id {
+		return nil, apperrors.ErrNotFound
+	}
+	copied := *r.contract
+	return &copied, nil
+}
+
+func (r *fakeContractRepo) Update(_ context.Context, c *domain.Contract) error {
+	copied := *c
+	r.contract = &copied
+	return nil
+}
+
+type fakeMilestoneRepo struct {
+	repository.MilestoneRepository
+	milestone *domain.Milestone
+	getErr    error
+}
+
+func (r *fakeMilestoneRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.Milestone, error) {
+	if r.getErr != nil {
+		return nil, r.getErr
+	}
+	if r.milestone.ID != id {
+		return nil, apperrors.ErrNotFound
+	}
+	copied := *r.milestone
+	return &copied, nil
+}
+
+func (r *fakeMilestoneRepo) GetByContractID(_ context.Context, _ uuid.UUID) ([]domain.Milestone, error) {
+	return []domain.Milestone{*r.milestone}, nil
+}
+
+func (r *fakeMilestoneRepo) Update(_ context.Context, m *domain.Milestone) error {
+	copied := *m
+	r.milestone = &copied
+	return nil
+}
+
+type fakeEscrowRepo struct {
+	repository.EscrowRepository
+	escrow *domain.Escrow
+	logs   []domain.EscrowLog
+}
+
+func (r *fakeEscrowRepo) GetByID(_ context.Context, id uuid.UUID) (*domain.Escrow, error) {
+	if r.escrow.ID != id {
+		return nil, apperrors.ErrNotFound
+	}
+	copied := *r.escrow
+	return &copied, nil
+}
+
+func (r *fakeEscrowRepo) GetByContractID(_ context.Context, _ uuid.UUID) (*domain.Escrow, error) {
+	copied := *r.escrow
+	return &copied, nil
+}
+
+func (r *fakeEscrowRepo) Update(_ context.Context, e *domain.Escrow) error {
+	copied := *e
+	r.escrow = &copied
+	return nil
+}
+
+func (r *fakeEscrowRepo) GetByPDA(_ context.Context, pda string) (*domain.Escrow, error) {
+	if r.escrow.EscrowPDA != pda {
+		return nil, apperrors.ErrNotFound
+	}
+	copied := *r.escrow
+	return &copied, nil
+}
+
+func (r *fakeEscrowRepo) CreateLog(_ context.Context, l *domain.EscrowLog) error {
+	r.logs = append(r.logs, *l)
+	return nil
+}
+
+type fakeWalletRepo struct {
+	repository.WalletRepository
+	wallet *domain.UserWallet
+}
+
+func (r *fakeWalletRepo) GetByUserIDAndAddress(_ context.Context, userID uuid.UUID, address string) (*domain.UserWallet, error) {
+	if r.wallet.UserID != userID || r.wallet.WalletAddress != address {
+		return nil, apperrors.ErrNotFound
+	}
+	return r.wallet, nil
+}
+
+type fakeJobRepo struct {
+	repository.JobRepository
+}
+
+func (fakeJobRepo) GetByID(context.Context, uuid.UUID) (*domain.Job, error) {
+	return nil, apperrors.ErrNotFound
+}
+
+type fakeNotificationRepo struct {
+	repository.NotificationRepository
+	created []domain.Notification
+}
+
+func (r *fakeNotificationRepo) Create(_ context.Context, n *domain.Notification) error {
+	r.created = append(r.created, *n)
+	return nil
+}
+
+type fakeTransactor struct{}
+
+func (fakeTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (fakeTransactor) AfterCommit(_ context.Context, fn func()) {
+	fn()
+}
+
+// fakeChain serves transactions that have landed; anything else is not
+// found, as for a signature still propagating
+type fakeChain struct {
+	solana.Client
+	landed map[string]*solana.Transaction
+	// history is the program's signatures, oldest first
+	history []solana.SignatureInfo
+}
+
+func (c *fakeChain) GetTransaction(_ context.Context, signature string) (*solana.Transaction, error) {
+	tx, ok := c.landed[signature]
+	if !ok {
+		return nil, solana.ErrTransactionNotFound
+	}
+	return tx, nil
+}
+
+func (c *fakeChain) GetSignaturesForAddress(_ context.Context, _ string, opts solana.SignaturesOptions) ([]solana.SignatureInfo, error) {
+	var out []solana.SignatureInfo
+	for n := len(c.history) - 1; n >= 0 && c.history[n].Signature != opts.Until; n-- {
+		out = append(out, c.history[n])
+	}
+	return out, nil
+}
+
+func (c *fakeChain) GetSignatureStatuses(_ context.Context, signatures ...string) ([]*solana.SignatureStatus, error) {
+	out := make([]*solana.SignatureStatus, len(signatures))
+	for n, signature := range signatures {
+		if tx, ok := c.landed[signature]; ok {
+			out[n] = &solana.SignatureStatus{Slot: tx.Slot, ConfirmationStatus: solana.CommitmentConfirmed}
+		}
+	}
+	return out, nil
+}
+
+type trackerFixture struct {
+	programID  solana.PublicKey
+	client     solana.PublicKey
+	freelancer solana.PublicKey
+	addrs      *solana.EscrowAddresses
+
+	payments      *fakePaymentRepo
+	contracts     *fakeContractRepo
+	milestones    *fakeMilestoneRepo
+	escrows       *fakeEscrowRepo
+	notifications *fakeNotificationRepo
+	chain         *fakeChain
+
+	escrowService *EscrowService
+	tracker       *PaymentTracker
+}
+
+func newTrackerFixture(t *testing.T) *trackerFixture {
+	t.Helper()
+	f := &trackerFixture{
+		programID:  solana.PublicKey{1},
+		client:     solana.PublicKey{2},
+		freelancer: solana.PublicKey{3},
+	}
+
+	contract := &domain.Contract{
+		ID:             uuid.New(),
+		ClientID:       uuid.New(),
+		FreelancerID:   uuid.New(),
+		TotalAmountSOL: decimal.RequireFromString("2"),
+		Status:         domain.ContractStatusActive,
+	}
+	addrs, err := solana.DeriveEscrowAddresses(f.programID, contract.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.addrs = addrs
+
+	f.payments = &fakePaymentRepo{}
+	f.contracts = &fakeContractRepo{contract: contract}
+	f.milestones = &fakeMilestoneRepo{milestone: &domain.Milestone{
+		ID:         uuid.New(),
+		ContractID: contract.ID,
+		Title:      "Design",
+		AmountSOL:  decimal.RequireFromString("1.5"),
+		Status:     domain.MilestoneStatusApproved,
+	}}
+	f.escrows = &fakeEscrowRepo{escrow: &domain.Escrow{
+		ID:               uuid.New(),
+		ContractID:       contract.ID,
+		EscrowPDA:        addrs.Escrow.String(),
+		VaultAddress:     addrs.Vault.String(),
+		ClientWallet:     f.client.String(),
+		FreelancerWallet: f.freelancer.String(),
+		TotalAmountSOL:   contract.TotalAmountSOL,
+		FundedAmountSOL:  contract.TotalAmountSOL,
+		Status:           domain.EscrowStatusFunded,
+	}}
+	verified := time.Now()
+	wallets := &fakeWalletRepo{wallet: &domain.UserWallet{
+		UserID:        contract.ClientID,
+		WalletAddress: f.client.String(),
+		VerifiedAt:    &verified,
+	}}
+	f.notifications = &fakeNotificationRepo{}
+	f.chain = &fakeChain{landed: map[string]*solana.Transaction{}}
+
+	notificationService := NewNotificationService(f.notifications, nil, fakeTransactor{})
+	settings := NewSettingsService(nil, domain.PlatformSettings{FeePercentage: decimal.RequireFromString("10")})
+	f.escrowService = NewEscrowService(
+		f.contracts, f.milestones, f.escrows, f.payments, wallets, nil, fakeTransactor{},
+		nil, notificationService, f.chain, f.programID,
+		NewFeeCalculator(settings, fakeJobRepo{}),
+	)
+	f.tracker = NewPaymentTracker(
+		f.payments, f.contracts, notificationService, f.chain,
+		solana.CommitmentConfirmed, time.Hour, f.escrowService,
+	)
+	return f
+}
+
+// releaseTx is a landed release_milestone transaction signed by the client
+// that paid lamports for milestoneID, with the event the program logs
+func (f *trackerFixture) releaseTx(signature string, milestoneID uuid.UUID, lamports uint64) *solana.Transaction {
+	ix := solana.NewReleaseMilestoneInstruction(f.programID, f.addrs, f.client, f.freelancer, milestoneID, lamports)
+
+	keys := []string{}
+	index := map[solana.PublicKey]int{}
+	for _, key := range append([]solana.PublicKey{f.client}, f.addrs.Escrow, f.addrs.Vault, f.freelancer, solana.SystemProgramID, f.programID) {
+		if _, ok := index[key]; !ok {
+			index[key] = len(keys)
+			keys = append(keys, key.String())
+		}
+	}
+	accounts := make([]int, len(ix.Accounts))
+	for n, acc := range ix.Accounts {
+		accounts[n] = index[acc.PublicKey]
+	}
+
+	discriminator := sha256.Sum256([]byte("event:" + solana.EventMilestoneReleased))
+	event := append([]byte{}, discriminator[:8]...)
+	event = append(event, f.addrs.Escrow[:]...)
+	event = append(event, milestoneID[:]...)
+	event = append(event, f.freelancer[:]...)
+	event = binary.LittleEndian.AppendUint64(event, lamports)
+	event = binary.LittleEndian.AppendUint64(event, lamports)
+	event = append(event, byte(solana.EscrowStatusPartiallyReleased))
+
+	program := f.programID.String()
+	blockTime := time.Now().Unix()
+	return &solana.Transaction{
+		Signature: signature,
+		Slot:      4242,
+		BlockTime: &blockTime,
+		Meta: &solana.TransactionMeta{
+			LogMessages: []string{
+				"Program " + program + " invoke [1]",
+				"Program data: " + base64.StdEncoding.EncodeToString(event),
+				"Program " + program + " success",
+			},
+		},
+		Transaction: solana.TransactionEnvelope{
+			Signatures: []string{signature},
+			Message: solana.TransactionMessage{
+				Header:      solana.MessageHeader{NumRequiredSignatures: 1},
+				AccountKeys: keys,
+				Instructions: []solana.CompiledInstruction{{
+					ProgramIDIndex: index[f.programID],
+					Accounts:       accounts,
+					Data:           utils.EncodeBase58(ix.Data),
+				}},
+			},
+		},
+	}
+}
+
+func testSignature(seed byte) string {
+	sig := make([]byte, 64)
+	sig[0] = seed
+	return utils.EncodeBase58(sig)
+}
+
+func TestPaymentTrackerSettlesSubmittedRelease(t *testing.T) {
+	ctx := context.Background()
+	f := newTrackerFixture(t)
+	milestone := f.milestones.milestone
+	signature := testSignature(1)
+
+	resp, err := f.escrowService.ReleaseMilestone(ctx, milestone.ID, f.contracts.contract.ClientID,
+		&ReleaseMilestoneRequest{TxSignature: signature})
+	if err != nil {
+		t.Fatalf("ReleaseMilestone: %v", err)
+	}
+	if resp.Payment.Status != domain.PaymentStatusPending || resp.Payment.TxSignature == nil || *resp.Payment.TxSignature != signature {
+		t.Fatalf("submitted payment = %+v, want pending with the signature", resp.Payment)
+	}
+	if f.milestones.milestone.Status != domain.MilestoneStatusApproved {
+		t.Fatalf("milestone status = %s before the transaction landed", f.milestones.milestone.Status)
+	}
+
+	// Still propagating: the tracker leaves it alone
+	if confirmed, failed, err := f.tracker.Poll(ctx); err != nil || confirmed != 0 || failed != 0 {
+		t.Fatalf("Poll before landing = %d, %d, %v", confirmed, failed, err)
+	}
+
+	f.chain.landed[signature] = f.releaseTx(signature, milestone.ID, solana.SOLToLamports(milestone.AmountSOL))
+	confirmed, failed, err := f.tracker.Poll(ctx)
+	if err != nil || confirmed != 1 || failed != 0 {
+		t.Fatalf("Poll = %d, %d, %v; want 1 confirmed", confirmed, failed, err)
+	}
+
+	payment, _ := f.payments.GetByID(ctx, resp.Payment.ID)
+	if payment.Status != domain.PaymentStatusConfirmed || payment.Slot == nil || *payment.Slot != 4242 || payment.BlockTime == nil {
+		t.Fatalf("payment = %+v, want confirmed at slot 4242", payment)
+	}
+	if !payment.PlatformFeeSOL.Equal(decimal.RequireFromString("0.15")) {
+		t.Errorf("platform fee = %s, want 0.15", payment.PlatformFeeSOL)
+	}
+	if !payment.NetAmountSOL.Equal(payment.AmountSOL) {
+		t.Errorf("net amount = %s, want the full %s the program paid out", payment.NetAmountSOL, payment.AmountSOL)
+	}
+	if got := f.milestones.milestone; got.Status != domain.MilestoneStatusPaid || got.PaymentID == nil || *got.PaymentID != payment.ID {
+		t.Errorf("milestone = %s paid by %v, want paid by %s", got.Status, got.PaymentID, payment.ID)
+	}
+	if !f.contracts.contract.ReleasedAmountSOL.Equal(milestone.AmountSOL) {
+		t.Errorf("contract released = %s, want %s", f.contracts.contract.ReleasedAmountSOL, milestone.AmountSOL)
+	}
+	if len(f.notifications.created) == 0 {
+		t.Error("no notifications were sent")
+	}
+
+	// Nothing is left pending
+	if confirmed, failed, err := f.tracker.Poll(ctx); err != nil || confirmed != 0 || failed != 0 {
+		t.Fatalf("second Poll = %d, %d, %v", confirmed, failed, err)
+	}
+}
+
+func TestPaymentTrackerFailsMismatchedRelease(t *testing.T) {
+	ctx := context.Background()
+	f := newTrackerFixture(t)
+	milestone := f.milestones.milestone
+	signature := testSignature(2)
+
+	resp, err := f.escrowService.ReleaseMilestone(ctx, milestone.ID, f.contracts.contract.ClientID,
+		&ReleaseMilestoneRequest{TxSignature: signature})
+	if err != nil {
+		t.Fatalf("ReleaseMilestone: %v", err)
+	}
+
+	// The transaction that landed released another milestone
+	f.chain.landed[signature] = f.releaseTx(signature, uuid.New(), solana.SOLToLamports(milestone.AmountSOL))
+	confirmed, failed, err := f.tracker.Poll(ctx)
+	if err != nil || confirmed != 0 || failed != 1 {
+		t.Fatalf("Poll = %d, %d, %v; want 1 failed", confirmed, failed, err)
+	}
+
+	payment, _ := f.payments.GetByID(ctx, resp.Payment.ID)
+	if payment.Status != domain.PaymentStatusFailed {
+		t.Errorf("payment status = %s, want failed", payment.Status)
+	}
+	if f.milestones.milestone.Status != domain.MilestoneStatusApproved || f.milestones.milestone.PaymentID != nil {
+		t.Errorf("milestone = %+v, want it still approved and unpaid", f.milestones.milestone)
+	}
+}
+
+func TestPaymentTrackerRejectsSecondPendingRelease(t *testing.T) {
+	ctx := context.Background()
+	f := newTrackerFixture(t)
+	milestone := f.milestones.milestone
+	clientID := f.contracts.contract.ClientID
+
+	if _, err := f.escrowService.ReleaseMilestone(ctx, milestone.ID, clientID, &ReleaseMilestoneRequest{TxSignature: testSignature(3)}); err != nil {
+		t.Fatalf("ReleaseMilestone: %v", err)
+	}
+	// Resubmitting the same signature returns the pending payment
+	resp, err := f.escrowService.ReleaseMilestone(ctx, milestone.ID, clientID, &ReleaseMilestoneRequest{TxSignature: testSignature(3)})
+	if err != nil || resp.Payment.Status != domain.PaymentStatusPending {
+		t.Fatalf("resubmission = %+v, %v; want the pending payment", resp, err)
+	}
+	// A different signature for the same milestone is a conflict
+	_, err = f.escrowService.ReleaseMilestone(ctx, milestone.ID, clientID, &ReleaseMilestoneRequest{TxSignature: testSignature(4)})
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) || appErr.StatusCode != http.StatusConflict {
+		t.Fatalf("second release = %v, want a 409 conflict", err)
+	}
+	if len(f.payments.payments) != 1 {
+		t.Errorf("stored %d payments, want 1", len(f.payments.payments))
+	}
+}
@@ -21,6 +21,7 @@ type ProfileService struct {
 	userRepo         repository.UserRepository
 	socialRepo       repository.SocialRepository
 	tokenWorkRepo    repository.TokenWorkRepository
+	tx               repository.Transactor
 	dexScreener      *dexscreener.Client
 }
 
@@ -31,6 +32,7 @@ func NewProfileService(
 	userRepo repository.UserRepository,
 	socialRepo repository.SocialRepository,
 	tokenWorkRepo repository.TokenWorkRepository,
+	tx repository.Transactor,
 ) *ProfileService {
 	return &ProfileService{
 		profileRepo:      profileRepo,
@@ -39,6 +41,7 @@ func NewProfileService(
 		userRepo:         userRepo,
 		socialRepo:       socialRepo,
 		tokenWorkRepo:    tokenWorkRepo,
+		tx:               tx,
 		dexScreener:      dexscreener.NewClient(),
 	}
 }
@@ -174,6 +177,7 @@ type UpdateProfileRequest struct {
 // UpdateProfile updates a user's profile
 func (s *ProfileService) UpdateProfile(ctx context.Context, userID uuid.UUID, req *UpdateProfileRequest) (*domain.Profile, error) {
 	profile, err := s.profileRepo.GetByUserID(ctx, userID)
+	isNew := false
 	if err != nil {
 		if errors.Is(err, apperrors.ErrNotFound) {
 			// Create profile if it doesn't exist
@@ -181,9 +185,7 @@ func (s *ProfileService) UpdateProfile(ctx context.Context, userID uuid.UUID, re
 				UserID:           userID,
 				AvailableForHire: true,
 			}
-			if err := s.profileRepo.Create(ctx, profile); err != nil {
-				return nil, err
-			}
+			isNew = true
 		} else {
 			return nil, err
 		}
@@ -227,7 +229,15 @@ func (s *ProfileService) UpdateProfile(ctx context.Context, userID uuid.UUID, re
 		profile.AvailabilityStatus = *req.AvailabilityStatus
 	}
 
-	if err := s.profileRepo.Update(ctx, profile); err != nil {
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if isNew {
+			if err := s.profileRepo.Create(ctx, profile); err != nil {
+				return err
+			}
+		}
+		return s.profileRepo.Update(ctx, profile)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -455,27 +465,29 @@ func (s *ProfileService) SetSocials(ctx context.Context, userID uuid.UUID, req *
 		return err
 	}
 
-	// Delete existing socials
-	if err := s.socialRepo.DeleteAllByProfileID(ctx, profile.ID); err != nil {
-		return err
-	}
-
-	// Add new socials
-	for _, input := range req.Socials {
-		if input.URL == "" {
-			continue
-		}
-		social := &domain.ProfileSocial{
-			ProfileID: profile.ID,
-			Platform:  input.Platform,
-			URL:       input.URL,
-		}
-		if err := s.socialRepo.Upsert(ctx, social); err != nil {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// Delete existing socials
+		if err := s.socialRepo.DeleteAllByProfileID(ctx, profile.ID); err != nil {
 			return err
 		}
-	}
 
-	return nil
+		// Add new socials
+		for _, input := range req.Socials {
+			if input.URL == "" {
+				continue
+			}
+			social := &domain.ProfileSocial{
+				ProfileID: profile.ID,
+				Platform:  input.Platform,
+				URL:       input.URL,
+			}
+			if err := s.socialRepo.Upsert(ctx, social); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
 
 // ============================================
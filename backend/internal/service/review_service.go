@@ -10,23 +10,23 @@ import (
 )
 
 type ReviewService struct {
-	reviewRepo       repository.ReviewRepository
-	notificationRepo repository.NotificationRepository
-	contractRepo     repository.ContractRepository
-	userRepo         repository.UserRepository
+	reviewRepo          repository.ReviewRepository
+	notificationService *NotificationService
+	contractRepo        repository.ContractRepository
+	userRepo            repository.UserRepository
 }
 
 func NewReviewService(
 	reviewRepo repository.ReviewRepository,
-	notificationRepo repository.NotificationRepository,
+	notificationService *NotificationService,
 	contractRepo repository.ContractRepository,
 	userRepo repository.UserRepository,
 ) *ReviewService {
 	return &ReviewService{
-		reviewRepo:       reviewRepo,
-		notificationRepo: notificationRepo,
-		contractRepo:     contractRepo,
-		userRepo:         userRepo,
+		reviewRepo:          reviewRepo,
+		notificationService: notificationService,
+		contractRepo:        contractRepo,
+		userRepo:            userRepo,
 	}
 }
 
@@ -120,14 +120,7 @@ func (s *ReviewService) CreateReview(ctx context.Context, reviewerID uuid.UUID,
 		reviewerName = reviewer.Username
 	}
 
-	notification := &domain.Notification{
-		UserID:     revieweeID,
-		Type:       domain.NotificationTypeNewReview,
-		Title:      "New Review Received",
-		Message:    stringPtr(reviewerName + " left you a " + ratingStars(req.OverallRating) + " review"),
-		ContractID: &req.ContractID,
-	}
-	s.notificationRepo.Create(ctx, notification)
+	s.notificationService.NotifyNewReview(ctx, revieweeID, req.ContractID, reviewerName, ratingStars(req.OverallRating))
 
 	return s.toReviewResponse(review, reviewer), nil
 }
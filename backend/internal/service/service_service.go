@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,20 +14,31 @@ import (
 )
 
 type ServiceService struct {
-	serviceRepo repository.ServiceRepository
-	orderRepo   repository.ServiceOrderRepository
-	userRepo    repository.UserRepository
+	serviceRepo     repository.ServiceRepository
+	orderRepo       repository.ServiceOrderRepository
+	userRepo        repository.UserRepository
+	tx              repository.Transactor
+	settingsService *SettingsService
+
+	notificationService *NotificationService
 }
 
 func NewServiceService(
 	serviceRepo repository.ServiceRepository,
 	orderRepo repository.ServiceOrderRepository,
 	userRepo repository.UserRepository,
+	tx repository.Transactor,
+	settingsService *SettingsService,
+	notificationService *NotificationService,
 ) *ServiceService {
 	return &ServiceService{
-		serviceRepo: serviceRepo,
-		orderRepo:   orderRepo,
-		userRepo:    userRepo,
+		serviceRepo:     serviceRepo,
+		orderRepo:       orderRepo,
+		userRepo:        userRepo,
+		tx:              tx,
+		settingsService: settingsService,
+
+		notificationService: notificationService,
 	}
 }
 
@@ -107,15 +119,25 @@ func (s *ServiceService) CreateService(ctx context.Context, freelancerID uuid.UU
 		Visibility:           domain.VisibilityPublic,
 	}
 
-	if err := s.serviceRepo.Create(ctx, service); err != nil {
-		return nil, err
-	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.serviceRepo.Create(ctx, service); err != nil {
+			return err
+		}
 
-	// Add skills if provided
-	if len(req.Skills) > 0 {
-		if err := s.serviceRepo.AddSkills(ctx, service.ID, req.Skills); err != nil {
-			// Non-fatal error, continue
+		// Add skills if provided
+		if len(req.Skills) > 0 {
+			err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+				return s.serviceRepo.AddSkills(ctx, service.ID, req.Skills)
+			})
+			if err != nil {
+				// Non-fatal error, continue
+				fmt.Printf("failed to add service skills: %v\n", err)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return service, nil
@@ -255,16 +277,27 @@ func (s *ServiceService) UpdateService(ctx context.Context, freelancerID, servic
 		service.PremiumRevisions = req.PremiumRevisions
 	}
 
-	if err := s.serviceRepo.Update(ctx, service); err != nil {
-		return nil, err
-	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.serviceRepo.Update(ctx, service); err != nil {
+			return err
+		}
 
-	// Update skills if provided
-	if req.Skills != nil {
-		s.serviceRepo.RemoveSkills(ctx, serviceID)
-		if len(req.Skills) > 0 {
-			s.serviceRepo.AddSkills(ctx, serviceID, req.Skills)
+		// Replace skills if provided; a failed replacement keeps the old set
+		if req.Skills != nil {
+			err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+				if err := s.serviceRepo.RemoveSkills(ctx, serviceID); err != nil {
+					return err
+				}
+				return s.serviceRepo.AddSkills(ctx, serviceID, req.Skills)
+			})
+			if err != nil {
+				fmt.Printf("failed to update service skills: %v\n", err)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return service, nil
@@ -470,6 +503,9 @@ func (s *ServiceService) PlaceOrder(ctx context.Context, clientID, serviceID uui
 	default:
 		return nil, apperrors.NewBadRequest("invalid package tier")
 	}
+	if err := s.settingsService.CheckMinEscrow(priceSOL); err != nil {
+		return nil, err
+	}
 
 	order := &domain.ServiceOrder{
 		ServiceID:        serviceID,
@@ -487,6 +523,7 @@ func (s *ServiceService) PlaceOrder(ctx context.Context, clientID, serviceID uui
 	if err := s.orderRepo.Create(ctx, order); err != nil {
 		return nil, err
 	}
+	s.notificationService.PublishOrderUpdate(ctx, order)
 
 	return order, nil
 }
@@ -580,7 +617,11 @@ func (s *ServiceService) AcceptOrder(ctx context.Context, freelancerID, orderID
 	order.StartedAt = &now
 	order.ExpectedDeliveryAt = &expectedDelivery
 
-	return s.orderRepo.Update(ctx, order)
+	if err := s.orderRepo.Update(ctx, order); err != nil {
+		return saveError(err)
+	}
+	s.notificationService.PublishOrderUpdate(ctx, order)
+	return nil
 }
 
 // DeliverOrderRequest represents a delivery submission
@@ -608,10 +649,6 @@ func (s *ServiceService) DeliverOrder(ctx context.Context, freelancerID, orderID
 	order.Status = domain.ServiceOrderStatusDelivered
 	order.DeliveredAt = &now
 
-	if err := s.orderRepo.Update(ctx, order); err != nil {
-		return err
-	}
-
 	// Create delivery message
 	msg := &domain.ServiceOrderMessage{
 		OrderID:        orderID,
@@ -621,7 +658,7 @@ func (s *ServiceService) DeliverOrder(ctx context.Context, freelancerID, orderID
 		MessageType:    domain.OrderMessageTypeDelivery,
 	}
 
-	return s.orderRepo.CreateMessage(ctx, msg)
+	return s.updateOrderWithMessage(ctx, order, msg)
 }
 
 // ServiceOrderRevisionRequest represents a revision request for service orders
@@ -651,10 +688,6 @@ func (s *ServiceService) RequestRevision(ctx context.Context, clientID, orderID
 	order.Status = domain.ServiceOrderStatusRevisionRequested
 	order.RevisionsUsed++
 
-	if err := s.orderRepo.Update(ctx, order); err != nil {
-		return err
-	}
-
 	// Create revision request message
 	msg := &domain.ServiceOrderMessage{
 		OrderID:     orderID,
@@ -663,7 +696,24 @@ func (s *ServiceService) RequestRevision(ctx context.Context, clientID, orderID
 		MessageType: domain.OrderMessageTypeRevisionRequest,
 	}
 
-	return s.orderRepo.CreateMessage(ctx, msg)
+	return s.updateOrderWithMessage(ctx, order, msg)
+}
+
+// updateOrderWithMessage saves an order status change together with the
+// order message that records it
+func (s *ServiceService) updateOrderWithMessage(ctx context.Context, order *domain.ServiceOrder, msg *domain.ServiceOrderMessage) error {
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.orderRepo.Update(ctx, order); err != nil {
+			return saveError(err)
+		}
+		return s.orderRepo.CreateMessage(ctx, msg)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.notificationService.PublishOrderUpdate(ctx, order)
+	return nil
 }
 
 // ApproveDelivery approves a delivery and completes the order
@@ -685,7 +735,11 @@ func (s *ServiceService) ApproveDelivery(ctx context.Context, clientID, orderID
 	order.Status = domain.ServiceOrderStatusCompleted
 	order.CompletedAt = &now
 
-	return s.orderRepo.Update(ctx, order)
+	if err := s.orderRepo.Update(ctx, order); err != nil {
+		return saveError(err)
+	}
+	s.notificationService.PublishOrderUpdate(ctx, order)
+	return nil
 }
 
 // CancelOrder cancels an order
@@ -706,7 +760,11 @@ func (s *ServiceService) CancelOrder(ctx context.Context, userID, orderID uuid.U
 	}
 
 	order.Status = domain.ServiceOrderStatusCancelled
-	return s.orderRepo.Update(ctx, order)
+	if err := s.orderRepo.Update(ctx, order); err != nil {
+		return saveError(err)
+	}
+	s.notificationService.PublishOrderUpdate(ctx, order)
+	return nil
 }
 
 // ========================================
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/trenchjob/backend/internal/domain"
+	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+// SettingsService keeps platform_settings and fee_overrides in memory.
+// Reads never touch the database; Run reloads both periodically so edits
+// made directly in the tables take effect without a restart.
+type SettingsService struct {
+	settingsRepo repository.PlatformSettingsRepository
+	defaults     domain.PlatformSettings
+
+	mu        sync.RWMutex
+	settings  domain.PlatformSettings
+	overrides []domain.FeeOverride
+}
+
+// NewSettingsService starts from defaults, which also stand in for any
+// setting missing from the table or holding an invalid value
+func NewSettingsService(settingsRepo repository.PlatformSettingsRepository, defaults domain.PlatformSettings) *SettingsService {
+	return &SettingsService{
+		settingsRepo: settingsRepo,
+		defaults:     defaults,
+		settings:     defaults,
+	}
+}
+
+// Reload replaces the cache with the current table contents
+func (s *SettingsService) Reload(ctx context.Context) error {
+	rows, err := s.settingsRepo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load platform settings: %w", err)
+	}
+	overrides, err := s.settingsRepo.ListFeeOverrides(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to load fee overrides: %w", err)
+	}
+
+	settings := s.defaults
+	for _, row := range rows {
+		var target *decimal.Decimal
+		switch row.Key {
+		case domain.SettingPlatformFeePercentage:
+			target = &settings.FeePercentage
+		case domain.SettingMinEscrowAmountSOL:
+			target = &settings.MinEscrowAmountSOL
+		case domain.SettingJobPostingFeeSOL:
+			target = &settings.JobPostingFeeSOL
+		default:
+			continue
+		}
+
+		value, err := decimal.NewFromString(row.Value)
+		if err != nil || value.IsNegative() {
+			log.Printf("settings: ignoring invalid %s value %q", row.Key, row.Value)
+			continue
+		}
+		*target = value
+	}
+	if settings.FeePercentage.GreaterThan(decimal.NewFromInt(100)) {
+		log.Printf("settings: ignoring %s above 100", domain.SettingPlatformFeePercentage)
+		settings.FeePercentage = s.defaults.FeePercentage
+	}
+
+	s.mu.Lock()
+	s.settings = settings
+	s.overrides = overrides
+	s.mu.Unlock()
+	return nil
+}
+
+// Run reloads the cache every interval until ctx is cancelled
+func (s *SettingsService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(ctx); err != nil {
+				log.Printf("settings: %v", err)
+			}
+		}
+	}
+}
+
+// Settings returns the cached platform settings
+func (s *SettingsService) Settings() domain.PlatformSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.settings
+}
+
+// FeeOverrides returns the cached fee overrides. The slice is shared and
+// must not be modified.
+func (s *SettingsService) FeeOverrides() []domain.FeeOverride {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.overrides
+}
+
+// CheckMinEscrow rejects amounts below the platform's minimum escrow
+func (s *SettingsService) CheckMinEscrow(amount decimal.Decimal) error {
+	minimum := s.Settings().MinEscrowAmountSOL
+	if amount.LessThan(minimum) {
+		return apperrors.NewBadRequest(fmt.Sprintf("amount must be at least %s SOL", minimum.String()))
+	}
+	return nil
+}
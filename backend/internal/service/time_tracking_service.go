@@ -0,0 +1,713 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/trenchjob/backend/internal/domain"
+	apperrors "github.com/trenchjob/backend/internal/pkg/errors"
+	"github.com/trenchjob/backend/internal/repository"
+)
+
+// weekLayout is how week starts are written in requests and messages
+const weekLayout = "2006-01-02"
+
+// maxEntryMinutes caps a single manually logged entry at one day
+const maxEntryMinutes = 24 * 60
+
+// TimeTrackingService records hours on hourly contracts and bills them
+// weekly. The freelancer logs time by hand or with a timer and submits each
+// week as a timesheet; when the client approves it, the hours become an
+// approved milestone that is released from escrow like any other.
+// Logged time is capped by the contract's weekly hour limit and by what
+// its escrowed budget can still pay for.
+type TimeTrackingService struct {
+	contractRepo        repository.ContractRepository
+	milestoneRepo       repository.MilestoneRepository
+	timeEntryRepo       repository.TimeEntryRepository
+	timesheetRepo       repository.TimesheetRepository
+	tx                  repository.Transactor
+	notificationService *NotificationService
+}
+
+func NewTimeTrackingService(
+	contractRepo repository.ContractRepository,
+	milestoneRepo repository.MilestoneRepository,
+	timeEntryRepo repository.TimeEntryRepository,
+	timesheetRepo repository.TimesheetRepository,
+	tx repository.Transactor,
+	notificationService *NotificationService,
+) *TimeTrackingService {
+	return &TimeTrackingService{
+		contractRepo:        contractRepo,
+		milestoneRepo:       milestoneRepo,
+		timeEntryRepo:       timeEntryRepo,
+		timesheetRepo:       timesheetRepo,
+		tx:                  tx,
+		notificationService: notificationService,
+	}
+}
+
+// LogTimeRequest records time worked without a timer
+type LogTimeRequest struct {
+	StartedAt   time.Time `json:"started_at"`
+	Minutes     int       `json:"minutes"`
+	Description *string   `json:"description,omitempty"`
+}
+
+// StartTimerRequest starts a timer on a contract
+type StartTimerRequest struct {
+	Description *string `json:"description,omitempty"`
+}
+
+// SubmitTimesheetRequest submits one week of logged time
+type SubmitTimesheetRequest struct {
+	WeekStart string `json:"week_start"`
+}
+
+// DisputeTimesheetRequest sends a timesheet back to the freelancer
+type DisputeTimesheetRequest struct {
+	Reason string `json:"reason"`
+}
+
+// WeekSummary is one week of time on a contract and what it would bill
+type WeekSummary struct {
+	WeekStart    string             `json:"week_start"`
+	Entries      []domain.TimeEntry `json:"entries"`
+	TotalMinutes int                `json:"total_minutes"`
+	LimitMinutes *int               `json:"limit_minutes,omitempty"`
+	AmountSOL    decimal.Decimal    `json:"amount_sol"`
+	Timesheet    *domain.Timesheet  `json:"timesheet,omitempty"`
+}
+
+// LogTime adds a manual time entry for the freelancer
+func (s *TimeTrackingService) LogTime(ctx context.Context, contractID, freelancerID uuid.UUID, req *LogTimeRequest) (*domain.TimeEntry, error) {
+	contract, err := s.freelancerContract(ctx, contractID, freelancerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureTrackable(contract); err != nil {
+		return nil, err
+	}
+
+	if req.StartedAt.IsZero() {
+		return nil, apperrors.NewBadRequest("started_at is required")
+	}
+	if req.Minutes < 1 || req.Minutes > maxEntryMinutes {
+		return nil, apperrors.NewBadRequest(fmt.Sprintf("minutes must be between 1 and %d", maxEntryMinutes))
+	}
+	endedAt := req.StartedAt.Add(time.Duration(req.Minutes) * time.Minute)
+	if endedAt.After(time.Now()) {
+		return nil, apperrors.NewBadRequest("time cannot be logged in the future")
+	}
+	if contract.StartedAt != nil && req.StartedAt.Before(*contract.StartedAt) {
+		return nil, apperrors.NewBadRequest("time cannot be logged before the contract started")
+	}
+	// Weeks are billed separately, so an entry must fall within one
+	if endedAt.After(nextWeekStart(req.StartedAt)) {
+		return nil, apperrors.NewBadRequest("an entry cannot run past midnight on Sunday (UTC); log each week's part separately")
+	}
+
+	entry := &domain.TimeEntry{
+		ContractID:   contract.ID,
+		FreelancerID: freelancerID,
+		WeekStart:    domain.WeekStart(req.StartedAt),
+		StartedAt:    req.StartedAt,
+		EndedAt:      &endedAt,
+		Minutes:      req.Minutes,
+		Description:  req.Description,
+		Source:       domain.TimeEntrySourceManual,
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.timeEntryRepo.LockContract(ctx, contract.ID); err != nil {
+			return apperrors.NewInternal(err)
+		}
+		if err := s.ensureWeekOpen(ctx, contract.ID, entry.WeekStart); err != nil {
+			return err
+		}
+		overlaps, err := s.timeEntryRepo.HasOverlap(ctx, contract.ID, freelancerID, entry.StartedAt, endedAt)
+		if err != nil {
+			return apperrors.NewInternal(err)
+		}
+		if overlaps {
+			return apperrors.NewConflict("this time overlaps time already logged or a running timer")
+		}
+		allowed, limit, err := s.allowedMinutes(ctx, contract, entry.WeekStart)
+		if err != nil {
+			return err
+		}
+		if entry.Minutes > allowed {
+			return apperrors.NewBadRequest(fmt.Sprintf("the %s leaves %d minutes to log for that week", limit, allowed))
+		}
+
+		if err := s.timeEntryRepo.Create(ctx, entry); err != nil {
+			return apperrors.NewInternal(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// StartTimer starts timing work on the contract. Only one timer can run
+// per contract at a time.
+func (s *TimeTrackingService) StartTimer(ctx context.Context, contractID, freelancerID uuid.UUID, req *StartTimerRequest) (*domain.TimeEntry, error) {
+	contract, err := s.freelancerContract(ctx, contractID, freelancerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureTrackable(contract); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entry := &domain.TimeEntry{
+		ContractID:   contract.ID,
+		FreelancerID: freelancerID,
+		WeekStart:    domain.WeekStart(now),
+		StartedAt:    now,
+		Description:  req.Description,
+		Source:       domain.TimeEntrySourceTimer,
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.timeEntryRepo.LockContract(ctx, contract.ID); err != nil {
+			return apperrors.NewInternal(err)
+		}
+
+		_, err := s.timeEntryRepo.GetRunning(ctx, contract.ID)
+		if err == nil {
+			return apperrors.NewConflict("a timer is already running on this contract")
+		}
+		if !errors.Is(err, apperrors.ErrNotFound) {
+			return apperrors.NewInternal(err)
+		}
+
+		if err := s.ensureWeekOpen(ctx, contract.ID, entry.WeekStart); err != nil {
+			return err
+		}
+		allowed, limit, err := s.allowedMinutes(ctx, contract, entry.WeekStart)
+		if err != nil {
+			return err
+		}
+		if allowed == 0 {
+			return apperrors.NewBadRequest(fmt.Sprintf("the %s leaves no time to log this week", limit))
+		}
+
+		if err := s.timeEntryRepo.Create(ctx, entry); err != nil {
+			return apperrors.NewInternal(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// StopTimer stops the contract's running timer. Time is rounded to the
+// nearest minute and cut down to whatever the weekly limit and budget still
+// allow. A timer left running past midnight on Sunday is split into one
+// entry per week.
+func (s *TimeTrackingService) StopTimer(ctx context.Context, contractID, freelancerID uuid.UUID) (*domain.TimeEntry, error) {
+	contract, err := s.freelancerContract(ctx, contractID, freelancerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry *domain.TimeEntry
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.timeEntryRepo.LockContract(ctx, contract.ID); err != nil {
+			return apperrors.NewInternal(err)
+		}
+
+		var err error
+		entry, err = s.timeEntryRepo.GetRunning(ctx, contract.ID)
+		if err != nil {
+			if errors.Is(err, apperrors.ErrNotFound) {
+				return apperrors.NewBadRequest("no timer is running on this contract")
+			}
+			return apperrors.NewInternal(err)
+		}
+
+		now := time.Now()
+		end := now
+		if boundary := nextWeekStart(entry.StartedAt); end.After(boundary) {
+			end = boundary
+		}
+		entry.EndedAt = &end
+		entry.Minutes, err = s.cappedMinutes(ctx, contract, entry.WeekStart, entry.StartedAt, end)
+		if err != nil {
+			return err
+		}
+		if err := s.timeEntryRepo.Update(ctx, entry); err != nil {
+			return apperrors.NewInternal(err)
+		}
+
+		// Weeks the timer ran into. SubmitTimesheet refuses any week a
+		// running timer overlaps, so these are all still open.
+		for start := end; start.Before(now); start = end {
+			end = nextWeekStart(start)
+			if end.After(now) {
+				end = now
+			}
+			part := &domain.TimeEntry{
+				ContractID:   entry.ContractID,
+				FreelancerID: entry.FreelancerID,
+				WeekStart:    domain.WeekStart(start),
+				StartedAt:    start,
+				EndedAt:      &end,
+				Description:  entry.Description,
+				Source:       domain.TimeEntrySourceTimer,
+			}
+			part.Minutes, err = s.cappedMinutes(ctx, contract, part.WeekStart, start, end)
+			if err != nil {
+				return err
+			}
+			if part.Minutes == 0 {
+				continue
+			}
+			if err := s.timeEntryRepo.Create(ctx, part); err != nil {
+				return apperrors.NewInternal(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// DeleteTimeEntry removes an entry from a week that has not been submitted
+func (s *TimeTrackingService) DeleteTimeEntry(ctx context.Context, entryID, freelancerID uuid.UUID) error {
+	entry, err := s.timeEntryRepo.GetByID(ctx, entryID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return apperrors.NewNotFound("time entry")
+		}
+		return apperrors.NewInternal(err)
+	}
+	if entry.FreelancerID != freelancerID {
+		return apperrors.NewForbidden("you did not log this time entry")
+	}
+
+	contract, err := s.getContract(ctx, entry.ContractID)
+	if err != nil {
+		return err
+	}
+	if err := ensureNotDisputed(contract); err != nil {
+		return err
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.timeEntryRepo.LockContract(ctx, contract.ID); err != nil {
+			return apperrors.NewInternal(err)
+		}
+		if err := s.ensureWeekOpen(ctx, contract.ID, entry.WeekStart); err != nil {
+			return err
+		}
+		if err := s.timeEntryRepo.Delete(ctx, entry.ID); err != nil {
+			return apperrors.NewInternal(err)
+		}
+		return nil
+	})
+}
+
+// GetWeek returns the time logged on a contract in the week containing
+// week, or the current week when week is empty
+func (s *TimeTrackingService) GetWeek(ctx context.Context, contractID, userID uuid.UUID, week string) (*WeekSummary, error) {
+	contract, err := s.partyContract(ctx, contractID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	weekStart := domain.WeekStart(time.Now())
+	if week != "" {
+		day, err := time.Parse(weekLayout, week)
+		if err != nil {
+			return nil, apperrors.NewBadRequest("week must be a date in YYYY-MM-DD format")
+		}
+		weekStart = domain.WeekStart(day)
+	}
+
+	entries, err := s.timeEntryRepo.ListByWeek(ctx, contract.ID, weekStart)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+
+	summary := &WeekSummary{
+		WeekStart: weekStart.Format(weekLayout),
+		Entries:   entries,
+	}
+	for _, e := range entries {
+		summary.TotalMinutes += e.Minutes
+	}
+	if contract.WeeklyHourLimit != nil {
+		limit := *contract.WeeklyHourLimit * 60
+		summary.LimitMinutes = &limit
+	}
+	if contract.HourlyRateSOL != nil {
+		summary.AmountSOL = domain.BillableAmount(summary.TotalMinutes, *contract.HourlyRateSOL)
+	}
+
+	timesheet, err := s.timesheetRepo.GetByWeek(ctx, contract.ID, weekStart)
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		return nil, apperrors.NewInternal(err)
+	}
+	summary.Timesheet = timesheet
+
+	return summary, nil
+}
+
+// SubmitTimesheet submits a week of logged time for the client's approval.
+// A disputed week can be corrected and submitted again.
+func (s *TimeTrackingService) SubmitTimesheet(ctx context.Context, contractID, freelancerID uuid.UUID, req *SubmitTimesheetRequest) (*domain.Timesheet, error) {
+	contract, err := s.freelancerContract(ctx, contractID, freelancerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureTrackable(contract); err != nil {
+		return nil, err
+	}
+
+	weekStart, err := time.Parse(weekLayout, req.WeekStart)
+	if err != nil {
+		return nil, apperrors.NewBadRequest("week_start must be a date in YYYY-MM-DD format")
+	}
+	if !domain.WeekStart(weekStart).Equal(weekStart) {
+		return nil, apperrors.NewBadRequest("week_start must be a Monday")
+	}
+	if weekStart.After(time.Now()) {
+		return nil, apperrors.NewBadRequest("that week has not started yet")
+	}
+
+	var timesheet *domain.Timesheet
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.timeEntryRepo.LockContract(ctx, contract.ID); err != nil {
+			return apperrors.NewInternal(err)
+		}
+
+		running, err := s.timeEntryRepo.GetRunning(ctx, contract.ID)
+		if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+			return apperrors.NewInternal(err)
+		}
+		if running != nil && running.StartedAt.Before(weekStart.AddDate(0, 0, 7)) {
+			return apperrors.NewBadRequest("stop the running timer before submitting this week")
+		}
+
+		total, err := s.timeEntryRepo.SumMinutes(ctx, contract.ID, &weekStart)
+		if err != nil {
+			return apperrors.NewInternal(err)
+		}
+		if total == 0 {
+			return apperrors.NewBadRequest("no time has been logged for that week")
+		}
+
+		now := time.Now()
+		timesheet, err = s.timesheetRepo.GetByWeek(ctx, contract.ID, weekStart)
+		switch {
+		case errors.Is(err, apperrors.ErrNotFound):
+			timesheet = &domain.Timesheet{
+				ContractID: contract.ID,
+				WeekStart:  weekStart,
+			}
+		case err != nil:
+			return apperrors.NewInternal(err)
+		case timesheet.Status != domain.TimesheetStatusDisputed:
+			return apperrors.NewConflict("the timesheet for this week has already been submitted")
+		}
+
+		timesheet.TotalMinutes = total
+		timesheet.HourlyRateSOL = *contract.HourlyRateSOL
+		timesheet.AmountSOL = domain.BillableAmount(total, *contract.HourlyRateSOL)
+		timesheet.Status = domain.TimesheetStatusSubmitted
+		timesheet.SubmittedAt = now
+		timesheet.DisputeReason = nil
+
+		if timesheet.ID == uuid.Nil {
+			if err := s.timesheetRepo.Create(ctx, timesheet); err != nil {
+				return apperrors.NewInternal(err)
+			}
+			return nil
+		}
+		if err := s.timesheetRepo.Update(ctx, timesheet); err != nil {
+			return saveError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	week := weekStart.Format(weekLayout)
+	if err := s.notificationService.NotifyTimesheetSubmitted(ctx, contract.ClientID, contract.ID, week); err != nil {
+		fmt.Printf("Failed to notify client of timesheet %s: %v\n", timesheet.ID, err)
+	}
+
+	return timesheet, nil
+}
+
+// ApproveTimesheet accepts a submitted week and bills it as an approved
+// milestone, ready for the client to release from escrow
+func (s *TimeTrackingService) ApproveTimesheet(ctx context.Context, timesheetID, clientID uuid.UUID) (*domain.Timesheet, error) {
+	timesheet, contract, err := s.clientTimesheet(ctx, timesheetID, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	milestones, err := s.milestoneRepo.GetByContractID(ctx, contract.ID)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+
+	week := timesheet.WeekStart.Format(weekLayout)
+	hours := decimal.NewFromInt(int64(timesheet.TotalMinutes)).Div(decimal.NewFromInt(60)).StringFixed(2)
+	now := time.Now()
+	milestone := &domain.Milestone{
+		ContractID:  contract.ID,
+		Title:       "Hours for the week of " + week,
+		Description: stringPtr(fmt.Sprintf("%s hours at %s SOL/hour", hours, timesheet.HourlyRateSOL.String())),
+		AmountSOL:   timesheet.AmountSOL,
+		SortOrder:   len(milestones) + 1,
+		Status:      domain.MilestoneStatusApproved,
+		SubmittedAt: &timesheet.SubmittedAt,
+		ApprovedAt:  &now,
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.milestoneRepo.Create(ctx, milestone); err != nil {
+			return apperrors.NewInternal(err)
+		}
+
+		timesheet.Status = domain.TimesheetStatusApproved
+		timesheet.MilestoneID = &milestone.ID
+		timesheet.ReviewedAt = &now
+		if err := s.timesheetRepo.Update(ctx, timesheet); err != nil {
+			return saveError(err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.notificationService.NotifyTimesheetApproved(ctx, contract.FreelancerID, contract.ID, week, timesheet.AmountSOL.String()); err != nil {
+		fmt.Printf("Failed to notify freelancer of timesheet %s: %v\n", timesheet.ID, err)
+	}
+
+	return timesheet, nil
+}
+
+// DisputeTimesheet returns a submitted week to the freelancer, who can
+// correct its entries and submit it again
+func (s *TimeTrackingService) DisputeTimesheet(ctx context.Context, timesheetID, clientID uuid.UUID, req *DisputeTimesheetRequest) (*domain.Timesheet, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, apperrors.NewBadRequest("reason is required")
+	}
+
+	timesheet, contract, err := s.clientTimesheet(ctx, timesheetID, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	timesheet.Status = domain.TimesheetStatusDisputed
+	timesheet.DisputeReason = &reason
+	timesheet.ReviewedAt = &now
+	if err := s.timesheetRepo.Update(ctx, timesheet); err != nil {
+		return nil, saveError(err)
+	}
+
+	week := timesheet.WeekStart.Format(weekLayout)
+	if err := s.notificationService.NotifyTimesheetDisputed(ctx, contract.FreelancerID, contract.ID, week, reason); err != nil {
+		fmt.Printf("Failed to notify freelancer of timesheet %s: %v\n", timesheet.ID, err)
+	}
+
+	return timesheet, nil
+}
+
+// ListTimesheets returns a contract's timesheets, newest week first
+func (s *TimeTrackingService) ListTimesheets(ctx context.Context, contractID, userID uuid.UUID) ([]domain.Timesheet, error) {
+	if _, err := s.partyContract(ctx, contractID, userID); err != nil {
+		return nil, err
+	}
+
+	timesheets, err := s.timesheetRepo.ListByContract(ctx, contractID)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+	return timesheets, nil
+}
+
+// GetTimesheet returns a timesheet with the entries of its week
+func (s *TimeTrackingService) GetTimesheet(ctx context.Context, timesheetID, userID uuid.UUID) (*domain.Timesheet, error) {
+	timesheet, err := s.getTimesheet(ctx, timesheetID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.partyContract(ctx, timesheet.ContractID, userID); err != nil {
+		return nil, err
+	}
+
+	timesheet.Entries, err = s.timeEntryRepo.ListByWeek(ctx, timesheet.ContractID, timesheet.WeekStart)
+	if err != nil {
+		return nil, apperrors.NewInternal(err)
+	}
+	return timesheet, nil
+}
+
+// allowedMinutes is how much more time fits in the week, and which limit
+// is the tighter one: the weekly hour limit or the escrowed budget
+func (s *TimeTrackingService) allowedMinutes(ctx context.Context, contract *domain.Contract, weekStart time.Time) (int, string, error) {
+	logged, err := s.timeEntryRepo.SumMinutes(ctx, contract.ID, nil)
+	if err != nil {
+		return 0, "", apperrors.NewInternal(err)
+	}
+	budgetMinutes := contract.TotalAmountSOL.Mul(decimal.NewFromInt(60)).Div(*contract.HourlyRateSOL).IntPart()
+	allowed, limit := int(budgetMinutes)-logged, "contract budget"
+
+	if contract.WeeklyHourLimit != nil {
+		week, err := s.timeEntryRepo.SumMinutes(ctx, contract.ID, &weekStart)
+		if err != nil {
+			return 0, "", apperrors.NewInternal(err)
+		}
+		if weekly := *contract.WeeklyHourLimit*60 - week; weekly < allowed {
+			allowed, limit = weekly, fmt.Sprintf("weekly limit of %d hours", *contract.WeeklyHourLimit)
+		}
+	}
+
+	if allowed < 0 {
+		allowed = 0
+	}
+	return allowed, limit, nil
+}
+
+// cappedMinutes is the time between start and end, rounded to the nearest
+// minute and capped at what the week still allows
+func (s *TimeTrackingService) cappedMinutes(ctx context.Context, contract *domain.Contract, weekStart, start, end time.Time) (int, error) {
+	allowed, _, err := s.allowedMinutes(ctx, contract, weekStart)
+	if err != nil {
+		return 0, err
+	}
+	minutes := int(end.Sub(start).Round(time.Minute) / time.Minute)
+	if minutes > allowed {
+		minutes = allowed
+	}
+	return minutes, nil
+}
+
+// ensureWeekOpen blocks changes to a week that is awaiting approval or has
+// been billed
+func (s *TimeTrackingService) ensureWeekOpen(ctx context.Context, contractID uuid.UUID, weekStart time.Time) error {
+	timesheet, err := s.timesheetRepo.GetByWeek(ctx, contractID, weekStart)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil
+		}
+		return apperrors.NewInternal(err)
+	}
+	if timesheet.Status != domain.TimesheetStatusDisputed {
+		return apperrors.NewConflict("the timesheet for that week has already been submitted")
+	}
+	return nil
+}
+
+// clientTimesheet loads a submitted timesheet on a contract the caller is
+// the client of
+func (s *TimeTrackingService) clientTimesheet(ctx context.Context, timesheetID, clientID uuid.UUID) (*domain.Timesheet, *domain.Contract, error) {
+	timesheet, err := s.getTimesheet(ctx, timesheetID)
+	if err != nil {
+		return nil, nil, err
+	}
+	contract, err := s.getContract(ctx, timesheet.ContractID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if contract.ClientID != clientID {
+		return nil, nil, apperrors.NewForbidden("you are not the client for this contract")
+	}
+	if err := ensureNotDisputed(contract); err != nil {
+		return nil, nil, err
+	}
+	if timesheet.Status != domain.TimesheetStatusSubmitted {
+		return nil, nil, apperrors.NewBadRequest("timesheet is not awaiting review")
+	}
+	return timesheet, contract, nil
+}
+
+// freelancerContract loads an hourly contract the caller is the freelancer on
+func (s *TimeTrackingService) freelancerContract(ctx context.Context, contractID, freelancerID uuid.UUID) (*domain.Contract, error) {
+	contract, err := s.getContract(ctx, contractID)
+	if err != nil {
+		return nil, err
+	}
+	if contract.FreelancerID != freelancerID {
+		return nil, apperrors.NewForbidden("you are not the freelancer for this contract")
+	}
+	if contract.PaymentType != domain.PaymentTypeHourly || contract.HourlyRateSOL == nil {
+		return nil, apperrors.NewBadRequest("time is only tracked on hourly contracts")
+	}
+	return contract, nil
+}
+
+func (s *TimeTrackingService) partyContract(ctx context.Context, contractID, userID uuid.UUID) (*domain.Contract, error) {
+	contract, err := s.getContract(ctx, contractID)
+	if err != nil {
+		return nil, err
+	}
+	if contract.ClientID != userID && contract.FreelancerID != userID {
+		return nil, apperrors.NewForbidden("you are not part of this contract")
+	}
+	return contract, nil
+}
+
+func (s *TimeTrackingService) getContract(ctx context.Context, contractID uuid.UUID) (*domain.Contract, error) {
+	contract, err := s.contractRepo.GetByID(ctx, contractID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewNotFound("contract")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	return contract, nil
+}
+
+func (s *TimeTrackingService) getTimesheet(ctx context.Context, timesheetID uuid.UUID) (*domain.Timesheet, error) {
+	timesheet, err := s.timesheetRepo.GetByID(ctx, timesheetID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil, apperrors.NewNotFound("timesheet")
+		}
+		return nil, apperrors.NewInternal(err)
+	}
+	return timesheet, nil
+}
+
+// nextWeekStart is midnight UTC at the end of the Sunday closing t's week
+func nextWeekStart(t time.Time) time.Time {
+	return domain.WeekStart(t).AddDate(0, 0, 7)
+}
+
+// ensureTrackable allows new time only while the contract is running
+func ensureTrackable(contract *domain.Contract) error {
+	if err := ensureNotDisputed(contract); err != nil {
+		return err
+	}
+	if contract.Status != domain.ContractStatusActive {
+		return apperrors.NewBadRequest("time can only be logged on an active contract")
+	}
+	return nil
+}
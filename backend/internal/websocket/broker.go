@@ -0,0 +1,257 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Envelope kinds carried between hubs
+const (
+	EnvelopeConversation = "conversation" // Message for a conversation's joined clients
+	EnvelopeUser         = "user"         // Message for every connection of one user
+	EnvelopePresence     = "presence"     // A user came online or went offline on a node
+	EnvelopeSnapshot     = "snapshot"     // Every user online on a node; also a heartbeat
+)
+
+// Envelope is what one hub tells the others. Node identifies the sender so
+// a hub can ignore its own envelopes when the broker echoes them back.
+type Envelope struct {
+	Node           string          `json:"node"`
+	Kind           string          `json:"kind"`
+	ConversationID uuid.UUID       `json:"conversation_id,omitempty"`
+	UserID         uuid.UUID       `json:"user_id,omitempty"`
+	SenderID       uuid.UUID       `json:"sender_id,omitempty"`
+	ExcludeSender  bool            `json:"exclude_sender,omitempty"`
+	IsOnline       bool            `json:"is_online,omitempty"`
+	Users          []uuid.UUID     `json:"users,omitempty"`
+	Message        json.RawMessage `json:"message,omitempty"`
+}
+
+// Broker carries envelopes between the hubs of every API instance. A hub
+// without a broker only reaches its own connections.
+type Broker interface {
+	// Publish sends an envelope to every hub, possibly including this one
+	Publish(ctx context.Context, env *Envelope) error
+
+	// Subscribe calls deliver for each envelope until ctx is cancelled
+	Subscribe(ctx context.Context, deliver func(env *Envelope)) error
+}
+
+// RunBroker exchanges envelopes with the other instances until ctx is
+// cancelled. Every interval the hub announces who is connected to it, which
+// heals presence after a missed envelope; instances silent for three
+// intervals are treated as gone and their users as offline.
+func (h *Hub) RunBroker(ctx context.Context, interval time.Duration) {
+	if h.broker == nil {
+		return
+	}
+
+	h.mu.Lock()
+	h.nodeTTL = 3 * interval
+	h.mu.Unlock()
+
+	go func() {
+		if err := h.broker.Subscribe(ctx, h.receive); err != nil {
+			log.Printf("websocket hub: broker subscription ended: %v", err)
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	h.publish(h.snapshot())
+	for {
+		select {
+		case <-ctx.Done():
+			// Tell the other instances our users are gone rather than
+			// leaving them online until this node expires
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			h.send(shutdownCtx, &Envelope{Node: h.nodeID, Kind: EnvelopeSnapshot})
+			cancel()
+			return
+		case env := <-h.outbox:
+			h.send(ctx, env)
+		case <-ticker.C:
+			h.publish(h.snapshot())
+			h.expireNodes(time.Now())
+		}
+	}
+}
+
+// publish queues an envelope for the other instances. Envelopes are sent in
+// order by RunBroker; when the queue is full they are dropped rather than
+// stalling the hub.
+func (h *Hub) publish(env *Envelope) {
+	if h.broker == nil {
+		return
+	}
+	env.Node = h.nodeID
+	select {
+	case h.outbox <- env:
+	default:
+		log.Printf("websocket hub: broker queue full, dropping %s envelope", env.Kind)
+	}
+}
+
+func (h *Hub) send(ctx context.Context, env *Envelope) {
+	if err := h.broker.Publish(ctx, env); err != nil {
+		log.Printf("websocket hub: failed to publish %s envelope: %v", env.Kind, err)
+	}
+}
+
+// snapshot lists every user connected to this instance
+func (h *Hub) snapshot() *Envelope {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	users := make([]uuid.UUID, 0, len(h.clients))
+	for userID := range h.clients {
+		users = append(users, userID)
+	}
+	return &Envelope{Kind: EnvelopeSnapshot, Users: users}
+}
+
+// receive handles an envelope from another instance
+func (h *Hub) receive(env *Envelope) {
+	if env.Node == h.nodeID {
+		return
+	}
+
+	switch env.Kind {
+	case EnvelopeConversation:
+		h.broadcast <- &BroadcastMessage{
+			ConversationID: env.ConversationID,
+			SenderID:       env.SenderID,
+			Message:        env.Message,
+			ExcludeSender:  env.ExcludeSender,
+		}
+	case EnvelopeUser:
+		h.SendToLocalUser(env.UserID, env.Message)
+	case EnvelopePresence, EnvelopeSnapshot:
+		h.applyRemotePresence(env)
+	}
+}
+
+// applyRemotePresence records which users another instance has online
+func (h *Hub) applyRemotePresence(env *Envelope) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	node := h.nodes[env.Node]
+	if node == nil {
+		node = &remoteNode{users: make(map[uuid.UUID]bool)}
+		h.nodes[env.Node] = node
+	}
+	node.seen = time.Now()
+
+	if env.Kind == EnvelopePresence {
+		h.withPresenceDiff([]uuid.UUID{env.UserID}, func() {
+			if env.IsOnline {
+				node.users[env.UserID] = true
+			} else {
+				delete(node.users, env.UserID)
+			}
+		})
+		return
+	}
+
+	affected := append(nodeUserIDs(node), env.Users...)
+	h.withPresenceDiff(affected, func() {
+		node.users = make(map[uuid.UUID]bool, len(env.Users))
+		for _, userID := range env.Users {
+			node.users[userID] = true
+		}
+		// An empty snapshot is also what an instance sends on shutdown
+		if len(node.users) == 0 {
+			delete(h.nodes, env.Node)
+		}
+	})
+}
+
+// expireNodes forgets instances that stopped announcing themselves
+func (h *Hub) expireNodes(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for nodeID, node := range h.nodes {
+		if now.Sub(node.seen) < h.nodeTTL {
+			continue
+		}
+		h.withPresenceDiff(nodeUserIDs(node), func() {
+			delete(h.nodes, nodeID)
+		})
+	}
+}
+
+// withPresenceDiff applies change and fires the presence callback for each
+// of the given users whose status across all instances it flipped. Callers
+// hold mu.
+func (h *Hub) withPresenceDiff(userIDs []uuid.UUID, change func()) {
+	before := make(map[uuid.UUID]bool, len(userIDs))
+	for _, userID := range userIDs {
+		before[userID] = h.isOnlineLocked(userID)
+	}
+	change()
+	for userID, wasOnline := range before {
+		if isOnline := h.isOnlineLocked(userID); isOnline != wasOnline {
+			h.firePresenceChange(userID, isOnline)
+		}
+	}
+}
+
+// isOnlineLocked reports whether the user is connected to any instance.
+// Callers hold mu.
+func (h *Hub) isOnlineLocked(userID uuid.UUID) bool {
+	if len(h.clients[userID]) > 0 {
+		return true
+	}
+	for _, node := range h.nodes {
+		if node.users[userID] {
+			return true
+		}
+	}
+	return false
+}
+
+// firePresenceChange queues a change for deliverPresence. Callers hold mu,
+// so it never blocks and never runs the callback itself.
+func (h *Hub) firePresenceChange(userID uuid.UUID, isOnline bool) {
+	if h.onPresenceChange == nil {
+		return
+	}
+	h.pendingMu.Lock()
+	h.pendingPresence = append(h.pendingPresence, PresenceUpdate{UserID: userID, IsOnline: isOnline})
+	h.pendingMu.Unlock()
+
+	select {
+	case h.presenceReady <- struct{}{}:
+	default:
+	}
+}
+
+// deliverPresence runs the presence callback for each queued change, one at
+// a time, so a user going offline is never reported before they came online
+func (h *Hub) deliverPresence() {
+	for range h.presenceReady {
+		h.pendingMu.Lock()
+		updates := h.pendingPresence
+		h.pendingPresence = nil
+		h.pendingMu.Unlock()
+
+		for _, update := range updates {
+			h.onPresenceChange(update.UserID, update.IsOnline)
+		}
+	}
+}
+
+func nodeUserIDs(node *remoteNode) []uuid.UUID {
+	userIDs := make([]uuid.UUID, 0, len(node.users))
+	for userID := range node.users {
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs
+}
@@ -3,6 +3,7 @@ package websocket
 import (
 	"encoding/json"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -36,6 +37,10 @@ type Client struct {
 	// Buffered channel of outbound messages
 	send chan []byte
 
+	// sendMu keeps send from being closed while a message is queued on it
+	sendMu sync.Mutex
+	closed bool
+
 	// Conversations this client is subscribed to
 	conversations map[uuid.UUID]bool
 
@@ -62,10 +67,33 @@ func (c *Client) UserID() uuid.UUID {
 
 // Send sends a message to the client
 func (c *Client) Send(message []byte) {
+	// Buffer full, client will be disconnected
+	c.queue(message)
+}
+
+// queue puts message in the send buffer without blocking, reporting false
+// when the buffer is full. Messages for a closed client are dropped.
+func (c *Client) queue(message []byte) bool {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.closed {
+		return true
+	}
 	select {
 	case c.send <- message:
+		return true
 	default:
-		// Buffer full, client will be disconnected
+		return false
+	}
+}
+
+// closeSend closes the send buffer once, ending writePump
+func (c *Client) closeSend() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.send)
 	}
 }
 
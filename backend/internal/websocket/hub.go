@@ -1,7 +1,9 @@
 package websocket
 
 import (
+	"log"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -31,6 +33,26 @@ type Hub struct {
 
 	// Online users callback (for database updates)
 	onPresenceChange func(userID uuid.UUID, isOnline bool)
+
+	// Presence changes waiting for the callback, in the order they happened
+	pendingMu       sync.Mutex
+	pendingPresence []PresenceUpdate
+	presenceReady   chan struct{}
+
+	// Fan-out to the hubs of other instances; nil keeps everything local
+	nodeID string
+	broker Broker
+	outbox chan *Envelope
+
+	// Users online on other instances, keyed by node ID and guarded by mu
+	nodes   map[string]*remoteNode
+	nodeTTL time.Duration
+}
+
+// remoteNode is what this hub knows about another instance's connections
+type remoteNode struct {
+	users map[uuid.UUID]bool
+	seen  time.Time
 }
 
 // BroadcastMessage represents a message to be broadcast to conversation participants
@@ -56,9 +78,19 @@ func NewHub() *Hub {
 		unregister:    make(chan *Client),
 		broadcast:     make(chan *BroadcastMessage, 256),
 		presence:      make(chan *PresenceUpdate, 64),
+		presenceReady: make(chan struct{}, 1),
+		nodeID:        uuid.NewString(),
+		outbox:        make(chan *Envelope, 256),
+		nodes:         make(map[string]*remoteNode),
 	}
 }
 
+// SetBroker makes the hub share messages and presence with the hubs of other
+// instances. Call it before Run; RunBroker does the actual exchange.
+func (h *Hub) SetBroker(broker Broker) {
+	h.broker = broker
+}
+
 // SetPresenceCallback sets the callback for presence changes
 func (h *Hub) SetPresenceCallback(callback func(userID uuid.UUID, isOnline bool)) {
 	h.onPresenceChange = callback
@@ -66,6 +98,7 @@ func (h *Hub) SetPresenceCallback(callback func(userID uuid.UUID, isOnline bool)
 
 // Run starts the hub's main loop
 func (h *Hub) Run() {
+	go h.deliverPresence()
 	for {
 		select {
 		case client := <-h.register:
@@ -87,10 +120,12 @@ func (h *Hub) registerClient(client *Client) {
 
 	// Add to user's clients
 	if h.clients[client.userID] == nil {
+		wasOnline := h.isOnlineLocked(client.userID)
 		h.clients[client.userID] = make(map[*Client]bool)
-		// User just came online
-		if h.onPresenceChange != nil {
-			go h.onPresenceChange(client.userID, true)
+		h.publish(&Envelope{Kind: EnvelopePresence, UserID: client.userID, IsOnline: true})
+		// User just came online, unless another instance already had them
+		if !wasOnline {
+			h.firePresenceChange(client.userID, true)
 		}
 	}
 	h.clients[client.userID][client] = true
@@ -105,13 +140,14 @@ func (h *Hub) unregisterClient(client *Client) {
 	if clients, ok := h.clients[client.userID]; ok {
 		if _, exists := clients[client]; exists {
 			delete(clients, client)
-			close(client.send)
+			client.closeSend()
 
 			// If no more clients for this user, they're offline
 			if len(clients) == 0 {
 				delete(h.clients, client.userID)
-				if h.onPresenceChange != nil {
-					go h.onPresenceChange(client.userID, false)
+				h.publish(&Envelope{Kind: EnvelopePresence, UserID: client.userID, IsOnline: false})
+				if !h.isOnlineLocked(client.userID) {
+					h.firePresenceChange(client.userID, false)
 				}
 			}
 		}
@@ -157,16 +193,14 @@ func (h *Hub) LeaveConversation(client *Client, conversationID uuid.UUID) {
 // broadcastToConversation sends a message to all participants in a conversation
 func (h *Hub) broadcastToConversation(msg *BroadcastMessage) {
 	h.mu.RLock()
-	clients := h.conversations[msg.ConversationID]
+	clients := clientList(h.conversations[msg.ConversationID])
 	h.mu.RUnlock()
 
-	for client := range clients {
+	for _, client := range clients {
 		if msg.ExcludeSender && client.userID == msg.SenderID {
 			continue
 		}
-		select {
-		case client.send <- msg.Message:
-		default:
+		if !client.queue(msg.Message) {
 			// Client's send buffer is full, remove them
 			go func(c *Client) {
 				h.unregister <- c
@@ -175,16 +209,22 @@ func (h *Hub) broadcastToConversation(msg *BroadcastMessage) {
 	}
 }
 
-// BroadcastToUser sends a message to all connections of a specific user
+// BroadcastToUser sends a message to all connections of a specific user,
+// on this instance and every other one
 func (h *Hub) BroadcastToUser(userID uuid.UUID, message []byte) {
+	h.publish(&Envelope{Kind: EnvelopeUser, UserID: userID, Message: message})
+	h.SendToLocalUser(userID, message)
+}
+
+// SendToLocalUser sends a message to the user's connections on this instance
+// only. Use it for events every instance observes for itself, like presence.
+func (h *Hub) SendToLocalUser(userID uuid.UUID, message []byte) {
 	h.mu.RLock()
-	clients := h.clients[userID]
+	clients := clientList(h.clients[userID])
 	h.mu.RUnlock()
 
-	for client := range clients {
-		select {
-		case client.send <- message:
-		default:
+	for _, client := range clients {
+		if !client.queue(message) {
 			go func(c *Client) {
 				h.unregister <- c
 			}(client)
@@ -192,8 +232,57 @@ func (h *Hub) BroadcastToUser(userID uuid.UUID, message []byte) {
 	}
 }
 
+// clientList copies a client set so it can be sent to after the hub's lock
+// is released, while register and unregister keep changing the set.
+// Callers must hold h.mu.
+func clientList(set map[*Client]bool) []*Client {
+	clients := make([]*Client, 0, len(set))
+	for client := range set {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// PushNotification sends a notify.new notification to every connection of a
+// user
+func (h *Hub) PushNotification(userID uuid.UUID, notification interface{}) {
+	h.notifyUser(userID, NotifyNew, notification)
+}
+
+// PushEvent sends a notify.event notification to every connection of a user
+func (h *Hub) PushEvent(userID uuid.UUID, event interface{}) {
+	h.notifyUser(userID, NotifyEvent, event)
+}
+
+// NotifyConversation sends a JSON-RPC notification to every connection that
+// joined the conversation, on this instance and every other one
+func (h *Hub) NotifyConversation(conversationID, senderID uuid.UUID, method string, params interface{}, excludeSender bool) {
+	data, err := MarshalNotification(NewNotification(method, params))
+	if err != nil {
+		log.Printf("websocket hub: failed to encode %s: %v", method, err)
+		return
+	}
+	h.BroadcastToConversation(conversationID, senderID, data, excludeSender)
+}
+
+func (h *Hub) notifyUser(userID uuid.UUID, method string, params interface{}) {
+	data, err := MarshalNotification(NewNotification(method, params))
+	if err != nil {
+		log.Printf("websocket hub: failed to encode %s: %v", method, err)
+		return
+	}
+	h.BroadcastToUser(userID, data)
+}
+
 // BroadcastToConversation broadcasts a message to conversation participants
 func (h *Hub) BroadcastToConversation(conversationID, senderID uuid.UUID, message []byte, excludeSender bool) {
+	h.publish(&Envelope{
+		Kind:           EnvelopeConversation,
+		ConversationID: conversationID,
+		SenderID:       senderID,
+		ExcludeSender:  excludeSender,
+		Message:        message,
+	})
 	h.broadcast <- &BroadcastMessage{
 		ConversationID: conversationID,
 		SenderID:       senderID,
@@ -208,11 +297,11 @@ func (h *Hub) handlePresenceUpdate(update *PresenceUpdate) {
 	// This is handled by the presence callback set during initialization
 }
 
-// IsUserOnline checks if a user has any active connections
+// IsUserOnline checks if a user has any active connections on any instance
 func (h *Hub) IsUserOnline(userID uuid.UUID) bool {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
-	return len(h.clients[userID]) > 0
+	return h.isOnlineLocked(userID)
 }
 
 // GetOnlineUsers returns a list of online user IDs from a given set
@@ -222,7 +311,7 @@ func (h *Hub) GetOnlineUsers(userIDs []uuid.UUID) []uuid.UUID {
 
 	online := make([]uuid.UUID, 0)
 	for _, id := range userIDs {
-		if len(h.clients[id]) > 0 {
+		if h.isOnlineLocked(id) {
 			online = append(online, id)
 		}
 	}
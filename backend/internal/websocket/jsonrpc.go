@@ -58,6 +58,9 @@ const (
 	MethodTyping             = "chat.typing"
 	MethodJoinConversation   = "chat.joinConversation"
 	MethodLeaveConversation  = "chat.leaveConversation"
+	MethodEditMessage        = "chat.editMessage"
+	MethodDeleteMessage      = "chat.deleteMessage"
+	MethodGetMessageEdits    = "chat.getMessageEdits"
 )
 
 // Server -> Client notification methods
@@ -66,13 +69,20 @@ const (
 	NotifyUserTyping  = "chat.userTyping"
 	NotifyPresence    = "chat.presence"
 	NotifyReadReceipt = "chat.readReceipt"
+
+	NotifyMessageEdited  = "chat.messageEdited"
+	NotifyMessageDeleted = "chat.messageDeleted"
+
+	NotifyNew   = "notify.new"   // A notification was created for the user
+	NotifyEvent = "notify.event" // A contract, milestone or order the user is party to changed
 )
 
 // SendMessageParams represents parameters for chat.sendMessage
 type SendMessageParams struct {
-	ConversationID string       `json:"conversation_id"`
-	Text           string       `json:"text"`
-	Attachments    []Attachment `json:"attachments,omitempty"`
+	ConversationID   string       `json:"conversation_id"`
+	Text             string       `json:"text"`
+	Attachments      []Attachment `json:"attachments,omitempty"`
+	ReplyToMessageID string       `json:"reply_to_message_id,omitempty"`
 }
 
 // Attachment represents a file attachment
@@ -125,11 +135,29 @@ type LeaveConversationParams struct {
 	ConversationID string `json:"conversation_id"`
 }
 
+// EditMessageParams represents parameters for chat.editMessage
+type EditMessageParams struct {
+	MessageID string `json:"message_id"`
+	Text      string `json:"text"`
+}
+
+// MessageIDParams represents parameters for chat.deleteMessage and
+// chat.getMessageEdits
+type MessageIDParams struct {
+	MessageID string `json:"message_id"`
+}
+
 // NewMessageNotification represents the payload for chat.newMessage notification
 type NewMessageNotification struct {
 	Message interface{} `json:"message"`
 }
 
+// MessageChangedNotification represents the payload for chat.messageEdited
+// and chat.messageDeleted notifications
+type MessageChangedNotification struct {
+	Message interface{} `json:"message"`
+}
+
 // TypingNotification represents the payload for chat.userTyping notification
 type TypingNotification struct {
 	ConversationID string `json:"conversation_id"`
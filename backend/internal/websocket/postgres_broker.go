@@ -0,0 +1,138 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// postgresBrokerChannel is the LISTEN/NOTIFY channel hubs share
+	postgresBrokerChannel = "ws_fanout"
+
+	// notifyPayloadLimit keeps NOTIFY payloads under Postgres' 8000 byte cap.
+	// Larger envelopes are parked in ws_fanout_payloads and sent by reference.
+	notifyPayloadLimit = 7900
+
+	// payloadRefPrefix marks a NOTIFY payload that names a parked envelope
+	payloadRefPrefix = "ref:"
+
+	// parkedPayloadTTL is how long parked envelopes are kept for listeners
+	parkedPayloadTTL = 5 * time.Minute
+
+	maxListenBackoff = 30 * time.Second
+)
+
+// PostgresBroker fans envelopes out through Postgres LISTEN/NOTIFY, so every
+// API instance using the same database receives them
+type PostgresBroker struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresBroker creates a broker on the given pool. Subscribe holds one
+// pool connection for as long as it runs.
+func NewPostgresBroker(pool *pgxpool.Pool) *PostgresBroker {
+	return &PostgresBroker{pool: pool}
+}
+
+// Publish sends an envelope to every listening hub
+func (b *PostgresBroker) Publish(ctx context.Context, env *Envelope) error {
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	payload := string(data)
+	if len(data) > notifyPayloadLimit {
+		var id string
+		err := b.pool.QueryRow(ctx,
+			`INSERT INTO ws_fanout_payloads (payload) VALUES ($1) RETURNING id::text`, payload,
+		).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to park envelope: %w", err)
+		}
+		payload = payloadRefPrefix + id
+
+		if _, err := b.pool.Exec(ctx,
+			`DELETE FROM ws_fanout_payloads WHERE created_at < $1`, time.Now().Add(-parkedPayloadTTL),
+		); err != nil {
+			log.Printf("websocket broker: failed to prune parked envelopes: %v", err)
+		}
+	}
+
+	_, err = b.pool.Exec(ctx, `SELECT pg_notify($1, $2)`, postgresBrokerChannel, payload)
+	return err
+}
+
+// Subscribe listens until ctx is cancelled, reconnecting with backoff when
+// the listening connection is lost. Envelopes published while disconnected
+// are not replayed.
+func (b *PostgresBroker) Subscribe(ctx context.Context, deliver func(env *Envelope)) error {
+	backoff := time.Second
+	for {
+		err := b.listen(ctx, deliver, func() { backoff = time.Second })
+		if ctx.Err() != nil {
+			return nil
+		}
+		log.Printf("websocket broker: listener stopped: %v; retrying in %s", err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxListenBackoff {
+			backoff = maxListenBackoff
+		}
+	}
+}
+
+// listen runs one LISTEN session; connected is called once it is established
+func (b *PostgresBroker) listen(ctx context.Context, deliver func(env *Envelope), connected func()) error {
+	conn, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// The session may be mid-wait or broken when we stop, so never hand it
+	// back to the pool
+	defer conn.Hijack().Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+postgresBrokerChannel); err != nil {
+		return err
+	}
+	connected()
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		env, err := b.decode(ctx, notification.Payload)
+		if err != nil {
+			log.Printf("websocket broker: dropping envelope: %v", err)
+			continue
+		}
+		deliver(env)
+	}
+}
+
+func (b *PostgresBroker) decode(ctx context.Context, payload string) (*Envelope, error) {
+	if id, ok := strings.CutPrefix(payload, payloadRefPrefix); ok {
+		err := b.pool.QueryRow(ctx, `SELECT payload FROM ws_fanout_payloads WHERE id = $1`, id).Scan(&payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load parked envelope %s: %w", id, err)
+		}
+	}
+
+	var env Envelope
+	if err := json.Unmarshal([]byte(payload), &env); err != nil {
+		return nil, err
+	}
+	return &env, nil
+}
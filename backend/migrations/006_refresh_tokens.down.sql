@@ -0,0 +1,5 @@
+-- Rollback refresh tokens
+
+DROP INDEX IF EXISTS idx_refresh_tokens_expires;
+DROP INDEX IF EXISTS idx_refresh_tokens_session;
+DROP TABLE IF EXISTS refresh_tokens;
@@ -0,0 +1,5 @@
+-- Rollback admin moderation
+
+DROP TABLE IF EXISTS admin_audit_log;
+DROP FUNCTION IF EXISTS prevent_admin_audit_log_change();
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
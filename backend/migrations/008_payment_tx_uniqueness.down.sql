@@ -0,0 +1,4 @@
+-- Rollback payment transaction uniqueness
+
+DROP INDEX IF EXISTS idx_payments_tx_signature_unique;
+CREATE INDEX IF NOT EXISTS idx_payments_tx_signature ON payments(tx_signature);
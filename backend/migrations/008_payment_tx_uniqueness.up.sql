@@ -0,0 +1,7 @@
+-- Payment Transaction Uniqueness Migration
+-- An on-chain transaction is recorded as at most one payment, which makes
+-- confirming the same signature twice a no-op
+
+DROP INDEX IF EXISTS idx_payments_tx_signature;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_tx_signature_unique
+    ON payments(tx_signature) WHERE tx_signature IS NOT NULL;
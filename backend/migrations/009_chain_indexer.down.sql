@@ -0,0 +1,4 @@
+-- Rollback chain indexer
+
+DROP INDEX IF EXISTS idx_escrow_logs_tx_action;
+DROP TABLE IF EXISTS chain_cursors;
@@ -0,0 +1,15 @@
+-- Chain Indexer Migration
+-- Cursor for the escrow program event indexer, plus idempotent escrow logs
+
+-- One row per indexed stream; last_signature is the newest transaction
+-- fully applied, so a restart resumes right after it
+CREATE TABLE IF NOT EXISTS chain_cursors (
+    name VARCHAR(50) PRIMARY KEY,
+    last_signature VARCHAR(88) NOT NULL,
+    last_slot BIGINT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- An on-chain event is logged once per escrow, whichever path sees it first
+CREATE UNIQUE INDEX IF NOT EXISTS idx_escrow_logs_tx_action
+    ON escrow_logs(escrow_id, action, tx_signature) WHERE tx_signature IS NOT NULL;
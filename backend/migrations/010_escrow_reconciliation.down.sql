@@ -0,0 +1,4 @@
+-- Rollback escrow reconciliation
+
+DROP TABLE IF EXISTS escrow_reconciliation_runs;
+DROP TABLE IF EXISTS escrow_drifts;
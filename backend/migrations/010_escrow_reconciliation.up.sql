@@ -0,0 +1,32 @@
+-- Escrow Reconciliation Migration
+-- Drift between escrow rows and on-chain state, and a summary of each reconciliation pass
+
+-- One row per mismatched field; it stays open until a later pass finds the
+-- field back in agreement with the chain
+CREATE TABLE IF NOT EXISTS escrow_drifts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    escrow_id UUID NOT NULL REFERENCES escrows(id) ON DELETE CASCADE,
+    field VARCHAR(30) NOT NULL,
+    db_value TEXT NOT NULL,
+    chain_value TEXT NOT NULL,
+    detected_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_escrow_drifts_open
+    ON escrow_drifts(escrow_id, field) WHERE resolved_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_escrow_drifts_detected ON escrow_drifts(detected_at DESC);
+
+CREATE TABLE IF NOT EXISTS escrow_reconciliation_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    checked INTEGER NOT NULL DEFAULT 0,
+    ok INTEGER NOT NULL DEFAULT 0,
+    healed INTEGER NOT NULL DEFAULT 0,
+    mismatched INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_escrow_reconciliation_runs_started ON escrow_reconciliation_runs(started_at DESC);
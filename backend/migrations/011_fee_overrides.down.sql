@@ -0,0 +1,3 @@
+-- Rollback fee overrides
+
+DROP TABLE IF EXISTS fee_overrides;
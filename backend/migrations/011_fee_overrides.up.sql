@@ -0,0 +1,21 @@
+-- Fee Overrides Migration
+-- Per-category and per-user platform fees, and time-boxed promotions
+
+-- A row with neither category_id nor user_id applies platform-wide; with
+-- starts_at/ends_at set it only applies inside that window. A promotional
+-- zero-fee period is a row with fee_percentage 0 and a window.
+CREATE TABLE IF NOT EXISTS fee_overrides (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    category_id INTEGER REFERENCES job_categories(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    fee_percentage DECIMAL(5, 2) NOT NULL CHECK (fee_percentage >= 0 AND fee_percentage <= 100),
+    starts_at TIMESTAMP WITH TIME ZONE,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (category_id IS NULL OR user_id IS NULL),
+    CHECK (starts_at IS NULL OR ends_at IS NULL OR starts_at < ends_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_fee_overrides_category ON fee_overrides(category_id) WHERE category_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_fee_overrides_user ON fee_overrides(user_id) WHERE user_id IS NOT NULL;
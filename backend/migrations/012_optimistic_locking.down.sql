@@ -0,0 +1,6 @@
+-- Rollback optimistic locking
+
+ALTER TABLE service_orders DROP COLUMN IF EXISTS version;
+ALTER TABLE escrows DROP COLUMN IF EXISTS version;
+ALTER TABLE milestones DROP COLUMN IF EXISTS version;
+ALTER TABLE contracts DROP COLUMN IF EXISTS version;
@@ -0,0 +1,9 @@
+-- Optimistic Locking Migration
+-- Row versions for records that are updated by competing requests
+
+-- Updates match on the version they read and bump it, so a write based on
+-- a stale read affects no rows and is reported as a conflict
+ALTER TABLE contracts ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE milestones ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE escrows ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE service_orders ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,4 @@
+-- Rollback time tracking
+
+DROP TABLE IF EXISTS timesheets;
+DROP TABLE IF EXISTS time_entries;
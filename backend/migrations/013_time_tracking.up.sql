@@ -0,0 +1,43 @@
+-- Time Tracking Migration
+-- Hours logged on hourly contracts and the weekly timesheets clients approve
+
+-- A running timer has no ended_at and zero minutes until it is stopped.
+-- week_start is the Monday (UTC) of the week the work started in.
+CREATE TABLE IF NOT EXISTS time_entries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    contract_id UUID NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+    freelancer_id UUID NOT NULL REFERENCES users(id),
+    week_start DATE NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ended_at TIMESTAMP WITH TIME ZONE,
+    minutes INTEGER NOT NULL DEFAULT 0 CHECK (minutes >= 0),
+    description TEXT,
+    source VARCHAR(20) NOT NULL DEFAULT 'manual',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_time_entries_contract_week ON time_entries(contract_id, week_start);
+
+-- At most one running timer per contract
+CREATE UNIQUE INDEX IF NOT EXISTS idx_time_entries_running ON time_entries(contract_id) WHERE ended_at IS NULL;
+
+-- One timesheet per contract and week. Approving it creates an approved
+-- milestone for the billed amount, which the client then releases from escrow.
+CREATE TABLE IF NOT EXISTS timesheets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    contract_id UUID NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+    week_start DATE NOT NULL,
+    total_minutes INTEGER NOT NULL,
+    hourly_rate_sol DECIMAL(18, 9) NOT NULL,
+    amount_sol DECIMAL(18, 9) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'submitted',
+    submitted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    dispute_reason TEXT,
+    milestone_id UUID REFERENCES milestones(id) ON DELETE SET NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (contract_id, week_start)
+);
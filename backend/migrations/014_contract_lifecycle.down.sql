@@ -0,0 +1,4 @@
+-- Rollback contract lifecycle
+
+DROP TABLE IF EXISTS contract_cancellations;
+DROP TABLE IF EXISTS contract_status_changes;
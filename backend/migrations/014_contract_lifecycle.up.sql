@@ -0,0 +1,35 @@
+-- Contract Lifecycle Migration
+-- Pause/resume history and cancellation requests
+
+-- Every pause, resume and cancellation with who did it and why
+CREATE TABLE IF NOT EXISTS contract_status_changes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    contract_id UUID NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+    from_status VARCHAR(30) NOT NULL,
+    to_status VARCHAR(30) NOT NULL,
+    changed_by UUID REFERENCES users(id),
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_contract_status_changes_contract ON contract_status_changes(contract_id, created_at);
+
+-- A mutual request waits for the other party to accept it. A request with
+-- notice cancels the contract by itself once effective_at passes.
+CREATE TABLE IF NOT EXISTS contract_cancellations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    contract_id UUID NOT NULL REFERENCES contracts(id) ON DELETE CASCADE,
+    requested_by UUID NOT NULL REFERENCES users(id),
+    mode VARCHAR(20) NOT NULL,
+    reason TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    effective_at TIMESTAMP WITH TIME ZONE,
+    responded_by UUID REFERENCES users(id),
+    responded_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- At most one open request per contract
+CREATE UNIQUE INDEX IF NOT EXISTS idx_contract_cancellations_pending ON contract_cancellations(contract_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_contract_cancellations_due ON contract_cancellations(effective_at) WHERE status = 'pending';
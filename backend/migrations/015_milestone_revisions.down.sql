@@ -0,0 +1,13 @@
+-- Rollback milestone revision history
+
+DROP INDEX IF EXISTS idx_milestone_revisions_round;
+
+UPDATE milestone_revisions SET revision_notes = '' WHERE revision_notes IS NULL;
+ALTER TABLE milestone_revisions ALTER COLUMN revision_notes SET NOT NULL;
+ALTER TABLE milestone_revisions DROP COLUMN IF EXISTS previous_submitted_at;
+ALTER TABLE milestone_revisions DROP COLUMN IF EXISTS previous_submission_urls;
+ALTER TABLE milestone_revisions DROP COLUMN IF EXISTS previous_submission_text;
+ALTER TABLE milestone_revisions DROP COLUMN IF EXISTS action;
+ALTER TABLE milestone_revisions DROP COLUMN IF EXISTS revision_number;
+
+ALTER TABLE milestones DROP COLUMN IF EXISTS max_revisions;
@@ -0,0 +1,16 @@
+-- Milestone Revision History Migration
+-- Keeps each revision request and resubmission with the work it replaced
+
+-- Optional cap on revision rounds; NULL means unlimited
+ALTER TABLE milestones ADD COLUMN IF NOT EXISTS max_revisions INT CHECK (max_revisions >= 0);
+
+-- 'requested' rows are the client asking for changes, 'resubmitted' rows the
+-- freelancer answering them. Both carry the submission as it stood before.
+ALTER TABLE milestone_revisions ADD COLUMN IF NOT EXISTS revision_number INT NOT NULL DEFAULT 1;
+ALTER TABLE milestone_revisions ADD COLUMN IF NOT EXISTS action VARCHAR(20) NOT NULL DEFAULT 'requested';
+ALTER TABLE milestone_revisions ADD COLUMN IF NOT EXISTS previous_submission_text TEXT;
+ALTER TABLE milestone_revisions ADD COLUMN IF NOT EXISTS previous_submission_urls TEXT[];
+ALTER TABLE milestone_revisions ADD COLUMN IF NOT EXISTS previous_submitted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE milestone_revisions ALTER COLUMN revision_notes DROP NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_milestone_revisions_round ON milestone_revisions(milestone_id, revision_number, action);
@@ -0,0 +1,8 @@
+-- Rollback milestone timers
+
+DROP INDEX IF EXISTS idx_milestones_awaiting_approval;
+DROP INDEX IF EXISTS idx_milestones_past_due;
+
+ALTER TABLE milestones DROP COLUMN IF EXISTS auto_approve_notice_sent_at;
+ALTER TABLE milestones DROP COLUMN IF EXISTS overdue_at;
+ALTER TABLE milestones DROP COLUMN IF EXISTS due_reminder_sent_at;
@@ -0,0 +1,11 @@
+-- Milestone Timers Migration
+-- Due-date reminders, overdue flags and auto-approval of silent reviews
+
+ALTER TABLE milestones ADD COLUMN IF NOT EXISTS due_reminder_sent_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE milestones ADD COLUMN IF NOT EXISTS overdue_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE milestones ADD COLUMN IF NOT EXISTS auto_approve_notice_sent_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_milestones_past_due ON milestones(due_date)
+    WHERE overdue_at IS NULL AND status IN ('pending', 'in_progress', 'revision_requested');
+CREATE INDEX IF NOT EXISTS idx_milestones_awaiting_approval ON milestones(submitted_at)
+    WHERE status = 'submitted';
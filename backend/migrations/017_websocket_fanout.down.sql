@@ -0,0 +1,3 @@
+-- Rollback WebSocket fan-out
+
+DROP TABLE IF EXISTS ws_fanout_payloads;
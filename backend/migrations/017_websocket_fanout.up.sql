@@ -0,0 +1,10 @@
+-- WebSocket Fan-out Migration
+-- Chat envelopes too large for a NOTIFY payload are parked here and sent by reference
+
+CREATE UNLOGGED TABLE IF NOT EXISTS ws_fanout_payloads (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    payload TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ws_fanout_payloads_created ON ws_fanout_payloads(created_at);
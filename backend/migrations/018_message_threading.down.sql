@@ -0,0 +1,8 @@
+-- Rollback message threading
+
+DROP TABLE IF EXISTS message_edits;
+
+DROP INDEX IF EXISTS idx_messages_reply_to;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE messages DROP COLUMN IF EXISTS reply_to_message_id;
@@ -0,0 +1,5 @@
+-- Rollback dispute settlement
+
+DROP INDEX IF EXISTS idx_payments_tx_signature_type_unique;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_tx_signature_unique
+    ON payments(tx_signature) WHERE tx_signature IS NOT NULL;
@@ -0,0 +1,8 @@
+-- Dispute Settlement Migration
+-- A dispute settlement can pay the freelancer and refund the client in one
+-- transaction, so a signature is unique per payment type rather than
+-- across all payments
+
+DROP INDEX IF EXISTS idx_payments_tx_signature_unique;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_tx_signature_type_unique
+    ON payments(tx_signature, payment_type) WHERE tx_signature IS NOT NULL;
@@ -0,0 +1,3 @@
+-- Rollback dispute version
+
+ALTER TABLE disputes DROP COLUMN IF EXISTS version;
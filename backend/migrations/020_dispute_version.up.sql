@@ -0,0 +1,5 @@
+-- Dispute Version Migration
+-- Evidence, status moves and the resolution all rewrite the dispute row, so
+-- it is versioned like contracts and escrows (see 012_optimistic_locking)
+
+ALTER TABLE disputes ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;